@@ -0,0 +1,116 @@
+package main
+
+import (
+	"backend-vtb/internal/config"
+	"backend-vtb/internal/service"
+	"backend-vtb/pkg/pricefeed"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const (
+	candlesUsage = "usage: candles replay FILE CURRENCY"
+	// replayBatch is how many recorded ticks are ingested at once.
+	replayBatch = 1000
+)
+
+// runCandles executes the candles subcommand with the given arguments.
+//
+// Supported commands:
+//   - replay FILE CURRENCY: aggregate the ticks recorded in the CSV file,
+//     quoted in CURRENCY, into the stored candles.
+func runCandles(ctx context.Context, candles service.Candles, args []string) error {
+	if len(args) != 3 || args[0] != "replay" {
+		return errors.New(candlesUsage)
+	}
+
+	ticks, err := replayFile(ctx, candles, args[1], args[2])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("ingested %d ticks\n", ticks)
+
+	return nil
+}
+
+// replayFile ingests the ticks recorded in the CSV file and returns their number.
+func replayFile(ctx context.Context, candles service.Candles, path, currency string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return ingestTicks(ctx, candles, pricefeed.NewReplay(file, currency, replayBatch))
+}
+
+// ingestTicks ingests the ticks of a finite source until it is exhausted and
+// returns their number.
+func ingestTicks(ctx context.Context, candles service.Candles, source pricefeed.TickSource) (int, error) {
+	var ingested int
+	for {
+		ticks, err := source.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return ingested, nil
+		}
+
+		if err != nil {
+			return ingested, err
+		}
+
+		if err := candles.Ingest(ctx, ticks); err != nil {
+			return ingested, err
+		}
+
+		ingested += len(ticks)
+	}
+}
+
+// runCandleIngestion backfills the gaps in the candles and replays the
+// configured file, then aggregates the ticks polled from the price source
+// until ctx is done. The backfill goes first, as it starts after the latest
+// ingested tick.
+func runCandleIngestion(ctx context.Context, candles service.Candles, prices pricefeed.Source,
+	cfg config.CryptoCandlesConfig, logger *slog.Logger) {
+	if cfg.Backfill > 0 {
+		if _, err := candles.Backfill(ctx, time.Now(), cfg.Backfill); err != nil && ctx.Err() == nil {
+			logger.Error("failed to backfill candles", slog.String("reason", err.Error()))
+		}
+	}
+
+	if cfg.ReplayFile != "" {
+		ticks, err := replayFile(ctx, candles, cfg.ReplayFile, prices.Currency())
+		if err != nil && ctx.Err() == nil {
+			logger.Error("failed to replay ticks", slog.String("file", cfg.ReplayFile),
+				slog.String("reason", err.Error()))
+		}
+
+		logger.Info("ticks replayed", slog.String("file", cfg.ReplayFile), slog.Int("ticks", ticks))
+	}
+
+	if cfg.PollInterval <= 0 {
+		return
+	}
+
+	poller := pricefeed.NewPoller(prices, cfg.PollInterval)
+	for {
+		ticks, err := poller.Next(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			err = candles.Ingest(ctx, ticks)
+		}
+
+		if err != nil && ctx.Err() == nil {
+			logger.Error("failed to ingest price ticks", slog.String("reason", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"backend-vtb/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+const ledgerUsage = "usage: ledger check"
+
+// runLedger executes the ledger subcommand with the given arguments.
+//
+// Supported commands:
+//   - check: verify that every journal entry and the ledger as a whole
+//     balance, printing the offending entries. It fails if any are found.
+func runLedger(ctx context.Context, ledger repository.Ledger, args []string) error {
+	if len(args) != 1 || args[0] != "check" {
+		return errors.New(ledgerUsage)
+	}
+
+	report, err := ledger.Check(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("checked %d journal entries with %d postings\n", report.Entries, report.Postings)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	if len(report.Unbalanced) > 0 {
+		fmt.Fprintln(w, "UNBALANCED ENTRY\tCURRENCY\tSUM")
+
+		for _, entry := range report.Unbalanced {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", entry.EntryID, entry.Currency, entry.Sum)
+		}
+	}
+
+	for _, id := range report.Empty {
+		fmt.Fprintf(w, "%s\tno postings\t\n", id)
+	}
+
+	currencies := make([]string, 0, len(report.Totals))
+	for currency := range report.Totals {
+		currencies = append(currencies, currency)
+	}
+
+	sort.Strings(currencies)
+
+	for _, currency := range currencies {
+		fmt.Fprintf(w, "total %s\t%d\t\n", currency, report.Totals[currency])
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !report.OK() {
+		return fmt.Errorf("ledger is inconsistent: %d unbalanced and %d empty entries",
+			len(report.Unbalanced), len(report.Empty))
+	}
+
+	fmt.Println("ledger is consistent")
+
+	return nil
+}
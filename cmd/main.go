@@ -2,12 +2,21 @@ package main
 
 import (
 	"backend-vtb/internal/config"
+	"backend-vtb/internal/domain"
 	"backend-vtb/internal/http"
 	"backend-vtb/internal/repository"
 	"backend-vtb/internal/server"
 	"backend-vtb/internal/service"
+	"backend-vtb/migrations"
 	"backend-vtb/pkg/auth"
 	"backend-vtb/pkg/database"
+	"backend-vtb/pkg/exrate"
+	"backend-vtb/pkg/gateway"
+	"backend-vtb/pkg/hash"
+	"backend-vtb/pkg/migrate"
+	"backend-vtb/pkg/otp"
+	"backend-vtb/pkg/pricefeed"
+	"backend-vtb/pkg/storage"
 	"context"
 	"fmt"
 	"log"
@@ -16,6 +25,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 )
 
 // @title LinkBase
@@ -28,11 +38,14 @@ import (
 // @securityDefinitions.apikey UsersAuth
 // @in header
 // @name Authorization
+//
+// Running the binary as `main migrate up|down|status|to N` manages the database
+// schema instead of starting the server, `main ledger check` verifies that
+// the ledger balances, `main rates sync FROM [TO]` stores exchange rates and
+// `main candles replay FILE CURRENCY` aggregates recorded crypto price ticks.
 func main() {
 	cfg := config.MustLoad()
 
-	fmt.Println("Config: ", cfg)
-
 	logger := setupLogger()
 
 	postgresClient, err := database.NewPostgresClient(cfg.Postgres)
@@ -40,16 +53,163 @@ func main() {
 		log.Fatalf("Failed to initialize Postgres DB: %v", err)
 	}
 
+	migrator, err := migrate.New(postgresClient, migrations.FS, logger)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), migrator, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+
+		return
+	}
+
+	if err := checkMigrations(context.Background(), migrator, logger, cfg.Postgres.RequireMigrated); err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
+
 	repos := repository.NewRepository(postgresClient)
 
-	tokenManager, err := auth.NewManager(cfg.JWT.SigningKey)
+	if len(os.Args) > 1 && os.Args[1] == "ledger" {
+		if err := runLedger(context.Background(), repos.Ledger, os.Args[2:]); err != nil {
+			log.Fatalf("Ledger check failed: %v", err)
+		}
+
+		return
+	}
+
+	tokenManager, err := newTokenManager(cfg.JWT)
 	if err != nil {
 		log.Fatalf("Failed to initialize token manager: %v", err)
 	}
 
-	serv := service.NewService(repos, logger)
+	hasher, err := newPasswordHasher(cfg.Hash)
+	if err != nil {
+		log.Fatalf("Failed to initialize password hasher: %v", err)
+	}
 
-	handlers := http.NewHandler(serv.Base, tokenManager)
+	objectStorage, err := storage.NewFileStorage(cfg.Storage.Path)
+	if err != nil {
+		log.Fatalf("Failed to initialize object storage: %v", err)
+	}
+
+	paymentGateway, err := newPaymentGateway(cfg.Gateway)
+	if err != nil {
+		log.Fatalf("Failed to initialize payment gateway: %v", err)
+	}
+
+	priceSource, err := newPriceSource(cfg.Crypto)
+	if err != nil {
+		log.Fatalf("Failed to initialize crypto price source: %v", err)
+	}
+
+	scheduleLocation, err := time.LoadLocation(cfg.Schedules.Timezone)
+	if err != nil {
+		log.Fatalf("Failed to load schedules timezone: %v", err)
+	}
+
+	var rateProvider exrate.Provider
+	if cfg.Rates.File != "" {
+		if rateProvider, err = exrate.NewFileProvider(cfg.Rates.File, "RUB"); err != nil {
+			log.Fatalf("Failed to load exchange rates: %v", err)
+		}
+	}
+
+	services := service.NewService(service.Deps{
+		Repos:           repos,
+		Hasher:          hasher,
+		TokenManager:    tokenManager,
+		AccessTokenTTL:  cfg.JWT.AccessTokenTTL,
+		RefreshTokenTTL: cfg.JWT.RefreshTokenTTL,
+		MFA: service.MFAConfig{
+			Issuer:        cfg.MFA.Issuer,
+			TOTP:          otp.NewTOTP(cfg.MFA.Skew),
+			ChallengeTTL:  cfg.MFA.ChallengeTTL,
+			RecoveryCodes: cfg.MFA.RecoveryCodes,
+		},
+		FineRules: domain.FineRules{
+			DiscountPercent:    cfg.Fines.DiscountPercent,
+			DiscountDays:       cfg.Fines.DiscountDays,
+			PaymentDays:        cfg.Fines.PaymentDays,
+			PenaltyBasisPoints: cfg.Fines.PenaltyBasisPoints,
+			PenaltyCapPercent:  cfg.Fines.PenaltyCapPercent,
+		},
+		FinePayee: domain.Payee{
+			Name:        cfg.Fines.Payee.Name,
+			PersonalAcc: cfg.Fines.Payee.PersonalAcc,
+			BankName:    cfg.Fines.Payee.BankName,
+			BIC:         cfg.Fines.Payee.BIC,
+			CorrespAcc:  cfg.Fines.Payee.CorrespAcc,
+			INN:         cfg.Fines.Payee.INN,
+			KPP:         cfg.Fines.Payee.KPP,
+			OKTMO:       cfg.Fines.Payee.OKTMO,
+			CBC:         cfg.Fines.Payee.CBC,
+		},
+		Storage:     objectStorage,
+		Gateway:     paymentGateway,
+		PriceSource: priceSource,
+		Webhooks: service.WebhooksConfig{
+			Secrets:   map[string][]byte{cfg.Gateway.Provider: []byte(cfg.Gateway.WebhookSecret)},
+			Tolerance: cfg.Gateway.WebhookTolerance,
+		},
+		Schedules: service.SchedulesConfig{
+			Location:    scheduleLocation,
+			MissedAfter: cfg.Schedules.MissedAfter,
+			BatchSize:   cfg.Schedules.BatchSize,
+		},
+		Autopay: service.AutopayConfig{
+			Notice:   cfg.Fines.AutopayNotice,
+			Location: scheduleLocation,
+		},
+		ExchangeRates: service.ExchangeRatesConfig{
+			Provider: rateProvider,
+			Location: scheduleLocation,
+			MaxAge:   cfg.Rates.MaxAge,
+		},
+		Candles: service.CandlesConfig{
+			MaxCandles: cfg.Crypto.Candles.MaxCandles,
+		},
+		Alerts: service.AlertsConfig{
+			Cooldown: cfg.Alerts.Cooldown,
+			MaxRules: cfg.Alerts.MaxRules,
+		},
+		Stats: service.StatsConfig{
+			Location: scheduleLocation,
+		},
+		Logger: logger,
+	})
+
+	if len(os.Args) > 1 && os.Args[1] == "rates" {
+		if err := runRates(context.Background(), services.ExchangeRates, os.Args[2:]); err != nil {
+			log.Fatalf("Rates sync failed: %v", err)
+		}
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "candles" {
+		if err := runCandles(context.Background(), services.Candles, os.Args[2:]); err != nil {
+			log.Fatalf("Candles replay failed: %v", err)
+		}
+
+		return
+	}
+
+	runCtx, stopRunners := context.WithCancel(context.Background())
+	defer stopRunners()
+
+	go runSchedules(runCtx, services.Schedules, cfg.Schedules.Interval, logger)
+	go runAlerts(runCtx, services.Alerts, cfg.Alerts.Interval, logger)
+
+	if rateProvider != nil && cfg.Rates.SyncInterval > 0 {
+		go runRateSync(runCtx, services.ExchangeRates, cfg.Rates.SyncInterval, cfg.Rates.MaxAge, logger)
+	}
+
+	go runCandleIngestion(runCtx, services.Candles, priceSource, cfg.Crypto.Candles, logger)
+
+	handlers := http.NewHandler(services, tokenManager)
 
 	srv := server.NewServer(cfg.HTTP, handlers.Init())
 	go func() {
@@ -65,6 +225,8 @@ func main() {
 
 	<-quit
 
+	stopRunners()
+
 	const timeout = 5 * time.Second
 
 	ctx, shutdown := context.WithTimeout(context.Background(), timeout)
@@ -75,6 +237,139 @@ func main() {
 	}
 }
 
+// newTokenManager builds the token manager from the configured signing keys.
+//
+// Without keys, tokens are signed with the HS256 SIGNING_KEY. With keys, the
+// HS256 secret, if still set, is kept as a retiring key for one access token
+// TTL so that tokens issued before the switch remain valid until they expire.
+func newTokenManager(cfg config.JWTConfig) (*auth.Manager, error) {
+	if len(cfg.Keys) == 0 {
+		return auth.NewManager(cfg.SigningKey)
+	}
+
+	keys := make([]*auth.Key, 0, len(cfg.Keys)+1)
+	for _, keyCfg := range cfg.Keys {
+		key, err := auth.LoadKey(keyCfg.ID, keyCfg.Path, auth.KeyStatus(keyCfg.Status), keyCfg.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if cfg.SigningKey != "" {
+		legacy, err := auth.NewHMACKey("", []byte(cfg.SigningKey), auth.KeyRetiring, time.Now().Add(cfg.AccessTokenTTL))
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, legacy)
+	}
+
+	keySet, err := auth.NewKeySet(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewManagerWithKeys(keySet), nil
+}
+
+// newPasswordHasher builds the password hasher for the configured algorithm.
+//
+// Hashes produced by any other supported algorithm, including legacy SHA1
+// hashes, are still accepted at sign-in and upgraded to the configured one.
+func newPasswordHasher(cfg config.HashConfig) (hash.PasswordHasher, error) {
+	argon2id := hash.NewArgon2idHasher(hash.Argon2Params{
+		Memory:      cfg.Argon2.Memory,
+		Iterations:  cfg.Argon2.Iterations,
+		Parallelism: cfg.Argon2.Parallelism,
+		SaltLength:  cfg.Argon2.SaltLength,
+		KeyLength:   cfg.Argon2.KeyLength,
+	})
+	bcrypt := hash.NewBcryptHasher(cfg.BcryptCost)
+	sha1 := hash.NewSHA1Hasher(cfg.Salt)
+
+	switch cfg.Algorithm {
+	case "argon2id":
+		return hash.NewUpgradingHasher(argon2id, bcrypt, sha1), nil
+	case "bcrypt":
+		return hash.NewUpgradingHasher(bcrypt, argon2id, sha1), nil
+	case "sha1":
+		return hash.NewUpgradingHasher(sha1, argon2id, bcrypt), nil
+	default:
+		return nil, fmt.Errorf("unknown password hashing algorithm %q", cfg.Algorithm)
+	}
+}
+
+// newPaymentGateway builds the adapter of the configured acquirer.
+func newPaymentGateway(cfg config.GatewayConfig) (gateway.PaymentGateway, error) {
+	switch cfg.Provider {
+	case "simulator":
+		return gateway.NewSimulator(gateway.SimulatorConfig{
+			Latency:       cfg.Simulator.Latency,
+			Jitter:        cfg.Simulator.Jitter,
+			DeclineRate:   cfg.Simulator.DeclineRate,
+			ChallengeCode: cfg.Simulator.ChallengeCode,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown payment gateway provider %q", cfg.Provider)
+	}
+}
+
+// newPriceSource builds the configured source of crypto prices.
+func newPriceSource(cfg config.CryptoConfig) (pricefeed.Source, error) {
+	switch cfg.PriceSource {
+	case "stub":
+		return pricefeed.NewStub(pricefeed.DefaultStubCurrency, pricefeed.DefaultStubPrices)
+	default:
+		return nil, fmt.Errorf("unknown crypto price source %q", cfg.PriceSource)
+	}
+}
+
+// runSchedules makes the due scheduled payments every interval until ctx is done.
+func runSchedules(ctx context.Context, schedules service.Schedules, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			processed, err := schedules.RunDue(ctx, now)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("failed to run payment schedules", slog.String("reason", err.Error()))
+			}
+
+			if processed > 0 {
+				logger.Info("payment schedules run", slog.Int("processed", processed))
+			}
+		}
+	}
+}
+
+// runAlerts evaluates the alert rules every interval until ctx is done.
+func runAlerts(ctx context.Context, alerts service.Alerts, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			fired, err := alerts.Evaluate(ctx, now)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("failed to evaluate alert rules", slog.String("reason", err.Error()))
+			}
+
+			if fired > 0 {
+				logger.Info("alert rules fired", slog.Int("fired", fired))
+			}
+		}
+	}
+}
+
 // setupLogger initializes and returns a new logger instance configured
 // with a text handler that outputs to the standard output.
 // The logger is set to debug level and includes the source of the log.
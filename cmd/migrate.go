@@ -0,0 +1,94 @@
+package main
+
+import (
+	"backend-vtb/pkg/migrate"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: migrate up|down|status|to N"
+
+// runMigrate executes the migrate subcommand with the given arguments.
+//
+// Supported commands:
+//   - up: apply all pending migrations.
+//   - down: roll back the most recently applied migration.
+//   - status: print every known migration and whether it is applied.
+//   - to N: migrate up or down so that exactly migrations 1..N are applied.
+func runMigrate(ctx context.Context, migrator *migrate.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx)
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], err)
+		}
+
+		return migrator.To(ctx, version)
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+
+	return w.Flush()
+}
+
+// checkMigrations logs pending migrations and, when required is set, returns
+// an error so that the server does not start against an outdated schema.
+func checkMigrations(ctx context.Context, migrator *migrate.Migrator, logger *slog.Logger, required bool) error {
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	logger.Warn("database schema is behind",
+		slog.Int("pending", len(pending)),
+		slog.Int("latest", migrator.Latest()))
+
+	if required {
+		return fmt.Errorf("%d pending migrations, run `migrate up` first", len(pending))
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"backend-vtb/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const ratesUsage = "usage: rates sync FROM [TO], with days as YYYY-MM-DD"
+
+// runRates executes the rates subcommand with the given arguments.
+//
+// Supported commands:
+//   - sync FROM [TO]: store the exchange rates the provider published for
+//     the days from FROM to TO inclusive, today by default.
+func runRates(ctx context.Context, rates service.ExchangeRates, args []string) error {
+	if len(args) < 2 || len(args) > 3 || args[0] != "sync" {
+		return errors.New(ratesUsage)
+	}
+
+	from, err := time.Parse(time.DateOnly, args[1])
+	if err != nil {
+		return errors.New(ratesUsage)
+	}
+
+	to := time.Now()
+	if len(args) == 3 {
+		if to, err = time.Parse(time.DateOnly, args[2]); err != nil {
+			return errors.New(ratesUsage)
+		}
+	}
+
+	days, err := rates.Sync(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("stored the rates of %d days\n", days)
+
+	return nil
+}
+
+// runRateSync stores the exchange rates of the last maxAge every interval
+// until ctx is done, so that rates published late are picked up as well.
+func runRateSync(ctx context.Context, rates service.ExchangeRates, interval, maxAge time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := rates.Sync(ctx, now.Add(-maxAge), now); err != nil && ctx.Err() == nil {
+				logger.Error("failed to sync exchange rates", slog.String("reason", err.Error()))
+			}
+		}
+	}
+}
@@ -334,8 +334,6 @@ var SwaggerInfo = &swag.Spec{
 	Description:      "LinkBase API",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
-	LeftDelim:        "{{",
-	RightDelim:       "}}",
 }
 
 func init() {
@@ -10,9 +10,18 @@ import (
 
 type (
 	Config struct {
-		HTTP     HTTPConfig
-		Postgres PostgresConfig
-		JWT      JWTConfig
+		HTTP      HTTPConfig
+		Postgres  PostgresConfig
+		JWT       JWTConfig
+		Hash      HashConfig
+		MFA       MFAConfig
+		Fines     FinesConfig
+		Storage   StorageConfig
+		Gateway   GatewayConfig
+		Schedules SchedulesConfig
+		Rates     RatesConfig
+		Crypto    CryptoConfig
+		Alerts    AlertsConfig
 	}
 
 	HTTPConfig struct {
@@ -29,12 +38,159 @@ type (
 		Password string `env:"POSTGRES_PASSWORD" env-required:"true"`
 		Database string `yaml:"database"`
 		SSLMode  string `yaml:"sslMode"`
+		// RequireMigrated makes the server refuse to start while there are
+		// pending schema migrations.
+		RequireMigrated bool `yaml:"requireMigrated"`
 	}
 
 	JWTConfig struct {
 		AccessTokenTTL  time.Duration `yaml:"accessTokenTTL"`
 		RefreshTokenTTL time.Duration `yaml:"refreshTokenTTL"`
-		SigningKey      string        `env:"SIGNING_KEY"`
+		// SigningKey is the HS256 secret used when no Keys are configured. Once
+		// Keys are set it only verifies tokens issued before the switch.
+		SigningKey string         `env:"SIGNING_KEY"`
+		Keys       []JWTKeyConfig `yaml:"keys"`
+	}
+
+	JWTKeyConfig struct {
+		ID string `yaml:"id"`
+		// Path points to a PEM encoded RSA, P-256 or Ed25519 key. Retiring keys
+		// may be given as a public key.
+		Path string `yaml:"path"`
+		// Status is either active or retiring.
+		Status    string    `yaml:"status"`
+		ExpiresAt time.Time `yaml:"expiresAt"`
+	}
+
+	HashConfig struct {
+		// Algorithm is used for new password hashes: argon2id, bcrypt or sha1.
+		Algorithm  string       `yaml:"algorithm" env-default:"argon2id"`
+		Argon2     Argon2Config `yaml:"argon2"`
+		BcryptCost int          `yaml:"bcryptCost" env-default:"12"`
+		// Salt is only used to verify legacy SHA1 hashes.
+		Salt string `env:"PASSWORD_SALT"`
+	}
+
+	MFAConfig struct {
+		// Issuer is the service name shown in authenticator apps.
+		Issuer string `yaml:"issuer" env-default:"VTB"`
+		// Skew is the number of 30 second steps a TOTP code may be off by.
+		Skew          uint          `yaml:"skew" env-default:"1"`
+		ChallengeTTL  time.Duration `yaml:"challengeTTL" env-default:"5m"`
+		RecoveryCodes int           `yaml:"recoveryCodes" env-default:"10"`
+	}
+
+	FinesConfig struct {
+		DiscountPercent int64 `yaml:"discountPercent" env-default:"50"`
+		DiscountDays    int   `yaml:"discountDays" env-default:"20"`
+		PaymentDays     int   `yaml:"paymentDays" env-default:"70"`
+		// PenaltyBasisPoints is accrued per full overdue day, 10 is 0.1% a day.
+		PenaltyBasisPoints int64 `yaml:"penaltyBasisPoints" env-default:"10"`
+		PenaltyCapPercent  int64 `yaml:"penaltyCapPercent" env-default:"100"`
+		// Payee receives fines paid by bank transfer, as printed in fine QR codes.
+		Payee PayeeConfig `yaml:"payee"`
+		// AutopayNotice is how long before an automatic payment of a fine the
+		// user is notified and can still cancel it.
+		AutopayNotice time.Duration `yaml:"autopayNotice" env-default:"24h"`
+	}
+
+	PayeeConfig struct {
+		Name        string `yaml:"name"`
+		PersonalAcc string `yaml:"personalAcc"`
+		BankName    string `yaml:"bankName"`
+		BIC         string `yaml:"bic"`
+		// CorrespAcc is "0" for payees served by the Bank of Russia directly.
+		CorrespAcc string `yaml:"correspAcc" env-default:"0"`
+		INN        string `yaml:"inn"`
+		KPP        string `yaml:"kpp"`
+		OKTMO      string `yaml:"oktmo"`
+		CBC        string `yaml:"cbc"`
+	}
+
+	StorageConfig struct {
+		// Path is the directory uploaded files are kept in.
+		Path string `yaml:"path" env-default:"./data/storage"`
+	}
+
+	GatewayConfig struct {
+		// Provider selects the acquirer; only simulator is available so far.
+		Provider  string                 `yaml:"provider" env-default:"simulator"`
+		Simulator GatewaySimulatorConfig `yaml:"simulator"`
+		// WebhookSecret verifies the webhooks of the provider. Webhooks are
+		// rejected while it is empty.
+		WebhookSecret string `env:"GATEWAY_WEBHOOK_SECRET"`
+		// WebhookTolerance is the maximum age of a webhook delivery.
+		WebhookTolerance time.Duration `yaml:"webhookTolerance" env-default:"5m"`
+	}
+
+	GatewaySimulatorConfig struct {
+		Latency time.Duration `yaml:"latency" env-default:"200ms"`
+		Jitter  time.Duration `yaml:"jitter" env-default:"100ms"`
+		// DeclineRate is the share of approved cards declined at random, 0 to 1.
+		DeclineRate float64 `yaml:"declineRate" env-default:"0"`
+		// ChallengeCode passes the 3-D Secure challenge of the test card.
+		ChallengeCode string `yaml:"challengeCode" env-default:"1234"`
+	}
+
+	SchedulesConfig struct {
+		// Interval is how often due payment schedules are looked for.
+		Interval time.Duration `yaml:"interval" env-default:"1m"`
+		// Timezone is the zone calendar rules are evaluated in.
+		Timezone string `yaml:"timezone" env-default:"Europe/Moscow"`
+		// MissedAfter is how late a run may still be made, e.g. after downtime.
+		MissedAfter time.Duration `yaml:"missedAfter" env-default:"24h"`
+		BatchSize   int           `yaml:"batchSize" env-default:"100"`
+	}
+
+	RatesConfig struct {
+		// File is a CSV file of daily exchange rates in rubles, see
+		// exrate.FileProvider. Without it rates can only be converted if
+		// stored by other means.
+		File string `yaml:"file"`
+		// MaxAge is how much older than the requested day the latest rate
+		// may be, e.g. over long holidays.
+		MaxAge time.Duration `yaml:"maxAge" env-default:"168h"`
+		// SyncInterval is how often the rates of the last MaxAge are stored
+		// from the file; zero disables the sync.
+		SyncInterval time.Duration `yaml:"syncInterval" env-default:"0"`
+	}
+
+	CryptoConfig struct {
+		// PriceSource selects where crypto prices come from; only stub, a
+		// feed of made-up prices, is available so far.
+		PriceSource string              `yaml:"priceSource" env-default:"stub"`
+		Candles     CryptoCandlesConfig `yaml:"candles"`
+	}
+
+	CryptoCandlesConfig struct {
+		// PollInterval is how often the price source is polled for ticks;
+		// zero disables the polling.
+		PollInterval time.Duration `yaml:"pollInterval" env-default:"10s"`
+		// ReplayFile is a CSV file of recorded ticks, quoted in the currency
+		// of the price source, ingested at start; see pricefeed.Replay.
+		ReplayFile string `yaml:"replayFile"`
+		// Backfill is how far back the gaps in the candles are filled from
+		// the history of the price source at start; zero disables it.
+		Backfill time.Duration `yaml:"backfill" env-default:"24h"`
+		// MaxCandles limits how many candles are returned at once.
+		MaxCandles int `yaml:"maxCandles" env-default:"1000"`
+	}
+
+	AlertsConfig struct {
+		// Interval is how often the alert rules are evaluated.
+		Interval time.Duration `yaml:"interval" env-default:"1m"`
+		// Cooldown is the cooldown of rules created without one.
+		Cooldown time.Duration `yaml:"cooldown" env-default:"1h"`
+		// MaxRules limits the number of rules of a user.
+		MaxRules int `yaml:"maxRules" env-default:"20"`
+	}
+
+	Argon2Config struct {
+		Memory      uint32 `yaml:"memory" env-default:"65536"`
+		Iterations  uint32 `yaml:"iterations" env-default:"3"`
+		Parallelism uint8  `yaml:"parallelism" env-default:"4"`
+		SaltLength  uint32 `yaml:"saltLength" env-default:"16"`
+		KeyLength   uint32 `yaml:"keyLength" env-default:"32"`
 	}
 )
 
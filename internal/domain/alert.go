@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AlertSubject is the value an alert rule watches.
+type AlertSubject string
+
+const (
+	// AlertPrice watches the market price of a crypto asset.
+	AlertPrice AlertSubject = "price"
+	// AlertBalance watches the amount the user owes on fines.
+	AlertBalance AlertSubject = "balance"
+)
+
+// AlertCondition is what the watched value must do for an alert to fire.
+type AlertCondition string
+
+const (
+	AlertAbove AlertCondition = "above"
+	AlertBelow AlertCondition = "below"
+	// AlertChange fires when the value moved by the threshold percent over
+	// the window: up for a positive threshold, down for a negative one.
+	AlertChange AlertCondition = "change"
+)
+
+// alertMaxMinutes bounds the windows and cooldowns of alert rules, 7 days.
+const alertMaxMinutes = 7 * 24 * 60
+
+// AlertRule tells the service to notify the user when a value meets a
+// condition. A rule fires when its condition starts to hold, and not again
+// until the condition stopped holding and the cooldown passed.
+type AlertRule struct {
+	ID        uuid.UUID      `json:"id" db:"id"`
+	UserID    uuid.UUID      `json:"-" db:"user_id"`
+	Subject   AlertSubject   `json:"subject" db:"subject"`
+	Asset     string         `json:"asset,omitempty" db:"asset"`
+	Condition AlertCondition `json:"condition" db:"condition"`
+	// Threshold is a decimal price in the currency of the price source for
+	// price rules, an amount in kopecks for balance rules and a percent for
+	// change rules.
+	Threshold string `json:"threshold" db:"threshold"`
+	// WindowMinutes is the period change rules compare the value over.
+	WindowMinutes int `json:"windowMinutes,omitempty" db:"window_minutes"`
+	// CooldownMinutes is how long the rule stays quiet after it fired.
+	CooldownMinutes int `json:"cooldownMinutes" db:"cooldown_minutes"`
+	// Triggered is whether the condition held at the last evaluation.
+	Triggered bool       `json:"triggered" db:"triggered"`
+	FiredAt   *time.Time `json:"firedAt,omitempty" db:"fired_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// Validate checks that the rule is complete for its subject and condition.
+// The threshold itself is checked by the service, which knows its unit.
+func (r AlertRule) Validate() error {
+	switch r.Subject {
+	case AlertPrice:
+		if r.Asset == "" {
+			return fmt.Errorf("%w: a price alert needs an asset", ErrAlertRule)
+		}
+	case AlertBalance:
+		if r.Asset != "" {
+			return fmt.Errorf("%w: a balance alert has no asset", ErrAlertRule)
+		}
+
+		// The amount owed is not kept over time to compare it with.
+		if r.Condition == AlertChange {
+			return fmt.Errorf("%w: change alerts only watch prices", ErrAlertRule)
+		}
+	default:
+		return fmt.Errorf("%w: unknown subject %q", ErrAlertRule, r.Subject)
+	}
+
+	switch r.Condition {
+	case AlertAbove, AlertBelow:
+		if r.WindowMinutes != 0 {
+			return fmt.Errorf("%w: only change alerts have a window", ErrAlertRule)
+		}
+	case AlertChange:
+		if r.WindowMinutes < 1 || r.WindowMinutes > alertMaxMinutes {
+			return fmt.Errorf("%w: window must be between 1 minute and 7 days", ErrAlertRule)
+		}
+	default:
+		return fmt.Errorf("%w: unknown condition %q", ErrAlertRule, r.Condition)
+	}
+
+	if r.CooldownMinutes < 0 || r.CooldownMinutes > alertMaxMinutes {
+		return fmt.Errorf("%w: cooldown must be between 0 and 7 days", ErrAlertRule)
+	}
+
+	return nil
+}
+
+// Window returns the period change rules compare the value over.
+func (r AlertRule) Window() time.Duration {
+	return time.Duration(r.WindowMinutes) * time.Minute
+}
+
+// CanFire reports whether the rule may fire at now given that its condition
+// holds: it did not hold at the last evaluation and the cooldown has passed.
+// A condition that starts to hold during the cooldown is not reported.
+func (r AlertRule) CanFire(now time.Time) bool {
+	cooldown := time.Duration(r.CooldownMinutes) * time.Minute
+
+	return !r.Triggered && (r.FiredAt == nil || !now.Before(r.FiredAt.Add(cooldown)))
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AutopayRule is the opt-in of a user to have new fines paid automatically
+// at the discount. A user without a rule has autopay disabled.
+type AutopayRule struct {
+	UserID  uuid.UUID `json:"-" db:"user_id"`
+	Enabled bool      `json:"enabled" db:"enabled"`
+	// MaxPerFine is the largest amount autopay pays for a single fine, in kopecks.
+	MaxPerFine int64 `json:"maxPerFine" db:"max_per_fine"`
+	// MonthlyLimit caps the amounts of the charges scheduled for a calendar
+	// month, in kopecks.
+	MonthlyLimit int64 `json:"monthlyLimit" db:"monthly_limit"`
+	// CardToken refers to the card saved at the acquirer.
+	CardToken string    `json:"-" db:"card_token"`
+	CardLast4 string    `json:"cardLast4,omitempty" db:"card_last4"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// AutopayChargeStatus is the status of the autopay of a fine.
+type AutopayChargeStatus string
+
+const (
+	// AutopayScheduled means the payment is scheduled through a one-off
+	// payment schedule, which records how it went.
+	AutopayScheduled AutopayChargeStatus = "scheduled"
+	// AutopayDeclined means the fine was not scheduled for payment; the
+	// reason tells why.
+	AutopayDeclined  AutopayChargeStatus = "declined"
+	AutopayCancelled AutopayChargeStatus = "cancelled"
+)
+
+// Reasons of declined autopay charges.
+const (
+	// AutopayNoDiscount is given when the discount window of the fine ends
+	// before the charge could be made.
+	AutopayNoDiscount   = "no_discount"
+	AutopayFineLimit    = "fine_limit"
+	AutopayMonthlyLimit = "monthly_limit"
+)
+
+// AutopayCharge is the decision of autopay on a new fine.
+type AutopayCharge struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"userId" db:"user_id"`
+	FineID     uuid.UUID  `json:"fineId" db:"fine_id"`
+	ScheduleID *uuid.UUID `json:"scheduleId,omitempty" db:"schedule_id"`
+	// Amount is the discounted amount of the fine at ChargeAt, in kopecks.
+	Amount int64 `json:"amount" db:"amount"`
+	// ChargeAt is when the fine is paid; until then the user may cancel.
+	ChargeAt time.Time `json:"chargeAt" db:"charge_at"`
+	// Month is the start of the calendar month the charge counts towards.
+	Month     time.Time           `json:"-" db:"month"`
+	Status    AutopayChargeStatus `json:"status" db:"status"`
+	Reason    string              `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time           `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time           `json:"updatedAt" db:"updated_at"`
+}
@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// CandleInterval is the length of time a candle covers.
+type CandleInterval string
+
+const (
+	CandleMinute     CandleInterval = "1m"
+	CandleFiveMinute CandleInterval = "5m"
+	CandleHour       CandleInterval = "1h"
+	CandleDay        CandleInterval = "1d"
+)
+
+// CandleIntervals are the intervals candles are aggregated into.
+var CandleIntervals = []CandleInterval{CandleMinute, CandleFiveMinute, CandleHour, CandleDay}
+
+// Duration returns the length of the interval, zero if it is unknown.
+func (i CandleInterval) Duration() time.Duration {
+	switch i {
+	case CandleMinute:
+		return time.Minute
+	case CandleFiveMinute:
+		return 5 * time.Minute
+	case CandleHour:
+		return time.Hour
+	case CandleDay:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// Candle is the OHLCV summary of the price ticks of an asset in an interval
+// starting at OpenTime. Prices and volume are exact decimal numbers.
+type Candle struct {
+	Symbol   string         `json:"-" db:"symbol"`
+	Interval CandleInterval `json:"-" db:"period"`
+	Currency string         `json:"-" db:"currency"`
+	OpenTime time.Time      `json:"openTime" db:"open_time"`
+	Open     string         `json:"open" db:"open"`
+	High     string         `json:"high" db:"high"`
+	Low      string         `json:"low" db:"low"`
+	Close    string         `json:"close" db:"close"`
+	Volume   string         `json:"volume" db:"volume"`
+	Ticks    int64          `json:"ticks" db:"ticks"`
+	// FirstAt and LastAt are the times of the ticks the open and close come from.
+	FirstAt time.Time `json:"-" db:"first_at"`
+	LastAt  time.Time `json:"-" db:"last_at"`
+	// Filled marks an interval without ticks, carried over at the previous close.
+	Filled bool `json:"filled" db:"-"`
+}
+
+// CandleSeries is the candles of an asset in one interval, oldest first.
+type CandleSeries struct {
+	Symbol   string         `json:"symbol"`
+	Interval CandleInterval `json:"interval"`
+	Currency string         `json:"currency"`
+	Candles  []Candle       `json:"candles"`
+}
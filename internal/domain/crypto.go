@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CryptoTransactionKind is what a crypto transaction did to the holdings.
+type CryptoTransactionKind string
+
+const (
+	CryptoBuy  CryptoTransactionKind = "buy"
+	CryptoSell CryptoTransactionKind = "sell"
+	// CryptoTransferIn adds units acquired elsewhere, e.g. moved from another
+	// wallet; its amount is the cost basis they bring along.
+	CryptoTransferIn CryptoTransactionKind = "transfer_in"
+	// CryptoTransferOut removes units without selling them.
+	CryptoTransferOut CryptoTransactionKind = "transfer_out"
+)
+
+func (k CryptoTransactionKind) Valid() bool {
+	switch k {
+	case CryptoBuy, CryptoSell, CryptoTransferIn, CryptoTransferOut:
+		return true
+	default:
+		return false
+	}
+}
+
+// Removes reports whether the transaction takes units out of the holdings.
+func (k CryptoTransactionKind) Removes() bool {
+	return k == CryptoSell || k == CryptoTransferOut
+}
+
+// CryptoTransaction is a transaction in a crypto asset recorded by a user.
+// All transactions in one asset are in the same currency.
+type CryptoTransaction struct {
+	ID     uuid.UUID             `json:"id" db:"id"`
+	UserID uuid.UUID             `json:"-" db:"user_id"`
+	Asset  string                `json:"asset" db:"asset"`
+	Kind   CryptoTransactionKind `json:"kind" db:"kind"`
+	// Quantity is an exact decimal number of units, e.g. "0.015".
+	Quantity string `json:"quantity" db:"quantity"`
+	// Amount is what was paid or received for the quantity, in minor units
+	// of Currency, fees excluded.
+	Amount     int64     `json:"amount" db:"amount"`
+	Fee        int64     `json:"fee" db:"fee"`
+	Currency   string    `json:"currency" db:"currency"`
+	ExecutedAt time.Time `json:"executedAt" db:"executed_at"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// CostBasisMethod is how the cost of units sold is found.
+type CostBasisMethod string
+
+const (
+	// CostBasisFIFO sells the units in the order they were acquired.
+	CostBasisFIFO CostBasisMethod = "fifo"
+	// CostBasisAverage sells at the average cost of all units held.
+	CostBasisAverage CostBasisMethod = "average"
+)
+
+// CryptoPortfolio is the crypto holdings of a user valued at market prices.
+type CryptoPortfolio struct {
+	Method   CostBasisMethod `json:"method"`
+	PricedAt time.Time       `json:"pricedAt"`
+	Holdings []CryptoHolding `json:"holdings"`
+	// Totals sums the holdings by currency.
+	Totals []CryptoTotal `json:"totals"`
+}
+
+// CryptoHolding is the position in one asset, in the currency of its
+// transactions. Assets sold out are kept for their realized profit or loss.
+// Amounts are in minor units of Currency.
+type CryptoHolding struct {
+	Asset    string `json:"asset"`
+	Currency string `json:"currency"`
+	Quantity string `json:"quantity"`
+	// Price is the market price of one unit.
+	Price         string `json:"price"`
+	CostBasis     int64  `json:"costBasis"`
+	MarketValue   int64  `json:"marketValue"`
+	RealizedPnL   int64  `json:"realizedPnl"`
+	UnrealizedPnL int64  `json:"unrealizedPnl"`
+	Fees          int64  `json:"fees"`
+}
+
+// CryptoTotal is the sum of the holdings in one currency.
+type CryptoTotal struct {
+	Currency      string `json:"currency"`
+	CostBasis     int64  `json:"costBasis"`
+	MarketValue   int64  `json:"marketValue"`
+	RealizedPnL   int64  `json:"realizedPnl"`
+	UnrealizedPnL int64  `json:"unrealizedPnl"`
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DisputeReason string
+
+const (
+	DisputeNotDriver       DisputeReason = "not_driver"
+	DisputeVehicleSold     DisputeReason = "vehicle_sold"
+	DisputeAlreadyPaid     DisputeReason = "already_paid"
+	DisputeIncorrectAmount DisputeReason = "incorrect_amount"
+	DisputeOther           DisputeReason = "other"
+)
+
+type DisputeStatus string
+
+const (
+	DisputeOpen      DisputeStatus = "open"
+	DisputeAccepted  DisputeStatus = "accepted"
+	DisputeRejected  DisputeStatus = "rejected"
+	DisputeWithdrawn DisputeStatus = "withdrawn"
+)
+
+// Dispute is a user's objection to a fine. While a dispute is open the fine
+// is disputed and accrues no penalty.
+type Dispute struct {
+	ID      uuid.UUID     `json:"id" db:"id"`
+	FineID  uuid.UUID     `json:"fineId" db:"fine_id"`
+	UserID  uuid.UUID     `json:"userId" db:"user_id"`
+	Reason  DisputeReason `json:"reason" db:"reason"`
+	Comment string        `json:"comment" db:"comment"`
+	Status  DisputeStatus `json:"status" db:"status"`
+	// ReviewerID is the operator who decided on the dispute.
+	ReviewerID *uuid.UUID `json:"reviewerId" db:"reviewer_id"`
+	Decision   string     `json:"decision" db:"decision"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	ResolvedAt *time.Time `json:"resolvedAt" db:"resolved_at"`
+}
+
+// Period returns the time the dispute was (or still is) open.
+func (d Dispute) Period() Period {
+	return Period{From: d.CreatedAt, To: d.ResolvedAt}
+}
+
+// DisputeAttachment is an evidence file uploaded for a dispute. The content
+// is kept in object storage under StorageKey.
+type DisputeAttachment struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	DisputeID   uuid.UUID `json:"disputeId" db:"dispute_id"`
+	FileName    string    `json:"fileName" db:"file_name"`
+	ContentType string    `json:"contentType" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	StorageKey  string    `json:"-" db:"storage_key"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+type FineEventType string
+
+const (
+	FineEventCreated          FineEventType = "created"
+	FineEventUpdated          FineEventType = "updated"
+	FineEventDisputeOpened    FineEventType = "dispute_opened"
+	FineEventDisputeWithdrawn FineEventType = "dispute_withdrawn"
+	FineEventDisputeAccepted  FineEventType = "dispute_accepted"
+	FineEventDisputeRejected  FineEventType = "dispute_rejected"
+	FineEventPaid             FineEventType = "paid"
+	FineEventPaymentRefunded  FineEventType = "payment_refunded"
+)
+
+// FineEvent is an entry of the append-only history of a fine. Status is the
+// status of the fine after the event.
+type FineEvent struct {
+	ID        uuid.UUID     `json:"id" db:"id"`
+	FineID    uuid.UUID     `json:"fineId" db:"fine_id"`
+	Type      FineEventType `json:"type" db:"type"`
+	Status    FineStatus    `json:"status" db:"status"`
+	DisputeID *uuid.UUID    `json:"disputeId" db:"dispute_id"`
+	ActorID   uuid.UUID     `json:"actorId" db:"actor_id"`
+	Comment   string        `json:"comment" db:"comment"`
+	CreatedAt time.Time     `json:"createdAt" db:"created_at"`
+}
@@ -0,0 +1,63 @@
+package domain
+
+import "errors"
+
+var (
+	ErrNotFound             = errors.New("not found")
+	ErrUserAlreadyExists    = errors.New("user with such email already exists")
+	ErrFineAlreadyExists    = errors.New("fine with such UIN already exists")
+	ErrInvalidFineStatus    = errors.New("invalid fine status")
+	ErrFineTransition       = errors.New("fine status cannot be changed this way")
+	ErrFineNotEditable      = errors.New("fine can only be edited while issued")
+	ErrFineNotDeletable     = errors.New("fine can only be deleted while issued")
+	ErrFineDeadline         = errors.New("fine deadlines can only be moved earlier")
+	ErrInvalidUIN           = errors.New("invalid UIN")
+	ErrDisputeExists        = errors.New("fine already has an open dispute")
+	ErrDisputeClosed        = errors.New("dispute is already closed")
+	ErrTooManyAttachments   = errors.New("too many attachments")
+	ErrAttachmentTooLarge   = errors.New("attachment is too large")
+	ErrAttachmentType       = errors.New("attachment type is not allowed")
+	ErrPaymentAlreadyExists = errors.New("payment with such idempotency key already exists")
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+	ErrInvalidPaymentTarget = errors.New("payment must target either a fine or a merchant")
+	ErrPaymentAmount        = errors.New("invalid payment amount")
+	ErrPaymentTransition    = errors.New("payment status cannot be changed this way")
+	ErrFineNotPayable       = errors.New("fine is not payable")
+	ErrFinePaymentExists    = errors.New("fine already has a payment in progress")
+	ErrRefundAmount         = errors.New("refund exceeds the refundable amount")
+	ErrRefundAlreadyExists  = errors.New("refund with such idempotency key already exists")
+	ErrRefundCompleted      = errors.New("refund is already completed")
+	ErrRefundNotAllowed     = errors.New("payments can only be refunded by operators")
+	ErrInvalidQRPayload     = errors.New("invalid payment QR payload")
+	ErrInvalidCard          = errors.New("card is invalid or expired")
+	ErrScheduleRule         = errors.New("invalid schedule rule")
+	ErrScheduleState        = errors.New("schedule cannot be changed in its current status")
+	ErrScheduleChanged      = errors.New("schedule was changed concurrently, try again")
+	ErrAutopayRule          = errors.New("invalid autopay rule")
+	ErrAutopayLimit         = errors.New("autopay monthly limit exceeded")
+	ErrAutopayState         = errors.New("autopay charge can no longer be cancelled")
+	ErrInvalidCurrency      = errors.New("invalid currency")
+	ErrNoExchangeRate       = errors.New("no exchange rate available")
+	ErrCryptoTransaction    = errors.New("invalid crypto transaction")
+	ErrUnknownAsset         = errors.New("unknown crypto asset")
+	ErrCostBasisMethod      = errors.New("unknown cost basis method")
+	ErrInsufficientHoldings = errors.New("transaction exceeds the holdings")
+	ErrCandleRange          = errors.New("invalid candle range")
+	ErrAlertRule            = errors.New("invalid alert rule")
+	ErrAlertLimit           = errors.New("too many alert rules")
+	ErrAlertChanged         = errors.New("alert rule was changed concurrently")
+	ErrStatsQuery           = errors.New("invalid statistics query")
+	ErrGatewayUnavailable   = errors.New("payment gateway is unavailable, try again later")
+	ErrWebhookProvider      = errors.New("unknown webhook provider")
+	ErrWebhookSignature     = errors.New("invalid webhook signature")
+	ErrWebhookPayload       = errors.New("invalid webhook payload")
+	ErrWebhookEventExists   = errors.New("webhook event has already been received")
+	ErrUnbalancedEntry      = errors.New("journal entry does not balance")
+	ErrInvalidCredentials   = errors.New("invalid email or password")
+	ErrInvalidRefreshToken  = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused   = errors.New("refresh token has already been used, session revoked")
+	ErrInvalidMFAToken      = errors.New("mfa token is invalid or expired")
+	ErrInvalidOTP           = errors.New("invalid one-time code")
+	ErrTOTPAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnrolled      = errors.New("two-factor authentication is not enrolled")
+)
@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// ExchangeRate is the official rate of a currency on a day: the price of one
+// unit of Currency in the base currency of the rate provider, the ruble.
+type ExchangeRate struct {
+	Currency string    `json:"currency" db:"currency"`
+	Date     time.Time `json:"date" db:"date"`
+	// Rate is an exact decimal number, e.g. "92.5012".
+	Rate string `json:"rate" db:"rate"`
+}
+
+// Valuation is what the balances of a user were worth in one currency at the
+// end of a day, converted at the rates of that day.
+type Valuation struct {
+	Currency string          `json:"currency"`
+	Date     time.Time       `json:"date"`
+	Balances []ValuedBalance `json:"balances"`
+	// Total is the sum of the values, in minor units of Currency.
+	Total int64 `json:"total"`
+}
+
+// ValuedBalance is an account balance converted into the currency of a valuation.
+type ValuedBalance struct {
+	AccountBalance
+	// Rate is the price of one unit of the balance currency in the valuation currency.
+	Rate string `json:"rate"`
+	// Value is the balance in minor units of the valuation currency.
+	Value int64 `json:"value"`
+}
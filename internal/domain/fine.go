@@ -0,0 +1,206 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FineStatus is the stored status of a fine. Whether an issued fine is in
+// its discount window, due or overdue depends on the date and is computed by
+// Fine.State.
+type FineStatus string
+
+const (
+	FineIssued    FineStatus = "issued"
+	FinePaid      FineStatus = "paid"
+	FineCancelled FineStatus = "cancelled"
+	FineDisputed  FineStatus = "disputed"
+)
+
+// fineTransitions lists the statuses each status may change to. Cancelled
+// fines are final, and paid fines are only issued again by the refund of the
+// whole payment.
+var fineTransitions = map[FineStatus][]FineStatus{
+	FineIssued:   {FinePaid, FineCancelled, FineDisputed},
+	FineDisputed: {FineIssued, FineCancelled},
+}
+
+// Valid reports whether the status is one of the known fine statuses.
+func (s FineStatus) Valid() bool {
+	switch s {
+	case FineIssued, FinePaid, FineCancelled, FineDisputed:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanTransition reports whether a fine may move from s to the given status.
+func (s FineStatus) CanTransition(to FineStatus) bool {
+	for _, next := range fineTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FineState is the lifecycle state of a fine at a given moment.
+type FineState string
+
+const (
+	// FineStatePending is the state of a fine whose issue date is still ahead.
+	FineStatePending    FineState = "pending"
+	FineStateDiscounted FineState = "discounted"
+	FineStateDue        FineState = "due"
+	FineStateOverdue    FineState = "overdue"
+	FineStatePaid       FineState = "paid"
+	FineStateCancelled  FineState = "cancelled"
+	FineStateDisputed   FineState = "disputed"
+)
+
+// Open reports whether a fine in this state still has to be paid.
+func (s FineState) Open() bool {
+	switch s {
+	case FineStatePending, FineStateDiscounted, FineStateDue, FineStateOverdue:
+		return true
+	default:
+		return false
+	}
+}
+
+// FineRules are the payment terms applied to fines.
+type FineRules struct {
+	// DiscountPercent is the discount for paying within the discount window.
+	DiscountPercent int64
+	// DiscountDays is the length of the discount window after the issue date,
+	// used when a fine does not set DiscountUntil itself.
+	DiscountDays int
+	// PaymentDays is the time to pay after the issue date, used when a fine
+	// is created without a due date.
+	PaymentDays int
+	// PenaltyBasisPoints is the penalty accrued for each full day after the
+	// due date, in hundredths of a percent of the amount.
+	PenaltyBasisPoints int64
+	// PenaltyCapPercent limits the total penalty to a percentage of the amount.
+	PenaltyCapPercent int64
+}
+
+// Fine is a penalty issued to a user by a state agency.
+type Fine struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	UserID uuid.UUID `json:"userId" db:"user_id"`
+	// UIN is the unique accrual identifier used to pay the fine.
+	UIN string `json:"uin" db:"uin"`
+	// Issuer is the agency that issued the fine, e.g. GIBDD.
+	Issuer string `json:"issuer" db:"issuer"`
+	// Article is the article of the administrative code the fine was issued under.
+	Article string `json:"article" db:"article"`
+	// Amount is the full amount of the fine in kopecks.
+	Amount   int64     `json:"amount" db:"amount"`
+	IssuedAt time.Time `json:"issuedAt" db:"issued_at"`
+	DueAt    time.Time `json:"dueAt" db:"due_at"`
+	// DiscountUntil is the last moment the fine can be paid at a discount, if any.
+	DiscountUntil *time.Time `json:"discountUntil" db:"discount_until"`
+	Status        FineStatus `json:"status" db:"status"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updatedAt" db:"updated_at"`
+	// PenaltyPauses are the periods in which no penalty accrues, i.e. while
+	// the fine was disputed. They are loaded separately when needed.
+	PenaltyPauses []Period `json:"-" db:"-"`
+}
+
+// Period is a time interval. An open period has no end.
+type Period struct {
+	From time.Time
+	To   *time.Time
+}
+
+// overlap returns how much of [from, to] falls within the period.
+func (p Period) overlap(from, to time.Time) time.Duration {
+	end := to
+	if p.To != nil && p.To.Before(end) {
+		end = *p.To
+	}
+
+	start := from
+	if p.From.After(start) {
+		start = p.From
+	}
+
+	if !end.After(start) {
+		return 0
+	}
+
+	return end.Sub(start)
+}
+
+// FineCharge is the amount payable for a fine at a given moment, in kopecks.
+type FineCharge struct {
+	State    FineState `json:"state"`
+	AsOf     time.Time `json:"asOf"`
+	Amount   int64     `json:"amount"`
+	Discount int64     `json:"discount"`
+	Penalty  int64     `json:"penalty"`
+	// OverdueDays is the number of full days since the due date.
+	OverdueDays int64 `json:"overdueDays"`
+	Total       int64 `json:"total"`
+}
+
+// State returns the lifecycle state of the fine at asOf.
+func (f Fine) State(asOf time.Time) FineState {
+	switch f.Status {
+	case FinePaid:
+		return FineStatePaid
+	case FineCancelled:
+		return FineStateCancelled
+	case FineDisputed:
+		return FineStateDisputed
+	}
+
+	switch {
+	case asOf.Before(f.IssuedAt):
+		return FineStatePending
+	case f.DiscountUntil != nil && !asOf.After(*f.DiscountUntil):
+		return FineStateDiscounted
+	case !asOf.After(f.DueAt):
+		return FineStateDue
+	default:
+		return FineStateOverdue
+	}
+}
+
+// Charge computes the amount payable at asOf under the given rules.
+//
+// The result depends only on the fine, its penalty pauses, the rules and
+// asOf, so it can be recomputed for any past or future date. Closed and
+// disputed fines are not payable, and time spent under dispute does not count
+// towards overdue days. Amounts are rounded down to whole kopecks.
+func (f Fine) Charge(asOf time.Time, rules FineRules) FineCharge {
+	charge := FineCharge{State: f.State(asOf), AsOf: asOf, Amount: f.Amount}
+	if !charge.State.Open() {
+		return charge
+	}
+
+	switch charge.State {
+	case FineStateDiscounted:
+		charge.Discount = f.Amount * rules.DiscountPercent / 100
+	case FineStateOverdue:
+		overdue := asOf.Sub(f.DueAt)
+		for _, pause := range f.PenaltyPauses {
+			overdue -= pause.overlap(f.DueAt, asOf)
+		}
+
+		charge.OverdueDays = int64(overdue / (24 * time.Hour))
+		charge.Penalty = min(
+			f.Amount*rules.PenaltyBasisPoints*charge.OverdueDays/10000,
+			f.Amount*rules.PenaltyCapPercent/100,
+		)
+	}
+
+	charge.Total = f.Amount - charge.Discount + charge.Penalty
+
+	return charge
+}
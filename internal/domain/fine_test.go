@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFineCharge(t *testing.T) {
+	const day = 24 * time.Hour
+
+	issuedAt := time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC)
+	discountUntil := issuedAt.Add(20 * day)
+	dueAt := issuedAt.Add(70 * day)
+
+	// 0.1% a day up to 1%: the cap is reached on the tenth overdue day.
+	rules := FineRules{DiscountPercent: 50, PenaltyBasisPoints: 10, PenaltyCapPercent: 1}
+
+	fine := Fine{Amount: 100000, IssuedAt: issuedAt, DueAt: dueAt, DiscountUntil: &discountUntil, Status: FineIssued}
+
+	withStatus := func(status FineStatus) Fine {
+		f := fine
+		f.Status = status
+
+		return f
+	}
+
+	withoutDiscount := fine
+	withoutDiscount.DiscountUntil = nil
+
+	paused := func(pauses ...Period) Fine {
+		f := fine
+		f.PenaltyPauses = pauses
+
+		return f
+	}
+
+	at := func(v time.Time) *time.Time { return &v }
+
+	for _, tc := range []struct {
+		name    string
+		fine    Fine
+		asOf    time.Time
+		state   FineState
+		days    int64
+		penalty int64
+		total   int64
+	}{
+		{"before issue", fine, issuedAt.Add(-time.Nanosecond), FineStatePending, 0, 0, 100000},
+		{"at issue", fine, issuedAt, FineStateDiscounted, 0, 0, 50000},
+		{"last moment of discount", fine, discountUntil, FineStateDiscounted, 0, 0, 50000},
+		{"discount over", fine, discountUntil.Add(time.Nanosecond), FineStateDue, 0, 0, 100000},
+		{"no discount", withoutDiscount, issuedAt, FineStateDue, 0, 0, 100000},
+		{"at due date", fine, dueAt, FineStateDue, 0, 0, 100000},
+		{"just overdue", fine, dueAt.Add(time.Nanosecond), FineStateOverdue, 0, 0, 100000},
+		{"almost a day overdue", fine, dueAt.Add(day - time.Nanosecond), FineStateOverdue, 0, 0, 100000},
+		{"a day overdue", fine, dueAt.Add(day), FineStateOverdue, 1, 100, 100100},
+		{"nine days overdue", fine, dueAt.Add(9 * day), FineStateOverdue, 9, 900, 100900},
+		{"penalty reaches the cap", fine, dueAt.Add(10 * day), FineStateOverdue, 10, 1000, 101000},
+		{"penalty capped", fine, dueAt.Add(11 * day), FineStateOverdue, 11, 1000, 101000},
+		{"paid", withStatus(FinePaid), dueAt.Add(day), FineStatePaid, 0, 0, 0},
+		{"cancelled", withStatus(FineCancelled), issuedAt, FineStateCancelled, 0, 0, 0},
+		{"disputed", withStatus(FineDisputed), dueAt.Add(day), FineStateDisputed, 0, 0, 0},
+		{
+			"paused while overdue", paused(Period{From: dueAt.Add(day / 2), To: at(dueAt.Add(day/2 + 2*day))}),
+			dueAt.Add(3 * day), FineStateOverdue, 1, 100, 100100,
+		},
+		{
+			"paused across the due date", paused(Period{From: dueAt.Add(-day), To: at(dueAt.Add(day))}),
+			dueAt.Add(3 * day), FineStateOverdue, 2, 200, 100200,
+		},
+		{
+			"paused before the due date", paused(Period{From: discountUntil, To: at(dueAt)}),
+			dueAt.Add(3 * day), FineStateOverdue, 3, 300, 100300,
+		},
+		{
+			"still paused", paused(Period{From: dueAt.Add(day)}),
+			dueAt.Add(5 * day), FineStateOverdue, 1, 100, 100100,
+		},
+		{
+			"paused twice", paused(
+				Period{From: dueAt, To: at(dueAt.Add(day))},
+				Period{From: dueAt.Add(2 * day), To: at(dueAt.Add(3 * day))},
+			),
+			dueAt.Add(4*day + time.Hour), FineStateOverdue, 2, 200, 100200,
+		},
+	} {
+		if state := tc.fine.State(tc.asOf); state != tc.state {
+			t.Errorf("%s: state %s, want %s", tc.name, state, tc.state)
+		}
+
+		charge := tc.fine.Charge(tc.asOf, rules)
+		if charge.State != tc.state || charge.OverdueDays != tc.days || charge.Penalty != tc.penalty || charge.Total != tc.total {
+			t.Errorf("%s: charge %s with %d days, penalty %d, total %d; want %s with %d days, penalty %d, total %d",
+				tc.name, charge.State, charge.OverdueDays, charge.Penalty, charge.Total,
+				tc.state, tc.days, tc.penalty, tc.total)
+		}
+	}
+}
+
+func TestFineStateOpen(t *testing.T) {
+	for state, open := range map[FineState]bool{
+		FineStatePending:    true,
+		FineStateDiscounted: true,
+		FineStateDue:        true,
+		FineStateOverdue:    true,
+		FineStatePaid:       false,
+		FineStateCancelled:  false,
+		FineStateDisputed:   false,
+	} {
+		if state.Open() != open {
+			t.Errorf("%s: open %v, want %v", state, state.Open(), open)
+		}
+	}
+}
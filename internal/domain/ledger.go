@@ -0,0 +1,227 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountType determines the side on which an account's balance grows:
+// assets grow with debits, liabilities with credits.
+type AccountType string
+
+const (
+	AccountAsset     AccountType = "asset"
+	AccountLiability AccountType = "liability"
+)
+
+// Codes of the system accounts. Every account exists once per currency.
+const (
+	// AccountAcquirer holds card payments captured by the acquirer that are
+	// owed to us.
+	AccountAcquirer = "acquirer"
+	// AccountTreasury holds fine payments owed to the state treasury.
+	AccountTreasury = "treasury"
+	// AccountMerchants holds payments owed to merchants.
+	AccountMerchants = "merchants"
+)
+
+const userAccountPrefix = "user:"
+
+// UserAccount returns the code of the account holding the user's funds.
+func UserAccount(userID uuid.UUID) string {
+	return userAccountPrefix + userID.String()
+}
+
+// Account is a ledger account. Its balance is not stored but derived from
+// its postings.
+type Account struct {
+	Code      string      `json:"code" db:"code"`
+	Currency  string      `json:"currency" db:"currency"`
+	Type      AccountType `json:"type" db:"type"`
+	UserID    *uuid.UUID  `json:"userId" db:"user_id"`
+	CreatedAt time.Time   `json:"createdAt" db:"created_at"`
+}
+
+// NewAccount describes the account with the given code and currency. User
+// accounts are liabilities: the money is the user's, we only hold it.
+func NewAccount(code, currency string) Account {
+	account := Account{Code: code, Currency: currency, Type: AccountLiability}
+
+	if code == AccountAcquirer {
+		account.Type = AccountAsset
+	}
+
+	if id, ok := strings.CutPrefix(code, userAccountPrefix); ok {
+		if userID, err := uuid.Parse(id); err == nil {
+			account.UserID = &userID
+		}
+	}
+
+	return account
+}
+
+// AccountBalance is the balance of an account on its normal side, so that
+// the funds held for a user are positive.
+type AccountBalance struct {
+	Code     string      `json:"code" db:"code"`
+	Currency string      `json:"currency" db:"currency"`
+	Type     AccountType `json:"type" db:"type"`
+	Balance  int64       `json:"balance" db:"balance"`
+}
+
+// JournalEntryKind is the business event a journal entry records.
+type JournalEntryKind string
+
+const (
+	// JournalPayment records the money of a captured payment arriving from the acquirer.
+	JournalPayment JournalEntryKind = "payment"
+	// JournalSettlement records the money leaving the user's account for a
+	// fine or a merchant.
+	JournalSettlement JournalEntryKind = "settlement"
+	// JournalRefund records money returned to the user's card.
+	JournalRefund JournalEntryKind = "refund"
+)
+
+// JournalEntry is a ledger transaction. Its postings balance to zero in
+// every currency, and it never changes once committed.
+type JournalEntry struct {
+	ID          uuid.UUID        `json:"id" db:"id"`
+	Kind        JournalEntryKind `json:"kind" db:"kind"`
+	PaymentID   *uuid.UUID       `json:"paymentId" db:"payment_id"`
+	Description string           `json:"description" db:"description"`
+	CreatedAt   time.Time        `json:"createdAt" db:"created_at"`
+	Postings    []Posting        `json:"postings" db:"-"`
+}
+
+// Posting changes the balance of an account by Amount minor units: positive
+// amounts are debits, negative amounts credits.
+type Posting struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	EntryID     uuid.UUID `json:"entryId" db:"entry_id"`
+	AccountCode string    `json:"accountCode" db:"account_code"`
+	Currency    string    `json:"currency" db:"currency"`
+	Amount      int64     `json:"amount" db:"amount"`
+}
+
+// Validate checks that the entry has postings, none of them zero, and that
+// they balance in every currency.
+func (e JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("%w: entry %s has %d postings", ErrUnbalancedEntry, e.ID, len(e.Postings))
+	}
+
+	sums := make(map[string]int64)
+	for _, posting := range e.Postings {
+		if posting.Amount == 0 {
+			return fmt.Errorf("%w: entry %s has a zero posting", ErrUnbalancedEntry, e.ID)
+		}
+
+		sums[posting.Currency] += posting.Amount
+	}
+
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("%w: entry %s is off by %d %s", ErrUnbalancedEntry, e.ID, sum, currency)
+		}
+	}
+
+	return nil
+}
+
+// newJournalEntry builds an entry of the payment that debits one account
+// and credits another by amount.
+func (p Payment) newJournalEntry(kind JournalEntryKind, debit, credit string, amount int64, description string, at time.Time) JournalEntry {
+	entry := JournalEntry{
+		ID:          uuid.New(),
+		Kind:        kind,
+		PaymentID:   &p.ID,
+		Description: description,
+		CreatedAt:   at,
+	}
+
+	entry.Postings = []Posting{
+		{ID: uuid.New(), EntryID: entry.ID, AccountCode: debit, Currency: p.Currency, Amount: amount},
+		{ID: uuid.New(), EntryID: entry.ID, AccountCode: credit, Currency: p.Currency, Amount: -amount},
+	}
+
+	return entry
+}
+
+// payeeAccount returns the account a payment is settled to.
+func (p Payment) payeeAccount() string {
+	if p.TargetType == PaymentTargetFine {
+		return AccountTreasury
+	}
+
+	return AccountMerchants
+}
+
+// CaptureEntries returns the journal entries of a captured payment: the money
+// arrives on the user's account from the acquirer, then is settled to the
+// treasury for a fine or to the merchant.
+func (p Payment) CaptureEntries(at time.Time) []JournalEntry {
+	user := UserAccount(p.UserID)
+
+	description := "merchant " + p.MerchantID
+	if p.TargetType == PaymentTargetFine && p.FineID != nil {
+		description = "fine " + p.FineID.String()
+	}
+
+	return []JournalEntry{
+		p.newJournalEntry(JournalPayment, AccountAcquirer, user, p.Amount, "card payment", at),
+		p.newJournalEntry(JournalSettlement, user, p.payeeAccount(), p.Amount, description, at),
+	}
+}
+
+// RefundEntry returns the journal entry of refunding amount of a captured
+// payment: the money is taken back from the payee to the user's account and
+// returned from there to the card.
+func (p Payment) RefundEntry(amount int64, at time.Time) JournalEntry {
+	user := UserAccount(p.UserID)
+
+	entry := p.newJournalEntry(JournalRefund, p.payeeAccount(), user, amount, "refund", at)
+	entry.Postings = append(entry.Postings,
+		Posting{ID: uuid.New(), EntryID: entry.ID, AccountCode: user, Currency: p.Currency, Amount: amount},
+		Posting{ID: uuid.New(), EntryID: entry.ID, AccountCode: AccountAcquirer, Currency: p.Currency, Amount: -amount},
+	)
+
+	return entry
+}
+
+// LedgerReport is the result of a ledger consistency check.
+type LedgerReport struct {
+	Entries  int64 `json:"entries"`
+	Postings int64 `json:"postings"`
+	// Unbalanced lists the entries whose postings do not sum to zero.
+	Unbalanced []UnbalancedEntry `json:"unbalanced"`
+	// Empty lists the entries without postings.
+	Empty []uuid.UUID `json:"empty"`
+	// Totals is the sum of all postings per currency, zero in a consistent ledger.
+	Totals map[string]int64 `json:"totals"`
+}
+
+// UnbalancedEntry is a journal entry whose postings in Currency sum to Sum
+// instead of zero.
+type UnbalancedEntry struct {
+	EntryID  uuid.UUID `json:"entryId" db:"entry_id"`
+	Currency string    `json:"currency" db:"currency"`
+	Sum      int64     `json:"sum" db:"sum"`
+}
+
+// OK reports whether the ledger passed the check.
+func (r LedgerReport) OK() bool {
+	if len(r.Unbalanced) > 0 || len(r.Empty) > 0 {
+		return false
+	}
+
+	for _, total := range r.Totals {
+		if total != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestJournalEntryValidate(t *testing.T) {
+	posting := func(account, currency string, amount int64) Posting {
+		return Posting{ID: uuid.New(), AccountCode: account, Currency: currency, Amount: amount}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		postings []Posting
+		valid    bool
+	}{
+		{"balanced", []Posting{posting(AccountAcquirer, "RUB", 100), posting(AccountTreasury, "RUB", -100)}, true},
+		{"balanced in two currencies", []Posting{
+			posting(AccountAcquirer, "RUB", 100), posting(AccountTreasury, "RUB", -100),
+			posting(AccountAcquirer, "USD", 1), posting(AccountMerchants, "USD", -1),
+		}, true},
+		{"no postings", nil, false},
+		{"one posting", []Posting{posting(AccountAcquirer, "RUB", 100)}, false},
+		{"zero posting", []Posting{
+			posting(AccountAcquirer, "RUB", 100), posting(AccountTreasury, "RUB", -100), posting(AccountMerchants, "RUB", 0),
+		}, false},
+		{"unbalanced", []Posting{posting(AccountAcquirer, "RUB", 100), posting(AccountTreasury, "RUB", -99)}, false},
+		{"currency mismatch", []Posting{posting(AccountAcquirer, "RUB", 100), posting(AccountTreasury, "USD", -100)}, false},
+	} {
+		err := JournalEntry{ID: uuid.New(), Postings: tc.postings}.Validate()
+		if tc.valid && err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+
+		if !tc.valid && !errors.Is(err, ErrUnbalancedEntry) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, ErrUnbalancedEntry)
+		}
+	}
+}
+
+func TestPaymentEntriesBalance(t *testing.T) {
+	fineID := uuid.New()
+	payment := Payment{
+		ID:         uuid.New(),
+		UserID:     uuid.New(),
+		Amount:     50000,
+		Currency:   "RUB",
+		TargetType: PaymentTargetFine,
+		FineID:     &fineID,
+	}
+
+	entries := append(payment.CaptureEntries(time.Now()), payment.RefundEntry(20000, time.Now()))
+
+	balances := make(map[string]int64)
+	for _, entry := range entries {
+		if err := entry.Validate(); err != nil {
+			t.Errorf("%s entry: %v", entry.Kind, err)
+		}
+
+		for _, posting := range entry.Postings {
+			balances[posting.AccountCode] += posting.Amount
+		}
+	}
+
+	// The refunded part is back at the acquirer, the rest at the treasury.
+	for account, want := range map[string]int64{
+		AccountAcquirer:             30000,
+		AccountTreasury:             -30000,
+		UserAccount(payment.UserID): 0,
+	} {
+		if balances[account] != want {
+			t.Errorf("%s: balance %d, want %d", account, balances[account], want)
+		}
+	}
+}
+
+func TestLedgerReportOK(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		report LedgerReport
+		ok     bool
+	}{
+		{"empty ledger", LedgerReport{}, true},
+		{"balanced", LedgerReport{Entries: 2, Postings: 4, Totals: map[string]int64{"RUB": 0}}, true},
+		{"unbalanced entry", LedgerReport{Unbalanced: []UnbalancedEntry{{EntryID: uuid.New(), Currency: "RUB", Sum: 1}}}, false},
+		{"entry without postings", LedgerReport{Empty: []uuid.UUID{uuid.New()}}, false},
+		{"drifted total", LedgerReport{Totals: map[string]int64{"RUB": 0, "USD": -5}}, false},
+	} {
+		if ok := tc.report.OK(); ok != tc.ok {
+			t.Errorf("%s: ok %v, want %v", tc.name, ok, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecoveryCode is a single-use code that replaces the TOTP code when the
+// authenticator is lost. Only its hash is stored.
+type RecoveryCode struct {
+	ID        uuid.UUID  `db:"id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	Hash      string     `db:"code_hash"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// TOTPEnrollment is what a user needs to add the account to an authenticator app.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	// QRCode is a PNG image of URI.
+	QRCode []byte `json:"qrCode"`
+}
+
+// SignInResult is either a pair of tokens or, for users with two-factor
+// authentication, a challenge token to be exchanged for them.
+type SignInResult struct {
+	Tokens      Tokens
+	MFARequired bool
+	MFAToken    string
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationKind tells what a notification is about.
+type NotificationKind string
+
+const (
+	NotificationAutopayScheduled NotificationKind = "autopay_scheduled"
+	NotificationAutopayDeclined  NotificationKind = "autopay_declined"
+	NotificationAlert            NotificationKind = "alert"
+)
+
+// Notification is a message to a user shown in the app.
+type Notification struct {
+	ID     uuid.UUID        `json:"id" db:"id"`
+	UserID uuid.UUID        `json:"-" db:"user_id"`
+	Kind   NotificationKind `json:"kind" db:"kind"`
+	// SubjectID is the object the notification is about, e.g. a fine.
+	SubjectID *uuid.UUID `json:"subjectId,omitempty" db:"subject_id"`
+	Message   string     `json:"message" db:"message"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentStatus is the status of a payment in the processing pipeline.
+type PaymentStatus string
+
+const (
+	PaymentPending    PaymentStatus = "pending"
+	PaymentAuthorized PaymentStatus = "authorized"
+	PaymentCaptured   PaymentStatus = "captured"
+	PaymentFailed     PaymentStatus = "failed"
+	PaymentRefunded   PaymentStatus = "refunded"
+)
+
+// paymentTransitions lists the statuses each status may change to. Failed
+// and refunded payments are final.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentPending:    {PaymentAuthorized, PaymentCaptured, PaymentFailed},
+	PaymentAuthorized: {PaymentCaptured, PaymentFailed},
+	PaymentCaptured:   {PaymentRefunded},
+}
+
+// Valid reports whether the status is one of the known payment statuses.
+func (s PaymentStatus) Valid() bool {
+	switch s {
+	case PaymentPending, PaymentAuthorized, PaymentCaptured, PaymentFailed, PaymentRefunded:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanTransition reports whether a payment may move from s to the given status.
+func (s PaymentStatus) CanTransition(to PaymentStatus) bool {
+	for _, next := range paymentTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PathTo returns the shortest sequence of transitions leading from s to the
+// given status, excluding s itself. It returns nil if the status cannot be
+// reached, including when it is s.
+func (s PaymentStatus) PathTo(to PaymentStatus) []PaymentStatus {
+	prev := map[PaymentStatus]PaymentStatus{s: s}
+	queue := []PaymentStatus{s}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range paymentTransitions[current] {
+			if _, seen := prev[next]; seen {
+				continue
+			}
+
+			prev[next] = current
+			if next != to {
+				queue = append(queue, next)
+				continue
+			}
+
+			var path []PaymentStatus
+			for step := next; step != s; step = prev[step] {
+				path = append([]PaymentStatus{step}, path...)
+			}
+
+			return path
+		}
+	}
+
+	return nil
+}
+
+// InProgress reports whether a payment in this status has taken or may still
+// take the money, so its target must not be paid again.
+func (s PaymentStatus) InProgress() bool {
+	return s == PaymentPending || s == PaymentAuthorized || s == PaymentCaptured
+}
+
+// PaymentTarget is what a payment pays for.
+type PaymentTarget string
+
+const (
+	PaymentTargetFine     PaymentTarget = "fine"
+	PaymentTargetMerchant PaymentTarget = "merchant"
+)
+
+// Payment is a transfer of money from the user to a fine or a merchant.
+// Amount is in minor units of Currency, e.g. kopecks for RUB.
+type Payment struct {
+	ID         uuid.UUID     `json:"id" db:"id"`
+	UserID     uuid.UUID     `json:"userId" db:"user_id"`
+	Amount     int64         `json:"amount" db:"amount"`
+	Currency   string        `json:"currency" db:"currency"`
+	Purpose    string        `json:"purpose" db:"purpose"`
+	TargetType PaymentTarget `json:"targetType" db:"target_type"`
+	FineID     *uuid.UUID    `json:"fineId" db:"fine_id"`
+	MerchantID string        `json:"merchantId" db:"merchant_id"`
+	Status     PaymentStatus `json:"status" db:"status"`
+	// Refunded is the amount returned to the card by succeeded refunds. The
+	// payment becomes refunded once it reaches Amount.
+	Refunded int64 `json:"refunded" db:"refunded"`
+	// FailureReason explains why a payment failed.
+	FailureReason string `json:"failureReason" db:"failure_reason"`
+	// GatewayRef is the acquirer's reference of the card transaction.
+	GatewayRef string `json:"gatewayRef" db:"gateway_ref"`
+	// ChallengeURL is the 3-D Secure page the user has to complete before a
+	// pending payment is authorized.
+	ChallengeURL string `json:"challengeUrl" db:"challenge_url"`
+	// IdempotencyKey is the client supplied key the payment was created with,
+	// and RequestHash the fingerprint of the request that used it.
+	IdempotencyKey string    `json:"-" db:"idempotency_key"`
+	RequestHash    string    `json:"-" db:"request_hash"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Refundable returns the amount that may still be refunded, not counting
+// refunds that are pending.
+func (p Payment) Refundable() int64 {
+	if p.Status != PaymentCaptured {
+		return 0
+	}
+
+	return p.Amount - p.Refunded
+}
+
+// PaymentTransition is a change of a payment's status together with its
+// effects, which are applied atomically. From and To may be equal to record
+// a change of the gateway state alone, such as a 3-D Secure challenge.
+type PaymentTransition struct {
+	PaymentID     uuid.UUID
+	From          PaymentStatus
+	To            PaymentStatus
+	FailureReason string
+	GatewayRef    string
+	ChallengeURL  string
+	// Entries record the money moved by the transition in the ledger.
+	Entries []JournalEntry
+	// FineEvent is set when the transition changes the status of the paid
+	// fine; the fine takes the status of the event. The fine has to be
+	// issued, or the transition fails with ErrFineNotPayable.
+	FineEvent *FineEvent
+}
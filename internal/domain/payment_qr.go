@@ -0,0 +1,56 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Payee holds the bank details of the recipient of a payment by bank transfer.
+type Payee struct {
+	Name        string `json:"name"`
+	PersonalAcc string `json:"personalAcc"`
+	BankName    string `json:"bankName"`
+	BIC         string `json:"bic"`
+	CorrespAcc  string `json:"correspAcc"`
+	INN         string `json:"inn,omitempty"`
+	KPP         string `json:"kpp,omitempty"`
+	// OKTMO is the code of the municipality the payment is credited to.
+	OKTMO string `json:"oktmo,omitempty"`
+	// CBC is the budget classification code of state payments.
+	CBC string `json:"cbc,omitempty"`
+}
+
+// QRFormat is the image format of a QR code.
+type QRFormat string
+
+const (
+	QRFormatPNG QRFormat = "png"
+	QRFormatSVG QRFormat = "svg"
+)
+
+// ContentType returns the MIME type of images in the format.
+func (f QRFormat) ContentType() string {
+	if f == QRFormatSVG {
+		return "image/svg+xml"
+	}
+
+	return "image/png"
+}
+
+// PaymentQR is a payment QR code rendered as an image.
+type PaymentQR struct {
+	// Payload is the text encoded in the QR code.
+	Payload string
+	Format  QRFormat
+	Image   []byte
+}
+
+// PaymentDraft is a payment pre-filled from a scanned QR code, to be reviewed
+// by the user before it is created. It targets the user's fine if the UIN
+// matches one, and the payee as a merchant otherwise.
+type PaymentDraft struct {
+	Amount     int64      `json:"amount"`
+	Currency   string     `json:"currency"`
+	Purpose    string     `json:"purpose"`
+	FineID     *uuid.UUID `json:"fineId,omitempty"`
+	MerchantID string     `json:"merchantId,omitempty"`
+	UIN        string     `json:"uin,omitempty"`
+	Payee      Payee      `json:"payee"`
+}
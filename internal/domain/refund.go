@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefundStatus is the status of a refund at the acquirer.
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed"
+)
+
+// Refund returns part or all of a captured payment to the card. A payment
+// may be refunded several times as long as the refunds that are pending or
+// succeeded do not exceed the captured amount.
+type Refund struct {
+	ID        uuid.UUID    `json:"id" db:"id"`
+	PaymentID uuid.UUID    `json:"paymentId" db:"payment_id"`
+	UserID    uuid.UUID    `json:"userId" db:"user_id"`
+	Amount    int64        `json:"amount" db:"amount"`
+	Currency  string       `json:"currency" db:"currency"`
+	Reason    string       `json:"reason" db:"reason"`
+	Status    RefundStatus `json:"status" db:"status"`
+	// FailureReason explains why a refund failed.
+	FailureReason  string    `json:"failureReason" db:"failure_reason"`
+	IdempotencyKey string    `json:"-" db:"idempotency_key"`
+	RequestHash    string    `json:"-" db:"request_hash"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Reserved reports whether the amount of the refund counts against the
+// refundable amount of the payment.
+func (r Refund) Reserved() bool {
+	return r.Status == RefundPending || r.Status == RefundSucceeded
+}
+
+// RefundCompletion records the outcome of a pending refund together with its
+// effects, which are applied atomically.
+type RefundCompletion struct {
+	RefundID      uuid.UUID
+	Status        RefundStatus
+	FailureReason string
+	At            time.Time
+	// Entry reverses the payment in the ledger; set when the refund succeeded.
+	Entry *JournalEntry
+	// FineEvent notes the refund in the history of the paid fine. The refund
+	// that completes the refund of the payment issues the fine again, and the
+	// event then records it as issued.
+	FineEvent *FineEvent
+}
@@ -0,0 +1,56 @@
+package domain
+
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+// Access token scopes. A scope ending in "*" grants every scope with the same prefix.
+const (
+	ScopeProfileRead    = "profile:read"
+	ScopeProfileWrite   = "profile:write"
+	ScopeFinesRead      = "fines:read"
+	ScopeFinesWrite     = "fines:write"
+	ScopeFinesReview    = "fines:review"
+	ScopePaymentsRead   = "payments:read"
+	ScopePaymentsWrite  = "payments:write"
+	ScopePaymentsRefund = "payments:refund"
+	ScopeCryptoRead     = "crypto:read"
+	ScopeCryptoWrite    = "crypto:write"
+	ScopeAlertsRead     = "alerts:read"
+	ScopeAlertsWrite    = "alerts:write"
+	ScopeAll            = "*"
+)
+
+var roleScopes = map[Role][]string{
+	RoleCustomer: {
+		ScopeProfileRead, ScopeProfileWrite,
+		ScopeFinesRead, ScopeFinesWrite,
+		ScopePaymentsRead, ScopePaymentsWrite,
+		ScopeCryptoRead, ScopeCryptoWrite,
+		ScopeAlertsRead, ScopeAlertsWrite,
+	},
+	RoleOperator: {
+		ScopeProfileRead, ScopeProfileWrite,
+		"fines:*",
+		ScopePaymentsRead, ScopePaymentsRefund,
+	},
+	RoleAdmin: {
+		ScopeAll,
+	},
+}
+
+// Scopes returns the scopes granted to the role.
+func (r Role) Scopes() []string {
+	return roleScopes[r]
+}
+
+// Valid reports whether r is a known role.
+func (r Role) Valid() bool {
+	_, ok := roleScopes[r]
+
+	return ok
+}
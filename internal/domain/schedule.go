@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleKind is the kind of calendar rule a schedule follows.
+type ScheduleKind string
+
+const (
+	// ScheduleOnce runs a single time, at the start of the schedule.
+	ScheduleOnce ScheduleKind = "once"
+	// ScheduleMonthly runs on a given day of every month. In months that are
+	// too short it runs on the last day instead.
+	ScheduleMonthly ScheduleKind = "monthly"
+	// ScheduleLastBusinessDay runs on the last weekday of every month.
+	ScheduleLastBusinessDay ScheduleKind = "last_business_day"
+	// ScheduleWeekly runs every Interval weeks on the weekday of the start.
+	ScheduleWeekly ScheduleKind = "weekly"
+)
+
+// ScheduleRule describes when the occurrences of a schedule fall. Every
+// occurrence keeps the time of day of the start of the schedule.
+type ScheduleRule struct {
+	Kind ScheduleKind `json:"kind" db:"kind"`
+	// Day is the day of the month of monthly schedules, 1 to 31.
+	Day int `json:"day,omitempty" db:"day"`
+	// Interval is the number of weeks between the runs of weekly schedules.
+	Interval int `json:"interval,omitempty" db:"interval_weeks"`
+}
+
+// Validate checks that the rule is complete for its kind.
+func (r ScheduleRule) Validate() error {
+	switch r.Kind {
+	case ScheduleOnce, ScheduleLastBusinessDay:
+		return nil
+	case ScheduleMonthly:
+		if r.Day < 1 || r.Day > 31 {
+			return fmt.Errorf("%w: day must be between 1 and 31", ErrScheduleRule)
+		}
+
+		return nil
+	case ScheduleWeekly:
+		if r.Interval < 1 || r.Interval > 52 {
+			return fmt.Errorf("%w: interval must be between 1 and 52 weeks", ErrScheduleRule)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrScheduleRule, r.Kind)
+	}
+}
+
+// Next returns the first occurrence after the given moment of a schedule
+// starting at start. It returns false if there is none, i.e. the single run
+// of a one-off schedule has passed.
+func (r ScheduleRule) Next(start, after time.Time) (time.Time, bool) {
+	if after.Before(start) {
+		after = start.Add(-time.Nanosecond)
+	}
+
+	switch r.Kind {
+	case ScheduleOnce:
+		return start, start.After(after)
+	case ScheduleWeekly:
+		step := 7 * r.Interval
+		// Jump close to the answer instead of walking from the start; the
+		// estimate may be a step off around daylight saving changes.
+		n := int(after.Sub(start)/(24*time.Hour)) / step
+		for {
+			next := start.AddDate(0, 0, n*step)
+			if next.After(after) {
+				return next, true
+			}
+
+			n++
+		}
+	case ScheduleMonthly, ScheduleLastBusinessDay:
+		year, month, _ := after.In(start.Location()).Date()
+		for {
+			next := r.inMonth(start, year, month)
+			if next.After(after) {
+				return next, true
+			}
+
+			month++
+			if month > time.December {
+				month = time.January
+				year++
+			}
+		}
+	default:
+		return time.Time{}, false
+	}
+}
+
+// inMonth returns the run of a monthly rule in the given month.
+func (r ScheduleRule) inMonth(start time.Time, year int, month time.Month) time.Time {
+	hour, minute, sec := start.Clock()
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, start.Location()).Day()
+
+	day := min(r.Day, last)
+	if r.Kind == ScheduleLastBusinessDay {
+		day = last
+		for {
+			weekday := time.Date(year, month, day, 0, 0, 0, 0, start.Location()).Weekday()
+			if weekday != time.Saturday && weekday != time.Sunday {
+				break
+			}
+
+			day--
+		}
+	}
+
+	return time.Date(year, month, day, hour, minute, sec, start.Nanosecond(), start.Location())
+}
+
+// ScheduleStatus is the status of a payment schedule.
+type ScheduleStatus string
+
+const (
+	ScheduleActive    ScheduleStatus = "active"
+	SchedulePaused    ScheduleStatus = "paused"
+	ScheduleCompleted ScheduleStatus = "completed"
+	ScheduleCancelled ScheduleStatus = "cancelled"
+)
+
+// Schedule makes payments on behalf of a user according to a calendar rule.
+// Each occurrence creates a payment of the fine or merchant with the saved
+// card of the schedule.
+type Schedule struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	UserID uuid.UUID `json:"userId" db:"user_id"`
+	ScheduleRule
+	// Amount is in minor units; for a fine it may be zero to pay whatever is due.
+	Amount     int64      `json:"amount" db:"amount"`
+	Currency   string     `json:"currency" db:"currency"`
+	Purpose    string     `json:"purpose" db:"purpose"`
+	FineID     *uuid.UUID `json:"fineId,omitempty" db:"fine_id"`
+	MerchantID string     `json:"merchantId,omitempty" db:"merchant_id"`
+	// CardToken refers to the card saved at the acquirer.
+	CardToken string         `json:"-" db:"card_token"`
+	CardLast4 string         `json:"cardLast4" db:"card_last4"`
+	StartsAt  time.Time      `json:"startsAt" db:"starts_at"`
+	EndsAt    *time.Time     `json:"endsAt,omitempty" db:"ends_at"`
+	Status    ScheduleStatus `json:"status" db:"status"`
+	// NextRunAt is the next occurrence, nil once the schedule is over.
+	NextRunAt *time.Time `json:"nextRunAt" db:"next_run_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// Advance moves the schedule to the occurrence following after, completing
+// it when there are no more.
+func (s *Schedule) Advance(after time.Time) {
+	next, ok := s.Next(s.StartsAt, after)
+	if !ok || (s.EndsAt != nil && next.After(*s.EndsAt)) {
+		s.Status = ScheduleCompleted
+		s.NextRunAt = nil
+
+		return
+	}
+
+	s.NextRunAt = &next
+}
+
+// OccurrenceStatus is the outcome of an occurrence of a schedule.
+type OccurrenceStatus string
+
+const (
+	OccurrenceSucceeded OccurrenceStatus = "succeeded"
+	OccurrenceFailed    OccurrenceStatus = "failed"
+	// OccurrenceSkipped means no payment was attempted, e.g. because the
+	// schedule was paused at the time.
+	OccurrenceSkipped OccurrenceStatus = "skipped"
+)
+
+// Reasons of skipped occurrences. Failed occurrences carry the failure
+// reason of the payment.
+const (
+	OccurrencePaused         = "paused"
+	OccurrenceMissed         = "missed"
+	OccurrenceFineNotPayable = "fine_not_payable"
+)
+
+// ScheduleOccurrence records what happened at a run of a schedule.
+type ScheduleOccurrence struct {
+	ID           uuid.UUID        `json:"id" db:"id"`
+	ScheduleID   uuid.UUID        `json:"scheduleId" db:"schedule_id"`
+	ScheduledFor time.Time        `json:"scheduledFor" db:"scheduled_for"`
+	Status       OccurrenceStatus `json:"status" db:"status"`
+	PaymentID    *uuid.UUID       `json:"paymentId,omitempty" db:"payment_id"`
+	Reason       string           `json:"reason,omitempty" db:"reason"`
+	CreatedAt    time.Time        `json:"createdAt" db:"created_at"`
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduleRuleValidate(t *testing.T) {
+	for _, tc := range []struct {
+		rule  ScheduleRule
+		valid bool
+	}{
+		{ScheduleRule{Kind: ScheduleOnce}, true},
+		{ScheduleRule{Kind: ScheduleLastBusinessDay}, true},
+		{ScheduleRule{Kind: ScheduleMonthly, Day: 1}, true},
+		{ScheduleRule{Kind: ScheduleMonthly, Day: 31}, true},
+		{ScheduleRule{Kind: ScheduleMonthly}, false},
+		{ScheduleRule{Kind: ScheduleMonthly, Day: 32}, false},
+		{ScheduleRule{Kind: ScheduleWeekly, Interval: 1}, true},
+		{ScheduleRule{Kind: ScheduleWeekly, Interval: 52}, true},
+		{ScheduleRule{Kind: ScheduleWeekly}, false},
+		{ScheduleRule{Kind: ScheduleWeekly, Interval: 53}, false},
+		{ScheduleRule{Kind: "daily"}, false},
+	} {
+		err := tc.rule.Validate()
+		if tc.valid && err != nil {
+			t.Errorf("%+v: %v", tc.rule, err)
+		}
+
+		if !tc.valid && !errors.Is(err, ErrScheduleRule) {
+			t.Errorf("%+v: error %v, want %v", tc.rule, err, ErrScheduleRule)
+		}
+	}
+}
+
+func TestScheduleRuleNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	// A Wednesday, the last day of a month before a leap February.
+	start := at(2024, time.January, 31, 9, 30)
+
+	once := ScheduleRule{Kind: ScheduleOnce}
+	weekly := ScheduleRule{Kind: ScheduleWeekly, Interval: 1}
+	fortnightly := ScheduleRule{Kind: ScheduleWeekly, Interval: 2}
+	monthly31 := ScheduleRule{Kind: ScheduleMonthly, Day: 31}
+	monthly15 := ScheduleRule{Kind: ScheduleMonthly, Day: 15}
+	lastBusinessDay := ScheduleRule{Kind: ScheduleLastBusinessDay}
+
+	for _, tc := range []struct {
+		name  string
+		rule  ScheduleRule
+		start time.Time
+		after time.Time
+		next  time.Time
+		ok    bool
+	}{
+		{"once ahead", once, start, start.Add(-time.Hour), start, true},
+		{"once just ahead", once, start, start.Add(-time.Nanosecond), start, true},
+		{"once run", once, start, start, time.Time{}, false},
+		{"once long run", once, start, start.AddDate(1, 0, 0), time.Time{}, false},
+
+		{"weekly before the start", weekly, start, start.AddDate(0, 0, -3), start, true},
+		{"weekly at the start", weekly, start, start, start.AddDate(0, 0, 7), true},
+		{"weekly just before a run", weekly, start, start.AddDate(0, 0, 7).Add(-time.Nanosecond), start.AddDate(0, 0, 7), true},
+		{"weekly at a run", weekly, start, start.AddDate(0, 0, 7), start.AddDate(0, 0, 14), true},
+		{"weekly far ahead", weekly, start, start.AddDate(1, 0, 1), start.AddDate(0, 0, 371), true},
+		{"fortnightly between runs", fortnightly, start, start.AddDate(0, 0, 8), start.AddDate(0, 0, 14), true},
+		{
+			"weekly keeps the local time over daylight saving",
+			weekly, time.Date(2024, time.March, 4, 9, 0, 0, 0, newYork), time.Date(2024, time.March, 4, 9, 0, 0, 0, newYork),
+			time.Date(2024, time.March, 11, 9, 0, 0, 0, newYork), true,
+		},
+
+		{"monthly clamped to a leap february", monthly31, start, start, at(2024, time.February, 29, 9, 30), true},
+		{"monthly on the clamped day", monthly31, start, at(2024, time.February, 29, 9, 29), at(2024, time.February, 29, 9, 30), true},
+		{"monthly back to the 31st", monthly31, start, at(2024, time.February, 29, 9, 30), at(2024, time.March, 31, 9, 30), true},
+		{"monthly clamped to the 30th", monthly31, start, at(2024, time.March, 31, 9, 30), at(2024, time.April, 30, 9, 30), true},
+		{"monthly clamped to february", monthly31, start, at(2025, time.January, 31, 10, 0), at(2025, time.February, 28, 9, 30), true},
+		{"monthly over the new year", monthly31, start, at(2024, time.December, 31, 10, 0), at(2025, time.January, 31, 9, 30), true},
+		{"monthly before the start", monthly15, start, at(2023, time.June, 1, 0, 0), at(2024, time.February, 15, 9, 30), true},
+		{"monthly later the same month", monthly15, start, at(2024, time.March, 1, 0, 0), at(2024, time.March, 15, 9, 30), true},
+
+		{"last business day on a thursday", lastBusinessDay, start, start, at(2024, time.February, 29, 9, 30), true},
+		{"last business day before a weekend", lastBusinessDay, start, at(2024, time.February, 29, 9, 30), at(2024, time.March, 29, 9, 30), true},
+		{"last business day on a tuesday", lastBusinessDay, start, at(2024, time.March, 29, 9, 30), at(2024, time.April, 30, 9, 30), true},
+
+		{"unknown kind", ScheduleRule{Kind: "daily"}, start, start, time.Time{}, false},
+	} {
+		next, ok := tc.rule.Next(tc.start, tc.after)
+		if ok != tc.ok || ok && !next.Equal(tc.next) {
+			t.Errorf("%s: next %v %v, want %v %v", tc.name, next, ok, tc.next, tc.ok)
+		}
+	}
+}
+
+func TestScheduleAdvance(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 9, 30, 0, 0, time.UTC)
+	endsAt := time.Date(2024, time.March, 31, 9, 30, 0, 0, time.UTC)
+
+	ptr := func(v time.Time) *time.Time { return &v }
+
+	for _, tc := range []struct {
+		name   string
+		rule   ScheduleRule
+		endsAt *time.Time
+		after  time.Time
+		next   *time.Time
+	}{
+		{"once pending", ScheduleRule{Kind: ScheduleOnce}, nil, start.Add(-time.Minute), &start},
+		{"once done", ScheduleRule{Kind: ScheduleOnce}, nil, start, nil},
+		{"before the end", ScheduleRule{Kind: ScheduleMonthly, Day: 31}, &endsAt, start, ptr(time.Date(2024, time.February, 29, 9, 30, 0, 0, time.UTC))},
+		{"run at the end", ScheduleRule{Kind: ScheduleMonthly, Day: 31}, &endsAt, time.Date(2024, time.February, 29, 9, 30, 0, 0, time.UTC), &endsAt},
+		{"past the end", ScheduleRule{Kind: ScheduleMonthly, Day: 31}, &endsAt, endsAt, nil},
+		{"endless", ScheduleRule{Kind: ScheduleMonthly, Day: 31}, nil, endsAt, ptr(time.Date(2024, time.April, 30, 9, 30, 0, 0, time.UTC))},
+	} {
+		schedule := Schedule{ScheduleRule: tc.rule, StartsAt: start, EndsAt: tc.endsAt, Status: ScheduleActive}
+		schedule.Advance(tc.after)
+
+		switch {
+		case tc.next == nil:
+			if schedule.NextRunAt != nil || schedule.Status != ScheduleCompleted {
+				t.Errorf("%s: next run %v, status %s; want none and completed", tc.name, schedule.NextRunAt, schedule.Status)
+			}
+		case schedule.NextRunAt == nil || !schedule.NextRunAt.Equal(*tc.next) || schedule.Status != ScheduleActive:
+			t.Errorf("%s: next run %v, status %s; want %v and active", tc.name, schedule.NextRunAt, schedule.Status, *tc.next)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a sign-in of a user on one device. Every refresh token issued
+// for it belongs to the same token family, identified by the session ID.
+type Session struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"-" db:"user_id"`
+	Device     string     `json:"device" db:"device"`
+	IP         string     `json:"ip" db:"ip"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	LastSeenAt time.Time  `json:"lastSeenAt" db:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expiresAt" db:"expires_at"`
+	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
+}
+
+// Active reports whether the session can still be refreshed at the given time.
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RefreshToken is a one-time refresh token. Only a hash of the token is
+// stored; the token itself is known to the client alone.
+type RefreshToken struct {
+	Hash      string     `db:"token_hash"`
+	SessionID uuid.UUID  `db:"session_id"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+type Tokens struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+}
@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Stats holds the per-user analytics blobs served by the /info routes.
+type Stats struct {
+	UserID      uuid.UUID `db:"user_id"`
+	NeuroMean   float64   `db:"neuro_mean"`
+	CryptoData  string    `db:"crypto_data"`
+	StatsData   string    `db:"stats_data"`
+	Analyze     string    `db:"analysis"`
+	APIInfo     string    `db:"api_info"`
+	FullAPIInfo string    `db:"full_api_info"`
+}
+
+// StatsMetric is a flow of money that statistics sum up.
+type StatsMetric string
+
+const (
+	// StatsSpending is the money paid for fines and to merchants, counted
+	// when the payment was captured.
+	StatsSpending StatsMetric = "spending"
+	// StatsIncome is the money refunded to the user, counted when the refund
+	// succeeded.
+	StatsIncome StatsMetric = "income"
+	// StatsFines is the amounts of the fines issued to the user, counted when
+	// issued. Cancelled fines are left out.
+	StatsFines StatsMetric = "fines"
+)
+
+// StatsMetrics are the metrics a statistics report has series of.
+var StatsMetrics = []StatsMetric{StatsSpending, StatsIncome, StatsFines}
+
+// StatsCategoryFines is the category of payments and refunds of fines;
+// those of merchant payments are categorized by merchant.
+const StatsCategoryFines = "fines"
+
+// StatsBucket is the length of the periods statistics are summed over.
+// Buckets start at midnight in the time zone of the report, weeks on Monday.
+type StatsBucket string
+
+const (
+	StatsDay   StatsBucket = "day"
+	StatsWeek  StatsBucket = "week"
+	StatsMonth StatsBucket = "month"
+)
+
+// Valid reports whether the bucket is one of the known lengths.
+func (b StatsBucket) Valid() bool {
+	switch b {
+	case StatsDay, StatsWeek, StatsMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+// Start returns the start of the bucket containing t, in the location of t.
+func (b StatsBucket) Start(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	switch b {
+	case StatsWeek:
+		return time.Date(year, month, day-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case StatsMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// Add returns the start of the bucket n buckets after the one starting at start.
+func (b StatsBucket) Add(start time.Time, n int) time.Time {
+	switch b {
+	case StatsWeek:
+		return start.AddDate(0, 0, 7*n)
+	case StatsMonth:
+		return start.AddDate(0, n, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+// StatsQuery selects the money flows a statistics repository sums up.
+type StatsQuery struct {
+	Metric StatsMetric
+	Bucket StatsBucket
+	// Location is the time zone the buckets start at midnight in.
+	Location *time.Location
+	From     time.Time
+	To       time.Time
+}
+
+// StatsRow is the sum of the flows of a metric in one bucket, currency and
+// category.
+type StatsRow struct {
+	// Start is the local date the bucket starts on, as midnight UTC.
+	Start    time.Time `db:"bucket"`
+	Currency string    `db:"currency"`
+	Category string    `db:"category"`
+	Amount   int64     `db:"amount"`
+	Count    int64     `db:"count"`
+}
+
+// StatsReport is the time series of the money flows of a user. Amounts are
+// in minor units of the currency of their series.
+type StatsReport struct {
+	Bucket   StatsBucket `json:"bucket"`
+	Timezone string      `json:"timezone"`
+	// From and To bound the period of the report, To excluded.
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// PreviousFrom starts the period of as many buckets right before From
+	// that the totals are compared with.
+	PreviousFrom time.Time `json:"previousFrom"`
+	// Series has a series per metric and currency, with at least one in
+	// rubles for every metric.
+	Series []StatsSeries `json:"series"`
+}
+
+// StatsSeries is the flows of a metric in one currency.
+type StatsSeries struct {
+	Metric   StatsMetric `json:"metric"`
+	Currency string      `json:"currency"`
+	// Points has a point for every bucket of the period, oldest first.
+	Points        []StatsPoint `json:"points"`
+	Total         int64        `json:"total"`
+	Count         int64        `json:"count"`
+	PreviousTotal int64        `json:"previousTotal"`
+	PreviousCount int64        `json:"previousCount"`
+	// Change is the change of Total from PreviousTotal in percent, rounded
+	// to two decimals; null if there was nothing in the previous period.
+	Change *float64 `json:"change"`
+	// Categories breaks Total down, largest first.
+	Categories []StatsCategory `json:"categories"`
+}
+
+// StatsPoint is the sum of the flows in the bucket starting at Start.
+type StatsPoint struct {
+	Start  time.Time `json:"start"`
+	Amount int64     `json:"amount"`
+	Count  int64     `json:"count"`
+}
+
+// StatsCategory is the part of a total falling into a category: fines or a
+// merchant for spending and income, the issuer for fines.
+type StatsCategory struct {
+	Category string `json:"category"`
+	Amount   int64  `json:"amount"`
+	Count    int64  `json:"count"`
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name"`
+	Email        string    `json:"email" db:"email"`
+	Phone        string    `json:"phone" db:"phone"`
+	Password     string    `json:"-" db:"password_hash"`
+	Role         Role      `json:"role" db:"role"`
+	RegisteredAt time.Time `json:"registeredAt" db:"registered_at"`
+	// TOTPSecret is set on enrollment and only takes effect once TOTPEnabled
+	// is set by confirming the first code.
+	TOTPSecret  string `json:"-" db:"totp_secret"`
+	TOTPEnabled bool   `json:"totpEnabled" db:"totp_enabled"`
+	// TOTPCounter is the time step of the last accepted code. Codes from this
+	// or an earlier step are rejected so that each code works only once.
+	TOTPCounter int64 `json:"-" db:"totp_counter"`
+}
+
+// BaseInfo is the short profile summary shown on the main screen.
+type BaseInfo struct {
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	Phone        string    `json:"phone"`
+	RegisteredAt time.Time `json:"registeredAt"`
+}
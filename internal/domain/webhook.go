@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookOutcome is what processing a webhook event did.
+type WebhookOutcome string
+
+const (
+	// WebhookApplied means the event changed the payment.
+	WebhookApplied WebhookOutcome = "applied"
+	// WebhookIgnored means the event was valid but had no effect, e.g. because
+	// a later event already moved the payment past it.
+	WebhookIgnored WebhookOutcome = "ignored"
+)
+
+// WebhookEvent is a verified webhook delivery, kept with its raw payload for
+// audit. Events are unique per provider and EventID; ProcessedAt stays nil
+// until the event has been applied, so that a redelivery retries it.
+type WebhookEvent struct {
+	ID          uuid.UUID      `json:"id" db:"id"`
+	Provider    string         `json:"provider" db:"provider"`
+	EventID     string         `json:"eventId" db:"event_id"`
+	EventType   string         `json:"eventType" db:"event_type"`
+	PaymentID   *uuid.UUID     `json:"paymentId" db:"payment_id"`
+	Payload     []byte         `json:"-" db:"payload"`
+	Signature   string         `json:"-" db:"signature"`
+	SentAt      time.Time      `json:"sentAt" db:"sent_at"`
+	ReceivedAt  time.Time      `json:"receivedAt" db:"received_at"`
+	ProcessedAt *time.Time     `json:"processedAt" db:"processed_at"`
+	Outcome     WebhookOutcome `json:"outcome" db:"outcome"`
+	// Detail explains the outcome, e.g. why the event was ignored.
+	Detail string `json:"detail" db:"detail"`
+}
@@ -4,8 +4,9 @@ import (
 	v1 "backend-vtb/internal/http/v1"
 	"backend-vtb/internal/service"
 	"backend-vtb/pkg/auth"
+	"net/http"
 
-	_ "link-base/docs"
+	_ "backend-vtb/docs"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -13,13 +14,13 @@ import (
 )
 
 type Handler struct {
-	service      service.Base
+	services     *service.Service
 	tokenManager auth.TokenManager
 }
 
-func NewHandler(service service.Base, tokenManager auth.TokenManager) *Handler {
+func NewHandler(services *service.Service, tokenManager auth.TokenManager) *Handler {
 	return &Handler{
-		service:      service,
+		services:     services,
 		tokenManager: tokenManager,
 	}
 }
@@ -28,6 +29,7 @@ func NewHandler(service service.Base, tokenManager auth.TokenManager) *Handler {
 //
 //   - /swagger/*any: Swagger UI
 //   - /ping: Returns "pong" to test the server is up.
+//   - /.well-known/jwks.json: Public keys for verifying access tokens.
 func (h *Handler) Init() *gin.Engine {
 	router := gin.Default()
 
@@ -41,6 +43,8 @@ func (h *Handler) Init() *gin.Engine {
 		c.String(200, "pong")
 	})
 
+	router.GET("/.well-known/jwks.json", h.jwks)
+
 	h.initAPI(router)
 
 	return router
@@ -51,9 +55,17 @@ func (h *Handler) Init() *gin.Engine {
 // It is a thin wrapper around v1.Handler.Init() that initializes the v1 API
 // endpoints and sets them up under the /api group.
 func (h *Handler) initAPI(router *gin.Engine) {
-	handlerV1 := v1.NewHandler(h.service, h.tokenManager)
+	handlerV1 := v1.NewHandler(h.services, h.tokenManager)
 	api := router.Group("/api")
 	{
 		handlerV1.Init(api)
 	}
 }
+
+// jwks serves the JSON Web Key Set with the public keys of the token manager.
+//
+// The set changes only on key rotation, so clients may cache it briefly.
+func (h *Handler) jwks(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokenManager.JWKS())
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) initAlertsRouter(api *gin.RouterGroup) {
+	alerts := api.Group("/alerts", h.userIdentity)
+	{
+		read := alerts.Group("", h.requireScopes(domain.ScopeAlertsRead))
+		{
+			read.GET("", h.listAlertRules)
+		}
+
+		write := alerts.Group("", h.requireScopes(domain.ScopeAlertsWrite))
+		{
+			write.POST("", h.createAlertRule)
+			write.DELETE("/:id", h.deleteAlertRule)
+		}
+	}
+}
+
+type alertRuleInput struct {
+	Subject         domain.AlertSubject   `json:"subject" binding:"required,oneof=price balance"`
+	Asset           string                `json:"asset" binding:"max=16"`
+	Condition       domain.AlertCondition `json:"condition" binding:"required,oneof=above below change"`
+	Threshold       string                `json:"threshold" binding:"required,max=64"`
+	WindowMinutes   int                   `json:"windowMinutes" binding:"min=0"`
+	CooldownMinutes *int                  `json:"cooldownMinutes" binding:"omitempty,min=0"`
+}
+
+// @Summary List Alert Rules
+// @Security UsersAuth
+// @Description Lists the user's alert rules with whether their condition currently holds and when
+// @Description they last fired
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.AlertRule
+// @Failure 401,403 {object} response
+// @Router /alerts [get]
+func (h *Handler) listAlertRules(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	rules, err := h.services.Alerts.List(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// @Summary Create Alert Rule
+// @Security UsersAuth
+// @Description Asks to be notified when the price of a crypto asset goes above or below a threshold
+// @Description in the currency of the price source or changes by a percent over a window of minutes
+// @Description (a negative percent for a fall), or when the amount owed on fines goes above or below
+// @Description a threshold in kopecks. A rule fires once when its condition starts to hold and then
+// @Description stays quiet until the condition stops holding and its cooldown passes
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param input body alertRuleInput true "alert rule"
+// @Success 201 {object} domain.AlertRule
+// @Failure 400,401,403,409 {object} response
+// @Router /alerts [post]
+func (h *Handler) createAlertRule(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input alertRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	rule, err := h.services.Alerts.Create(c.Request.Context(), id, service.AlertRuleInput{
+		Subject:         input.Subject,
+		Asset:           input.Asset,
+		Condition:       input.Condition,
+		Threshold:       input.Threshold,
+		WindowMinutes:   input.WindowMinutes,
+		CooldownMinutes: input.CooldownMinutes,
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// @Summary Delete Alert Rule
+// @Security UsersAuth
+// @Description Removes an alert rule
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param id path string true "alert rule id"
+// @Success 204
+// @Failure 400,401,403,404 {object} response
+// @Router /alerts/{id} [delete]
+func (h *Handler) deleteAlertRule(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ruleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Alerts.Delete(c.Request.Context(), id, ruleID); err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,178 @@
+package v1
+
+import (
+	"backend-vtb/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initAuthRouter(api *gin.RouterGroup) {
+	auth := api.Group("/auth")
+	{
+		auth.POST("/sign-up", h.signUp)
+		auth.POST("/sign-in", h.signIn)
+		auth.POST("/refresh", h.refresh)
+		auth.POST("/sign-out", h.signOut)
+		auth.POST("/mfa/verify", h.verifyMFA)
+	}
+}
+
+type signUpInput struct {
+	Name     string `json:"name" binding:"required,max=64"`
+	Email    string `json:"email" binding:"required,email,max=64"`
+	Phone    string `json:"phone" binding:"max=20"`
+	Password string `json:"password" binding:"required,min=8,max=64"`
+}
+
+type signInInput struct {
+	Email    string `json:"email" binding:"required,email,max=64"`
+	Password string `json:"password" binding:"required,min=8,max=64"`
+}
+
+type refreshInput struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// signInResponse holds either the tokens or, if mfaRequired is set, the
+// challenge token to be passed to /auth/mfa/verify together with a code.
+type signInResponse struct {
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	MFARequired  bool   `json:"mfaRequired"`
+	MFAToken     string `json:"mfaToken,omitempty"`
+}
+
+// @Summary User Sign Up
+// @Description Registers a new user and returns a pair of tokens
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body signUpInput true "sign up info"
+// @Success 201 {object} tokenResponse
+// @Failure 400,409 {object} response
+// @Router /auth/sign-up [post]
+func (h *Handler) signUp(c *gin.Context) {
+	var input signUpInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	tokens, err := h.services.Users.SignUp(c.Request.Context(), service.UserSignUpInput{
+		Name:     input.Name,
+		Email:    input.Email,
+		Phone:    input.Phone,
+		Password: input.Password,
+		Client:   clientInfo(c),
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// @Summary User Sign In
+// @Description Authenticates a user by email and password. Users with two-factor
+// @Description authentication get an MFA challenge token instead of the tokens
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body signInInput true "sign in info"
+// @Success 200 {object} signInResponse
+// @Failure 400,401 {object} response
+// @Router /auth/sign-in [post]
+func (h *Handler) signIn(c *gin.Context) {
+	var input signInInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	result, err := h.services.Users.SignIn(c.Request.Context(), service.UserSignInInput{
+		Email:    input.Email,
+		Password: input.Password,
+		Client:   clientInfo(c),
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, signInResponse{
+		AccessToken:  result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
+		MFARequired:  result.MFARequired,
+		MFAToken:     result.MFAToken,
+	})
+}
+
+// @Summary Refresh Tokens
+// @Description Exchanges a refresh token for a new pair of tokens
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body refreshInput true "refresh token"
+// @Success 200 {object} tokenResponse
+// @Failure 400,401 {object} response
+// @Router /auth/refresh [post]
+func (h *Handler) refresh(c *gin.Context) {
+	var input refreshInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	tokens, err := h.services.Users.RefreshTokens(c.Request.Context(), input.RefreshToken, clientInfo(c))
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// @Summary User Sign Out
+// @Description Revokes the given refresh token
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body refreshInput true "refresh token"
+// @Success 204
+// @Failure 400 {object} response
+// @Router /auth/sign-out [post]
+func (h *Handler) signOut(c *gin.Context) {
+	var input refreshInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.Users.SignOut(c.Request.Context(), input.RefreshToken); err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// clientInfo describes the device that sent the request.
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{
+		Device: c.Request.UserAgent(),
+		IP:     c.ClientIP(),
+	}
+}
@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/pkg/auth"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAuthRefreshReplay(t *testing.T) {
+	s := newTestServer(t)
+
+	var first tokenResponse
+	body := `{"name":"Alice","email":"alice@example.com","password":"password1"}`
+	if code := s.do(http.MethodPost, "/auth/sign-up", "", body, &first); code != http.StatusCreated {
+		t.Fatalf("sign-up: status %d", code)
+	}
+
+	// Another session of the same user is not affected by the replay.
+	var other signInResponse
+	if code := s.do(http.MethodPost, "/auth/sign-in", "", `{"email":"alice@example.com","password":"password1"}`, &other); code != http.StatusOK {
+		t.Fatalf("sign-in: status %d", code)
+	}
+
+	refresh := func(token string, out *tokenResponse) int {
+		return s.do(http.MethodPost, "/auth/refresh", "", `{"refreshToken":"`+token+`"}`, out)
+	}
+
+	var second tokenResponse
+	if code := refresh(first.RefreshToken, &second); code != http.StatusOK || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("refresh: status %d, want %d and a new token", code, http.StatusOK)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"replayed rotated token", first.RefreshToken, http.StatusUnauthorized},
+		{"newer token of the revoked session", second.RefreshToken, http.StatusUnauthorized},
+		{"replayed again", first.RefreshToken, http.StatusUnauthorized},
+		{"unknown token", "unknown", http.StatusUnauthorized},
+		{"token of another session", other.RefreshToken, http.StatusOK},
+	} {
+		if code := refresh(tc.token, &tokenResponse{}); code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, code, tc.want)
+		}
+	}
+
+	var sessions struct {
+		Sessions []domain.Session `json:"sessions"`
+	}
+	if code := s.do(http.MethodGet, "/sessions", other.AccessToken, "", &sessions); code != http.StatusOK || len(sessions.Sessions) != 1 {
+		t.Errorf("sessions = %d with %d active, want 200 and 1", code, len(sessions.Sessions))
+	}
+}
+
+func TestAuthManagementScopes(t *testing.T) {
+	s := newTestServer(t)
+	customer := s.signUp("Alice")
+
+	var sessions struct {
+		Sessions []domain.Session `json:"sessions"`
+	}
+	if code := s.do(http.MethodGet, "/sessions", customer, "", &sessions); code != http.StatusOK || len(sessions.Sessions) != 1 {
+		t.Fatalf("sessions = %d with %d active, want 200 and 1", code, len(sessions.Sessions))
+	}
+
+	claims, err := s.tokens.Parse(customer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A token of the same user that may only read the profile.
+	profile, err := s.tokens.NewJWT(auth.Claims{Subject: claims.Subject, Scopes: []string{domain.ScopeProfileRead}}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := "/sessions/" + sessions.Sessions[0].ID.String()
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"list sessions", http.MethodGet, "/sessions", profile, http.StatusOK},
+		{"revoke a session", http.MethodDelete, session, profile, http.StatusForbidden},
+		{"enroll TOTP", http.MethodPost, "/auth/mfa/totp", profile, http.StatusForbidden},
+		{"confirm TOTP", http.MethodPost, "/auth/mfa/totp/confirm", profile, http.StatusForbidden},
+		{"disable TOTP", http.MethodPost, "/auth/mfa/totp/disable", profile, http.StatusForbidden},
+		{"enroll TOTP as the customer", http.MethodPost, "/auth/mfa/totp", customer, http.StatusOK},
+		{"revoke a session as the customer", http.MethodDelete, session, customer, http.StatusNoContent},
+	} {
+		if code := s.do(tc.method, tc.path, tc.token, `{"code":"000000"}`, nil); code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/service"
+	"backend-vtb/pkg/gateway"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) initAutopayRouter(api *gin.RouterGroup) {
+	autopay := api.Group("/autopay", h.userIdentity)
+	{
+		read := autopay.Group("", h.requireScopes(domain.ScopePaymentsRead))
+		{
+			read.GET("", h.getAutopayRule)
+			read.GET("/charges", h.listAutopayCharges)
+		}
+
+		write := autopay.Group("", h.requireScopes(domain.ScopePaymentsWrite))
+		{
+			write.PUT("", h.setAutopayRule)
+			write.POST("/charges/:id/cancel", h.cancelAutopayCharge)
+		}
+	}
+}
+
+type autopayRuleInput struct {
+	Enabled      bool              `json:"enabled"`
+	MaxPerFine   int64             `json:"maxPerFine" binding:"min=0"`
+	MonthlyLimit int64             `json:"monthlyLimit" binding:"min=0"`
+	Card         *paymentCardInput `json:"card"`
+}
+
+// @Summary Get Autopay Rule
+// @Security UsersAuth
+// @Description Returns the user's rule for paying new fines automatically at the discount
+// @Tags Autopay
+// @Accept json
+// @Produce json
+// @Success 200 {object} domain.AutopayRule
+// @Failure 401,403 {object} response
+// @Router /autopay [get]
+func (h *Handler) getAutopayRule(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	rule, err := h.services.Autopay.Rule(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// @Summary Set Autopay Rule
+// @Security UsersAuth
+// @Description Opts in to or out of autopay. Every new fine of an opted-in user is scheduled to be
+// @Description paid with the saved card at the discount after a notice period, in which the user is
+// @Description notified and can cancel, unless it exceeds the limit per fine or the monthly limit
+// @Description (in kopecks). A card is required to enable autopay for the first time
+// @Tags Autopay
+// @Accept json
+// @Produce json
+// @Param input body autopayRuleInput true "rule"
+// @Success 200 {object} domain.AutopayRule
+// @Failure 400,401,403,502 {object} response
+// @Router /autopay [put]
+func (h *Handler) setAutopayRule(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input autopayRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	ruleInput := service.AutopayRuleInput{
+		Enabled:      input.Enabled,
+		MaxPerFine:   input.MaxPerFine,
+		MonthlyLimit: input.MonthlyLimit,
+	}
+
+	if input.Card != nil {
+		ruleInput.Card = &gateway.Card{
+			Number:   input.Card.Number,
+			ExpMonth: input.Card.ExpMonth,
+			ExpYear:  input.Card.ExpYear,
+			CVC:      input.Card.CVC,
+			Holder:   input.Card.Holder,
+		}
+	}
+
+	rule, err := h.services.Autopay.SetRule(c.Request.Context(), id, ruleInput)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// @Summary List Autopay Charges
+// @Security UsersAuth
+// @Description Lists what autopay decided on each new fine, newest first: scheduled with the time
+// @Description of the charge, declined with the reason, or cancelled by the user
+// @Tags Autopay
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.AutopayCharge
+// @Failure 401,403 {object} response
+// @Router /autopay/charges [get]
+func (h *Handler) listAutopayCharges(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	charges, err := h.services.Autopay.Charges(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"charges": charges})
+}
+
+// @Summary Cancel Autopay Charge
+// @Security UsersAuth
+// @Description Calls off the automatic payment of a fine before it is made
+// @Tags Autopay
+// @Accept json
+// @Produce json
+// @Param id path string true "charge id"
+// @Success 200 {object} domain.AutopayCharge
+// @Failure 400,401,403,404,409 {object} response
+// @Router /autopay/charges/{id}/cancel [post]
+func (h *Handler) cancelAutopayCharge(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	chargeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	charge, err := h.services.Autopay.Cancel(c.Request.Context(), id, chargeID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, charge)
+}
@@ -1,28 +1,45 @@
 package v1
 
 import (
+	"backend-vtb/internal/domain"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 func (h *Handler) initInfoRouter(api *gin.RouterGroup) {
 	info := api.Group("/info", h.userIdentity)
 	{
-		info.GET("/getname", h.getName)
-		info.GET("/getamount", h.getAmount)
-		info.GET("/getachievements", h.getAchievements)
-		info.GET("/getbaseinfo", h.getBaseInfo)
-		info.GET("/getneuromean", h.getNeuroMean)
-		info.GET("/getcryptodata", h.getCryptoData)
-		info.GET("/getapiinfo", h.getAPIInfo)
-		info.GET("/getfullapiinfo", h.getFullAPIInfo)
-		info.GET("/getfines", h.getFines)
-		info.GET("/getfine", h.getFineByID)
-		info.GET("/getpayments", h.getPayments)
-		info.GET("/getpayment", h.getPaymentByID)
-		info.GET("/getstatsdata", h.getStatsData)
-		info.GET("/getanalize", h.getAnalyze)
+		profile := info.Group("", h.requireScopes(domain.ScopeProfileRead))
+		{
+			profile.GET("/getname", h.getName)
+			profile.GET("/getachievements", h.getAchievements)
+			profile.GET("/getbaseinfo", h.getBaseInfo)
+			profile.GET("/getneuromean", h.getNeuroMean)
+			profile.GET("/getapiinfo", h.getAPIInfo)
+			profile.GET("/getfullapiinfo", h.getFullAPIInfo)
+			profile.GET("/getstatsdata", h.getStatsData)
+			profile.GET("/getanalize", h.getAnalyze)
+		}
+
+		fines := info.Group("", h.requireScopes(domain.ScopeFinesRead))
+		{
+			fines.GET("/getamount", h.getAmount)
+			fines.GET("/getfines", h.getFines)
+			fines.GET("/getfine", h.getFineByID)
+		}
+
+		payments := info.Group("", h.requireScopes(domain.ScopePaymentsRead))
+		{
+			payments.GET("/getpayments", h.getPayments)
+			payments.GET("/getpayment", h.getPaymentByID)
+		}
+
+		crypto := info.Group("", h.requireScopes(domain.ScopeCryptoRead))
+		{
+			crypto.GET("/getcryptodata", h.getCryptoData)
+		}
 	}
 }
 
@@ -40,9 +57,9 @@ func (h *Handler) getName(c *gin.Context) {
 		return
 	}
 
-	name, err := h.service.GetName(id)
+	name, err := h.services.Base.GetName(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
@@ -50,11 +67,11 @@ func (h *Handler) getName(c *gin.Context) {
 }
 
 // @Summary Get Fine Amount
-// @Description Retrieves the amount of the fine
+// @Description Retrieves the total currently payable across the user's open fines, in kopecks
 // @Tags Fine
 // @Accept json
 // @Produce json
-// @Success 200 {object} int
+// @Success 200 {object} money.Money
 // @Router /getamount [get]
 func (h *Handler) getAmount(c *gin.Context) {
 	id, err := getUserId(c)
@@ -63,9 +80,9 @@ func (h *Handler) getAmount(c *gin.Context) {
 		return
 	}
 
-	amount, err := h.service.GetAmount(id)
+	amount, err := h.services.Base.GetAmount(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
@@ -77,7 +94,7 @@ func (h *Handler) getAmount(c *gin.Context) {
 // @Tags User
 // @Accept json
 // @Produce json
-// @Success 200 {object} string
+// @Success 200 {array} domain.Achievement
 // @Router /getachievements [get]
 func (h *Handler) getAchievements(c *gin.Context) {
 	id, err := getUserId(c)
@@ -86,9 +103,9 @@ func (h *Handler) getAchievements(c *gin.Context) {
 		return
 	}
 
-	achievements, err := h.service.GetAchievements(id)
+	achievements, err := h.services.Base.GetAchievements(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
@@ -100,7 +117,7 @@ func (h *Handler) getAchievements(c *gin.Context) {
 // @Tags Profile
 // @Accept json
 // @Produce json
-// @Success 200 {object} string
+// @Success 200 {object} domain.BaseInfo
 // @Router /getbaseinfo [get]
 func (h *Handler) getBaseInfo(c *gin.Context) {
 	id, err := getUserId(c)
@@ -109,9 +126,9 @@ func (h *Handler) getBaseInfo(c *gin.Context) {
 		return
 	}
 
-	baseInfo, err := h.service.GetBaseInfo(id)
+	baseInfo, err := h.services.Base.GetBaseInfo(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
@@ -132,9 +149,9 @@ func (h *Handler) getNeuroMean(c *gin.Context) {
 		return
 	}
 
-	neuroMean, err := h.service.GetNeuroMean(id)
+	neuroMean, err := h.services.Base.GetNeuroMean(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
@@ -142,11 +159,12 @@ func (h *Handler) getNeuroMean(c *gin.Context) {
 }
 
 // @Summary Get Crypto Data
-// @Description Retrieves cryptocurrency data
+// @Description Retrieves the user's crypto portfolio valued at current prices, with the cost basis
+// @Description computed by FIFO
 // @Tags Crypto
 // @Accept json
 // @Produce json
-// @Success 200 {object} string
+// @Success 200 {object} domain.CryptoPortfolio
 // @Router /getcryptodata [get]
 func (h *Handler) getCryptoData(c *gin.Context) {
 	id, err := getUserId(c)
@@ -155,13 +173,13 @@ func (h *Handler) getCryptoData(c *gin.Context) {
 		return
 	}
 
-	cryptoData, err := h.service.GetCryptoData(id)
+	portfolio, err := h.services.Base.GetCryptoData(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"cryptoData": cryptoData})
+	c.JSON(http.StatusOK, gin.H{"portfolio": portfolio})
 }
 
 // @Summary Get API Short Info
@@ -178,9 +196,9 @@ func (h *Handler) getAPIInfo(c *gin.Context) {
 		return
 	}
 
-	apiInfo, err := h.service.GetAPIInfo(id)
+	apiInfo, err := h.services.Base.GetAPIInfo(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
@@ -201,9 +219,9 @@ func (h *Handler) getFullAPIInfo(c *gin.Context) {
 		return
 	}
 
-	fullAPIInfo, err := h.service.GetFullAPIInfo(id)
+	fullAPIInfo, err := h.services.Base.GetFullAPIInfo(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
@@ -215,7 +233,7 @@ func (h *Handler) getFullAPIInfo(c *gin.Context) {
 // @Tags Fine
 // @Accept json
 // @Produce json
-// @Success 200 {object} string
+// @Success 200 {array} domain.Fine
 // @Router /getfines [get]
 func (h *Handler) getFines(c *gin.Context) {
 	id, err := getUserId(c)
@@ -224,9 +242,9 @@ func (h *Handler) getFines(c *gin.Context) {
 		return
 	}
 
-	fines, err := h.service.GetFines(id)
+	fines, err := h.services.Base.GetFines(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
@@ -234,11 +252,12 @@ func (h *Handler) getFines(c *gin.Context) {
 }
 
 // @Summary Get Fine by ID
-// @Description Retrieves a specific fine by its ID
+// @Description Retrieves a specific fine by its ID. Deprecated: use GET /fines/{id}
 // @Tags Fine
 // @Accept json
 // @Produce json
-// @Success 200 {object} string
+// @Param id query string true "fine id"
+// @Success 200 {object} domain.Fine
 // @Router /getfine [get]
 func (h *Handler) getFineByID(c *gin.Context) {
 	id, err := getUserId(c)
@@ -247,9 +266,15 @@ func (h *Handler) getFineByID(c *gin.Context) {
 		return
 	}
 
-	fine, err := h.service.GetFineByID(id)
+	fineID, err := uuid.Parse(c.Query("id"))
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	fine, err := h.services.Fines.Get(c.Request.Context(), id, fineID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
@@ -261,7 +286,7 @@ func (h *Handler) getFineByID(c *gin.Context) {
 // @Tags Payment
 // @Accept json
 // @Produce json
-// @Success 200 {object} string
+// @Success 200 {array} domain.Payment
 // @Router /getpayments [get]
 func (h *Handler) getPayments(c *gin.Context) {
 	id, err := getUserId(c)
@@ -270,9 +295,9 @@ func (h *Handler) getPayments(c *gin.Context) {
 		return
 	}
 
-	payments, err := h.service.GetPayments(id)
+	payments, err := h.services.Base.GetPayments(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
@@ -280,11 +305,12 @@ func (h *Handler) getPayments(c *gin.Context) {
 }
 
 // @Summary Get Payment by ID
-// @Description Retrieves a specific payment by its ID
+// @Description Retrieves a specific payment by its ID. Deprecated: use GET /payments/{id}
 // @Tags Payment
 // @Accept json
 // @Produce json
-// @Success 200 {object} string
+// @Param id query string true "payment id"
+// @Success 200 {object} domain.Payment
 // @Router /getpayment [get]
 func (h *Handler) getPaymentByID(c *gin.Context) {
 	id, err := getUserId(c)
@@ -293,9 +319,15 @@ func (h *Handler) getPaymentByID(c *gin.Context) {
 		return
 	}
 
-	payment, err := h.service.GetPaymentByID(id)
+	paymentID, err := uuid.Parse(c.Query("id"))
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	payment, err := h.services.Payments.Get(c.Request.Context(), id, paymentID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
@@ -303,11 +335,12 @@ func (h *Handler) getPaymentByID(c *gin.Context) {
 }
 
 // @Summary Get Stats Data
-// @Description Retrieves statistical data for the graph
+// @Description Retrieves the daily spending, income and fines of the user over the last 30 days for
+// @Description the graph, see /stats
 // @Tags Stats
 // @Accept json
 // @Produce json
-// @Success 200 {object} string
+// @Success 200 {object} domain.StatsReport
 // @Router /getstatsdata [get]
 func (h *Handler) getStatsData(c *gin.Context) {
 	id, err := getUserId(c)
@@ -316,13 +349,13 @@ func (h *Handler) getStatsData(c *gin.Context) {
 		return
 	}
 
-	statsData, err := h.service.GetStatsData(id)
+	report, err := h.services.Base.GetStatsData(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"statsData": statsData})
+	c.JSON(http.StatusOK, gin.H{"stats": report})
 }
 
 // @Summary Get User Analyze Data
@@ -338,11 +371,11 @@ func (h *Handler) getAnalyze(c *gin.Context) {
 		return
 	}
 
-	statsData, err := h.service.GetStatsData(id)
+	analyze, err := h.services.Base.GetAnalyze(c.Request.Context(), id)
 	if err != nil {
-		newResponse(c, http.StatusInternalServerError, err.Error())
+		newResponse(c, statusFromError(err), err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"statsData": statsData})
+	c.JSON(http.StatusOK, gin.H{"analyze": analyze})
 }
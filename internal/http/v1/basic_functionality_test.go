@@ -0,0 +1,127 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/pkg/auth"
+	"backend-vtb/pkg/money"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestInfoRoutes(t *testing.T) {
+	s := newTestServer(t)
+	token := s.signUp("Alice")
+
+	var name struct {
+		Name string `json:"name"`
+	}
+	if code := s.do(http.MethodGet, "/info/getname", token, "", &name); code != http.StatusOK || name.Name != "Alice" {
+		t.Errorf("getname = %d %q, want 200 Alice", code, name.Name)
+	}
+
+	var amount struct {
+		Amount money.Money `json:"amount"`
+	}
+	if code := s.do(http.MethodGet, "/info/getamount", token, "", &amount); code != http.StatusOK || amount.Amount.Amount != 0 {
+		t.Errorf("getamount without fines = %d %+v, want 200 and zero", code, amount.Amount)
+	}
+
+	first := s.createFine(token, "1", 50000)
+	s.createFine(token, "2", 30000)
+
+	if code := s.do(http.MethodGet, "/info/getamount", token, "", &amount); code != http.StatusOK || amount.Amount != money.New(80000, "RUB") {
+		t.Errorf("getamount = %d %+v, want 200 and 80000 RUB", code, amount.Amount)
+	}
+
+	var fines struct {
+		Fines []domain.Fine `json:"fines"`
+	}
+	if code := s.do(http.MethodGet, "/info/getfines", token, "", &fines); code != http.StatusOK || len(fines.Fines) != 2 {
+		t.Errorf("getfines = %d with %d fines, want 200 and 2", code, len(fines.Fines))
+	}
+
+	var fine struct {
+		Fine domain.Fine `json:"fine"`
+	}
+	if code := s.do(http.MethodGet, "/info/getfine?id="+first.ID.String(), token, "", &fine); code != http.StatusOK || fine.Fine.ID != first.ID {
+		t.Errorf("getfine = %d %v, want 200 %v", code, fine.Fine.ID, first.ID)
+	}
+
+	for _, tc := range []struct {
+		name string
+		path string
+		want int
+	}{
+		{"invalid id", "/info/getfine?id=fine", http.StatusBadRequest},
+		{"unknown id", "/info/getfine?id=" + uuid.NewString(), http.StatusNotFound},
+	} {
+		if code := s.do(http.MethodGet, tc.path, token, "", nil); code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, code, tc.want)
+		}
+	}
+
+	var payments struct {
+		Payments []domain.Payment `json:"payments"`
+	}
+	if code := s.do(http.MethodGet, "/info/getpayments", token, "", &payments); code != http.StatusOK || len(payments.Payments) != 0 {
+		t.Errorf("getpayments = %d with %d payments, want 200 and none", code, len(payments.Payments))
+	}
+
+	var crypto struct {
+		Portfolio *domain.CryptoPortfolio `json:"portfolio"`
+	}
+	if code := s.do(http.MethodGet, "/info/getcryptodata", token, "", &crypto); code != http.StatusOK || crypto.Portfolio == nil || len(crypto.Portfolio.Holdings) != 0 {
+		t.Errorf("getcryptodata = %d %+v, want 200 and an empty portfolio", code, crypto.Portfolio)
+	}
+
+	var stats struct {
+		Stats domain.StatsReport `json:"stats"`
+	}
+	if code := s.do(http.MethodGet, "/info/getstatsdata", token, "", &stats); code != http.StatusOK || stats.Stats.Bucket != domain.StatsDay || len(stats.Stats.Series) == 0 {
+		t.Errorf("getstatsdata = %d %+v, want 200 and a daily report", code, stats.Stats)
+	}
+}
+
+func TestInfoRoutesRequireAuth(t *testing.T) {
+	s := newTestServer(t)
+	token := s.signUp("Alice")
+
+	var fines struct {
+		Fines []domain.Fine `json:"fines"`
+	}
+	if code := s.do(http.MethodGet, "/info/getfines", token, "", &fines); code != http.StatusOK {
+		t.Fatalf("getfines: status %d", code)
+	}
+
+	// A token of the same user limited to the profile.
+	claims, err := s.tokens.Parse(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := s.tokens.NewJWT(auth.Claims{Subject: claims.Subject, Scopes: []string{domain.ScopeProfileRead}}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		token string
+		want  int
+	}{
+		{"no token", "/info/getname", "", http.StatusUnauthorized},
+		{"invalid token", "/info/getname", "token", http.StatusUnauthorized},
+		{"profile scope", "/info/getname", profile, http.StatusOK},
+		{"missing fines scope", "/info/getamount", profile, http.StatusForbidden},
+		{"missing payments scope", "/info/getpayments", profile, http.StatusForbidden},
+		{"missing crypto scope", "/info/getcryptodata", profile, http.StatusForbidden},
+	} {
+		if code := s.do(http.MethodGet, tc.path, tc.token, "", nil); code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/service"
+	"backend-vtb/pkg/money"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) initCryptoRouter(api *gin.RouterGroup) {
+	crypto := api.Group("/crypto", h.userIdentity)
+	{
+		read := crypto.Group("", h.requireScopes(domain.ScopeCryptoRead))
+		{
+			read.GET("/portfolio", h.getCryptoPortfolio)
+			read.GET("/transactions", h.listCryptoTransactions)
+			read.GET("/:symbol/candles", h.getCryptoCandles)
+		}
+
+		write := crypto.Group("", h.requireScopes(domain.ScopeCryptoWrite))
+		{
+			write.POST("/transactions", h.createCryptoTransaction)
+			write.DELETE("/transactions/:id", h.deleteCryptoTransaction)
+		}
+	}
+}
+
+type cryptoTransactionInput struct {
+	Asset      string                       `json:"asset" binding:"required,max=16"`
+	Kind       domain.CryptoTransactionKind `json:"kind" binding:"required,oneof=buy sell transfer_in transfer_out"`
+	Quantity   string                       `json:"quantity" binding:"required,max=64"`
+	Amount     int64                        `json:"amount" binding:"min=0"`
+	Fee        int64                        `json:"fee" binding:"min=0"`
+	Currency   string                       `json:"currency" binding:"omitempty,iso4217"`
+	ExecutedAt time.Time                    `json:"executedAt"`
+}
+
+// @Summary Get Crypto Portfolio
+// @Security UsersAuth
+// @Description Computes the user's crypto holdings from their transactions: quantity, cost basis,
+// @Description market value at current prices and realized and unrealized profit or loss, in minor
+// @Description units of the currency of each asset's transactions, with totals by currency
+// @Tags Crypto
+// @Accept json
+// @Produce json
+// @Param method query string false "cost basis method: fifo (default) or average"
+// @Success 200 {object} domain.CryptoPortfolio
+// @Failure 400,401,403,422 {object} response
+// @Router /crypto/portfolio [get]
+func (h *Handler) getCryptoPortfolio(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	method := domain.CostBasisMethod(c.Query("method"))
+
+	portfolio, err := h.services.Crypto.Portfolio(c.Request.Context(), id, method)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, portfolio)
+}
+
+// @Summary List Crypto Transactions
+// @Security UsersAuth
+// @Description Lists the crypto transactions the user recorded, in the order they were executed
+// @Tags Crypto
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.CryptoTransaction
+// @Failure 401,403 {object} response
+// @Router /crypto/transactions [get]
+func (h *Handler) listCryptoTransactions(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	transactions, err := h.services.Crypto.Transactions(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transactions": transactions})
+}
+
+// @Summary Record Crypto Transaction
+// @Security UsersAuth
+// @Description Records a purchase, sale or transfer of a crypto asset. The amount paid or received
+// @Description and the fee are in minor units of the currency (rubles by default), which must be the
+// @Description same for all transactions in an asset; an incoming transfer's amount is the cost basis
+// @Description it brings along. Sales and outgoing transfers cannot exceed the holdings at their time
+// @Tags Crypto
+// @Accept json
+// @Produce json
+// @Param input body cryptoTransactionInput true "transaction"
+// @Success 201 {object} domain.CryptoTransaction
+// @Failure 400,401,403,409 {object} response
+// @Router /crypto/transactions [post]
+func (h *Handler) createCryptoTransaction(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input cryptoTransactionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	transaction, err := h.services.Crypto.AddTransaction(c.Request.Context(), id, service.CryptoTransactionInput{
+		Asset:      input.Asset,
+		Kind:       input.Kind,
+		Quantity:   input.Quantity,
+		Amount:     money.New(input.Amount, input.Currency),
+		Fee:        input.Fee,
+		ExecutedAt: input.ExecutedAt,
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction)
+}
+
+// @Summary Delete Crypto Transaction
+// @Security UsersAuth
+// @Description Removes a recorded crypto transaction, unless a later sale or transfer would then
+// @Description exceed the holdings
+// @Tags Crypto
+// @Accept json
+// @Produce json
+// @Param id path string true "transaction id"
+// @Success 204
+// @Failure 400,401,403,404,409 {object} response
+// @Router /crypto/transactions/{id} [delete]
+func (h *Handler) deleteCryptoTransaction(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	transactionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Crypto.DeleteTransaction(c.Request.Context(), id, transactionID); err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get Crypto Candles
+// @Security UsersAuth
+// @Description Returns the OHLCV candles of a crypto asset's price in one interval, oldest first.
+// @Description Intervals without ticks are filled at the previous close and marked as filled
+// @Tags Crypto
+// @Accept json
+// @Produce json
+// @Param symbol path string true "asset symbol, e.g. BTC"
+// @Param interval query string false "1m (default), 5m, 1h or 1d"
+// @Param from query string false "RFC 3339 time, 100 intervals before to by default"
+// @Param to query string false "RFC 3339 time, now by default"
+// @Success 200 {object} domain.CandleSeries
+// @Failure 400,401,403 {object} response
+// @Router /crypto/{symbol}/candles [get]
+func (h *Handler) getCryptoCandles(c *gin.Context) {
+	var (
+		from, to time.Time
+		err      error
+	)
+
+	if value := c.Query("from"); value != "" {
+		from, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			newResponse(c, http.StatusBadRequest, "invalid from param")
+			return
+		}
+	}
+
+	if value := c.Query("to"); value != "" {
+		to, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			newResponse(c, http.StatusBadRequest, "invalid to param")
+			return
+		}
+	}
+
+	interval := domain.CandleInterval(c.DefaultQuery("interval", string(domain.CandleMinute)))
+
+	series, err := h.services.Candles.Candles(c.Request.Context(), c.Param("symbol"), interval, from, to)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
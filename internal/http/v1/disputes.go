@@ -0,0 +1,381 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead is the room left for multipart headers when limiting the
+// size of an upload request.
+const multipartOverhead = 64 << 10
+
+func (h *Handler) initDisputesRouter(api *gin.RouterGroup) {
+	disputes := api.Group("/disputes", h.userIdentity)
+	{
+		read := disputes.Group("", h.requireScopes(domain.ScopeFinesRead))
+		{
+			read.GET("/:id", h.getDispute)
+			read.GET("/:id/attachments", h.listDisputeAttachments)
+			read.GET("/:id/attachments/:attachmentId", h.downloadDisputeAttachment)
+		}
+
+		write := disputes.Group("", h.requireScopes(domain.ScopeFinesWrite))
+		{
+			write.POST("/:id/attachments", h.uploadDisputeAttachment)
+			write.POST("/:id/withdraw", h.withdrawDispute)
+		}
+
+		disputes.POST("/:id/decision", h.requireScopes(domain.ScopeFinesReview), h.decideDispute)
+	}
+}
+
+type disputeOpenInput struct {
+	Reason  domain.DisputeReason `json:"reason" binding:"required,oneof=not_driver vehicle_sold already_paid incorrect_amount other"`
+	Comment string               `json:"comment" binding:"max=2000"`
+}
+
+type disputeDecisionInput struct {
+	Decision string `json:"decision" binding:"required,oneof=accept reject"`
+	Comment  string `json:"comment" binding:"max=2000"`
+}
+
+// @Summary Open Dispute
+// @Security UsersAuth
+// @Description Contests an issued fine. The fine stays disputed, and accrues no penalty,
+// @Description until the dispute is withdrawn or decided
+// @Tags Dispute
+// @Accept json
+// @Produce json
+// @Param id path string true "fine id"
+// @Param input body disputeOpenInput true "dispute"
+// @Success 201 {object} domain.Dispute
+// @Failure 400,401,403,404,409 {object} response
+// @Router /fines/{id}/disputes [post]
+func (h *Handler) openDispute(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fineID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	var input disputeOpenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	dispute, err := h.services.Disputes.Open(c.Request.Context(), id, fineID, service.DisputeOpenInput{
+		Reason:  input.Reason,
+		Comment: input.Comment,
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, dispute)
+}
+
+// @Summary List Fine Disputes
+// @Security UsersAuth
+// @Description Lists the disputes of a fine, oldest first
+// @Tags Dispute
+// @Accept json
+// @Produce json
+// @Param id path string true "fine id"
+// @Success 200 {array} domain.Dispute
+// @Failure 400,401,403,404 {object} response
+// @Router /fines/{id}/disputes [get]
+func (h *Handler) listFineDisputes(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fineID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	disputes, err := h.services.Disputes.List(c.Request.Context(), actor, fineID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"disputes": disputes})
+}
+
+// @Summary Get Dispute
+// @Security UsersAuth
+// @Description Retrieves a dispute of the user; reviewers can retrieve any dispute
+// @Tags Dispute
+// @Accept json
+// @Produce json
+// @Param id path string true "dispute id"
+// @Success 200 {object} domain.Dispute
+// @Failure 400,401,403,404 {object} response
+// @Router /disputes/{id} [get]
+func (h *Handler) getDispute(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	dispute, err := h.services.Disputes.Get(c.Request.Context(), actor, disputeID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// @Summary Upload Dispute Attachment
+// @Security UsersAuth
+// @Description Attaches an evidence file to an open dispute. Up to 5 JPEG, PNG or PDF files
+// @Description of at most 10 MB each are accepted; the type is detected from the content
+// @Tags Dispute
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "dispute id"
+// @Param file formData file true "evidence file"
+// @Success 201 {object} domain.DisputeAttachment
+// @Failure 400,401,403,404,409,413,415 {object} response
+// @Router /disputes/{id}/attachments [post]
+func (h *Handler) uploadDisputeAttachment(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxAttachmentSize+multipartOverhead)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			newResponse(c, http.StatusRequestEntityTooLarge, domain.ErrAttachmentTooLarge.Error())
+			return
+		}
+
+		newResponse(c, http.StatusBadRequest, "invalid file")
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.services.Disputes.AddAttachment(c.Request.Context(), id, disputeID, service.AttachmentInput{
+		FileName: header.Filename,
+		Size:     header.Size,
+		Body:     file,
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// @Summary List Dispute Attachments
+// @Security UsersAuth
+// @Description Lists the evidence files of a dispute
+// @Tags Dispute
+// @Accept json
+// @Produce json
+// @Param id path string true "dispute id"
+// @Success 200 {array} domain.DisputeAttachment
+// @Failure 400,401,403,404 {object} response
+// @Router /disputes/{id}/attachments [get]
+func (h *Handler) listDisputeAttachments(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	attachments, err := h.services.Disputes.ListAttachments(c.Request.Context(), actor, disputeID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// @Summary Download Dispute Attachment
+// @Security UsersAuth
+// @Description Downloads an evidence file of a dispute
+// @Tags Dispute
+// @Produce application/octet-stream
+// @Param id path string true "dispute id"
+// @Param attachmentId path string true "attachment id"
+// @Success 200 {file} file
+// @Failure 400,401,403,404 {object} response
+// @Router /disputes/{id}/attachments/{attachmentId} [get]
+func (h *Handler) downloadDisputeAttachment(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid attachmentId param")
+		return
+	}
+
+	attachment, body, err := h.services.Disputes.GetAttachment(c.Request.Context(), actor, disputeID, attachmentID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+	defer body.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, body, map[string]string{
+		"Content-Disposition": "attachment; filename=" + strconv.Quote(attachment.FileName),
+	})
+}
+
+// @Summary Withdraw Dispute
+// @Security UsersAuth
+// @Description Withdraws an open dispute; the fine becomes payable again
+// @Tags Dispute
+// @Accept json
+// @Produce json
+// @Param id path string true "dispute id"
+// @Success 200 {object} domain.Dispute
+// @Failure 400,401,403,404,409 {object} response
+// @Router /disputes/{id}/withdraw [post]
+func (h *Handler) withdrawDispute(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	dispute, err := h.services.Disputes.Withdraw(c.Request.Context(), id, disputeID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// @Summary Decide Dispute
+// @Security UsersAuth
+// @Description Closes an open dispute. Accepting it cancels the fine, rejecting it makes the
+// @Description fine payable again. Requires the fines:review scope
+// @Tags Dispute
+// @Accept json
+// @Produce json
+// @Param id path string true "dispute id"
+// @Param input body disputeDecisionInput true "decision"
+// @Success 200 {object} domain.Dispute
+// @Failure 400,401,403,404,409 {object} response
+// @Router /disputes/{id}/decision [post]
+func (h *Handler) decideDispute(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	disputeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	var input disputeDecisionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	dispute, err := h.services.Disputes.Decide(c.Request.Context(), id, disputeID, service.DisputeDecisionInput{
+		Accept:  input.Decision == "accept",
+		Comment: input.Comment,
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, dispute)
+}
+
+// getActor returns the current user along with whether the access token
+// grants the right to review the disputes and refund the payments of other
+// users.
+func getActor(c *gin.Context) (service.Actor, error) {
+	id, err := getUserId(c)
+	if err != nil {
+		return service.Actor{}, err
+	}
+
+	claims, err := getClaims(c)
+	if err != nil {
+		return service.Actor{}, err
+	}
+
+	return service.Actor{
+		UserID:   id,
+		Reviewer: claims.HasScope(domain.ScopeFinesReview),
+		Refunder: claims.HasScope(domain.ScopePaymentsRefund),
+	}, nil
+}
@@ -0,0 +1,374 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) initFinesRouter(api *gin.RouterGroup) {
+	fines := api.Group("/fines", h.userIdentity)
+	{
+		read := fines.Group("", h.requireScopes(domain.ScopeFinesRead))
+		{
+			read.GET("", h.listFines)
+			read.GET("/:id", h.getFine)
+			read.GET("/:id/charge", h.getFineCharge)
+			read.GET("/:id/history", h.getFineHistory)
+			read.GET("/:id/qr", h.getFineQR)
+			read.GET("/:id/disputes", h.listFineDisputes)
+			read.GET("/by-uin/:uin", h.getFineByUIN)
+		}
+
+		write := fines.Group("", h.requireScopes(domain.ScopeFinesWrite))
+		{
+			write.POST("", h.createFine)
+			write.PATCH("/:id", h.updateFine)
+			write.DELETE("/:id", h.deleteFine)
+			write.POST("/:id/disputes", h.openDispute)
+		}
+	}
+}
+
+type fineCreateInput struct {
+	UIN           string     `json:"uin" binding:"max=32"`
+	Issuer        string     `json:"issuer" binding:"required,max=255"`
+	Article       string     `json:"article" binding:"max=64"`
+	Amount        int64      `json:"amount" binding:"required,min=1"`
+	IssuedAt      time.Time  `json:"issuedAt" binding:"required"`
+	DueAt         *time.Time `json:"dueAt"`
+	DiscountUntil *time.Time `json:"discountUntil"`
+}
+
+type fineUpdateInput struct {
+	Issuer        *string            `json:"issuer" binding:"omitempty,max=255"`
+	Article       *string            `json:"article" binding:"omitempty,max=64"`
+	Amount        *int64             `json:"amount" binding:"omitempty,min=1"`
+	DueAt         *time.Time         `json:"dueAt"`
+	DiscountUntil *time.Time         `json:"discountUntil"`
+	Status        *domain.FineStatus `json:"status" binding:"omitempty,oneof=issued cancelled"`
+}
+
+// @Summary List Fines
+// @Security UsersAuth
+// @Description Lists the user's fines, newest first
+// @Tags Fine
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Fine
+// @Failure 401,403 {object} response
+// @Router /fines [get]
+func (h *Handler) listFines(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fines, err := h.services.Fines.List(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"fines": fines})
+}
+
+// @Summary Get Fine
+// @Security UsersAuth
+// @Description Retrieves one of the user's fines
+// @Tags Fine
+// @Accept json
+// @Produce json
+// @Param id path string true "fine id"
+// @Success 200 {object} domain.Fine
+// @Failure 400,401,403,404 {object} response
+// @Router /fines/{id} [get]
+func (h *Handler) getFine(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fineID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	fine, err := h.services.Fines.Get(c.Request.Context(), id, fineID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, fine)
+}
+
+// @Summary Get Fine Charge
+// @Security UsersAuth
+// @Description Computes the lifecycle state of a fine and the amount payable, including the
+// @Description early payment discount or the late penalty, as of the given moment (now by default)
+// @Tags Fine
+// @Accept json
+// @Produce json
+// @Param id path string true "fine id"
+// @Param asOf query string false "RFC 3339 timestamp"
+// @Success 200 {object} domain.FineCharge
+// @Failure 400,401,403,404 {object} response
+// @Router /fines/{id}/charge [get]
+func (h *Handler) getFineCharge(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fineID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	asOf := time.Now()
+	if value := c.Query("asOf"); value != "" {
+		asOf, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			newResponse(c, http.StatusBadRequest, "invalid asOf param")
+			return
+		}
+	}
+
+	charge, err := h.services.Fines.Charge(c.Request.Context(), id, fineID, asOf)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, charge)
+}
+
+// @Summary Get Fine QR Code
+// @Security UsersAuth
+// @Description Renders the bank details of an unpaid fine and the amount payable now as a
+// @Description GOST R 56042 (ST00012) payment QR code, which banking apps can scan to pay the fine
+// @Tags Fine
+// @Accept json
+// @Produce image/png,image/svg+xml
+// @Param id path string true "fine id"
+// @Param format query string false "png (default) or svg"
+// @Success 200 {file} binary
+// @Failure 400,401,403,404,409 {object} response
+// @Router /fines/{id}/qr [get]
+func (h *Handler) getFineQR(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fineID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	format := domain.QRFormat(c.DefaultQuery("format", string(domain.QRFormatPNG)))
+	if format != domain.QRFormatPNG && format != domain.QRFormatSVG {
+		newResponse(c, http.StatusBadRequest, "invalid format param")
+		return
+	}
+
+	qr, err := h.services.Fines.QR(c.Request.Context(), id, fineID, format)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	// The amount changes with the discount window and penalties.
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, qr.Format.ContentType(), qr.Image)
+}
+
+// @Summary Get Fine History
+// @Security UsersAuth
+// @Description Lists the append-only status history of a fine, oldest first
+// @Tags Fine
+// @Accept json
+// @Produce json
+// @Param id path string true "fine id"
+// @Success 200 {array} domain.FineEvent
+// @Failure 400,401,403,404 {object} response
+// @Router /fines/{id}/history [get]
+func (h *Handler) getFineHistory(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fineID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	events, err := h.services.Fines.History(c.Request.Context(), id, fineID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// @Summary Get Fine by UIN
+// @Security UsersAuth
+// @Description Looks up one of the user's fines by the UIN printed on the paper notice.
+// @Description Malformed UINs and UINs with a wrong check digit are rejected with 400
+// @Tags Fine
+// @Accept json
+// @Produce json
+// @Param uin path string true "20 or 25 digit UIN"
+// @Success 200 {object} domain.Fine
+// @Failure 400,401,403,404 {object} response
+// @Router /fines/by-uin/{uin} [get]
+func (h *Handler) getFineByUIN(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fine, err := h.services.Base.GetFineByUIN(c.Request.Context(), id, c.Param("uin"))
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, fine)
+}
+
+// @Summary Create Fine
+// @Security UsersAuth
+// @Description Adds a fine to the user's account. Amounts are in kopecks; the UIN, if given,
+// @Description must have a valid check digit
+// @Tags Fine
+// @Accept json
+// @Produce json
+// @Param input body fineCreateInput true "fine"
+// @Success 201 {object} domain.Fine
+// @Failure 400,401,403,409 {object} response
+// @Router /fines [post]
+func (h *Handler) createFine(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input fineCreateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	fine, err := h.services.Fines.Create(c.Request.Context(), id, service.FineCreateInput{
+		UIN:           input.UIN,
+		Issuer:        input.Issuer,
+		Article:       input.Article,
+		Amount:        input.Amount,
+		IssuedAt:      input.IssuedAt,
+		DueAt:         input.DueAt,
+		DiscountUntil: input.DiscountUntil,
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, fine)
+}
+
+// @Summary Update Fine
+// @Security UsersAuth
+// @Description Changes the given fields of an issued fine or cancels it. The due date and the end
+// @Description of the discount can only be moved earlier
+// @Tags Fine
+// @Accept json
+// @Produce json
+// @Param id path string true "fine id"
+// @Param input body fineUpdateInput true "fields to change"
+// @Success 200 {object} domain.Fine
+// @Failure 400,401,403,404,409 {object} response
+// @Router /fines/{id} [patch]
+func (h *Handler) updateFine(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fineID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	var input fineUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	fine, err := h.services.Fines.Update(c.Request.Context(), id, fineID, service.FineUpdateInput{
+		Issuer:        input.Issuer,
+		Article:       input.Article,
+		Amount:        input.Amount,
+		DueAt:         input.DueAt,
+		DiscountUntil: input.DiscountUntil,
+		Status:        input.Status,
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, fine)
+}
+
+// @Summary Delete Fine
+// @Security UsersAuth
+// @Description Removes an issued fine from the user's account, unless a payment of it is in progress
+// @Tags Fine
+// @Accept json
+// @Produce json
+// @Param id path string true "fine id"
+// @Success 204
+// @Failure 400,401,403,404,409 {object} response
+// @Router /fines/{id} [delete]
+func (h *Handler) deleteFine(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fineID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Fines.Delete(c.Request.Context(), id, fineID); err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
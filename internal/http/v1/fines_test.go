@@ -0,0 +1,187 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestFinesCRUD(t *testing.T) {
+	s := newTestServer(t)
+	token := s.signUp("Alice")
+
+	fine := s.createFine(token, "1", 50000)
+	if fine.Status != domain.FineIssued || fine.DueAt.IsZero() {
+		t.Fatalf("created fine = %+v, want issued with a due date", fine)
+	}
+
+	var list struct {
+		Fines []domain.Fine `json:"fines"`
+	}
+	if code := s.do(http.MethodGet, "/fines", token, "", &list); code != http.StatusOK || len(list.Fines) != 1 {
+		t.Errorf("list = %d with %d fines, want 200 and 1", code, len(list.Fines))
+	}
+
+	var got domain.Fine
+	if code := s.do(http.MethodGet, "/fines/"+fine.ID.String(), token, "", &got); code != http.StatusOK || got.UIN != fine.UIN {
+		t.Errorf("get = %d %q, want 200 %q", code, got.UIN, fine.UIN)
+	}
+
+	if code := s.do(http.MethodGet, "/fines/by-uin/"+fine.UIN, token, "", &got); code != http.StatusOK || got.ID != fine.ID {
+		t.Errorf("get by UIN = %d %v, want 200 %v", code, got.ID, fine.ID)
+	}
+
+	var updated domain.Fine
+	if code := s.do(http.MethodPatch, "/fines/"+fine.ID.String(), token, `{"article":"12.12"}`, &updated); code != http.StatusOK || updated.Article != "12.12" {
+		t.Errorf("update = %d %q, want 200 12.12", code, updated.Article)
+	}
+
+	if code := s.do(http.MethodDelete, "/fines/"+fine.ID.String(), token, "", nil); code != http.StatusNoContent {
+		t.Errorf("delete: status %d, want 204", code)
+	}
+
+	if code := s.do(http.MethodGet, "/fines/"+fine.ID.String(), token, "", nil); code != http.StatusNotFound {
+		t.Errorf("get after delete: status %d, want 404", code)
+	}
+
+	// Only issued fines nobody is paying can be deleted.
+	pending := s.createFine(token, "2", 10000)
+	if code := s.do(http.MethodPost, "/payments", token, `{"fineId":"`+pending.ID.String()+`"}`, nil); code != http.StatusCreated {
+		t.Fatalf("create payment: status %d", code)
+	}
+
+	paid := s.createFine(token, "3", 10000)
+	s.payFine(token, paid)
+
+	cancelled := s.createFine(token, "4", 10000)
+	if code := s.do(http.MethodPatch, "/fines/"+cancelled.ID.String(), token, `{"status":"cancelled"}`, nil); code != http.StatusOK {
+		t.Fatalf("cancel: status %d", code)
+	}
+
+	for _, tc := range []struct {
+		name string
+		fine domain.Fine
+	}{
+		{"payment in progress", pending},
+		{"paid", paid},
+		{"cancelled", cancelled},
+	} {
+		if code := s.do(http.MethodDelete, "/fines/"+tc.fine.ID.String(), token, "", nil); code != http.StatusConflict {
+			t.Errorf("delete %s: status %d, want 409", tc.name, code)
+		}
+	}
+}
+
+func TestFinesCreateValidation(t *testing.T) {
+	s := newTestServer(t)
+	token := s.signUp("Alice")
+
+	s.createFine(token, "1", 50000)
+
+	issuedAt := time.Now().Add(-time.Hour).UTC()
+	beforeIssued := issuedAt.Add(-time.Minute)
+	due := issuedAt.AddDate(0, 0, 10)
+	afterDue := due.Add(time.Minute)
+	body := func(input fineCreateInput) string {
+		if input.IssuedAt.IsZero() {
+			input.IssuedAt = issuedAt
+		}
+
+		b, _ := json.Marshal(input)
+
+		return string(b)
+	}
+
+	for _, tc := range []struct {
+		name string
+		body string
+		want int
+	}{
+		{"malformed", `{"issuer":`, http.StatusBadRequest},
+		{"no issuer", body(fineCreateInput{UIN: testUIN(t, "2"), Amount: 100}), http.StatusBadRequest},
+		{"no amount", body(fineCreateInput{UIN: testUIN(t, "2"), Issuer: "GIBDD"}), http.StatusBadRequest},
+		{"bad check digit", body(fineCreateInput{UIN: "18810177200000000020", Issuer: "GIBDD", Amount: 100}), http.StatusBadRequest},
+		{"duplicate UIN", body(fineCreateInput{UIN: testUIN(t, "1"), Issuer: "GIBDD", Amount: 100}), http.StatusConflict},
+		{"due before issued", body(fineCreateInput{UIN: testUIN(t, "2"), Issuer: "GIBDD", Amount: 100, DueAt: &beforeIssued}), http.StatusBadRequest},
+		{"discount after due", body(fineCreateInput{UIN: testUIN(t, "2"), Issuer: "GIBDD", Amount: 100, DueAt: &due, DiscountUntil: &afterDue}), http.StatusBadRequest},
+		{"discount until due", body(fineCreateInput{UIN: testUIN(t, "2"), Issuer: "GIBDD", Amount: 100, DueAt: &due, DiscountUntil: &due}), http.StatusCreated},
+	} {
+		if code := s.do(http.MethodPost, "/fines", token, tc.body, nil); code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, code, tc.want)
+		}
+	}
+}
+
+func TestFinesUpdateRules(t *testing.T) {
+	s := newTestServer(t)
+	token := s.signUp("Alice")
+
+	fine := s.createFine(token, "1", 50000)
+	path := "/fines/" + fine.ID.String()
+
+	later, _ := json.Marshal(fine.DueAt.Add(24 * time.Hour))
+	earlier, _ := json.Marshal(fine.DueAt.Add(-24 * time.Hour))
+
+	for _, tc := range []struct {
+		name string
+		body string
+		want int
+	}{
+		{"paid status", `{"status":"paid"}`, http.StatusBadRequest},
+		{"unknown status", `{"status":"lost"}`, http.StatusBadRequest},
+		{"due date later", `{"dueAt":` + string(later) + `}`, http.StatusBadRequest},
+		{"due date earlier", `{"dueAt":` + string(earlier) + `}`, http.StatusOK},
+		{"cancel", `{"status":"cancelled"}`, http.StatusOK},
+		{"edit cancelled", `{"amount":100}`, http.StatusConflict},
+		{"reissue", `{"status":"issued"}`, http.StatusConflict},
+	} {
+		if code := s.do(http.MethodPatch, path, token, tc.body, nil); code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, code, tc.want)
+		}
+	}
+}
+
+func TestFinesAccess(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.signUp("Alice")
+	bob := s.signUp("Bob")
+
+	fine := s.createFine(alice, "1", 50000)
+	path := "/fines/" + fine.ID.String()
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"no token", http.MethodGet, "/fines", "", http.StatusUnauthorized},
+		{"invalid id", http.MethodGet, "/fines/fine", alice, http.StatusBadRequest},
+		{"unknown fine", http.MethodGet, "/fines/" + uuid.NewString(), alice, http.StatusNotFound},
+		{"other user", http.MethodGet, path, bob, http.StatusNotFound},
+		{"other user by UIN", http.MethodGet, "/fines/by-uin/" + fine.UIN, bob, http.StatusNotFound},
+		{"other user update", http.MethodPatch, path, bob, http.StatusNotFound},
+		{"other user delete", http.MethodDelete, path, bob, http.StatusNotFound},
+	} {
+		body := ""
+		if tc.method == http.MethodPatch {
+			body = `{"article":"12.12"}`
+		}
+
+		if code := s.do(tc.method, tc.path, tc.token, body, nil); code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, code, tc.want)
+		}
+	}
+
+	var list struct {
+		Fines []domain.Fine `json:"fines"`
+	}
+	if code := s.do(http.MethodGet, "/fines", bob, "", &list); code != http.StatusOK || len(list.Fines) != 0 {
+		t.Errorf("list of other user = %d with %d fines, want 200 and none", code, len(list.Fines))
+	}
+}
@@ -8,13 +8,13 @@ import (
 )
 
 type Handler struct {
-	service      service.Base
+	services     *service.Service
 	tokenManager auth.TokenManager
 }
 
-func NewHandler(service service.Base, tokenManager auth.TokenManager) *Handler {
+func NewHandler(services *service.Service, tokenManager auth.TokenManager) *Handler {
 	return &Handler{
-		service:      service,
+		services:     services,
 		tokenManager: tokenManager,
 	}
 }
@@ -22,6 +22,20 @@ func NewHandler(service service.Base, tokenManager auth.TokenManager) *Handler {
 func (h *Handler) Init(api *gin.RouterGroup) {
 	v1 := api.Group("/v1")
 	{
+		h.initAuthRouter(v1)
+		h.initSessionsRouter(v1)
+		h.initMFARouter(v1)
 		h.initInfoRouter(v1)
+		h.initFinesRouter(v1)
+		h.initDisputesRouter(v1)
+		h.initPaymentsRouter(v1)
+		h.initSchedulesRouter(v1)
+		h.initAutopayRouter(v1)
+		h.initNotificationsRouter(v1)
+		h.initCryptoRouter(v1)
+		h.initAlertsRouter(v1)
+		h.initStatsRouter(v1)
+		h.initLedgerRouter(v1)
+		h.initWebhooksRouter(v1)
 	}
 }
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository/memory"
+	"backend-vtb/internal/service"
+	"backend-vtb/pkg/auth"
+	"backend-vtb/pkg/gateway"
+	"backend-vtb/pkg/hash"
+	"backend-vtb/pkg/uin"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var testFineRules = domain.FineRules{
+	PaymentDays:        70,
+	PenaltyBasisPoints: 10,
+	PenaltyCapPercent:  100,
+}
+
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	tokens auth.TokenManager
+}
+
+// newTestServer serves the v1 API over the in-memory repositories.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	tokens, err := auth.NewManager("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services := service.NewService(service.Deps{
+		Repos:           memory.NewRepository(),
+		Hasher:          hash.NewBcryptHasher(4),
+		TokenManager:    tokens,
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+		FineRules:       testFineRules,
+		Gateway:         gateway.NewSimulator(gateway.SimulatorConfig{}),
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	router := gin.New()
+	NewHandler(services, tokens).Init(router.Group("/api"))
+
+	return &testServer{t: t, router: router, tokens: tokens}
+}
+
+// do sends the request and decodes the JSON response into out, if any.
+func (s *testServer) do(method, path, token, body string, out any) int {
+	s.t.Helper()
+
+	req := httptest.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	if out != nil && rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: decode %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+
+	return rec.Code
+}
+
+// signUp registers a user and returns its access token.
+func (s *testServer) signUp(name string) string {
+	s.t.Helper()
+
+	var tokens struct {
+		AccessToken string `json:"accessToken"`
+	}
+
+	body := `{"name":"` + name + `","email":"` + strings.ToLower(name) + `@example.com","password":"password1"}`
+	if code := s.do(http.MethodPost, "/auth/sign-up", "", body, &tokens); code != http.StatusCreated {
+		s.t.Fatalf("sign-up %s: status %d", name, code)
+	}
+
+	return tokens.AccessToken
+}
+
+// createFine creates a fine of amount issued an hour ago under the UIN
+// with the given serial.
+func (s *testServer) createFine(token, serial string, amount int64) domain.Fine {
+	s.t.Helper()
+
+	var fine domain.Fine
+
+	body, _ := json.Marshal(fineCreateInput{
+		UIN:      testUIN(s.t, serial),
+		Issuer:   "GIBDD",
+		Article:  "12.9",
+		Amount:   amount,
+		IssuedAt: time.Now().Add(-time.Hour).UTC(),
+	})
+	if code := s.do(http.MethodPost, "/fines", token, string(body), &fine); code != http.StatusCreated {
+		s.t.Fatalf("create fine %s: status %d", serial, code)
+	}
+
+	return fine
+}
+
+// testUIN returns a valid 20-digit UIN ending in the serial.
+func testUIN(t *testing.T, serial string) string {
+	t.Helper()
+
+	base := "1881017720000000000"
+	base = base[:len(base)-len(serial)] + serial
+
+	check, err := uin.CheckDigit(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base + string(check)
+}
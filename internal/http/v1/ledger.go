@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initLedgerRouter(api *gin.RouterGroup) {
+	ledger := api.Group("/ledger", h.userIdentity, h.requireScopes(domain.ScopePaymentsRead))
+	{
+		ledger.GET("/balances", h.getLedgerBalances)
+		ledger.GET("/entries", h.getLedgerEntries)
+		ledger.GET("/valuation", h.getLedgerValuation)
+	}
+}
+
+// @Summary Get Balances
+// @Security UsersAuth
+// @Description Returns the balances of the user's ledger accounts, one per currency, derived from
+// @Description their postings
+// @Tags Ledger
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.AccountBalance
+// @Failure 401,403 {object} response
+// @Router /ledger/balances [get]
+func (h *Handler) getLedgerBalances(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	balances, err := h.services.Ledger.Balances(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"balances": balances})
+}
+
+// @Summary Get Ledger Entries
+// @Security UsersAuth
+// @Description Lists the journal entries touching the user's account with all their postings,
+// @Description newest first. Positive amounts are debits, negative amounts credits
+// @Tags Ledger
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.JournalEntry
+// @Failure 401,403 {object} response
+// @Router /ledger/entries [get]
+func (h *Handler) getLedgerEntries(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	entries, err := h.services.Ledger.Entries(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+// @Summary Get Balance Valuation
+// @Security UsersAuth
+// @Description Values the balances of the user's ledger accounts as they were at the end of the
+// @Description given day (today by default) in one currency, converted at the exchange rates of that day
+// @Tags Ledger
+// @Accept json
+// @Produce json
+// @Param currency query string false "ISO 4217 code, RUB by default"
+// @Param date query string false "day as YYYY-MM-DD"
+// @Success 200 {object} domain.Valuation
+// @Failure 400,401,403,422 {object} response
+// @Router /ledger/valuation [get]
+func (h *Handler) getLedgerValuation(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var date time.Time
+	if value := c.Query("date"); value != "" {
+		date, err = time.Parse(time.DateOnly, value)
+		if err != nil {
+			newResponse(c, http.StatusBadRequest, "invalid date param")
+			return
+		}
+	}
+
+	valuation, err := h.services.Ledger.Valuation(c.Request.Context(), id, c.DefaultQuery("currency", "RUB"), date)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, valuation)
+}
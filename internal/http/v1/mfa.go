@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initMFARouter(api *gin.RouterGroup) {
+	mfa := api.Group("/auth/mfa/totp", h.userIdentity, h.requireScopes(domain.ScopeProfileWrite))
+	{
+		mfa.POST("", h.enrollTOTP)
+		mfa.POST("/confirm", h.confirmTOTP)
+		mfa.POST("/disable", h.disableTOTP)
+	}
+}
+
+type mfaVerifyInput struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+type totpCodeInput struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// @Summary Verify Second Factor
+// @Description Exchanges the MFA challenge token from sign-in and a TOTP or recovery code for a pair of tokens
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body mfaVerifyInput true "challenge and code"
+// @Success 200 {object} tokenResponse
+// @Failure 400,401 {object} response
+// @Router /auth/mfa/verify [post]
+func (h *Handler) verifyMFA(c *gin.Context) {
+	var input mfaVerifyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	tokens, err := h.services.Users.VerifyMFA(c.Request.Context(), input.MFAToken, input.Code, clientInfo(c))
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// @Summary Enroll TOTP
+// @Security UsersAuth
+// @Description Generates a TOTP secret and returns it with an otpauth:// URI and a base64 QR code PNG.
+// @Description Two-factor authentication is enabled only after the first code is confirmed
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} domain.TOTPEnrollment
+// @Failure 401,403,409 {object} response
+// @Router /auth/mfa/totp [post]
+func (h *Handler) enrollTOTP(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	enrollment, err := h.services.Users.EnrollTOTP(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// @Summary Confirm TOTP
+// @Security UsersAuth
+// @Description Enables two-factor authentication with the first code from the authenticator
+// @Description and returns one-time recovery codes, which are never shown again
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body totpCodeInput true "TOTP code"
+// @Success 200 {object} recoveryCodesResponse
+// @Failure 400,401,403,409 {object} response
+// @Router /auth/mfa/totp/confirm [post]
+func (h *Handler) confirmTOTP(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input totpCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	codes, err := h.services.Users.ConfirmTOTP(c.Request.Context(), id, input.Code)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// @Summary Disable TOTP
+// @Security UsersAuth
+// @Description Disables two-factor authentication after checking a TOTP or recovery code
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body totpCodeInput true "TOTP or recovery code"
+// @Success 204
+// @Failure 400,401,403,409 {object} response
+// @Router /auth/mfa/totp/disable [post]
+func (h *Handler) disableTOTP(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input totpCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.Users.DisableTOTP(c.Request.Context(), id, input.Code); err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"backend-vtb/pkg/auth"
 	"errors"
 	"net/http"
 	"strings"
@@ -12,7 +13,8 @@ import (
 const (
 	authorizationHeader = "Authorization"
 
-	userCtx = "id"
+	userCtx   = "id"
+	claimsCtx = "claims"
 )
 
 // userIdentity is a middleware that extracts the user ID from the Authorization header
@@ -22,14 +24,43 @@ const (
 // If the header is empty or invalid, or if the token is invalid, the middleware returns
 // a 401 error with a corresponding error message.
 //
-// The user ID is stored in the request context under the key "userId".
+// The user ID is stored in the request context under the key "id" and the
+// parsed token claims under the key "claims".
 func (h *Handler) userIdentity(c *gin.Context) {
-	id, err := h.parseAuthHeader(c)
+	claims, err := h.parseAuthHeader(c)
 	if err != nil {
 		newResponse(c, http.StatusUnauthorized, err.Error())
+		return
 	}
 
-	c.Set(userCtx, id)
+	c.Set(userCtx, claims.Subject)
+	c.Set(claimsCtx, claims)
+}
+
+// requireScopes returns a middleware that lets the request through only if
+// the access token grants every one of the given scopes.
+//
+// It must be registered after userIdentity. If any scope is missing, the
+// middleware responds with 403 and lists the required and missing scopes.
+//
+// Parameters:
+//   - scopes: The scopes required by the route, e.g. "fines:read".
+//
+// Returns:
+//   - gin.HandlerFunc: The middleware enforcing the scopes.
+func (h *Handler) requireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := getClaims(c)
+		if err != nil {
+			newResponse(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		if missing := claims.MissingScopes(scopes...); len(missing) > 0 {
+			newScopeResponse(c, scopes, missing)
+			return
+		}
+	}
 }
 
 // parseAuthHeader extracts and validates the JWT token from the Authorization header.
@@ -42,21 +73,21 @@ func (h *Handler) userIdentity(c *gin.Context) {
 //   - c: The Gin context for the current HTTP request.
 //
 // Returns:
-//   - string: The extracted token if the header is valid.
+//   - *auth.Claims: The claims of the token if the header is valid.
 //   - error: An error if the header is empty, invalid, or the token cannot be retrieved.
-func (h *Handler) parseAuthHeader(c *gin.Context) (string, error) {
+func (h *Handler) parseAuthHeader(c *gin.Context) (*auth.Claims, error) {
 	header := c.GetHeader(authorizationHeader)
 	if header == "" {
-		return "", errors.New("empty auth header")
+		return nil, errors.New("empty auth header")
 	}
 
 	headerParts := strings.Split(header, " ")
 	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-		return "", errors.New("invalid auth header")
+		return nil, errors.New("invalid auth header")
 	}
 
 	if len(headerParts[1]) == 0 {
-		return "", errors.New("token is empty")
+		return nil, errors.New("token is empty")
 	}
 
 	return h.tokenManager.Parse(headerParts[1])
@@ -79,6 +110,21 @@ func getUserId(c *gin.Context) (uuid.UUID, error) {
 	return getIdByContext(c, userCtx)
 }
 
+// getClaims retrieves the access token claims stored by userIdentity.
+func getClaims(c *gin.Context) (*auth.Claims, error) {
+	claimsFromCtx, ok := c.Get(claimsCtx)
+	if !ok {
+		return nil, errors.New("claims not found")
+	}
+
+	claims, ok := claimsFromCtx.(*auth.Claims)
+	if !ok {
+		return nil, errors.New("claims are of invalid type")
+	}
+
+	return claims, nil
+}
+
 // getIdByContext retrieves the UUID value from the provided Gin context.
 //
 // The function retrieves the value associated with the provided context key,
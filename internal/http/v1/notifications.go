@@ -0,0 +1,40 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initNotificationsRouter(api *gin.RouterGroup) {
+	notifications := api.Group("/notifications", h.userIdentity, h.requireScopes(domain.ScopeProfileRead))
+	{
+		notifications.GET("", h.listNotifications)
+	}
+}
+
+// @Summary List Notifications
+// @Security UsersAuth
+// @Description Lists the messages left for the user, e.g. about fines paid by autopay, newest first
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Notification
+// @Failure 401,403 {object} response
+// @Router /notifications [get]
+func (h *Handler) listNotifications(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	notifications, err := h.services.Notifications.List(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": notifications})
+}
@@ -0,0 +1,460 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/service"
+	"backend-vtb/pkg/gateway"
+	"backend-vtb/pkg/money"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+func (h *Handler) initPaymentsRouter(api *gin.RouterGroup) {
+	payments := api.Group("/payments", h.userIdentity)
+	{
+		read := payments.Group("", h.requireScopes(domain.ScopePaymentsRead))
+		{
+			read.GET("", h.listPayments)
+			read.GET("/:id", h.getPayment)
+			read.GET("/:id/refunds", h.listRefunds)
+		}
+
+		write := payments.Group("", h.requireScopes(domain.ScopePaymentsWrite))
+		{
+			write.POST("", h.createPayment)
+			write.POST("/drafts/qr", h.draftPaymentFromQR)
+			write.POST("/:id/authorize", h.authorizePayment)
+			write.POST("/:id/3ds", h.authenticatePayment)
+			write.POST("/:id/capture", h.capturePayment)
+			write.POST("/:id/void", h.voidPayment)
+		}
+
+		refund := payments.Group("", h.requireScopes(domain.ScopePaymentsRefund))
+		{
+			refund.POST("/:id/refunds", h.createRefund)
+		}
+	}
+}
+
+type paymentCreateInput struct {
+	Amount     int64      `json:"amount" binding:"min=0"`
+	Currency   string     `json:"currency" binding:"omitempty,iso4217"`
+	Purpose    string     `json:"purpose" binding:"max=255"`
+	FineID     *uuid.UUID `json:"fineId"`
+	MerchantID string     `json:"merchantId" binding:"max=64"`
+}
+
+type paymentQRInput struct {
+	// Payload is the text of the scanned QR code, starting with ST0001.
+	Payload string `json:"payload" binding:"required,max=2048"`
+}
+
+type paymentCardInput struct {
+	Number   string `json:"number" binding:"required,numeric,min=12,max=19"`
+	ExpMonth int    `json:"expMonth" binding:"required,min=1,max=12"`
+	ExpYear  int    `json:"expYear" binding:"required,min=0,max=9999"`
+	CVC      string `json:"cvc" binding:"required,numeric,min=3,max=4"`
+	Holder   string `json:"holder" binding:"max=255"`
+}
+
+type paymentChallengeInput struct {
+	Code string `json:"code" binding:"required,max=64"`
+}
+
+type refundCreateInput struct {
+	Amount int64  `json:"amount" binding:"min=0"`
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// @Summary List Payments
+// @Security UsersAuth
+// @Description Lists the user's payments, newest first
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Payment
+// @Failure 401,403 {object} response
+// @Router /payments [get]
+func (h *Handler) listPayments(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	payments, err := h.services.Payments.List(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"payments": payments})
+}
+
+// @Summary Get Payment
+// @Security UsersAuth
+// @Description Retrieves one of the user's payments
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param id path string true "payment id"
+// @Success 200 {object} domain.Payment
+// @Failure 400,401,403,404 {object} response
+// @Router /payments/{id} [get]
+func (h *Handler) getPayment(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	payment, err := h.services.Payments.Get(c.Request.Context(), id, paymentID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// @Summary Create Payment
+// @Security UsersAuth
+// @Description Starts a payment of either a fine (fineId) or a merchant (merchantId). Amounts are in
+// @Description minor units; the amount of a fine payment may be omitted and defaults to the amount due.
+// @Description Retrying with the same Idempotency-Key returns the original payment with the
+// @Description Idempotent-Replayed header set instead of paying twice
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "unique key of the request"
+// @Param input body paymentCreateInput true "payment"
+// @Success 201 {object} domain.Payment
+// @Failure 400,401,403,404,409,422 {object} response
+// @Router /payments [post]
+func (h *Handler) createPayment(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	key := c.GetHeader(idempotencyKeyHeader)
+	if len(key) > maxIdempotencyKeyLength {
+		newResponse(c, http.StatusBadRequest, "invalid Idempotency-Key header")
+		return
+	}
+
+	var input paymentCreateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	payment, replayed, err := h.services.Payments.Create(c.Request.Context(), id, service.PaymentCreateInput{
+		IdempotencyKey: key,
+		Amount:         money.New(input.Amount, input.Currency),
+		Purpose:        input.Purpose,
+		FineID:         input.FineID,
+		MerchantID:     input.MerchantID,
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	if replayed {
+		c.Header(idempotentReplayedHeader, "true")
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// @Summary Draft Payment From QR Code
+// @Security UsersAuth
+// @Description Parses the payload of a scanned GOST R 56042 (ST00012) payment QR code and returns a
+// @Description payment draft to review before creating it. A UIN that matches one of the user's fines
+// @Description targets that fine at the amount payable now; otherwise the payee becomes the merchant
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param input body paymentQRInput true "scanned payload"
+// @Success 200 {object} domain.PaymentDraft
+// @Failure 400,401,403,409 {object} response
+// @Router /payments/drafts/qr [post]
+func (h *Handler) draftPaymentFromQR(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input paymentQRInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	draft, err := h.services.Payments.DraftFromQR(c.Request.Context(), id, input.Payload)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
+
+// @Summary Authorize Payment
+// @Security UsersAuth
+// @Description Reserves the amount of a pending payment on the card. A declined card fails the payment
+// @Description with the decline code as failureReason. If the issuer requires 3-D Secure, the payment
+// @Description stays pending with a challengeUrl to complete through /payments/{id}/3ds
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param id path string true "payment id"
+// @Param input body paymentCardInput true "card"
+// @Success 200 {object} domain.Payment
+// @Failure 400,401,403,404,409,502 {object} response
+// @Router /payments/{id}/authorize [post]
+func (h *Handler) authorizePayment(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	var input paymentCardInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	payment, err := h.services.Payments.Authorize(c.Request.Context(), id, paymentID, gateway.Card{
+		Number:   input.Number,
+		ExpMonth: input.ExpMonth,
+		ExpYear:  input.ExpYear,
+		CVC:      input.CVC,
+		Holder:   input.Holder,
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// @Summary Complete 3-D Secure
+// @Security UsersAuth
+// @Description Submits the response to the 3-D Secure challenge of a pending payment
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param id path string true "payment id"
+// @Param input body paymentChallengeInput true "challenge response"
+// @Success 200 {object} domain.Payment
+// @Failure 400,401,403,404,409,502 {object} response
+// @Router /payments/{id}/3ds [post]
+func (h *Handler) authenticatePayment(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	var input paymentChallengeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	payment, err := h.services.Payments.Authenticate(c.Request.Context(), id, paymentID, input.Code)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// @Summary Capture Payment
+// @Security UsersAuth
+// @Description Charges an authorized payment and settles it to the fine or merchant. A fine that is
+// @Description no longer payable voids the authorization instead
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param id path string true "payment id"
+// @Success 200 {object} domain.Payment
+// @Failure 400,401,403,404,409,502 {object} response
+// @Router /payments/{id}/capture [post]
+func (h *Handler) capturePayment(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	payment, err := h.services.Payments.Capture(c.Request.Context(), id, paymentID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// @Summary Void Payment
+// @Security UsersAuth
+// @Description Cancels a payment that has not been captured, releasing its authorization
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param id path string true "payment id"
+// @Success 200 {object} domain.Payment
+// @Failure 400,401,403,404,409,502 {object} response
+// @Router /payments/{id}/void [post]
+func (h *Handler) voidPayment(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	payment, err := h.services.Payments.Void(c.Request.Context(), id, paymentID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// @Summary List Refunds
+// @Security UsersAuth
+// @Description Lists the refunds of one of the user's payments, oldest first
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param id path string true "payment id"
+// @Success 200 {array} domain.Refund
+// @Failure 400,401,403,404 {object} response
+// @Router /payments/{id}/refunds [get]
+func (h *Handler) listRefunds(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	refunds, err := h.services.Payments.Refunds(c.Request.Context(), id, paymentID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"refunds": refunds})
+}
+
+// @Summary Refund Payment
+// @Security UsersAuth
+// @Description Returns all or part of a captured payment of any user to the card; operators only.
+// @Description Omit the amount to refund
+// @Description whatever has not been refunded yet. A payment may be refunded several times, never
+// @Description beyond its amount; it becomes refunded once fully refunded. A refund the acquirer
+// @Description rejects is stored as failed. Retrying with the same Idempotency-Key returns the
+// @Description original refund with the Idempotent-Replayed header set
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param id path string true "payment id"
+// @Param Idempotency-Key header string false "unique key of the request"
+// @Param input body refundCreateInput true "refund"
+// @Success 201 {object} domain.Refund
+// @Failure 400,401,403,404,409,422,502 {object} response
+// @Router /payments/{id}/refunds [post]
+func (h *Handler) createRefund(c *gin.Context) {
+	actor, err := getActor(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	key := c.GetHeader(idempotencyKeyHeader)
+	if len(key) > maxIdempotencyKeyLength {
+		newResponse(c, http.StatusBadRequest, "invalid Idempotency-Key header")
+		return
+	}
+
+	var input refundCreateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	refund, replayed, err := h.services.Payments.Refund(c.Request.Context(), actor, paymentID, service.RefundCreateInput{
+		IdempotencyKey: key,
+		Amount:         money.Money{Amount: input.Amount},
+		Reason:         input.Reason,
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	if replayed {
+		c.Header(idempotentReplayedHeader, "true")
+	}
+
+	c.JSON(http.StatusCreated, refund)
+}
@@ -0,0 +1,160 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/pkg/auth"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// payFine pays the whole fine with a card that needs no 3-D Secure and
+// returns the captured payment.
+func (s *testServer) payFine(token string, fine domain.Fine) domain.Payment {
+	s.t.Helper()
+
+	var payment domain.Payment
+
+	if code := s.do(http.MethodPost, "/payments", token, `{"fineId":"`+fine.ID.String()+`"}`, &payment); code != http.StatusCreated {
+		s.t.Fatalf("create payment: status %d", code)
+	}
+
+	card := `{"number":"4242424242424242","expMonth":12,"expYear":` + strconv.Itoa(time.Now().Year()+1) + `,"cvc":"123"}`
+	if code := s.do(http.MethodPost, "/payments/"+payment.ID.String()+"/authorize", token, card, &payment); code != http.StatusOK {
+		s.t.Fatalf("authorize payment: status %d", code)
+	}
+
+	if code := s.do(http.MethodPost, "/payments/"+payment.ID.String()+"/capture", token, "", &payment); code != http.StatusOK || payment.Status != domain.PaymentCaptured {
+		s.t.Fatalf("capture payment: status %d, payment %s", code, payment.Status)
+	}
+
+	return payment
+}
+
+// operator returns a token of an operator allowed to refund.
+func (s *testServer) operator() string {
+	s.t.Helper()
+
+	claims, err := s.tokens.Parse(s.signUp("Operator"))
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	token, err := s.tokens.NewJWT(auth.Claims{Subject: claims.Subject, Scopes: domain.RoleOperator.Scopes()}, time.Minute)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestPaymentsRefundAccess(t *testing.T) {
+	s := newTestServer(t)
+	customer := s.signUp("Alice")
+	payment := s.payFine(customer, s.createFine(customer, "1", 50000))
+
+	path := "/payments/" + payment.ID.String() + "/refunds"
+
+	if code := s.do(http.MethodPost, path, customer, `{"amount":100}`, nil); code != http.StatusForbidden {
+		t.Errorf("refund by the customer: status %d, want %d", code, http.StatusForbidden)
+	}
+
+	var refund domain.Refund
+	if code := s.do(http.MethodPost, path, s.operator(), `{"amount":100}`, &refund); code != http.StatusCreated {
+		t.Fatalf("refund by an operator: status %d, want %d", code, http.StatusCreated)
+	}
+
+	if refund.UserID != payment.UserID || refund.Status != domain.RefundSucceeded {
+		t.Errorf("refund of user %v is %s, want of %v and succeeded", refund.UserID, refund.Status, payment.UserID)
+	}
+}
+
+func TestPaymentsRefunds(t *testing.T) {
+	s := newTestServer(t)
+	customer := s.signUp("Alice")
+	operator := s.operator()
+	fine := s.createFine(customer, "1", 50000)
+	payment := s.payFine(customer, fine)
+
+	path := "/payments/" + payment.ID.String()
+
+	for _, tc := range []struct {
+		name     string
+		body     string
+		want     int
+		status   domain.PaymentStatus
+		refunded int64
+		fine     domain.FineStatus
+	}{
+		{"partial", `{"amount":20000}`, http.StatusCreated, domain.PaymentCaptured, 20000, domain.FinePaid},
+		{"beyond the rest", `{"amount":30001}`, http.StatusConflict, domain.PaymentCaptured, 20000, domain.FinePaid},
+		{"another partial", `{"amount":10000}`, http.StatusCreated, domain.PaymentCaptured, 30000, domain.FinePaid},
+		{"the rest", `{}`, http.StatusCreated, domain.PaymentRefunded, 50000, domain.FineIssued},
+		{"fully refunded", `{"amount":1}`, http.StatusConflict, domain.PaymentRefunded, 50000, domain.FineIssued},
+	} {
+		if code := s.do(http.MethodPost, path+"/refunds", operator, tc.body, nil); code != tc.want {
+			t.Fatalf("%s: status %d, want %d", tc.name, code, tc.want)
+		}
+
+		if code := s.do(http.MethodGet, path, customer, "", &payment); code != http.StatusOK {
+			t.Fatalf("%s: get payment: status %d", tc.name, code)
+		}
+
+		if payment.Status != tc.status || payment.Refunded != tc.refunded {
+			t.Errorf("%s: payment %s with %d refunded, want %s with %d", tc.name, payment.Status, payment.Refunded, tc.status, tc.refunded)
+		}
+
+		if code := s.do(http.MethodGet, "/fines/"+fine.ID.String(), customer, "", &fine); code != http.StatusOK || fine.Status != tc.fine {
+			t.Errorf("%s: fine = %d %s, want %s", tc.name, code, fine.Status, tc.fine)
+		}
+	}
+
+	var refunds struct {
+		Refunds []domain.Refund `json:"refunds"`
+	}
+	if code := s.do(http.MethodGet, path+"/refunds", customer, "", &refunds); code != http.StatusOK || len(refunds.Refunds) != 3 {
+		t.Fatalf("list refunds = %d with %d refunds, want 200 and 3", code, len(refunds.Refunds))
+	}
+
+	// The reissued fine can be paid again.
+	s.payFine(customer, fine)
+}
+
+func TestPaymentsRefundCap(t *testing.T) {
+	s := newTestServer(t)
+	customer := s.signUp("Alice")
+	operator := s.operator()
+	payment := s.payFine(customer, s.createFine(customer, "1", 50000))
+
+	const attempts = 8
+
+	// The service checks each refund against the payment as it read it; only
+	// the repository, under the lock of the payment, keeps concurrent refunds
+	// from adding up beyond its amount.
+	codes := make(chan int, attempts)
+	for range attempts {
+		go func() {
+			codes <- s.do(http.MethodPost, "/payments/"+payment.ID.String()+"/refunds", operator, `{"amount":20000}`, nil)
+		}()
+	}
+
+	var created int
+	for range attempts {
+		switch code := <-codes; code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+		default:
+			t.Errorf("concurrent refund: status %d", code)
+		}
+	}
+
+	if created != 2 {
+		t.Errorf("%d refunds of 20000 created out of 50000, want 2", created)
+	}
+
+	if code := s.do(http.MethodGet, "/payments/"+payment.ID.String(), customer, "", &payment); code != http.StatusOK || payment.Refunded != 40000 {
+		t.Errorf("payment = %d with %d refunded, want 200 and 40000", code, payment.Refunded)
+	}
+}
@@ -1,6 +1,12 @@
 package v1
 
-import "github.com/gin-gonic/gin"
+import (
+	"backend-vtb/internal/domain"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
 
 type response struct {
 	Message string `json:"message"`
@@ -19,3 +25,99 @@ type response struct {
 func newResponse(c *gin.Context, statusCode int, message string) {
 	c.AbortWithStatusJSON(statusCode, response{message})
 }
+
+type scopeResponse struct {
+	Message  string   `json:"message"`
+	Code     string   `json:"code"`
+	Required []string `json:"required"`
+	Missing  []string `json:"missing"`
+}
+
+// newScopeResponse aborts the request with 403 Forbidden, reporting which of
+// the required scopes the access token lacks.
+//
+// Parameters:
+//   - c: The Gin context for the current HTTP request.
+//   - required: All scopes required by the route.
+//   - missing: The required scopes not granted by the token.
+func newScopeResponse(c *gin.Context, required, missing []string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, scopeResponse{
+		Message:  "insufficient scope",
+		Code:     "insufficient_scope",
+		Required: required,
+		Missing:  missing,
+	})
+}
+
+// statusFromError maps errors returned by the service layer to HTTP status codes.
+//
+// Known domain errors get their dedicated status; anything else is reported
+// as an internal server error.
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrNotFound),
+		errors.Is(err, domain.ErrWebhookProvider):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidFineStatus),
+		errors.Is(err, domain.ErrFineDeadline),
+		errors.Is(err, domain.ErrInvalidUIN),
+		errors.Is(err, domain.ErrInvalidPaymentTarget),
+		errors.Is(err, domain.ErrPaymentAmount),
+		errors.Is(err, domain.ErrWebhookPayload),
+		errors.Is(err, domain.ErrInvalidQRPayload),
+		errors.Is(err, domain.ErrInvalidCard),
+		errors.Is(err, domain.ErrScheduleRule),
+		errors.Is(err, domain.ErrAutopayRule),
+		errors.Is(err, domain.ErrInvalidCurrency),
+		errors.Is(err, domain.ErrCryptoTransaction),
+		errors.Is(err, domain.ErrUnknownAsset),
+		errors.Is(err, domain.ErrCostBasisMethod),
+		errors.Is(err, domain.ErrCandleRange),
+		errors.Is(err, domain.ErrAlertRule),
+		errors.Is(err, domain.ErrStatsQuery):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrIdempotencyKeyReused),
+		errors.Is(err, domain.ErrNoExchangeRate):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrAttachmentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrAttachmentType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, domain.ErrUserAlreadyExists),
+		errors.Is(err, domain.ErrFineAlreadyExists),
+		errors.Is(err, domain.ErrFineTransition),
+		errors.Is(err, domain.ErrFineNotEditable),
+		errors.Is(err, domain.ErrFineNotDeletable),
+		errors.Is(err, domain.ErrDisputeExists),
+		errors.Is(err, domain.ErrDisputeClosed),
+		errors.Is(err, domain.ErrTooManyAttachments),
+		errors.Is(err, domain.ErrPaymentAlreadyExists),
+		errors.Is(err, domain.ErrPaymentTransition),
+		errors.Is(err, domain.ErrFineNotPayable),
+		errors.Is(err, domain.ErrFinePaymentExists),
+		errors.Is(err, domain.ErrRefundAmount),
+		errors.Is(err, domain.ErrRefundAlreadyExists),
+		errors.Is(err, domain.ErrRefundCompleted),
+		errors.Is(err, domain.ErrScheduleState),
+		errors.Is(err, domain.ErrScheduleChanged),
+		errors.Is(err, domain.ErrAutopayState),
+		errors.Is(err, domain.ErrInsufficientHoldings),
+		errors.Is(err, domain.ErrAlertLimit),
+		errors.Is(err, domain.ErrTOTPAlreadyEnabled),
+		errors.Is(err, domain.ErrTOTPNotEnrolled):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrGatewayUnavailable):
+		return http.StatusBadGateway
+	case errors.Is(err, domain.ErrInvalidCredentials),
+		errors.Is(err, domain.ErrInvalidRefreshToken),
+		errors.Is(err, domain.ErrRefreshTokenReused),
+		errors.Is(err, domain.ErrInvalidMFAToken),
+		errors.Is(err, domain.ErrInvalidOTP),
+		errors.Is(err, domain.ErrWebhookSignature):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrRefundNotAllowed):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,291 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/service"
+	"backend-vtb/pkg/gateway"
+	"backend-vtb/pkg/money"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) initSchedulesRouter(api *gin.RouterGroup) {
+	schedules := api.Group("/schedules", h.userIdentity)
+	{
+		read := schedules.Group("", h.requireScopes(domain.ScopePaymentsRead))
+		{
+			read.GET("", h.listSchedules)
+			read.GET("/:id", h.getSchedule)
+			read.GET("/:id/occurrences", h.listScheduleOccurrences)
+		}
+
+		write := schedules.Group("", h.requireScopes(domain.ScopePaymentsWrite))
+		{
+			write.POST("", h.createSchedule)
+			write.POST("/:id/pause", h.pauseSchedule)
+			write.POST("/:id/resume", h.resumeSchedule)
+			write.DELETE("/:id", h.cancelSchedule)
+		}
+	}
+}
+
+type scheduleRuleInput struct {
+	Kind     domain.ScheduleKind `json:"kind" binding:"required,oneof=once monthly last_business_day weekly"`
+	Day      int                 `json:"day" binding:"min=0,max=31"`
+	Interval int                 `json:"interval" binding:"min=0,max=52"`
+}
+
+type scheduleCreateInput struct {
+	Rule       scheduleRuleInput `json:"rule" binding:"required"`
+	StartsAt   time.Time         `json:"startsAt"`
+	EndsAt     *time.Time        `json:"endsAt"`
+	Amount     int64             `json:"amount" binding:"min=0"`
+	Currency   string            `json:"currency" binding:"omitempty,iso4217"`
+	Purpose    string            `json:"purpose" binding:"max=255"`
+	FineID     *uuid.UUID        `json:"fineId"`
+	MerchantID string            `json:"merchantId" binding:"max=64"`
+	Card       paymentCardInput  `json:"card" binding:"required"`
+}
+
+// @Summary List Payment Schedules
+// @Security UsersAuth
+// @Description Lists the user's scheduled and recurring payments, newest first
+// @Tags Schedule
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Schedule
+// @Failure 401,403 {object} response
+// @Router /schedules [get]
+func (h *Handler) listSchedules(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	schedules, err := h.services.Schedules.List(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules})
+}
+
+// @Summary Get Payment Schedule
+// @Security UsersAuth
+// @Description Returns a payment schedule with its next run
+// @Tags Schedule
+// @Accept json
+// @Produce json
+// @Param id path string true "schedule id"
+// @Success 200 {object} domain.Schedule
+// @Failure 400,401,403,404 {object} response
+// @Router /schedules/{id} [get]
+func (h *Handler) getSchedule(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	schedule, err := h.services.Schedules.Get(c.Request.Context(), id, scheduleID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// @Summary List Payment Schedule Occurrences
+// @Security UsersAuth
+// @Description Lists the past runs of a payment schedule, newest first, each succeeded, failed
+// @Description with the reason the payment failed, or skipped while paused or when too late to pay
+// @Tags Schedule
+// @Accept json
+// @Produce json
+// @Param id path string true "schedule id"
+// @Success 200 {array} domain.ScheduleOccurrence
+// @Failure 400,401,403,404 {object} response
+// @Router /schedules/{id}/occurrences [get]
+func (h *Handler) listScheduleOccurrences(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	occurrences, err := h.services.Schedules.Occurrences(c.Request.Context(), id, scheduleID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"occurrences": occurrences})
+}
+
+// @Summary Create Payment Schedule
+// @Security UsersAuth
+// @Description Schedules a payment of a merchant (merchantId) by a calendar rule: once at startsAt,
+// @Description monthly on a day, on the last business day of every month, or every interval weeks.
+// @Description A fine (fineId) can be scheduled once and is paid at the amount due at the time.
+// @Description The card is saved at the acquirer and charged without 3-D Secure at every run
+// @Tags Schedule
+// @Accept json
+// @Produce json
+// @Param input body scheduleCreateInput true "schedule"
+// @Success 201 {object} domain.Schedule
+// @Failure 400,401,403,404,502 {object} response
+// @Router /schedules [post]
+func (h *Handler) createSchedule(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input scheduleCreateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	schedule, err := h.services.Schedules.Create(c.Request.Context(), id, service.ScheduleCreateInput{
+		Rule: domain.ScheduleRule{
+			Kind:     input.Rule.Kind,
+			Day:      input.Rule.Day,
+			Interval: input.Rule.Interval,
+		},
+		StartsAt:   input.StartsAt,
+		EndsAt:     input.EndsAt,
+		Amount:     money.New(input.Amount, input.Currency),
+		Purpose:    input.Purpose,
+		FineID:     input.FineID,
+		MerchantID: input.MerchantID,
+		Card: gateway.Card{
+			Number:   input.Card.Number,
+			ExpMonth: input.Card.ExpMonth,
+			ExpYear:  input.Card.ExpYear,
+			CVC:      input.Card.CVC,
+			Holder:   input.Card.Holder,
+		},
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// @Summary Pause Payment Schedule
+// @Security UsersAuth
+// @Description Stops an active schedule from making payments until it is resumed
+// @Tags Schedule
+// @Accept json
+// @Produce json
+// @Param id path string true "schedule id"
+// @Success 200 {object} domain.Schedule
+// @Failure 400,401,403,404,409 {object} response
+// @Router /schedules/{id}/pause [post]
+func (h *Handler) pauseSchedule(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	schedule, err := h.services.Schedules.Pause(c.Request.Context(), id, scheduleID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// @Summary Resume Payment Schedule
+// @Security UsersAuth
+// @Description Reactivates a paused schedule. Runs that fell into the pause are recorded as skipped
+// @Tags Schedule
+// @Accept json
+// @Produce json
+// @Param id path string true "schedule id"
+// @Success 200 {object} domain.Schedule
+// @Failure 400,401,403,404,409 {object} response
+// @Router /schedules/{id}/resume [post]
+func (h *Handler) resumeSchedule(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	schedule, err := h.services.Schedules.Resume(c.Request.Context(), id, scheduleID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// @Summary Cancel Payment Schedule
+// @Security UsersAuth
+// @Description Cancels an active or paused schedule for good; its past runs are kept
+// @Tags Schedule
+// @Accept json
+// @Produce json
+// @Param id path string true "schedule id"
+// @Success 200 {object} domain.Schedule
+// @Failure 400,401,403,404,409 {object} response
+// @Router /schedules/{id} [delete]
+func (h *Handler) cancelSchedule(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	schedule, err := h.services.Schedules.Cancel(c.Request.Context(), id, scheduleID)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handler) initSessionsRouter(api *gin.RouterGroup) {
+	sessions := api.Group("/sessions", h.userIdentity)
+	{
+		sessions.GET("", h.requireScopes(domain.ScopeProfileRead), h.getSessions)
+		sessions.DELETE("/:id", h.requireScopes(domain.ScopeProfileWrite), h.revokeSession)
+	}
+}
+
+// @Summary Get Active Sessions
+// @Security UsersAuth
+// @Description Lists the user's active sessions with device, IP and last activity
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Session
+// @Router /sessions [get]
+func (h *Handler) getSessions(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sessions, err := h.services.Users.GetSessions(c.Request.Context(), id)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// @Summary Revoke Session
+// @Security UsersAuth
+// @Description Revokes a session so that its refresh tokens can no longer be used
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param id path string true "session id"
+// @Success 204
+// @Failure 400,403,404 {object} response
+// @Router /sessions/{id} [delete]
+func (h *Handler) revokeSession(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	if err := h.services.Users.RevokeSession(c.Request.Context(), id, sessionID); err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initStatsRouter(api *gin.RouterGroup) {
+	stats := api.Group("/stats", h.userIdentity, h.requireScopes(domain.ScopePaymentsRead, domain.ScopeFinesRead))
+	{
+		stats.GET("", h.getStats)
+	}
+}
+
+// @Summary Get Statistics
+// @Security UsersAuth
+// @Description Returns the spending, income and fines of the user per bucket in a time zone, with a
+// @Description breakdown by category and a comparison with the totals of as many buckets right before.
+// @Description Amounts are in minor units, with a series per metric and currency
+// @Tags Stats
+// @Accept json
+// @Produce json
+// @Param bucket query string false "day (default), week or month"
+// @Param from query string false "first day as YYYY-MM-DD, 30 days, 12 weeks or 12 months before to by default"
+// @Param to query string false "last day as YYYY-MM-DD, today by default"
+// @Param timezone query string false "IANA time zone, e.g. Europe/Moscow"
+// @Success 200 {object} domain.StatsReport
+// @Failure 400,401,403 {object} response
+// @Router /stats [get]
+func (h *Handler) getStats(c *gin.Context) {
+	id, err := getUserId(c)
+	if err != nil {
+		newResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	input := service.StatsInput{
+		Bucket:   domain.StatsBucket(c.Query("bucket")),
+		Timezone: c.Query("timezone"),
+	}
+
+	if value := c.Query("from"); value != "" {
+		input.From, err = time.Parse(time.DateOnly, value)
+		if err != nil {
+			newResponse(c, http.StatusBadRequest, "invalid from param")
+			return
+		}
+	}
+
+	if value := c.Query("to"); value != "" {
+		input.To, err = time.Parse(time.DateOnly, value)
+		if err != nil {
+			newResponse(c, http.StatusBadRequest, "invalid to param")
+			return
+		}
+	}
+
+	report, err := h.services.Stats.Report(c.Request.Context(), id, input)
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"backend-vtb/internal/service"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	webhookSignatureHeader = "Webhook-Signature"
+	webhookTimestampHeader = "Webhook-Timestamp"
+	maxWebhookSize         = 1 << 20
+)
+
+// initWebhooksRouter registers the endpoints called by payment providers.
+// They are authenticated by the signature of the request, not by a token.
+func (h *Handler) initWebhooksRouter(api *gin.RouterGroup) {
+	webhooks := api.Group("/webhooks")
+	{
+		webhooks.POST("/payments/:provider", h.paymentWebhook)
+	}
+}
+
+type webhookResponse struct {
+	EventID string `json:"eventId"`
+	Outcome string `json:"outcome"`
+	Detail  string `json:"detail"`
+}
+
+// @Summary Payment Webhook
+// @Description Receives payment status changes from the acquirer. The request is signed with
+// @Description HMAC-SHA256 over "<Webhook-Timestamp>.<body>" and sent as Webhook-Signature: v1=<hex>.
+// @Description Redelivered events are acknowledged without being applied again
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param provider path string true "payment provider"
+// @Param Webhook-Signature header string true "v1=<hex HMAC-SHA256>"
+// @Param Webhook-Timestamp header string true "Unix time the request was sent at"
+// @Success 200 {object} webhookResponse
+// @Failure 400,401,404,413 {object} response
+// @Router /webhooks/payments/{provider} [post]
+func (h *Handler) paymentWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookSize+1))
+	if err != nil {
+		newResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if len(payload) > maxWebhookSize {
+		newResponse(c, http.StatusRequestEntityTooLarge, "webhook payload is too large")
+		return
+	}
+
+	event, err := h.services.Webhooks.HandlePayment(c.Request.Context(), service.WebhookDelivery{
+		Provider:  c.Param("provider"),
+		Signature: c.GetHeader(webhookSignatureHeader),
+		Timestamp: c.GetHeader(webhookTimestampHeader),
+		Payload:   payload,
+	})
+	if err != nil {
+		newResponse(c, statusFromError(err), err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookResponse{
+		EventID: event.EventID,
+		Outcome: string(event.Outcome),
+		Detail:  event.Detail,
+	})
+}
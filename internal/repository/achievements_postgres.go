@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type AchievementsRepo struct {
+	db *sqlx.DB
+}
+
+func NewAchievementsRepo(db *sqlx.DB) *AchievementsRepo {
+	return &AchievementsRepo{db: db}
+}
+
+func (r *AchievementsRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Achievement, error) {
+	achievements := make([]domain.Achievement, 0)
+
+	err := r.db.SelectContext(ctx, &achievements,
+		`SELECT id, user_id, title, description, achieved_at FROM achievements WHERE user_id = $1 ORDER BY achieved_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return achievements, nil
+}
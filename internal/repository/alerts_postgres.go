@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const alertRuleColumns = `id, user_id, subject, asset, condition, threshold, window_minutes, cooldown_minutes,
+	triggered, fired_at, created_at, updated_at`
+
+type AlertsRepo struct {
+	db *sqlx.DB
+}
+
+func NewAlertsRepo(db *sqlx.DB) *AlertsRepo {
+	return &AlertsRepo{db: db}
+}
+
+func (r *AlertsRepo) Create(ctx context.Context, rule domain.AlertRule, limit int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(ctx, tx, rule.UserID); err != nil {
+		return err
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT count(*) FROM alert_rules WHERE user_id = $1`, rule.UserID); err != nil {
+		return err
+	}
+
+	if count >= limit {
+		return domain.ErrAlertLimit
+	}
+
+	_, err = tx.NamedExecContext(ctx,
+		`INSERT INTO alert_rules (`+alertRuleColumns+`)
+		VALUES (:id, :user_id, :subject, :asset, :condition, :threshold, :window_minutes, :cooldown_minutes,
+			:triggered, :fired_at, :created_at, :updated_at)`, rule)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *AlertsRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.AlertRule, error) {
+	var rule domain.AlertRule
+
+	err := r.db.GetContext(ctx, &rule, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return domain.AlertRule{}, wrapNotFound(err)
+	}
+
+	return rule, nil
+}
+
+func (r *AlertsRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.AlertRule, error) {
+	rules := make([]domain.AlertRule, 0)
+
+	err := r.db.SelectContext(ctx, &rules,
+		`SELECT `+alertRuleColumns+` FROM alert_rules WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (r *AlertsRepo) GetAll(ctx context.Context) ([]domain.AlertRule, error) {
+	rules := make([]domain.AlertRule, 0)
+
+	err := r.db.SelectContext(ctx, &rules, `SELECT `+alertRuleColumns+` FROM alert_rules ORDER BY user_id, created_at`)
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (r *AlertsRepo) Update(ctx context.Context, rule domain.AlertRule, loadedAt time.Time) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE alert_rules SET triggered = $3, fired_at = $4, updated_at = $5
+		WHERE id = $1 AND updated_at = $2`,
+		rule.ID, loadedAt, rule.Triggered, rule.FiredAt, rule.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrAlertChanged
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *AlertsRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const autopayRuleColumns = `user_id, enabled, max_per_fine, monthly_limit, card_token, card_last4, created_at, updated_at`
+
+const autopayChargeColumns = `id, user_id, fine_id, schedule_id, amount, charge_at, month, status, reason,
+	created_at, updated_at`
+
+type AutopayRepo struct {
+	db *sqlx.DB
+}
+
+func NewAutopayRepo(db *sqlx.DB) *AutopayRepo {
+	return &AutopayRepo{db: db}
+}
+
+func (r *AutopayRepo) GetRule(ctx context.Context, userID uuid.UUID) (domain.AutopayRule, error) {
+	var rule domain.AutopayRule
+
+	err := r.db.GetContext(ctx, &rule, `SELECT `+autopayRuleColumns+` FROM autopay_rules WHERE user_id = $1`, userID)
+	if err != nil {
+		return domain.AutopayRule{}, wrapNotFound(err)
+	}
+
+	return rule, nil
+}
+
+func (r *AutopayRepo) SaveRule(ctx context.Context, rule domain.AutopayRule) error {
+	_, err := r.db.NamedExecContext(ctx,
+		`INSERT INTO autopay_rules (`+autopayRuleColumns+`)
+		VALUES (:user_id, :enabled, :max_per_fine, :monthly_limit, :card_token, :card_last4, :created_at, :updated_at)
+		ON CONFLICT (user_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			max_per_fine = EXCLUDED.max_per_fine,
+			monthly_limit = EXCLUDED.monthly_limit,
+			card_token = EXCLUDED.card_token,
+			card_last4 = EXCLUDED.card_last4,
+			updated_at = EXCLUDED.updated_at`, rule)
+
+	return err
+}
+
+func (r *AutopayRepo) CreateCharge(ctx context.Context, charge domain.AutopayCharge, schedule *domain.Schedule) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if charge.Status == domain.AutopayScheduled {
+		// Locking the rule serializes the charges of the user, so that
+		// concurrent fines cannot both fit into what is left of the limit.
+		var limit int64
+		err := tx.GetContext(ctx, &limit,
+			`SELECT monthly_limit FROM autopay_rules WHERE user_id = $1 FOR UPDATE`, charge.UserID)
+		if err != nil {
+			return wrapNotFound(err)
+		}
+
+		var used int64
+		err = tx.GetContext(ctx, &used,
+			`SELECT COALESCE(SUM(amount), 0) FROM autopay_charges
+			WHERE user_id = $1 AND month = $2 AND status = $3`,
+			charge.UserID, charge.Month, domain.AutopayScheduled)
+		if err != nil {
+			return err
+		}
+
+		if used+charge.Amount > limit {
+			return domain.ErrAutopayLimit
+		}
+	}
+
+	if schedule != nil {
+		if _, err := tx.NamedExecContext(ctx, insertSchedule, schedule); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.NamedExecContext(ctx,
+		`INSERT INTO autopay_charges (`+autopayChargeColumns+`)
+		VALUES (:id, :user_id, :fine_id, :schedule_id, :amount, :charge_at, :month, :status, :reason,
+			:created_at, :updated_at)`, charge)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *AutopayRepo) GetCharge(ctx context.Context, id uuid.UUID) (domain.AutopayCharge, error) {
+	var charge domain.AutopayCharge
+
+	err := r.db.GetContext(ctx, &charge, `SELECT `+autopayChargeColumns+` FROM autopay_charges WHERE id = $1`, id)
+	if err != nil {
+		return domain.AutopayCharge{}, wrapNotFound(err)
+	}
+
+	return charge, nil
+}
+
+func (r *AutopayRepo) GetCharges(ctx context.Context, userID uuid.UUID) ([]domain.AutopayCharge, error) {
+	charges := make([]domain.AutopayCharge, 0)
+
+	err := r.db.SelectContext(ctx, &charges,
+		`SELECT `+autopayChargeColumns+` FROM autopay_charges WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return charges, nil
+}
+
+func (r *AutopayRepo) UpdateChargeStatus(ctx context.Context, id uuid.UUID, from, to domain.AutopayChargeStatus,
+	updatedAt time.Time,
+) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE autopay_charges SET status = $3, updated_at = $4 WHERE id = $1 AND status = $2`,
+		id, from, to, updatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrAutopayState
+		}
+
+		return err
+	}
+
+	return nil
+}
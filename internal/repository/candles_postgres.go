@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const candleColumns = `symbol, period, open_time, currency, open, high, low, close, volume, ticks, first_at, last_at`
+
+type CandlesRepo struct {
+	db *sqlx.DB
+}
+
+func NewCandlesRepo(db *sqlx.DB) *CandlesRepo {
+	return &CandlesRepo{db: db}
+}
+
+func (r *CandlesRepo) Merge(ctx context.Context, candles []domain.Candle) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The open comes from the earliest tick and the close from the latest,
+	// whichever of the stored and the new candle holds it.
+	for _, candle := range candles {
+		_, err := tx.NamedExecContext(ctx,
+			`INSERT INTO candles (`+candleColumns+`)
+			VALUES (:symbol, :period, :open_time, :currency, :open, :high, :low, :close, :volume, :ticks,
+				:first_at, :last_at)
+			ON CONFLICT (symbol, period, open_time) DO UPDATE SET
+				open = CASE WHEN EXCLUDED.first_at < candles.first_at THEN EXCLUDED.open ELSE candles.open END,
+				close = CASE WHEN EXCLUDED.last_at >= candles.last_at THEN EXCLUDED.close ELSE candles.close END,
+				high = GREATEST(candles.high, EXCLUDED.high),
+				low = LEAST(candles.low, EXCLUDED.low),
+				volume = candles.volume + EXCLUDED.volume,
+				ticks = candles.ticks + EXCLUDED.ticks,
+				first_at = LEAST(candles.first_at, EXCLUDED.first_at),
+				last_at = GREATEST(candles.last_at, EXCLUDED.last_at)`, candle)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *CandlesRepo) GetRange(ctx context.Context, symbol string, interval domain.CandleInterval,
+	from, to time.Time) ([]domain.Candle, error) {
+	candles := make([]domain.Candle, 0)
+
+	err := r.db.SelectContext(ctx, &candles,
+		`SELECT `+candleColumns+` FROM candles
+		WHERE symbol = $1 AND period = $2 AND open_time >= $3 AND open_time < $4
+		ORDER BY open_time`, symbol, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return candles, nil
+}
+
+func (r *CandlesRepo) GetLastBefore(ctx context.Context, symbol string, interval domain.CandleInterval,
+	before time.Time) (domain.Candle, error) {
+	var candle domain.Candle
+
+	err := r.db.GetContext(ctx, &candle,
+		`SELECT `+candleColumns+` FROM candles
+		WHERE symbol = $1 AND period = $2 AND open_time < $3
+		ORDER BY open_time DESC
+		LIMIT 1`, symbol, interval, before)
+	if err != nil {
+		return domain.Candle{}, wrapNotFound(err)
+	}
+
+	return candle, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const cryptoTransactionColumns = `id, user_id, asset, kind, quantity, amount, fee, currency, executed_at, created_at`
+
+// cryptoTransactionOrder is the execution order of transactions.
+const cryptoTransactionOrder = `ORDER BY executed_at, created_at, id`
+
+type CryptoTransactionsRepo struct {
+	db *sqlx.DB
+}
+
+func NewCryptoTransactionsRepo(db *sqlx.DB) *CryptoTransactionsRepo {
+	return &CryptoTransactionsRepo{db: db}
+}
+
+func (r *CryptoTransactionsRepo) Create(ctx context.Context, transaction domain.CryptoTransaction,
+	check func([]domain.CryptoTransaction) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(ctx, tx, transaction.UserID); err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExecContext(ctx,
+		`INSERT INTO crypto_transactions (`+cryptoTransactionColumns+`)
+		VALUES (:id, :user_id, :asset, :kind, :quantity, :amount, :fee, :currency, :executed_at, :created_at)`,
+		transaction)
+	if err != nil {
+		return err
+	}
+
+	if err := checkCryptoAsset(ctx, tx, transaction, check); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *CryptoTransactionsRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.CryptoTransaction, error) {
+	var transaction domain.CryptoTransaction
+
+	err := r.db.GetContext(ctx, &transaction,
+		`SELECT `+cryptoTransactionColumns+` FROM crypto_transactions WHERE id = $1`, id)
+	if err != nil {
+		return domain.CryptoTransaction{}, wrapNotFound(err)
+	}
+
+	return transaction, nil
+}
+
+func (r *CryptoTransactionsRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.CryptoTransaction, error) {
+	transactions := make([]domain.CryptoTransaction, 0)
+
+	err := r.db.SelectContext(ctx, &transactions,
+		`SELECT `+cryptoTransactionColumns+` FROM crypto_transactions WHERE user_id = $1 `+cryptoTransactionOrder, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+func (r *CryptoTransactionsRepo) Delete(ctx context.Context, transaction domain.CryptoTransaction,
+	check func([]domain.CryptoTransaction) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(ctx, tx, transaction.UserID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM crypto_transactions WHERE id = $1`, transaction.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+
+	if err := checkCryptoAsset(ctx, tx, transaction, check); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockUser serializes changes to rows of the user that are checked together,
+// such as its crypto transactions. The lock does not conflict with the key share locks of foreign keys, so
+// other rows referencing the user can still be written meanwhile.
+func lockUser(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) error {
+	var id uuid.UUID
+
+	err := tx.GetContext(ctx, &id, `SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE`, userID)
+
+	return wrapNotFound(err)
+}
+
+// checkCryptoAsset runs check on the transactions of the user in the asset
+// of transaction, as changed by the current transaction.
+func checkCryptoAsset(ctx context.Context, tx *sqlx.Tx, transaction domain.CryptoTransaction,
+	check func([]domain.CryptoTransaction) error) error {
+	transactions := make([]domain.CryptoTransaction, 0)
+
+	err := tx.SelectContext(ctx, &transactions,
+		`SELECT `+cryptoTransactionColumns+` FROM crypto_transactions WHERE user_id = $1 AND asset = $2 `+
+			cryptoTransactionOrder, transaction.UserID, transaction.Asset)
+	if err != nil {
+		return err
+	}
+
+	return check(transactions)
+}
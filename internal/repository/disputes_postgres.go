@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const disputeColumns = `id, fine_id, user_id, reason, comment, status, reviewer_id, decision, created_at, resolved_at`
+
+type DisputesRepo struct {
+	db *sqlx.DB
+}
+
+func NewDisputesRepo(db *sqlx.DB) *DisputesRepo {
+	return &DisputesRepo{db: db}
+}
+
+func (r *DisputesRepo) Open(ctx context.Context, dispute domain.Dispute, event domain.FineEvent) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE fines SET status = $2, updated_at = $3 WHERE id = $1 AND status = $4`,
+		dispute.FineID, domain.FineDisputed, dispute.CreatedAt, domain.FineIssued)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		return domain.ErrFineTransition
+	}
+
+	_, err = tx.NamedExecContext(ctx,
+		`INSERT INTO disputes (`+disputeColumns+`)
+		VALUES (:id, :fine_id, :user_id, :reason, :comment, :status, :reviewer_id, :decision, :created_at, :resolved_at)`,
+		dispute)
+	if isUniqueViolation(err) {
+		return domain.ErrDisputeExists
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := insertFineEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *DisputesRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.Dispute, error) {
+	var dispute domain.Dispute
+
+	err := r.db.GetContext(ctx, &dispute, `SELECT `+disputeColumns+` FROM disputes WHERE id = $1`, id)
+	if err != nil {
+		return domain.Dispute{}, wrapNotFound(err)
+	}
+
+	return dispute, nil
+}
+
+func (r *DisputesRepo) GetByFine(ctx context.Context, fineID uuid.UUID) ([]domain.Dispute, error) {
+	disputes := make([]domain.Dispute, 0)
+
+	err := r.db.SelectContext(ctx, &disputes,
+		`SELECT `+disputeColumns+` FROM disputes WHERE fine_id = $1 ORDER BY created_at`, fineID)
+	if err != nil {
+		return nil, err
+	}
+
+	return disputes, nil
+}
+
+func (r *DisputesRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Dispute, error) {
+	disputes := make([]domain.Dispute, 0)
+
+	err := r.db.SelectContext(ctx, &disputes,
+		`SELECT `+disputeColumns+` FROM disputes WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return disputes, nil
+}
+
+func (r *DisputesRepo) Resolve(ctx context.Context, dispute domain.Dispute, fineStatus domain.FineStatus, event domain.FineEvent) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE disputes SET status = $2, reviewer_id = $3, decision = $4, resolved_at = $5
+		WHERE id = $1 AND status = $6`,
+		dispute.ID, dispute.Status, dispute.ReviewerID, dispute.Decision, dispute.ResolvedAt, domain.DisputeOpen)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		return domain.ErrDisputeClosed
+	}
+
+	res, err = tx.ExecContext(ctx,
+		`UPDATE fines SET status = $2, updated_at = $3 WHERE id = $1`, dispute.FineID, fineStatus, event.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+
+	if err := insertFineEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *DisputesRepo) AddAttachment(ctx context.Context, attachment domain.DisputeAttachment) error {
+	_, err := r.db.NamedExecContext(ctx,
+		`INSERT INTO dispute_attachments (id, dispute_id, file_name, content_type, size, storage_key, created_at)
+		VALUES (:id, :dispute_id, :file_name, :content_type, :size, :storage_key, :created_at)`, attachment)
+
+	return err
+}
+
+func (r *DisputesRepo) GetAttachment(ctx context.Context, id uuid.UUID) (domain.DisputeAttachment, error) {
+	var attachment domain.DisputeAttachment
+
+	err := r.db.GetContext(ctx, &attachment,
+		`SELECT id, dispute_id, file_name, content_type, size, storage_key, created_at
+		FROM dispute_attachments WHERE id = $1`, id)
+	if err != nil {
+		return domain.DisputeAttachment{}, wrapNotFound(err)
+	}
+
+	return attachment, nil
+}
+
+func (r *DisputesRepo) GetAttachments(ctx context.Context, disputeID uuid.UUID) ([]domain.DisputeAttachment, error) {
+	attachments := make([]domain.DisputeAttachment, 0)
+
+	err := r.db.SelectContext(ctx, &attachments,
+		`SELECT id, dispute_id, file_name, content_type, size, storage_key, created_at
+		FROM dispute_attachments WHERE dispute_id = $1 ORDER BY created_at`, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
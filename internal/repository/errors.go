@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the Postgres error code for a unique constraint violation.
+const uniqueViolation = "23505"
+
+// wrapNotFound translates sql.ErrNoRows into domain.ErrNotFound so that callers
+// never have to depend on database/sql to detect a missing row.
+func wrapNotFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrNotFound
+	}
+
+	return err
+}
+
+// isUniqueViolation reports whether err was caused by a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+// isUniqueViolationOf reports whether err was caused by a violation of the
+// named unique constraint or index.
+func isUniqueViolationOf(err error, constraint string) bool {
+	var pqErr *pq.Error
+
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == constraint
+}
+
+// checkAffected returns domain.ErrNotFound if the statement did not touch any row.
+func checkAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
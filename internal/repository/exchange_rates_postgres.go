@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ExchangeRatesRepo struct {
+	db *sqlx.DB
+}
+
+func NewExchangeRatesRepo(db *sqlx.DB) *ExchangeRatesRepo {
+	return &ExchangeRatesRepo{db: db}
+}
+
+func (r *ExchangeRatesRepo) Save(ctx context.Context, rates ...domain.ExchangeRate) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Days are passed as text, so that the time zone of the session cannot
+	// move them to a neighbouring day.
+	for _, rate := range rates {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO exchange_rates (currency, date, rate) VALUES ($1, $2, $3)
+			ON CONFLICT (currency, date) DO UPDATE SET rate = EXCLUDED.rate`,
+			rate.Currency, rate.Date.Format(time.DateOnly), rate.Rate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ExchangeRatesRepo) GetAsOf(ctx context.Context, currency string, date time.Time) (domain.ExchangeRate, error) {
+	var rate domain.ExchangeRate
+
+	err := r.db.GetContext(ctx, &rate,
+		`SELECT currency, date, rate FROM exchange_rates
+		WHERE currency = $1 AND date <= $2
+		ORDER BY date DESC
+		LIMIT 1`, currency, date.Format(time.DateOnly))
+	if err != nil {
+		return domain.ExchangeRate{}, wrapNotFound(err)
+	}
+
+	return rate, nil
+}
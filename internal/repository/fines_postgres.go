@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const fineColumns = `id, user_id, uin, issuer, article, amount, issued_at, due_at, discount_until, status,
+	created_at, updated_at`
+
+type FinesRepo struct {
+	db *sqlx.DB
+}
+
+func NewFinesRepo(db *sqlx.DB) *FinesRepo {
+	return &FinesRepo{db: db}
+}
+
+func (r *FinesRepo) Create(ctx context.Context, fine domain.Fine, event domain.FineEvent) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NamedExecContext(ctx,
+		`INSERT INTO fines (`+fineColumns+`)
+		VALUES (:id, :user_id, :uin, :issuer, :article, :amount, :issued_at, :due_at, :discount_until, :status,
+			:created_at, :updated_at)`, fine)
+	if isUniqueViolation(err) {
+		return domain.ErrFineAlreadyExists
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := insertFineEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *FinesRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.Fine, error) {
+	var fine domain.Fine
+
+	err := r.db.GetContext(ctx, &fine, `SELECT `+fineColumns+` FROM fines WHERE id = $1`, id)
+	if err != nil {
+		return domain.Fine{}, wrapNotFound(err)
+	}
+
+	return fine, nil
+}
+
+func (r *FinesRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Fine, error) {
+	fines := make([]domain.Fine, 0)
+
+	err := r.db.SelectContext(ctx, &fines,
+		`SELECT `+fineColumns+` FROM fines WHERE user_id = $1 ORDER BY issued_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return fines, nil
+}
+
+func (r *FinesRepo) GetByUIN(ctx context.Context, userID uuid.UUID, uin string) (domain.Fine, error) {
+	var fine domain.Fine
+
+	err := r.db.GetContext(ctx, &fine,
+		`SELECT `+fineColumns+` FROM fines WHERE user_id = $1 AND uin = $2`, userID, uin)
+	if err != nil {
+		return domain.Fine{}, wrapNotFound(err)
+	}
+
+	return fine, nil
+}
+
+func (r *FinesRepo) Update(ctx context.Context, fine domain.Fine, event domain.FineEvent) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.NamedExecContext(ctx,
+		`UPDATE fines SET issuer = :issuer, article = :article, amount = :amount, due_at = :due_at,
+			discount_until = :discount_until, status = :status, updated_at = :updated_at
+		WHERE id = :id`, fine)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+
+	if err := insertFineEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *FinesRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM fines WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *FinesRepo) GetHistory(ctx context.Context, fineID uuid.UUID) ([]domain.FineEvent, error) {
+	events := make([]domain.FineEvent, 0)
+
+	err := r.db.SelectContext(ctx, &events,
+		`SELECT id, fine_id, type, status, dispute_id, actor_id, comment, created_at
+		FROM fine_events WHERE fine_id = $1 ORDER BY created_at, id`, fineID)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func insertFineEvent(ctx context.Context, tx *sqlx.Tx, event domain.FineEvent) error {
+	_, err := tx.NamedExecContext(ctx,
+		`INSERT INTO fine_events (id, fine_id, type, status, dispute_id, actor_id, comment, created_at)
+		VALUES (:id, :fine_id, :type, :status, :dispute_id, :actor_id, :comment, :created_at)`, event)
+
+	return err
+}
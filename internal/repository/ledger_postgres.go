@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type LedgerRepo struct {
+	db *sqlx.DB
+}
+
+func NewLedgerRepo(db *sqlx.DB) *LedgerRepo {
+	return &LedgerRepo{db: db}
+}
+
+func (r *LedgerRepo) Post(ctx context.Context, entry domain.JournalEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertJournalEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *LedgerRepo) GetBalances(ctx context.Context, userID uuid.UUID, asOf time.Time) ([]domain.AccountBalance, error) {
+	balances := make([]domain.AccountBalance, 0)
+
+	// Liabilities are credited when they grow, so their balance is negated.
+	err := r.db.SelectContext(ctx, &balances,
+		`SELECT a.code, a.currency, a.type,
+			COALESCE(sum(p.amount), 0) * CASE WHEN a.type = 'liability' THEN -1 ELSE 1 END AS balance
+		FROM ledger_accounts a
+		LEFT JOIN (postings p JOIN journal_entries e ON e.id = p.entry_id AND e.created_at <= $2)
+			ON p.account_code = a.code AND p.currency = a.currency
+		WHERE a.user_id = $1
+		GROUP BY a.code, a.currency, a.type
+		ORDER BY a.currency`, userID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+func (r *LedgerRepo) GetEntries(ctx context.Context, accountCode string) ([]domain.JournalEntry, error) {
+	entries := make([]domain.JournalEntry, 0)
+
+	err := r.db.SelectContext(ctx, &entries,
+		`SELECT id, kind, payment_id, description, created_at FROM journal_entries
+		WHERE id IN (SELECT entry_id FROM postings WHERE account_code = $1)
+		ORDER BY created_at DESC, id`, accountCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	ids := make([]uuid.UUID, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+
+	query, args, err := sqlx.In(
+		`SELECT id, entry_id, account_code, currency, amount FROM postings WHERE entry_id IN (?) ORDER BY amount DESC`, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var postings []domain.Posting
+	if err := r.db.SelectContext(ctx, &postings, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+
+	byEntry := make(map[uuid.UUID][]domain.Posting, len(entries))
+	for _, posting := range postings {
+		byEntry[posting.EntryID] = append(byEntry[posting.EntryID], posting)
+	}
+
+	for i := range entries {
+		entries[i].Postings = byEntry[entries[i].ID]
+	}
+
+	return entries, nil
+}
+
+func (r *LedgerRepo) Check(ctx context.Context) (domain.LedgerReport, error) {
+	report := domain.LedgerReport{
+		Unbalanced: make([]domain.UnbalancedEntry, 0),
+		Empty:      make([]uuid.UUID, 0),
+		Totals:     make(map[string]int64),
+	}
+
+	// Read everything from one snapshot so that entries committed meanwhile
+	// do not show up in some of the queries only.
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return domain.LedgerReport{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY`); err != nil {
+		return domain.LedgerReport{}, err
+	}
+
+	err = tx.QueryRowxContext(ctx,
+		`SELECT (SELECT count(*) FROM journal_entries), (SELECT count(*) FROM postings)`).
+		Scan(&report.Entries, &report.Postings)
+	if err != nil {
+		return domain.LedgerReport{}, err
+	}
+
+	err = tx.SelectContext(ctx, &report.Unbalanced,
+		`SELECT entry_id, currency, sum(amount) AS sum FROM postings
+		GROUP BY entry_id, currency HAVING sum(amount) <> 0
+		ORDER BY entry_id`)
+	if err != nil {
+		return domain.LedgerReport{}, err
+	}
+
+	err = tx.SelectContext(ctx, &report.Empty,
+		`SELECT e.id FROM journal_entries e
+		WHERE NOT EXISTS (SELECT 1 FROM postings p WHERE p.entry_id = e.id)
+		ORDER BY e.id`)
+	if err != nil {
+		return domain.LedgerReport{}, err
+	}
+
+	rows, err := tx.QueryxContext(ctx, `SELECT currency, sum(amount) FROM postings GROUP BY currency`)
+	if err != nil {
+		return domain.LedgerReport{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			currency string
+			total    int64
+		)
+
+		if err := rows.Scan(&currency, &total); err != nil {
+			return domain.LedgerReport{}, err
+		}
+
+		report.Totals[currency] = total
+	}
+
+	if err := rows.Err(); err != nil {
+		return domain.LedgerReport{}, err
+	}
+
+	return report, nil
+}
+
+// insertJournalEntry validates the entry and stores it with its postings,
+// creating the accounts it touches on first use.
+func insertJournalEntry(ctx context.Context, tx *sqlx.Tx, entry domain.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	for _, posting := range entry.Postings {
+		_, err := tx.NamedExecContext(ctx,
+			`INSERT INTO ledger_accounts (code, currency, type, user_id)
+			VALUES (:code, :currency, :type, :user_id)
+			ON CONFLICT DO NOTHING`, domain.NewAccount(posting.AccountCode, posting.Currency))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.NamedExecContext(ctx,
+		`INSERT INTO journal_entries (id, kind, payment_id, description, created_at)
+		VALUES (:id, :kind, :payment_id, :description, :created_at)`, entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExecContext(ctx,
+		`INSERT INTO postings (id, entry_id, account_code, currency, amount)
+		VALUES (:id, :entry_id, :account_code, :currency, :amount)`, entry.Postings)
+
+	return err
+}
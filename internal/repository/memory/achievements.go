@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Achievements = (*AchievementsRepo)(nil)
+
+type AchievementsRepo struct {
+	mu           sync.RWMutex
+	achievements []domain.Achievement
+}
+
+// NewAchievementsRepo creates an AchievementsRepo pre-populated with the given achievements.
+func NewAchievementsRepo(achievements ...domain.Achievement) *AchievementsRepo {
+	return &AchievementsRepo{achievements: append([]domain.Achievement(nil), achievements...)}
+}
+
+func (r *AchievementsRepo) GetByUser(_ context.Context, userID uuid.UUID) ([]domain.Achievement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	achievements := make([]domain.Achievement, 0)
+	for _, achievement := range r.achievements {
+		if achievement.UserID == userID {
+			achievements = append(achievements, achievement)
+		}
+	}
+
+	sort.Slice(achievements, func(i, j int) bool {
+		return achievements[i].AchievedAt.Before(achievements[j].AchievedAt)
+	})
+
+	return achievements, nil
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Alerts = (*AlertsRepo)(nil)
+
+type AlertsRepo struct {
+	mu    sync.RWMutex
+	rules map[uuid.UUID]domain.AlertRule
+}
+
+// NewAlertsRepo creates an AlertsRepo pre-populated with the given rules.
+func NewAlertsRepo(rules ...domain.AlertRule) *AlertsRepo {
+	r := &AlertsRepo{rules: make(map[uuid.UUID]domain.AlertRule, len(rules))}
+	for _, rule := range rules {
+		r.rules[rule.ID] = rule
+	}
+
+	return r
+}
+
+func (r *AlertsRepo) Create(_ context.Context, rule domain.AlertRule, limit int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int
+	for _, stored := range r.rules {
+		if stored.UserID == rule.UserID {
+			count++
+		}
+	}
+
+	if count >= limit {
+		return domain.ErrAlertLimit
+	}
+
+	r.rules[rule.ID] = rule
+
+	return nil
+}
+
+func (r *AlertsRepo) GetByID(_ context.Context, id uuid.UUID) (domain.AlertRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rule, ok := r.rules[id]
+	if !ok {
+		return domain.AlertRule{}, domain.ErrNotFound
+	}
+
+	return rule, nil
+}
+
+func (r *AlertsRepo) GetByUser(_ context.Context, userID uuid.UUID) ([]domain.AlertRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := make([]domain.AlertRule, 0)
+	for _, rule := range r.rules {
+		if rule.UserID == userID {
+			rules = append(rules, rule)
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].CreatedAt.After(rules[j].CreatedAt)
+	})
+
+	return rules, nil
+}
+
+func (r *AlertsRepo) GetAll(_ context.Context) ([]domain.AlertRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := make([]domain.AlertRule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		rules = append(rules, rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].UserID != rules[j].UserID {
+			return rules[i].UserID.String() < rules[j].UserID.String()
+		}
+
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+
+	return rules, nil
+}
+
+func (r *AlertsRepo) Update(_ context.Context, rule domain.AlertRule, loadedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.rules[rule.ID]
+	if !ok || !stored.UpdatedAt.Equal(loadedAt) {
+		return domain.ErrAlertChanged
+	}
+
+	stored.Triggered = rule.Triggered
+	stored.FiredAt = rule.FiredAt
+	stored.UpdatedAt = rule.UpdatedAt
+	r.rules[rule.ID] = stored
+
+	return nil
+}
+
+func (r *AlertsRepo) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rules[id]; !ok {
+		return domain.ErrNotFound
+	}
+
+	delete(r.rules, id)
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Autopay = (*AutopayRepo)(nil)
+
+type AutopayRepo struct {
+	mu        sync.RWMutex
+	rules     map[uuid.UUID]domain.AutopayRule
+	charges   map[uuid.UUID]domain.AutopayCharge
+	schedules *SchedulesRepo
+}
+
+// NewAutopayRepo creates an empty AutopayRepo storing the schedules of
+// charges in schedules.
+func NewAutopayRepo(schedules *SchedulesRepo) *AutopayRepo {
+	return &AutopayRepo{
+		rules:     make(map[uuid.UUID]domain.AutopayRule),
+		charges:   make(map[uuid.UUID]domain.AutopayCharge),
+		schedules: schedules,
+	}
+}
+
+func (r *AutopayRepo) GetRule(_ context.Context, userID uuid.UUID) (domain.AutopayRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rule, ok := r.rules[userID]
+	if !ok {
+		return domain.AutopayRule{}, domain.ErrNotFound
+	}
+
+	return rule, nil
+}
+
+func (r *AutopayRepo) SaveRule(_ context.Context, rule domain.AutopayRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.rules[rule.UserID]; ok {
+		rule.CreatedAt = stored.CreatedAt
+	}
+
+	r.rules[rule.UserID] = rule
+
+	return nil
+}
+
+func (r *AutopayRepo) CreateCharge(_ context.Context, charge domain.AutopayCharge, schedule *domain.Schedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if charge.Status == domain.AutopayScheduled {
+		rule, ok := r.rules[charge.UserID]
+		if !ok {
+			return domain.ErrNotFound
+		}
+
+		var used int64
+		for _, existing := range r.charges {
+			if existing.UserID == charge.UserID && existing.Status == domain.AutopayScheduled &&
+				existing.Month.Equal(charge.Month) {
+				used += existing.Amount
+			}
+		}
+
+		if used+charge.Amount > rule.MonthlyLimit {
+			return domain.ErrAutopayLimit
+		}
+	}
+
+	if schedule != nil {
+		r.schedules.mu.Lock()
+		r.schedules.schedules[schedule.ID] = *schedule
+		r.schedules.mu.Unlock()
+	}
+
+	r.charges[charge.ID] = charge
+
+	return nil
+}
+
+func (r *AutopayRepo) GetCharge(_ context.Context, id uuid.UUID) (domain.AutopayCharge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	charge, ok := r.charges[id]
+	if !ok {
+		return domain.AutopayCharge{}, domain.ErrNotFound
+	}
+
+	return charge, nil
+}
+
+func (r *AutopayRepo) GetCharges(_ context.Context, userID uuid.UUID) ([]domain.AutopayCharge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	charges := make([]domain.AutopayCharge, 0)
+	for _, charge := range r.charges {
+		if charge.UserID == userID {
+			charges = append(charges, charge)
+		}
+	}
+
+	sort.Slice(charges, func(i, j int) bool {
+		return charges[i].CreatedAt.After(charges[j].CreatedAt)
+	})
+
+	return charges, nil
+}
+
+func (r *AutopayRepo) UpdateChargeStatus(_ context.Context, id uuid.UUID, from, to domain.AutopayChargeStatus,
+	updatedAt time.Time,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	charge, ok := r.charges[id]
+	if !ok || charge.Status != from {
+		return domain.ErrAutopayState
+	}
+
+	charge.Status = to
+	charge.UpdatedAt = updatedAt
+	r.charges[id] = charge
+
+	return nil
+}
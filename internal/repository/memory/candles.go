@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/ohlc"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type candleKey struct {
+	symbol   string
+	interval domain.CandleInterval
+	openTime int64
+}
+
+var _ repository.Candles = (*CandlesRepo)(nil)
+
+type CandlesRepo struct {
+	mu      sync.RWMutex
+	candles map[candleKey]domain.Candle
+}
+
+// NewCandlesRepo creates a CandlesRepo pre-populated with the given candles.
+func NewCandlesRepo(candles ...domain.Candle) *CandlesRepo {
+	r := &CandlesRepo{candles: make(map[candleKey]domain.Candle)}
+	_ = r.Merge(context.Background(), candles)
+
+	return r
+}
+
+func (r *CandlesRepo) Merge(_ context.Context, candles []domain.Candle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, candle := range candles {
+		key := candleKey{symbol: candle.Symbol, interval: candle.Interval, openTime: candle.OpenTime.Unix()}
+
+		stored, ok := r.candles[key]
+		if !ok {
+			r.candles[key] = candle
+			continue
+		}
+
+		a, err := toOHLC(stored)
+		if err != nil {
+			return err
+		}
+
+		b, err := toOHLC(candle)
+		if err != nil {
+			return err
+		}
+
+		merged := ohlc.Merge(a, b)
+		stored.Open, stored.High, stored.Low = formatRat(merged.Open), formatRat(merged.High), formatRat(merged.Low)
+		stored.Close, stored.Volume, stored.Ticks = formatRat(merged.Close), formatRat(merged.Volume), merged.Ticks
+		stored.FirstAt, stored.LastAt = merged.FirstAt, merged.LastAt
+		r.candles[key] = stored
+	}
+
+	return nil
+}
+
+func (r *CandlesRepo) GetRange(_ context.Context, symbol string, interval domain.CandleInterval,
+	from, to time.Time) ([]domain.Candle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	candles := make([]domain.Candle, 0)
+	for key, candle := range r.candles {
+		if key.symbol == symbol && key.interval == interval &&
+			!candle.OpenTime.Before(from) && candle.OpenTime.Before(to) {
+			candles = append(candles, candle)
+		}
+	}
+
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].OpenTime.Before(candles[j].OpenTime)
+	})
+
+	return candles, nil
+}
+
+func (r *CandlesRepo) GetLastBefore(_ context.Context, symbol string, interval domain.CandleInterval,
+	before time.Time) (domain.Candle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var (
+		last  domain.Candle
+		found bool
+	)
+	for key, candle := range r.candles {
+		if key.symbol == symbol && key.interval == interval && candle.OpenTime.Before(before) &&
+			(!found || candle.OpenTime.After(last.OpenTime)) {
+			last, found = candle, true
+		}
+	}
+
+	if !found {
+		return domain.Candle{}, domain.ErrNotFound
+	}
+
+	return last, nil
+}
+
+func toOHLC(candle domain.Candle) (ohlc.Candle, error) {
+	values := make([]*big.Rat, 0, 5)
+	for _, value := range []string{candle.Open, candle.High, candle.Low, candle.Close, candle.Volume} {
+		r, ok := new(big.Rat).SetString(value)
+		if !ok {
+			return ohlc.Candle{}, fmt.Errorf("invalid candle value %q", value)
+		}
+
+		values = append(values, r)
+	}
+
+	return ohlc.Candle{
+		OpenTime: candle.OpenTime,
+		Open:     values[0],
+		High:     values[1],
+		Low:      values[2],
+		Close:    values[3],
+		Volume:   values[4],
+		Ticks:    candle.Ticks,
+		FirstAt:  candle.FirstAt,
+		LastAt:   candle.LastAt,
+	}, nil
+}
+
+func formatRat(r *big.Rat) string {
+	return strings.TrimRight(strings.TrimRight(r.FloatString(18), "0"), ".")
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.CryptoTransactions = (*CryptoTransactionsRepo)(nil)
+
+type CryptoTransactionsRepo struct {
+	mu           sync.RWMutex
+	transactions map[uuid.UUID]domain.CryptoTransaction
+}
+
+// NewCryptoTransactionsRepo creates a CryptoTransactionsRepo pre-populated with the given transactions.
+func NewCryptoTransactionsRepo(transactions ...domain.CryptoTransaction) *CryptoTransactionsRepo {
+	r := &CryptoTransactionsRepo{transactions: make(map[uuid.UUID]domain.CryptoTransaction, len(transactions))}
+	for _, transaction := range transactions {
+		r.transactions[transaction.ID] = transaction
+	}
+
+	return r
+}
+
+func (r *CryptoTransactionsRepo) Create(_ context.Context, transaction domain.CryptoTransaction,
+	check func([]domain.CryptoTransaction) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.transactions[transaction.ID] = transaction
+
+	if err := check(r.asset(transaction.UserID, transaction.Asset)); err != nil {
+		delete(r.transactions, transaction.ID)
+		return err
+	}
+
+	return nil
+}
+
+func (r *CryptoTransactionsRepo) GetByID(_ context.Context, id uuid.UUID) (domain.CryptoTransaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	transaction, ok := r.transactions[id]
+	if !ok {
+		return domain.CryptoTransaction{}, domain.ErrNotFound
+	}
+
+	return transaction, nil
+}
+
+func (r *CryptoTransactionsRepo) GetByUser(_ context.Context, userID uuid.UUID) ([]domain.CryptoTransaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	transactions := make([]domain.CryptoTransaction, 0)
+	for _, transaction := range r.transactions {
+		if transaction.UserID == userID {
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	sortCryptoTransactions(transactions)
+
+	return transactions, nil
+}
+
+func (r *CryptoTransactionsRepo) Delete(_ context.Context, transaction domain.CryptoTransaction,
+	check func([]domain.CryptoTransaction) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.transactions[transaction.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	delete(r.transactions, transaction.ID)
+
+	if err := check(r.asset(stored.UserID, stored.Asset)); err != nil {
+		r.transactions[stored.ID] = stored
+		return err
+	}
+
+	return nil
+}
+
+// asset returns the transactions of the user in the asset in execution order.
+// The caller must hold mu.
+func (r *CryptoTransactionsRepo) asset(userID uuid.UUID, asset string) []domain.CryptoTransaction {
+	transactions := make([]domain.CryptoTransaction, 0)
+	for _, transaction := range r.transactions {
+		if transaction.UserID == userID && transaction.Asset == asset {
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	sortCryptoTransactions(transactions)
+
+	return transactions
+}
+
+func sortCryptoTransactions(transactions []domain.CryptoTransaction) {
+	sort.Slice(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if !a.ExecutedAt.Equal(b.ExecutedAt) {
+			return a.ExecutedAt.Before(b.ExecutedAt)
+		}
+
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+
+		return a.ID.String() < b.ID.String()
+	})
+}
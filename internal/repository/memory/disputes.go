@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Disputes = (*DisputesRepo)(nil)
+
+// DisputesRepo keeps disputes next to the FinesRepo it changes, so that
+// opening and resolving a dispute updates the fine as in one transaction.
+type DisputesRepo struct {
+	mu          sync.RWMutex
+	fines       *FinesRepo
+	disputes    map[uuid.UUID]domain.Dispute
+	attachments map[uuid.UUID]domain.DisputeAttachment
+}
+
+func NewDisputesRepo(fines *FinesRepo) *DisputesRepo {
+	return &DisputesRepo{
+		fines:       fines,
+		disputes:    make(map[uuid.UUID]domain.Dispute),
+		attachments: make(map[uuid.UUID]domain.DisputeAttachment),
+	}
+}
+
+func (r *DisputesRepo) Open(_ context.Context, dispute domain.Dispute, event domain.FineEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fines.mu.Lock()
+	defer r.fines.mu.Unlock()
+
+	fine, ok := r.fines.fines[dispute.FineID]
+	if !ok || fine.Status != domain.FineIssued {
+		return domain.ErrFineTransition
+	}
+
+	for _, existing := range r.disputes {
+		if existing.FineID == dispute.FineID && existing.Status == domain.DisputeOpen {
+			return domain.ErrDisputeExists
+		}
+	}
+
+	r.disputes[dispute.ID] = dispute
+
+	return r.fines.setStatus(dispute.FineID, domain.FineDisputed, event)
+}
+
+func (r *DisputesRepo) GetByID(_ context.Context, id uuid.UUID) (domain.Dispute, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dispute, ok := r.disputes[id]
+	if !ok {
+		return domain.Dispute{}, domain.ErrNotFound
+	}
+
+	return dispute, nil
+}
+
+func (r *DisputesRepo) GetByFine(_ context.Context, fineID uuid.UUID) ([]domain.Dispute, error) {
+	return r.filter(func(d domain.Dispute) bool { return d.FineID == fineID }), nil
+}
+
+func (r *DisputesRepo) GetByUser(_ context.Context, userID uuid.UUID) ([]domain.Dispute, error) {
+	return r.filter(func(d domain.Dispute) bool { return d.UserID == userID }), nil
+}
+
+func (r *DisputesRepo) Resolve(_ context.Context, dispute domain.Dispute, fineStatus domain.FineStatus, event domain.FineEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fines.mu.Lock()
+	defer r.fines.mu.Unlock()
+
+	existing, ok := r.disputes[dispute.ID]
+	if !ok || existing.Status != domain.DisputeOpen {
+		return domain.ErrDisputeClosed
+	}
+
+	existing.Status = dispute.Status
+	existing.ReviewerID = dispute.ReviewerID
+	existing.Decision = dispute.Decision
+	existing.ResolvedAt = dispute.ResolvedAt
+	r.disputes[dispute.ID] = existing
+
+	return r.fines.setStatus(existing.FineID, fineStatus, event)
+}
+
+func (r *DisputesRepo) AddAttachment(_ context.Context, attachment domain.DisputeAttachment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.disputes[attachment.DisputeID]; !ok {
+		return domain.ErrNotFound
+	}
+
+	r.attachments[attachment.ID] = attachment
+
+	return nil
+}
+
+func (r *DisputesRepo) GetAttachment(_ context.Context, id uuid.UUID) (domain.DisputeAttachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attachment, ok := r.attachments[id]
+	if !ok {
+		return domain.DisputeAttachment{}, domain.ErrNotFound
+	}
+
+	return attachment, nil
+}
+
+func (r *DisputesRepo) GetAttachments(_ context.Context, disputeID uuid.UUID) ([]domain.DisputeAttachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attachments := make([]domain.DisputeAttachment, 0)
+	for _, attachment := range r.attachments {
+		if attachment.DisputeID == disputeID {
+			attachments = append(attachments, attachment)
+		}
+	}
+
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].CreatedAt.Before(attachments[j].CreatedAt)
+	})
+
+	return attachments, nil
+}
+
+func (r *DisputesRepo) filter(keep func(domain.Dispute) bool) []domain.Dispute {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	disputes := make([]domain.Dispute, 0)
+	for _, dispute := range r.disputes {
+		if keep(dispute) {
+			disputes = append(disputes, dispute)
+		}
+	}
+
+	sort.Slice(disputes, func(i, j int) bool {
+		return disputes[i].CreatedAt.Before(disputes[j].CreatedAt)
+	})
+
+	return disputes
+}
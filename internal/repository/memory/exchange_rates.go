@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sync"
+	"time"
+)
+
+var _ repository.ExchangeRates = (*ExchangeRatesRepo)(nil)
+
+type ExchangeRatesRepo struct {
+	mu    sync.RWMutex
+	rates map[string]map[string]domain.ExchangeRate
+}
+
+// NewExchangeRatesRepo creates an ExchangeRatesRepo pre-populated with the given rates.
+func NewExchangeRatesRepo(rates ...domain.ExchangeRate) *ExchangeRatesRepo {
+	r := &ExchangeRatesRepo{rates: make(map[string]map[string]domain.ExchangeRate)}
+	_ = r.Save(context.Background(), rates...)
+
+	return r
+}
+
+func (r *ExchangeRatesRepo) Save(_ context.Context, rates ...domain.ExchangeRate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rate := range rates {
+		if r.rates[rate.Currency] == nil {
+			r.rates[rate.Currency] = make(map[string]domain.ExchangeRate)
+		}
+
+		r.rates[rate.Currency][rate.Date.Format(time.DateOnly)] = rate
+	}
+
+	return nil
+}
+
+func (r *ExchangeRatesRepo) GetAsOf(_ context.Context, currency string, date time.Time) (domain.ExchangeRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Dates formatted as YYYY-MM-DD order the same way as the days.
+	day := date.Format(time.DateOnly)
+
+	var (
+		latest domain.ExchangeRate
+		found  string
+	)
+	for key, rate := range r.rates[currency] {
+		if key <= day && key > found {
+			latest, found = rate, key
+		}
+	}
+
+	if found == "" {
+		return domain.ExchangeRate{}, domain.ErrNotFound
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Fines = (*FinesRepo)(nil)
+
+type FinesRepo struct {
+	mu      sync.RWMutex
+	fines   map[uuid.UUID]domain.Fine
+	history map[uuid.UUID][]domain.FineEvent
+}
+
+// NewFinesRepo creates a FinesRepo pre-populated with the given fines.
+func NewFinesRepo(fines ...domain.Fine) *FinesRepo {
+	r := &FinesRepo{
+		fines:   make(map[uuid.UUID]domain.Fine, len(fines)),
+		history: make(map[uuid.UUID][]domain.FineEvent),
+	}
+	for _, fine := range fines {
+		r.fines[fine.ID] = fine
+	}
+
+	return r
+}
+
+func (r *FinesRepo) Create(_ context.Context, fine domain.Fine, event domain.FineEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.fines {
+		if fine.UIN != "" && existing.UserID == fine.UserID && existing.UIN == fine.UIN {
+			return domain.ErrFineAlreadyExists
+		}
+	}
+
+	r.fines[fine.ID] = fine
+	r.history[fine.ID] = append(r.history[fine.ID], event)
+
+	return nil
+}
+
+func (r *FinesRepo) GetByID(_ context.Context, id uuid.UUID) (domain.Fine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fine, ok := r.fines[id]
+	if !ok {
+		return domain.Fine{}, domain.ErrNotFound
+	}
+
+	return fine, nil
+}
+
+func (r *FinesRepo) GetByUser(_ context.Context, userID uuid.UUID) ([]domain.Fine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fines := make([]domain.Fine, 0)
+	for _, fine := range r.fines {
+		if fine.UserID == userID {
+			fines = append(fines, fine)
+		}
+	}
+
+	sort.Slice(fines, func(i, j int) bool {
+		return fines[i].IssuedAt.After(fines[j].IssuedAt)
+	})
+
+	return fines, nil
+}
+
+func (r *FinesRepo) GetByUIN(_ context.Context, userID uuid.UUID, uin string) (domain.Fine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, fine := range r.fines {
+		if fine.UserID == userID && fine.UIN == uin {
+			return fine, nil
+		}
+	}
+
+	return domain.Fine{}, domain.ErrNotFound
+}
+
+func (r *FinesRepo) Update(_ context.Context, fine domain.Fine, event domain.FineEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.fines[fine.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	// Mirror the Postgres implementation, which never changes these columns.
+	fine.UserID = existing.UserID
+	fine.UIN = existing.UIN
+	fine.IssuedAt = existing.IssuedAt
+	fine.CreatedAt = existing.CreatedAt
+	r.fines[fine.ID] = fine
+	r.history[fine.ID] = append(r.history[fine.ID], event)
+
+	return nil
+}
+
+func (r *FinesRepo) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.fines[id]; !ok {
+		return domain.ErrNotFound
+	}
+
+	delete(r.fines, id)
+	delete(r.history, id)
+
+	return nil
+}
+
+func (r *FinesRepo) GetHistory(_ context.Context, fineID uuid.UUID) ([]domain.FineEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append(make([]domain.FineEvent, 0, len(r.history[fineID])), r.history[fineID]...), nil
+}
+
+// setStatus changes the fine status and records the event. The caller must
+// hold r.mu.
+func (r *FinesRepo) setStatus(id uuid.UUID, status domain.FineStatus, event domain.FineEvent) error {
+	fine, ok := r.fines[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	fine.Status = status
+	fine.UpdatedAt = event.CreatedAt
+	r.fines[id] = fine
+	r.history[id] = append(r.history[id], event)
+
+	return nil
+}
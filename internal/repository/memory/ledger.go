@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Ledger = (*LedgerRepo)(nil)
+
+type LedgerRepo struct {
+	mu       sync.RWMutex
+	accounts map[string]domain.Account
+	entries  []domain.JournalEntry
+}
+
+func NewLedgerRepo() *LedgerRepo {
+	return &LedgerRepo{accounts: make(map[string]domain.Account)}
+}
+
+func (r *LedgerRepo) Post(_ context.Context, entry domain.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.post(entry)
+
+	return nil
+}
+
+// post stores a validated entry. The caller must hold r.mu.
+func (r *LedgerRepo) post(entry domain.JournalEntry) {
+	for _, posting := range entry.Postings {
+		key := posting.AccountCode + "/" + posting.Currency
+		if _, ok := r.accounts[key]; !ok {
+			r.accounts[key] = domain.NewAccount(posting.AccountCode, posting.Currency)
+		}
+	}
+
+	entry.Postings = append([]domain.Posting(nil), entry.Postings...)
+	r.entries = append(r.entries, entry)
+}
+
+func (r *LedgerRepo) GetBalances(_ context.Context, userID uuid.UUID, asOf time.Time) ([]domain.AccountBalance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	balances := make([]domain.AccountBalance, 0)
+	for _, account := range r.accounts {
+		if account.UserID == nil || *account.UserID != userID {
+			continue
+		}
+
+		balance := domain.AccountBalance{Code: account.Code, Currency: account.Currency, Type: account.Type}
+		for _, entry := range r.entries {
+			if entry.CreatedAt.After(asOf) {
+				continue
+			}
+
+			for _, posting := range entry.Postings {
+				if posting.AccountCode == account.Code && posting.Currency == account.Currency {
+					balance.Balance += posting.Amount
+				}
+			}
+		}
+
+		// Liabilities are credited when they grow.
+		if account.Type == domain.AccountLiability {
+			balance.Balance = -balance.Balance
+		}
+
+		balances = append(balances, balance)
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Currency < balances[j].Currency
+	})
+
+	return balances, nil
+}
+
+func (r *LedgerRepo) GetEntries(_ context.Context, accountCode string) ([]domain.JournalEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]domain.JournalEntry, 0)
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		for _, posting := range r.entries[i].Postings {
+			if posting.AccountCode == accountCode {
+				entries = append(entries, r.entries[i])
+				break
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *LedgerRepo) Check(_ context.Context) (domain.LedgerReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	report := domain.LedgerReport{
+		Entries:    int64(len(r.entries)),
+		Unbalanced: make([]domain.UnbalancedEntry, 0),
+		Empty:      make([]uuid.UUID, 0),
+		Totals:     make(map[string]int64),
+	}
+
+	for _, entry := range r.entries {
+		if len(entry.Postings) == 0 {
+			report.Empty = append(report.Empty, entry.ID)
+			continue
+		}
+
+		sums := make(map[string]int64)
+		for _, posting := range entry.Postings {
+			sums[posting.Currency] += posting.Amount
+			report.Totals[posting.Currency] += posting.Amount
+			report.Postings++
+		}
+
+		for currency, sum := range sums {
+			if sum != 0 {
+				report.Unbalanced = append(report.Unbalanced, domain.UnbalancedEntry{EntryID: entry.ID, Currency: currency, Sum: sum})
+			}
+		}
+	}
+
+	return report, nil
+}
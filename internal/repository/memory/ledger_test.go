@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLedgerCheck(t *testing.T) {
+	r := NewLedgerRepo()
+
+	payment := domain.Payment{ID: uuid.New(), UserID: uuid.New(), Amount: 50000, Currency: "RUB", TargetType: domain.PaymentTargetFine}
+	for _, entry := range payment.CaptureEntries(time.Now()) {
+		if err := r.Post(context.Background(), entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := r.Check(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.OK() || report.Entries != 2 || report.Postings != 4 {
+		t.Fatalf("report of a consistent ledger = %+v, want OK with 2 entries of 4 postings", report)
+	}
+
+	// Entries that got past validation, as rows edited by hand would.
+	drifted := domain.JournalEntry{ID: uuid.New(), Postings: []domain.Posting{
+		{ID: uuid.New(), AccountCode: domain.AccountAcquirer, Currency: "RUB", Amount: 100},
+		{ID: uuid.New(), AccountCode: domain.AccountTreasury, Currency: "RUB", Amount: -90},
+	}}
+	empty := domain.JournalEntry{ID: uuid.New()}
+
+	r.mu.Lock()
+	r.post(drifted)
+	r.post(empty)
+	r.mu.Unlock()
+
+	report, err = r.Check(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.OK() {
+		t.Fatal("report of a drifted ledger is OK")
+	}
+
+	want := domain.UnbalancedEntry{EntryID: drifted.ID, Currency: "RUB", Sum: 10}
+	if len(report.Unbalanced) != 1 || report.Unbalanced[0] != want {
+		t.Errorf("unbalanced = %+v, want %+v", report.Unbalanced, want)
+	}
+
+	if len(report.Empty) != 1 || report.Empty[0] != empty.ID {
+		t.Errorf("empty = %v, want %v", report.Empty, empty.ID)
+	}
+
+	if report.Totals["RUB"] != 10 {
+		t.Errorf("RUB total %d, want 10", report.Totals["RUB"])
+	}
+}
@@ -0,0 +1,36 @@
+// Package memory provides in-memory implementations of the repository
+// interfaces. It is meant for tests and local experiments that should not
+// depend on a running Postgres instance.
+package memory
+
+import "backend-vtb/internal/repository"
+
+// NewRepository returns a repository.Repository backed by empty in-memory stores.
+func NewRepository() *repository.Repository {
+	fines := NewFinesRepo()
+	ledger := NewLedgerRepo()
+	payments := NewPaymentsRepo(fines, ledger)
+	refunds := NewRefundsRepo(payments)
+	schedules := NewSchedulesRepo()
+
+	return &repository.Repository{
+		Users:         NewUsersRepo(),
+		Sessions:      NewSessionsRepo(),
+		RecoveryCodes: NewRecoveryCodesRepo(),
+		Fines:         fines,
+		Disputes:      NewDisputesRepo(fines),
+		Payments:      payments,
+		Refunds:       refunds,
+		Ledger:        ledger,
+		Webhooks:      NewWebhooksRepo(),
+		Schedules:     schedules,
+		Autopay:       NewAutopayRepo(schedules),
+		Notifications: NewNotificationsRepo(),
+		ExchangeRates: NewExchangeRatesRepo(),
+		Crypto:        NewCryptoTransactionsRepo(),
+		Candles:       NewCandlesRepo(),
+		Alerts:        NewAlertsRepo(),
+		Achievements:  NewAchievementsRepo(),
+		Stats:         NewStatsRepo(fines, payments, refunds, ledger),
+	}
+}
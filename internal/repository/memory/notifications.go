@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Notifications = (*NotificationsRepo)(nil)
+
+type NotificationsRepo struct {
+	mu            sync.RWMutex
+	notifications []domain.Notification
+}
+
+// NewNotificationsRepo creates a NotificationsRepo pre-populated with the given notifications.
+func NewNotificationsRepo(notifications ...domain.Notification) *NotificationsRepo {
+	return &NotificationsRepo{notifications: append([]domain.Notification(nil), notifications...)}
+}
+
+func (r *NotificationsRepo) Create(_ context.Context, notification domain.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notifications = append(r.notifications, notification)
+
+	return nil
+}
+
+func (r *NotificationsRepo) GetByUser(_ context.Context, userID uuid.UUID) ([]domain.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notifications := make([]domain.Notification, 0)
+	for _, notification := range r.notifications {
+		if notification.UserID == userID {
+			notifications = append(notifications, notification)
+		}
+	}
+
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+
+	return notifications, nil
+}
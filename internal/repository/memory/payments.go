@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Payments = (*PaymentsRepo)(nil)
+
+// PaymentsRepo keeps payments next to the fines and the ledger their
+// transitions change, so that a transition is applied as in one transaction.
+type PaymentsRepo struct {
+	mu       sync.RWMutex
+	fines    *FinesRepo
+	ledger   *LedgerRepo
+	payments map[uuid.UUID]domain.Payment
+}
+
+// NewPaymentsRepo creates a PaymentsRepo pre-populated with the given payments.
+func NewPaymentsRepo(fines *FinesRepo, ledger *LedgerRepo, payments ...domain.Payment) *PaymentsRepo {
+	r := &PaymentsRepo{
+		fines:    fines,
+		ledger:   ledger,
+		payments: make(map[uuid.UUID]domain.Payment, len(payments)),
+	}
+	for _, payment := range payments {
+		r.payments[payment.ID] = payment
+	}
+
+	return r
+}
+
+func (r *PaymentsRepo) Create(_ context.Context, payment domain.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.payments {
+		if payment.IdempotencyKey != "" && existing.UserID == payment.UserID &&
+			existing.IdempotencyKey == payment.IdempotencyKey {
+			return domain.ErrPaymentAlreadyExists
+		}
+
+		if payment.FineID != nil && existing.FineID != nil && *existing.FineID == *payment.FineID &&
+			existing.Status.InProgress() && payment.Status.InProgress() {
+			return domain.ErrFinePaymentExists
+		}
+	}
+
+	r.payments[payment.ID] = payment
+
+	return nil
+}
+
+func (r *PaymentsRepo) GetByID(_ context.Context, id uuid.UUID) (domain.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payment, ok := r.payments[id]
+	if !ok {
+		return domain.Payment{}, domain.ErrNotFound
+	}
+
+	return payment, nil
+}
+
+func (r *PaymentsRepo) GetByUser(_ context.Context, userID uuid.UUID) ([]domain.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payments := make([]domain.Payment, 0)
+	for _, payment := range r.payments {
+		if payment.UserID == userID {
+			payments = append(payments, payment)
+		}
+	}
+
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].CreatedAt.After(payments[j].CreatedAt)
+	})
+
+	return payments, nil
+}
+
+func (r *PaymentsRepo) GetByIdempotencyKey(_ context.Context, userID uuid.UUID, key string) (domain.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, payment := range r.payments {
+		if payment.UserID == userID && payment.IdempotencyKey == key {
+			return payment, nil
+		}
+	}
+
+	return domain.Payment{}, domain.ErrNotFound
+}
+
+func (r *PaymentsRepo) Transition(_ context.Context, transition domain.PaymentTransition) error {
+	for _, entry := range transition.Entries {
+		if err := entry.Validate(); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fines.mu.Lock()
+	defer r.fines.mu.Unlock()
+	r.ledger.mu.Lock()
+	defer r.ledger.mu.Unlock()
+
+	payment, ok := r.payments[transition.PaymentID]
+	if !ok || payment.Status != transition.From {
+		return domain.ErrPaymentTransition
+	}
+
+	if event := transition.FineEvent; event != nil {
+		if fine, ok := r.fines.fines[event.FineID]; !ok || fine.Status != domain.FineIssued {
+			return domain.ErrFineNotPayable
+		}
+
+		if err := r.fines.setStatus(event.FineID, event.Status, *event); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range transition.Entries {
+		r.ledger.post(entry)
+	}
+
+	payment.Status = transition.To
+	payment.FailureReason = transition.FailureReason
+	payment.GatewayRef = transition.GatewayRef
+	payment.ChallengeURL = transition.ChallengeURL
+	payment.UpdatedAt = time.Now()
+	r.payments[payment.ID] = payment
+
+	return nil
+}
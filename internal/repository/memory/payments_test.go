@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPaymentsTransitionPaysIssuedFinesOnly(t *testing.T) {
+	for _, status := range []domain.FineStatus{domain.FinePaid, domain.FineCancelled, domain.FineDisputed} {
+		fine := domain.Fine{ID: uuid.New(), Status: status}
+		payment := domain.Payment{ID: uuid.New(), FineID: &fine.ID, Status: domain.PaymentAuthorized}
+
+		fines := NewFinesRepo(fine)
+		payments := NewPaymentsRepo(fines, NewLedgerRepo(), payment)
+
+		err := payments.Transition(context.Background(), domain.PaymentTransition{
+			PaymentID: payment.ID,
+			From:      domain.PaymentAuthorized,
+			To:        domain.PaymentCaptured,
+			FineEvent: &domain.FineEvent{ID: uuid.New(), FineID: fine.ID, Status: domain.FinePaid, CreatedAt: time.Now()},
+		})
+		if !errors.Is(err, domain.ErrFineNotPayable) {
+			t.Errorf("%s fine: error %v, want %v", status, err, domain.ErrFineNotPayable)
+		}
+
+		if got, _ := payments.GetByID(context.Background(), payment.ID); got.Status != domain.PaymentAuthorized {
+			t.Errorf("%s fine: payment is %s, want it left %s", status, got.Status, domain.PaymentAuthorized)
+		}
+
+		if got, _ := fines.GetByID(context.Background(), fine.ID); got.Status != status {
+			t.Errorf("%s fine: fine is %s, want it left %s", status, got.Status, status)
+		}
+	}
+}
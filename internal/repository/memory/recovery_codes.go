@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.RecoveryCodes = (*RecoveryCodesRepo)(nil)
+
+type RecoveryCodesRepo struct {
+	mu    sync.RWMutex
+	codes map[uuid.UUID]domain.RecoveryCode
+}
+
+// NewRecoveryCodesRepo creates a RecoveryCodesRepo pre-populated with the given codes.
+func NewRecoveryCodesRepo(codes ...domain.RecoveryCode) *RecoveryCodesRepo {
+	r := &RecoveryCodesRepo{codes: make(map[uuid.UUID]domain.RecoveryCode, len(codes))}
+	for _, code := range codes {
+		r.codes[code.ID] = code
+	}
+
+	return r
+}
+
+func (r *RecoveryCodesRepo) Replace(_ context.Context, userID uuid.UUID, codes []domain.RecoveryCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, code := range r.codes {
+		if code.UserID == userID {
+			delete(r.codes, id)
+		}
+	}
+
+	for _, code := range codes {
+		code.UserID = userID
+		r.codes[code.ID] = code
+	}
+
+	return nil
+}
+
+func (r *RecoveryCodesRepo) GetUnused(_ context.Context, userID uuid.UUID) ([]domain.RecoveryCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	codes := make([]domain.RecoveryCode, 0)
+	for _, code := range r.codes {
+		if code.UserID == userID && code.UsedAt == nil {
+			codes = append(codes, code)
+		}
+	}
+
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].CreatedAt.Before(codes[j].CreatedAt)
+	})
+
+	return codes, nil
+}
+
+func (r *RecoveryCodesRepo) Use(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[id]
+	if !ok || code.UsedAt != nil {
+		return domain.ErrNotFound
+	}
+
+	now := time.Now()
+	code.UsedAt = &now
+	r.codes[id] = code
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Refunds = (*RefundsRepo)(nil)
+
+// RefundsRepo keeps refunds next to the payments they return, so that a
+// refund and its effect on the payment are applied as in one transaction.
+type RefundsRepo struct {
+	mu       sync.RWMutex
+	payments *PaymentsRepo
+	refunds  map[uuid.UUID]domain.Refund
+}
+
+// NewRefundsRepo creates a RefundsRepo pre-populated with the given refunds.
+func NewRefundsRepo(payments *PaymentsRepo, refunds ...domain.Refund) *RefundsRepo {
+	r := &RefundsRepo{
+		payments: payments,
+		refunds:  make(map[uuid.UUID]domain.Refund, len(refunds)),
+	}
+	for _, refund := range refunds {
+		r.refunds[refund.ID] = refund
+	}
+
+	return r
+}
+
+func (r *RefundsRepo) Create(_ context.Context, refund domain.Refund) error {
+	_, err := r.create(refund, nil)
+
+	return err
+}
+
+func (r *RefundsRepo) CreateUpTo(_ context.Context, refund domain.Refund, total int64) (domain.Refund, error) {
+	return r.create(refund, &total)
+}
+
+// create stores the refund. If total is set, the amount of the refund is the
+// part of it not reserved yet.
+func (r *RefundsRepo) create(refund domain.Refund, total *int64) (domain.Refund, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payments.mu.RLock()
+	defer r.payments.mu.RUnlock()
+
+	payment, ok := r.payments.payments[refund.PaymentID]
+	if !ok {
+		return domain.Refund{}, domain.ErrNotFound
+	}
+
+	if payment.Status != domain.PaymentCaptured {
+		return domain.Refund{}, domain.ErrPaymentTransition
+	}
+
+	var reserved int64
+	for _, existing := range r.refunds {
+		if existing.PaymentID != refund.PaymentID {
+			continue
+		}
+
+		if refund.IdempotencyKey != "" && existing.IdempotencyKey == refund.IdempotencyKey {
+			return domain.Refund{}, domain.ErrRefundAlreadyExists
+		}
+
+		if existing.Reserved() {
+			reserved += existing.Amount
+		}
+	}
+
+	if total != nil {
+		refund.Amount = *total - reserved
+		if refund.Amount <= 0 {
+			refund.Amount = 0
+
+			return refund, nil
+		}
+	}
+
+	if reserved+refund.Amount > payment.Amount {
+		return domain.Refund{}, domain.ErrRefundAmount
+	}
+
+	r.refunds[refund.ID] = refund
+
+	return refund, nil
+}
+
+func (r *RefundsRepo) GetByID(_ context.Context, id uuid.UUID) (domain.Refund, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	refund, ok := r.refunds[id]
+	if !ok {
+		return domain.Refund{}, domain.ErrNotFound
+	}
+
+	return refund, nil
+}
+
+func (r *RefundsRepo) GetByPayment(_ context.Context, paymentID uuid.UUID) ([]domain.Refund, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	refunds := make([]domain.Refund, 0)
+	for _, refund := range r.refunds {
+		if refund.PaymentID == paymentID {
+			refunds = append(refunds, refund)
+		}
+	}
+
+	sort.Slice(refunds, func(i, j int) bool {
+		return refunds[i].CreatedAt.Before(refunds[j].CreatedAt)
+	})
+
+	return refunds, nil
+}
+
+func (r *RefundsRepo) GetByIdempotencyKey(_ context.Context, paymentID uuid.UUID, key string) (domain.Refund, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, refund := range r.refunds {
+		if refund.PaymentID == paymentID && refund.IdempotencyKey == key {
+			return refund, nil
+		}
+	}
+
+	return domain.Refund{}, domain.ErrNotFound
+}
+
+func (r *RefundsRepo) Complete(_ context.Context, completion domain.RefundCompletion) error {
+	if completion.Entry != nil {
+		if err := completion.Entry.Validate(); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payments.mu.Lock()
+	defer r.payments.mu.Unlock()
+	r.payments.fines.mu.Lock()
+	defer r.payments.fines.mu.Unlock()
+	r.payments.ledger.mu.Lock()
+	defer r.payments.ledger.mu.Unlock()
+
+	refund, ok := r.refunds[completion.RefundID]
+	if !ok || refund.Status != domain.RefundPending {
+		return domain.ErrRefundCompleted
+	}
+
+	refund.Status = completion.Status
+	refund.FailureReason = completion.FailureReason
+	refund.UpdatedAt = completion.At
+
+	if completion.Status == domain.RefundSucceeded {
+		payment, ok := r.payments.payments[refund.PaymentID]
+		if !ok || payment.Status != domain.PaymentCaptured {
+			return domain.ErrPaymentTransition
+		}
+
+		payment.Refunded += refund.Amount
+		if payment.Refunded == payment.Amount {
+			payment.Status = domain.PaymentRefunded
+		}
+		payment.UpdatedAt = completion.At
+		r.payments.payments[payment.ID] = payment
+
+		if completion.Entry != nil {
+			r.payments.ledger.post(*completion.Entry)
+		}
+
+		if event := completion.FineEvent; event != nil {
+			// The whole payment is returned, so the fine has to be paid again.
+			fine, ok := r.payments.fines.fines[event.FineID]
+			if ok && payment.Status == domain.PaymentRefunded && fine.Status == domain.FinePaid {
+				event.Status = domain.FineIssued
+				if err := r.payments.fines.setStatus(event.FineID, domain.FineIssued, *event); err != nil {
+					return err
+				}
+			} else {
+				r.payments.fines.history[event.FineID] = append(r.payments.fines.history[event.FineID], *event)
+			}
+		}
+	}
+
+	r.refunds[refund.ID] = refund
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Schedules = (*SchedulesRepo)(nil)
+
+type SchedulesRepo struct {
+	mu          sync.RWMutex
+	schedules   map[uuid.UUID]domain.Schedule
+	occurrences map[uuid.UUID][]domain.ScheduleOccurrence
+}
+
+// NewSchedulesRepo creates a SchedulesRepo pre-populated with the given schedules.
+func NewSchedulesRepo(schedules ...domain.Schedule) *SchedulesRepo {
+	r := &SchedulesRepo{
+		schedules:   make(map[uuid.UUID]domain.Schedule, len(schedules)),
+		occurrences: make(map[uuid.UUID][]domain.ScheduleOccurrence),
+	}
+	for _, schedule := range schedules {
+		r.schedules[schedule.ID] = schedule
+	}
+
+	return r
+}
+
+func (r *SchedulesRepo) Create(_ context.Context, schedule domain.Schedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.schedules[schedule.ID] = schedule
+
+	return nil
+}
+
+func (r *SchedulesRepo) GetByID(_ context.Context, id uuid.UUID) (domain.Schedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schedule, ok := r.schedules[id]
+	if !ok {
+		return domain.Schedule{}, domain.ErrNotFound
+	}
+
+	return schedule, nil
+}
+
+func (r *SchedulesRepo) GetByUser(_ context.Context, userID uuid.UUID) ([]domain.Schedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schedules := make([]domain.Schedule, 0)
+	for _, schedule := range r.schedules {
+		if schedule.UserID == userID {
+			schedules = append(schedules, schedule)
+		}
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.After(schedules[j].CreatedAt)
+	})
+
+	return schedules, nil
+}
+
+func (r *SchedulesRepo) GetDue(_ context.Context, now time.Time, limit int) ([]domain.Schedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schedules := make([]domain.Schedule, 0)
+	for _, schedule := range r.schedules {
+		if schedule.Status == domain.ScheduleActive && schedule.NextRunAt != nil && !schedule.NextRunAt.After(now) {
+			schedules = append(schedules, schedule)
+		}
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].NextRunAt.Before(*schedules[j].NextRunAt)
+	})
+
+	if len(schedules) > limit {
+		schedules = schedules[:limit]
+	}
+
+	return schedules, nil
+}
+
+func (r *SchedulesRepo) Update(_ context.Context, schedule domain.Schedule, loadedAt time.Time,
+	occurrences ...domain.ScheduleOccurrence,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.schedules[schedule.ID]
+	if !ok || !stored.UpdatedAt.Equal(loadedAt) {
+		return domain.ErrScheduleChanged
+	}
+
+	stored.Status = schedule.Status
+	stored.NextRunAt = schedule.NextRunAt
+	stored.UpdatedAt = schedule.UpdatedAt
+	r.schedules[schedule.ID] = stored
+
+	recorded := r.occurrences[schedule.ID]
+	for _, occurrence := range occurrences {
+		if !hasOccurrence(recorded, occurrence.ScheduledFor) {
+			recorded = append(recorded, occurrence)
+		}
+	}
+	r.occurrences[schedule.ID] = recorded
+
+	return nil
+}
+
+func (r *SchedulesRepo) GetOccurrences(_ context.Context, scheduleID uuid.UUID) ([]domain.ScheduleOccurrence, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	occurrences := append(make([]domain.ScheduleOccurrence, 0), r.occurrences[scheduleID]...)
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].ScheduledFor.After(occurrences[j].ScheduledFor)
+	})
+
+	return occurrences, nil
+}
+
+func hasOccurrence(occurrences []domain.ScheduleOccurrence, scheduledFor time.Time) bool {
+	for _, occurrence := range occurrences {
+		if occurrence.ScheduledFor.Equal(scheduledFor) {
+			return true
+		}
+	}
+
+	return false
+}
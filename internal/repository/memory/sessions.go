@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Sessions = (*SessionsRepo)(nil)
+
+type SessionsRepo struct {
+	mu       sync.RWMutex
+	sessions map[uuid.UUID]domain.Session
+	tokens   map[string]domain.RefreshToken
+}
+
+// NewSessionsRepo creates a SessionsRepo pre-populated with the given sessions.
+func NewSessionsRepo(sessions ...domain.Session) *SessionsRepo {
+	r := &SessionsRepo{
+		sessions: make(map[uuid.UUID]domain.Session, len(sessions)),
+		tokens:   make(map[string]domain.RefreshToken),
+	}
+	for _, session := range sessions {
+		r.sessions[session.ID] = session
+	}
+
+	return r
+}
+
+func (r *SessionsRepo) Create(_ context.Context, session domain.Session, token domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions[session.ID] = session
+	r.tokens[token.Hash] = token
+
+	return nil
+}
+
+func (r *SessionsRepo) GetByID(_ context.Context, id uuid.UUID) (domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return domain.Session{}, domain.ErrNotFound
+	}
+
+	return session, nil
+}
+
+func (r *SessionsRepo) GetActiveByUser(_ context.Context, userID uuid.UUID) ([]domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	sessions := make([]domain.Session, 0)
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.Active(now) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *SessionsRepo) GetRefreshToken(_ context.Context, tokenHash string) (domain.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return domain.RefreshToken{}, domain.ErrNotFound
+	}
+
+	return token, nil
+}
+
+func (r *SessionsRepo) Rotate(_ context.Context, usedHash string, session domain.Session, next domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	used, ok := r.tokens[usedHash]
+	if !ok || used.UsedAt != nil {
+		return domain.ErrRefreshTokenReused
+	}
+
+	stored, ok := r.sessions[session.ID]
+	if !ok || stored.RevokedAt != nil {
+		return domain.ErrNotFound
+	}
+
+	usedAt := session.LastSeenAt
+	used.UsedAt = &usedAt
+	r.tokens[usedHash] = used
+
+	stored.Device = session.Device
+	stored.IP = session.IP
+	stored.LastSeenAt = session.LastSeenAt
+	stored.ExpiresAt = session.ExpiresAt
+	r.sessions[session.ID] = stored
+
+	r.tokens[next.Hash] = next
+
+	return nil
+}
+
+func (r *SessionsRepo) Revoke(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	session.RevokedAt = &now
+	r.sessions[id] = session
+
+	return nil
+}
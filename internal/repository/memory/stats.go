@@ -0,0 +1,185 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Stats = (*StatsRepo)(nil)
+
+// StatsRepo computes statistics from the fines, payments, refunds and
+// journal entries kept by the other repositories.
+type StatsRepo struct {
+	mu       sync.RWMutex
+	fines    *FinesRepo
+	payments *PaymentsRepo
+	refunds  *RefundsRepo
+	ledger   *LedgerRepo
+	stats    map[uuid.UUID]domain.Stats
+}
+
+// NewStatsRepo creates a StatsRepo pre-populated with the given per-user stats.
+func NewStatsRepo(fines *FinesRepo, payments *PaymentsRepo, refunds *RefundsRepo, ledger *LedgerRepo,
+	stats ...domain.Stats,
+) *StatsRepo {
+	r := &StatsRepo{
+		fines:    fines,
+		payments: payments,
+		refunds:  refunds,
+		ledger:   ledger,
+		stats:    make(map[uuid.UUID]domain.Stats, len(stats)),
+	}
+	for _, s := range stats {
+		r.stats[s.UserID] = s
+	}
+
+	return r
+}
+
+func (r *StatsRepo) GetByUser(_ context.Context, userID uuid.UUID) (domain.Stats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats, ok := r.stats[userID]
+	if !ok {
+		return domain.Stats{}, domain.ErrNotFound
+	}
+
+	return stats, nil
+}
+
+// statsFlow is a single amount summed up by Aggregate.
+type statsFlow struct {
+	at       time.Time
+	currency string
+	category string
+	amount   int64
+}
+
+func (r *StatsRepo) Aggregate(_ context.Context, userID uuid.UUID, query domain.StatsQuery) ([]domain.StatsRow, error) {
+	// The locks are taken in the order the refunds repository takes them.
+	r.refunds.mu.RLock()
+	defer r.refunds.mu.RUnlock()
+	r.payments.mu.RLock()
+	defer r.payments.mu.RUnlock()
+	r.fines.mu.RLock()
+	defer r.fines.mu.RUnlock()
+	r.ledger.mu.RLock()
+	defer r.ledger.mu.RUnlock()
+
+	var flows []statsFlow
+
+	switch query.Metric {
+	case domain.StatsSpending:
+		for _, entry := range r.ledger.entries {
+			if entry.Kind != domain.JournalSettlement || entry.PaymentID == nil {
+				continue
+			}
+
+			payment, ok := r.payments.payments[*entry.PaymentID]
+			if !ok || payment.UserID != userID {
+				continue
+			}
+
+			flows = append(flows, statsFlow{
+				at:       entry.CreatedAt,
+				currency: payment.Currency,
+				category: paymentCategory(payment),
+				amount:   payment.Amount,
+			})
+		}
+	case domain.StatsIncome:
+		for _, refund := range r.refunds.refunds {
+			if refund.UserID != userID || refund.Status != domain.RefundSucceeded {
+				continue
+			}
+
+			payment, ok := r.payments.payments[refund.PaymentID]
+			if !ok {
+				continue
+			}
+
+			flows = append(flows, statsFlow{
+				at:       refund.UpdatedAt,
+				currency: refund.Currency,
+				category: paymentCategory(payment),
+				amount:   refund.Amount,
+			})
+		}
+	case domain.StatsFines:
+		for _, fine := range r.fines.fines {
+			if fine.UserID != userID || fine.Status == domain.FineCancelled {
+				continue
+			}
+
+			flows = append(flows, statsFlow{
+				at:       fine.IssuedAt,
+				currency: "RUB",
+				category: fine.Issuer,
+				amount:   fine.Amount,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown stats metric %q", query.Metric)
+	}
+
+	type rowKey struct {
+		start    int64
+		currency string
+		category string
+	}
+
+	sums := make(map[rowKey]domain.StatsRow)
+	for _, flow := range flows {
+		if flow.at.Before(query.From) || !flow.at.Before(query.To) {
+			continue
+		}
+
+		local := query.Bucket.Start(flow.at.In(query.Location))
+		year, month, day := local.Date()
+		start := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+		key := rowKey{start: start.Unix(), currency: flow.currency, category: flow.category}
+		row := sums[key]
+		row.Start, row.Currency, row.Category = start, flow.currency, flow.category
+		row.Amount += flow.amount
+		row.Count++
+		sums[key] = row
+	}
+
+	rows := make([]domain.StatsRow, 0, len(sums))
+	for _, row := range sums {
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+
+		return a.Category < b.Category
+	})
+
+	return rows, nil
+}
+
+// paymentCategory returns the statistics category of the payment.
+func paymentCategory(payment domain.Payment) string {
+	if payment.TargetType == domain.PaymentTargetFine {
+		return domain.StatsCategoryFines
+	}
+
+	return payment.MerchantID
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Users = (*UsersRepo)(nil)
+
+type UsersRepo struct {
+	mu    sync.RWMutex
+	users map[uuid.UUID]domain.User
+}
+
+// NewUsersRepo creates a UsersRepo pre-populated with the given users.
+func NewUsersRepo(users ...domain.User) *UsersRepo {
+	r := &UsersRepo{users: make(map[uuid.UUID]domain.User, len(users))}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+
+	return r
+}
+
+func (r *UsersRepo) Create(_ context.Context, user domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return domain.ErrUserAlreadyExists
+		}
+	}
+
+	r.users[user.ID] = user
+
+	return nil
+}
+
+func (r *UsersRepo) GetByID(_ context.Context, id uuid.UUID) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return domain.User{}, domain.ErrNotFound
+	}
+
+	return user, nil
+}
+
+func (r *UsersRepo) GetByEmail(_ context.Context, email string) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return domain.User{}, domain.ErrNotFound
+}
+
+func (r *UsersRepo) UpdatePassword(_ context.Context, id uuid.UUID, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	user.Password = passwordHash
+	r.users[id] = user
+
+	return nil
+}
+
+func (r *UsersRepo) SetTOTPSecret(_ context.Context, id uuid.UUID, secret string) error {
+	return r.update(id, func(user *domain.User) error {
+		user.TOTPSecret = secret
+		user.TOTPEnabled = false
+		user.TOTPCounter = 0
+
+		return nil
+	})
+}
+
+func (r *UsersRepo) EnableTOTP(_ context.Context, id uuid.UUID, counter int64) error {
+	return r.update(id, func(user *domain.User) error {
+		user.TOTPEnabled = true
+		user.TOTPCounter = counter
+
+		return nil
+	})
+}
+
+func (r *UsersRepo) DisableTOTP(_ context.Context, id uuid.UUID) error {
+	return r.update(id, func(user *domain.User) error {
+		user.TOTPSecret = ""
+		user.TOTPEnabled = false
+		user.TOTPCounter = 0
+
+		return nil
+	})
+}
+
+func (r *UsersRepo) UseTOTPCounter(_ context.Context, id uuid.UUID, counter int64) error {
+	return r.update(id, func(user *domain.User) error {
+		if user.TOTPCounter >= counter {
+			return domain.ErrInvalidOTP
+		}
+
+		user.TOTPCounter = counter
+
+		return nil
+	})
+}
+
+// update applies fn to the stored user under the write lock.
+func (r *UsersRepo) update(id uuid.UUID, fn func(user *domain.User) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	if err := fn(&user); err != nil {
+		return err
+	}
+
+	r.users[id] = user
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ repository.Webhooks = (*WebhooksRepo)(nil)
+
+type WebhooksRepo struct {
+	mu     sync.RWMutex
+	events map[uuid.UUID]domain.WebhookEvent
+}
+
+// NewWebhooksRepo creates a WebhooksRepo pre-populated with the given events.
+func NewWebhooksRepo(events ...domain.WebhookEvent) *WebhooksRepo {
+	r := &WebhooksRepo{events: make(map[uuid.UUID]domain.WebhookEvent, len(events))}
+	for _, event := range events {
+		r.events[event.ID] = event
+	}
+
+	return r
+}
+
+func (r *WebhooksRepo) Create(_ context.Context, event domain.WebhookEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.events {
+		if existing.Provider == event.Provider && existing.EventID == event.EventID {
+			return domain.ErrWebhookEventExists
+		}
+	}
+
+	r.events[event.ID] = event
+
+	return nil
+}
+
+func (r *WebhooksRepo) GetByEventID(_ context.Context, provider, eventID string) (domain.WebhookEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, event := range r.events {
+		if event.Provider == provider && event.EventID == eventID {
+			return event, nil
+		}
+	}
+
+	return domain.WebhookEvent{}, domain.ErrNotFound
+}
+
+func (r *WebhooksRepo) Complete(_ context.Context, id uuid.UUID, outcome domain.WebhookOutcome, detail string, processedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, ok := r.events[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+
+	event.Outcome = outcome
+	event.Detail = detail
+	event.ProcessedAt = &processedAt
+	r.events[id] = event
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const notificationColumns = `id, user_id, kind, subject_id, message, created_at`
+
+type NotificationsRepo struct {
+	db *sqlx.DB
+}
+
+func NewNotificationsRepo(db *sqlx.DB) *NotificationsRepo {
+	return &NotificationsRepo{db: db}
+}
+
+func (r *NotificationsRepo) Create(ctx context.Context, notification domain.Notification) error {
+	_, err := r.db.NamedExecContext(ctx,
+		`INSERT INTO notifications (`+notificationColumns+`)
+		VALUES (:id, :user_id, :kind, :subject_id, :message, :created_at)`, notification)
+
+	return err
+}
+
+func (r *NotificationsRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Notification, error) {
+	notifications := make([]domain.Notification, 0)
+
+	err := r.db.SelectContext(ctx, &notifications,
+		`SELECT `+notificationColumns+` FROM notifications WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const paymentColumns = `id, user_id, amount, currency, purpose, target_type, fine_id, merchant_id, status,
+	refunded, failure_reason, gateway_ref, challenge_url, idempotency_key, request_hash, created_at, updated_at`
+
+type PaymentsRepo struct {
+	db *sqlx.DB
+}
+
+func NewPaymentsRepo(db *sqlx.DB) *PaymentsRepo {
+	return &PaymentsRepo{db: db}
+}
+
+func (r *PaymentsRepo) Create(ctx context.Context, payment domain.Payment) error {
+	_, err := r.db.NamedExecContext(ctx,
+		`INSERT INTO payments (`+paymentColumns+`)
+		VALUES (:id, :user_id, :amount, :currency, :purpose, :target_type, :fine_id, :merchant_id, :status,
+			:refunded, :failure_reason, :gateway_ref, :challenge_url, :idempotency_key, :request_hash,
+			:created_at, :updated_at)`, payment)
+
+	switch {
+	case isUniqueViolationOf(err, "payments_user_id_idempotency_key_idx"):
+		return domain.ErrPaymentAlreadyExists
+	case isUniqueViolationOf(err, "payments_fine_id_in_progress_idx"):
+		return domain.ErrFinePaymentExists
+	}
+
+	return err
+}
+
+func (r *PaymentsRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.Payment, error) {
+	var payment domain.Payment
+
+	err := r.db.GetContext(ctx, &payment, `SELECT `+paymentColumns+` FROM payments WHERE id = $1`, id)
+	if err != nil {
+		return domain.Payment{}, wrapNotFound(err)
+	}
+
+	return payment, nil
+}
+
+func (r *PaymentsRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Payment, error) {
+	payments := make([]domain.Payment, 0)
+
+	err := r.db.SelectContext(ctx, &payments,
+		`SELECT `+paymentColumns+` FROM payments WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (r *PaymentsRepo) GetByIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (domain.Payment, error) {
+	var payment domain.Payment
+
+	err := r.db.GetContext(ctx, &payment,
+		`SELECT `+paymentColumns+` FROM payments WHERE user_id = $1 AND idempotency_key = $2`, userID, key)
+	if err != nil {
+		return domain.Payment{}, wrapNotFound(err)
+	}
+
+	return payment, nil
+}
+
+func (r *PaymentsRepo) Transition(ctx context.Context, transition domain.PaymentTransition) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE payments SET status = $3, failure_reason = $4, gateway_ref = $5, challenge_url = $6, updated_at = $7
+		WHERE id = $1 AND status = $2`,
+		transition.PaymentID, transition.From, transition.To, transition.FailureReason,
+		transition.GatewayRef, transition.ChallengeURL, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrPaymentTransition
+		}
+
+		return err
+	}
+
+	for _, entry := range transition.Entries {
+		if err := insertJournalEntry(ctx, tx, entry); err != nil {
+			return err
+		}
+	}
+
+	if event := transition.FineEvent; event != nil {
+		// The fine may have been paid, cancelled or disputed since the
+		// service checked it.
+		res, err := tx.ExecContext(ctx,
+			`UPDATE fines SET status = $2, updated_at = $3 WHERE id = $1 AND status = $4`,
+			event.FineID, event.Status, event.CreatedAt, domain.FineIssued)
+		if err != nil {
+			return err
+		}
+
+		if err := checkAffected(res); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return domain.ErrFineNotPayable
+			}
+
+			return err
+		}
+
+		if err := insertFineEvent(ctx, tx, *event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type RecoveryCodesRepo struct {
+	db *sqlx.DB
+}
+
+func NewRecoveryCodesRepo(db *sqlx.DB) *RecoveryCodesRepo {
+	return &RecoveryCodesRepo{db: db}
+}
+
+func (r *RecoveryCodesRepo) Replace(ctx context.Context, userID uuid.UUID, codes []domain.RecoveryCode) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, code := range codes {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO recovery_codes (id, user_id, code_hash, created_at) VALUES ($1, $2, $3, $4)`,
+			code.ID, userID, code.Hash, code.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *RecoveryCodesRepo) GetUnused(ctx context.Context, userID uuid.UUID) ([]domain.RecoveryCode, error) {
+	codes := make([]domain.RecoveryCode, 0)
+
+	err := r.db.SelectContext(ctx, &codes,
+		`SELECT id, user_id, code_hash, used_at, created_at FROM recovery_codes
+		WHERE user_id = $1 AND used_at IS NULL ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (r *RecoveryCodesRepo) Use(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE recovery_codes SET used_at = now() WHERE id = $1 AND used_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const refundColumns = `id, payment_id, user_id, amount, currency, reason, status, failure_reason, idempotency_key,
+	request_hash, created_at, updated_at`
+
+type RefundsRepo struct {
+	db *sqlx.DB
+}
+
+func NewRefundsRepo(db *sqlx.DB) *RefundsRepo {
+	return &RefundsRepo{db: db}
+}
+
+// Create locks the payment so that concurrent refunds cannot together exceed
+// the captured amount.
+func (r *RefundsRepo) Create(ctx context.Context, refund domain.Refund) error {
+	_, err := r.create(ctx, refund, nil)
+
+	return err
+}
+
+func (r *RefundsRepo) CreateUpTo(ctx context.Context, refund domain.Refund, total int64) (domain.Refund, error) {
+	return r.create(ctx, refund, &total)
+}
+
+// create stores the refund under the lock of its payment. If total is set,
+// the amount of the refund is the part of it not reserved yet.
+func (r *RefundsRepo) create(ctx context.Context, refund domain.Refund, total *int64) (domain.Refund, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return domain.Refund{}, err
+	}
+	defer tx.Rollback()
+
+	var payment domain.Payment
+
+	err = tx.GetContext(ctx, &payment,
+		`SELECT `+paymentColumns+` FROM payments WHERE id = $1 FOR UPDATE`, refund.PaymentID)
+	if err != nil {
+		return domain.Refund{}, wrapNotFound(err)
+	}
+
+	if payment.Status != domain.PaymentCaptured {
+		return domain.Refund{}, domain.ErrPaymentTransition
+	}
+
+	var reserved int64
+
+	err = tx.GetContext(ctx, &reserved,
+		`SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id = $1 AND status IN ($2, $3)`,
+		refund.PaymentID, domain.RefundPending, domain.RefundSucceeded)
+	if err != nil {
+		return domain.Refund{}, err
+	}
+
+	if total != nil {
+		refund.Amount = *total - reserved
+		if refund.Amount <= 0 {
+			refund.Amount = 0
+
+			return refund, nil
+		}
+	}
+
+	if reserved+refund.Amount > payment.Amount {
+		return domain.Refund{}, domain.ErrRefundAmount
+	}
+
+	_, err = tx.NamedExecContext(ctx,
+		`INSERT INTO refunds (`+refundColumns+`)
+		VALUES (:id, :payment_id, :user_id, :amount, :currency, :reason, :status, :failure_reason, :idempotency_key,
+			:request_hash, :created_at, :updated_at)`, refund)
+	if isUniqueViolation(err) {
+		return domain.Refund{}, domain.ErrRefundAlreadyExists
+	}
+
+	if err != nil {
+		return domain.Refund{}, err
+	}
+
+	return refund, tx.Commit()
+}
+
+func (r *RefundsRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.Refund, error) {
+	var refund domain.Refund
+
+	err := r.db.GetContext(ctx, &refund, `SELECT `+refundColumns+` FROM refunds WHERE id = $1`, id)
+	if err != nil {
+		return domain.Refund{}, wrapNotFound(err)
+	}
+
+	return refund, nil
+}
+
+func (r *RefundsRepo) GetByPayment(ctx context.Context, paymentID uuid.UUID) ([]domain.Refund, error) {
+	refunds := make([]domain.Refund, 0)
+
+	err := r.db.SelectContext(ctx, &refunds,
+		`SELECT `+refundColumns+` FROM refunds WHERE payment_id = $1 ORDER BY created_at`, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return refunds, nil
+}
+
+func (r *RefundsRepo) GetByIdempotencyKey(ctx context.Context, paymentID uuid.UUID, key string) (domain.Refund, error) {
+	var refund domain.Refund
+
+	err := r.db.GetContext(ctx, &refund,
+		`SELECT `+refundColumns+` FROM refunds WHERE payment_id = $1 AND idempotency_key = $2`, paymentID, key)
+	if err != nil {
+		return domain.Refund{}, wrapNotFound(err)
+	}
+
+	return refund, nil
+}
+
+func (r *RefundsRepo) Complete(ctx context.Context, completion domain.RefundCompletion) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var refund domain.Refund
+
+	err = tx.GetContext(ctx, &refund,
+		`UPDATE refunds SET status = $2, failure_reason = $3, updated_at = $4
+		WHERE id = $1 AND status = $5
+		RETURNING `+refundColumns,
+		completion.RefundID, completion.Status, completion.FailureReason, completion.At, domain.RefundPending)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrRefundCompleted
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if completion.Status != domain.RefundSucceeded {
+		return tx.Commit()
+	}
+
+	var paymentStatus domain.PaymentStatus
+
+	err = tx.GetContext(ctx, &paymentStatus,
+		`UPDATE payments SET refunded = refunded + $2, updated_at = $3,
+			status = CASE WHEN refunded + $2 = amount THEN $5 ELSE status END
+		WHERE id = $1 AND status = $4
+		RETURNING status`,
+		refund.PaymentID, refund.Amount, completion.At, domain.PaymentCaptured, domain.PaymentRefunded)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrPaymentTransition
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if completion.Entry != nil {
+		if err := insertJournalEntry(ctx, tx, *completion.Entry); err != nil {
+			return err
+		}
+	}
+
+	if event := completion.FineEvent; event != nil {
+		// The whole payment is returned, so the fine has to be paid again.
+		if paymentStatus == domain.PaymentRefunded {
+			res, err := tx.ExecContext(ctx,
+				`UPDATE fines SET status = $2, updated_at = $3 WHERE id = $1 AND status = $4`,
+				event.FineID, domain.FineIssued, event.CreatedAt, domain.FinePaid)
+			if err != nil {
+				return err
+			}
+
+			if err := checkAffected(res); err == nil {
+				event.Status = domain.FineIssued
+			} else if !errors.Is(err, domain.ErrNotFound) {
+				return err
+			}
+		}
+
+		if err := insertFineEvent(ctx, tx, *event); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -1,11 +1,297 @@
 package repository
 
 import (
+	"backend-vtb/internal/domain"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
-type Repository struct{}
+type Users interface {
+	Create(ctx context.Context, user domain.User) error
+	GetByID(ctx context.Context, id uuid.UUID) (domain.User, error)
+	GetByEmail(ctx context.Context, email string) (domain.User, error)
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	// SetTOTPSecret stores a pending secret and leaves two-factor authentication disabled.
+	SetTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error
+	EnableTOTP(ctx context.Context, id uuid.UUID, counter int64) error
+	DisableTOTP(ctx context.Context, id uuid.UUID) error
+	// UseTOTPCounter records counter as the last accepted time step. It returns
+	// domain.ErrInvalidOTP if a code from the same or a later step has already
+	// been accepted.
+	UseTOTPCounter(ctx context.Context, id uuid.UUID, counter int64) error
+}
+
+type RecoveryCodes interface {
+	// Replace deletes all recovery codes of the user and stores codes instead.
+	Replace(ctx context.Context, userID uuid.UUID, codes []domain.RecoveryCode) error
+	GetUnused(ctx context.Context, userID uuid.UUID) ([]domain.RecoveryCode, error)
+	// Use marks the code as used. It returns domain.ErrNotFound if the code
+	// does not exist or has already been used.
+	Use(ctx context.Context, id uuid.UUID) error
+}
+
+type Sessions interface {
+	// Create stores a new session together with its first refresh token.
+	Create(ctx context.Context, session domain.Session, token domain.RefreshToken) error
+	GetByID(ctx context.Context, id uuid.UUID) (domain.Session, error)
+	GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]domain.Session, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (domain.RefreshToken, error)
+	// Rotate atomically marks the refresh token with usedHash as used, stores
+	// next and updates the session activity. It returns domain.ErrRefreshTokenReused
+	// if the token has already been used and domain.ErrNotFound if the session
+	// has been revoked.
+	Rotate(ctx context.Context, usedHash string, session domain.Session, next domain.RefreshToken) error
+	Revoke(ctx context.Context, id uuid.UUID) error
+}
+
+// Fines stores fines together with their append-only history: every change
+// records an event in the same transaction.
+type Fines interface {
+	// Create stores a new fine. It returns domain.ErrFineAlreadyExists if the
+	// user already has a fine with the same UIN.
+	Create(ctx context.Context, fine domain.Fine, event domain.FineEvent) error
+	GetByID(ctx context.Context, id uuid.UUID) (domain.Fine, error)
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Fine, error)
+	GetByUIN(ctx context.Context, userID uuid.UUID, uin string) (domain.Fine, error)
+	Update(ctx context.Context, fine domain.Fine, event domain.FineEvent) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// GetHistory returns the events of the fine, oldest first.
+	GetHistory(ctx context.Context, fineID uuid.UUID) ([]domain.FineEvent, error)
+}
+
+type Disputes interface {
+	// Open stores the dispute, marks the fine as disputed and records the
+	// event. It returns domain.ErrFineTransition if the fine is not issued.
+	Open(ctx context.Context, dispute domain.Dispute, event domain.FineEvent) error
+	GetByID(ctx context.Context, id uuid.UUID) (domain.Dispute, error)
+	GetByFine(ctx context.Context, fineID uuid.UUID) ([]domain.Dispute, error)
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Dispute, error)
+	// Resolve closes the dispute with its new status, sets the fine status
+	// and records the event. It returns domain.ErrDisputeClosed if the
+	// dispute is no longer open.
+	Resolve(ctx context.Context, dispute domain.Dispute, fineStatus domain.FineStatus, event domain.FineEvent) error
+	AddAttachment(ctx context.Context, attachment domain.DisputeAttachment) error
+	GetAttachment(ctx context.Context, id uuid.UUID) (domain.DisputeAttachment, error)
+	GetAttachments(ctx context.Context, disputeID uuid.UUID) ([]domain.DisputeAttachment, error)
+}
+
+type Payments interface {
+	// Create stores a new payment. It returns domain.ErrPaymentAlreadyExists if
+	// the user already has a payment with the same idempotency key, and
+	// domain.ErrFinePaymentExists if the fine already has a payment in progress.
+	Create(ctx context.Context, payment domain.Payment) error
+	GetByID(ctx context.Context, id uuid.UUID) (domain.Payment, error)
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Payment, error)
+	GetByIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (domain.Payment, error)
+	// Transition moves the payment from one status to another, posting the
+	// ledger entries and updating the fine in the same transaction. It returns
+	// domain.ErrPaymentTransition if the payment is no longer in the from status.
+	Transition(ctx context.Context, transition domain.PaymentTransition) error
+}
+
+// Refunds stores the refunds of captured payments.
+type Refunds interface {
+	// Create stores a pending refund. It returns domain.ErrRefundAmount if the
+	// pending and succeeded refunds of the payment would exceed its amount,
+	// domain.ErrPaymentTransition if the payment is not captured, and
+	// domain.ErrRefundAlreadyExists if the payment already has a refund with
+	// the same idempotency key.
+	Create(ctx context.Context, refund domain.Refund) error
+	// CreateUpTo stores a pending refund of the part of total that the
+	// pending and succeeded refunds of the payment do not cover yet, and
+	// returns it. The part is computed under the same lock as in Create, so
+	// concurrent calls never refund more than total together. If nothing is
+	// missing, it stores nothing and returns a refund with a zero amount.
+	CreateUpTo(ctx context.Context, refund domain.Refund, total int64) (domain.Refund, error)
+	GetByID(ctx context.Context, id uuid.UUID) (domain.Refund, error)
+	// GetByPayment returns the refunds of the payment, oldest first.
+	GetByPayment(ctx context.Context, paymentID uuid.UUID) ([]domain.Refund, error)
+	GetByIdempotencyKey(ctx context.Context, paymentID uuid.UUID, key string) (domain.Refund, error)
+	// Complete records the outcome of a pending refund. A succeeded refund is
+	// added to the refunded amount of the payment, marking it refunded once
+	// fully refunded, and its ledger entry and fine event are stored in the
+	// same transaction. A fully refunded payment issues its paid fine again.
+	// It returns domain.ErrRefundCompleted if the refund is
+	// no longer pending.
+	Complete(ctx context.Context, completion domain.RefundCompletion) error
+}
+
+// Ledger is the double-entry ledger. Entries are only ever added.
+type Ledger interface {
+	// Post commits a journal entry. It returns domain.ErrUnbalancedEntry if
+	// the postings do not balance.
+	Post(ctx context.Context, entry domain.JournalEntry) error
+	// GetBalances returns the balances of the user's accounts as of the given
+	// moment, derived from the postings of the entries committed until then.
+	GetBalances(ctx context.Context, userID uuid.UUID, asOf time.Time) ([]domain.AccountBalance, error)
+	// GetEntries returns the entries touching the account with all their postings, newest first.
+	GetEntries(ctx context.Context, accountCode string) ([]domain.JournalEntry, error)
+	// Check verifies that every entry balances and so does the whole ledger.
+	Check(ctx context.Context) (domain.LedgerReport, error)
+}
+
+// Webhooks keeps the webhook events received from payment providers.
+type Webhooks interface {
+	// Create stores a received event. It returns domain.ErrWebhookEventExists
+	// if the provider has already delivered an event with the same id.
+	Create(ctx context.Context, event domain.WebhookEvent) error
+	GetByEventID(ctx context.Context, provider, eventID string) (domain.WebhookEvent, error)
+	// Complete records the outcome of processing the event.
+	Complete(ctx context.Context, id uuid.UUID, outcome domain.WebhookOutcome, detail string, processedAt time.Time) error
+}
+
+// Schedules stores payment schedules and the occurrences they went through.
+type Schedules interface {
+	Create(ctx context.Context, schedule domain.Schedule) error
+	GetByID(ctx context.Context, id uuid.UUID) (domain.Schedule, error)
+	// GetByUser returns the schedules of the user, newest first.
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Schedule, error)
+	// GetDue returns up to limit active schedules whose next run is not after
+	// now, earliest first.
+	GetDue(ctx context.Context, now time.Time, limit int) ([]domain.Schedule, error)
+	// Update stores the schedule and the given occurrences in one transaction.
+	// It returns domain.ErrScheduleChanged if the schedule was updated after
+	// it was loaded with the given UpdatedAt. An occurrence that has already
+	// been recorded is kept as it was.
+	Update(ctx context.Context, schedule domain.Schedule, loadedAt time.Time, occurrences ...domain.ScheduleOccurrence) error
+	// GetOccurrences returns the occurrences of the schedule, newest first.
+	GetOccurrences(ctx context.Context, scheduleID uuid.UUID) ([]domain.ScheduleOccurrence, error)
+}
+
+// Autopay stores the autopay rules of users and the charges made under them.
+type Autopay interface {
+	GetRule(ctx context.Context, userID uuid.UUID) (domain.AutopayRule, error)
+	// SaveRule creates or replaces the rule of the user.
+	SaveRule(ctx context.Context, rule domain.AutopayRule) error
+	// CreateCharge stores the charge together with the schedule paying it, if
+	// any, in one transaction. It returns domain.ErrAutopayLimit if a scheduled
+	// charge would take the charges scheduled for its month over the monthly
+	// limit of the user's rule.
+	CreateCharge(ctx context.Context, charge domain.AutopayCharge, schedule *domain.Schedule) error
+	GetCharge(ctx context.Context, id uuid.UUID) (domain.AutopayCharge, error)
+	// GetCharges returns the charges of the user, newest first.
+	GetCharges(ctx context.Context, userID uuid.UUID) ([]domain.AutopayCharge, error)
+	// UpdateChargeStatus moves the charge from one status to another. It
+	// returns domain.ErrAutopayState if the charge is no longer in the from status.
+	UpdateChargeStatus(ctx context.Context, id uuid.UUID, from, to domain.AutopayChargeStatus, updatedAt time.Time) error
+}
+
+type Notifications interface {
+	Create(ctx context.Context, notification domain.Notification) error
+	// GetByUser returns the notifications of the user, newest first.
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Notification, error)
+}
+
+// CryptoTransactions keeps the crypto transactions users record.
+type CryptoTransactions interface {
+	// Create stores the transaction if check accepts the transactions of the
+	// user in its asset, in execution order, with it included. Changes to the
+	// transactions of a user are serialized, so that concurrent ones cannot
+	// together break what check verifies.
+	Create(ctx context.Context, tx domain.CryptoTransaction, check func([]domain.CryptoTransaction) error) error
+	GetByID(ctx context.Context, id uuid.UUID) (domain.CryptoTransaction, error)
+	// GetByUser returns the transactions of the user in execution order.
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.CryptoTransaction, error)
+	// Delete removes the transaction if check accepts the remaining
+	// transactions of the user in its asset, see Create.
+	Delete(ctx context.Context, tx domain.CryptoTransaction, check func([]domain.CryptoTransaction) error) error
+}
+
+// Candles keeps the OHLCV candles of crypto prices.
+type Candles interface {
+	// Merge adds the ticks summarized by the candles to those already stored
+	// for the same assets, intervals and starts.
+	Merge(ctx context.Context, candles []domain.Candle) error
+	// GetRange returns the candles of the asset in the interval starting in
+	// [from, to), oldest first.
+	GetRange(ctx context.Context, symbol string, interval domain.CandleInterval, from, to time.Time) ([]domain.Candle, error)
+	// GetLastBefore returns the latest candle of the asset in the interval
+	// starting before the given time.
+	GetLastBefore(ctx context.Context, symbol string, interval domain.CandleInterval, before time.Time) (domain.Candle, error)
+}
+
+// Alerts keeps the alert rules of users and the state of their evaluation.
+type Alerts interface {
+	// Create stores the rule. It returns domain.ErrAlertLimit if the user
+	// already has limit rules.
+	Create(ctx context.Context, rule domain.AlertRule, limit int) error
+	GetByID(ctx context.Context, id uuid.UUID) (domain.AlertRule, error)
+	// GetByUser returns the rules of the user, newest first.
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.AlertRule, error)
+	// GetAll returns the rules of all users, grouped by user.
+	GetAll(ctx context.Context) ([]domain.AlertRule, error)
+	// Update stores whether the rule is triggered and when it fired. It
+	// returns domain.ErrAlertChanged if the rule was updated after it was
+	// loaded with the given UpdatedAt, or deleted.
+	Update(ctx context.Context, rule domain.AlertRule, loadedAt time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// ExchangeRates keeps the history of official daily exchange rates.
+type ExchangeRates interface {
+	// Save stores the rates, replacing those already stored for the same
+	// currency and day.
+	Save(ctx context.Context, rates ...domain.ExchangeRate) error
+	// GetAsOf returns the latest rate of the currency published on or before
+	// the day of date.
+	GetAsOf(ctx context.Context, currency string, date time.Time) (domain.ExchangeRate, error)
+}
+
+type Achievements interface {
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Achievement, error)
+}
+
+type Stats interface {
+	GetByUser(ctx context.Context, userID uuid.UUID) (domain.Stats, error)
+	// Aggregate sums up the flows of the metric of the user from From until
+	// To per bucket, currency and category, oldest bucket first. Buckets
+	// without flows are left out.
+	Aggregate(ctx context.Context, userID uuid.UUID, query domain.StatsQuery) ([]domain.StatsRow, error)
+}
+
+type Repository struct {
+	Users         Users
+	Sessions      Sessions
+	RecoveryCodes RecoveryCodes
+	Fines         Fines
+	Disputes      Disputes
+	Payments      Payments
+	Refunds       Refunds
+	Ledger        Ledger
+	Webhooks      Webhooks
+	Schedules     Schedules
+	Autopay       Autopay
+	Notifications Notifications
+	ExchangeRates ExchangeRates
+	Crypto        CryptoTransactions
+	Candles       Candles
+	Alerts        Alerts
+	Achievements  Achievements
+	Stats         Stats
+}
 
 func NewRepository(db *sqlx.DB) *Repository {
-	return &Repository{}
+	return &Repository{
+		Users:         NewUsersRepo(db),
+		Sessions:      NewSessionsRepo(db),
+		RecoveryCodes: NewRecoveryCodesRepo(db),
+		Fines:         NewFinesRepo(db),
+		Disputes:      NewDisputesRepo(db),
+		Payments:      NewPaymentsRepo(db),
+		Refunds:       NewRefundsRepo(db),
+		Ledger:        NewLedgerRepo(db),
+		Webhooks:      NewWebhooksRepo(db),
+		Schedules:     NewSchedulesRepo(db),
+		Autopay:       NewAutopayRepo(db),
+		Notifications: NewNotificationsRepo(db),
+		ExchangeRates: NewExchangeRatesRepo(db),
+		Crypto:        NewCryptoTransactionsRepo(db),
+		Candles:       NewCandlesRepo(db),
+		Alerts:        NewAlertsRepo(db),
+		Achievements:  NewAchievementsRepo(db),
+		Stats:         NewStatsRepo(db),
+	}
 }
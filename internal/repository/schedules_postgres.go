@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const scheduleColumns = `id, user_id, kind, day, interval_weeks, amount, currency, purpose, fine_id, merchant_id,
+	card_token, card_last4, starts_at, ends_at, status, next_run_at, created_at, updated_at`
+
+const insertSchedule = `INSERT INTO payment_schedules (` + scheduleColumns + `)
+	VALUES (:id, :user_id, :kind, :day, :interval_weeks, :amount, :currency, :purpose, :fine_id, :merchant_id,
+		:card_token, :card_last4, :starts_at, :ends_at, :status, :next_run_at, :created_at, :updated_at)`
+
+const occurrenceColumns = `id, schedule_id, scheduled_for, status, payment_id, reason, created_at`
+
+type SchedulesRepo struct {
+	db *sqlx.DB
+}
+
+func NewSchedulesRepo(db *sqlx.DB) *SchedulesRepo {
+	return &SchedulesRepo{db: db}
+}
+
+func (r *SchedulesRepo) Create(ctx context.Context, schedule domain.Schedule) error {
+	_, err := r.db.NamedExecContext(ctx, insertSchedule, schedule)
+
+	return err
+}
+
+func (r *SchedulesRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.Schedule, error) {
+	var schedule domain.Schedule
+
+	err := r.db.GetContext(ctx, &schedule, `SELECT `+scheduleColumns+` FROM payment_schedules WHERE id = $1`, id)
+	if err != nil {
+		return domain.Schedule{}, wrapNotFound(err)
+	}
+
+	return schedule, nil
+}
+
+func (r *SchedulesRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]domain.Schedule, error) {
+	schedules := make([]domain.Schedule, 0)
+
+	err := r.db.SelectContext(ctx, &schedules,
+		`SELECT `+scheduleColumns+` FROM payment_schedules WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (r *SchedulesRepo) GetDue(ctx context.Context, now time.Time, limit int) ([]domain.Schedule, error) {
+	schedules := make([]domain.Schedule, 0)
+
+	err := r.db.SelectContext(ctx, &schedules,
+		`SELECT `+scheduleColumns+` FROM payment_schedules
+		WHERE status = $1 AND next_run_at <= $2
+		ORDER BY next_run_at
+		LIMIT $3`, domain.ScheduleActive, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (r *SchedulesRepo) Update(ctx context.Context, schedule domain.Schedule, loadedAt time.Time,
+	occurrences ...domain.ScheduleOccurrence,
+) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE payment_schedules SET status = $3, next_run_at = $4, updated_at = $5
+		WHERE id = $1 AND updated_at = $2`,
+		schedule.ID, loadedAt, schedule.Status, schedule.NextRunAt, schedule.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrScheduleChanged
+		}
+
+		return err
+	}
+
+	for _, occurrence := range occurrences {
+		_, err := tx.NamedExecContext(ctx,
+			`INSERT INTO payment_schedule_occurrences (`+occurrenceColumns+`)
+			VALUES (:id, :schedule_id, :scheduled_for, :status, :payment_id, :reason, :created_at)
+			ON CONFLICT (schedule_id, scheduled_for) DO NOTHING`, occurrence)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SchedulesRepo) GetOccurrences(ctx context.Context, scheduleID uuid.UUID) ([]domain.ScheduleOccurrence, error) {
+	occurrences := make([]domain.ScheduleOccurrence, 0)
+
+	err := r.db.SelectContext(ctx, &occurrences,
+		`SELECT `+occurrenceColumns+` FROM payment_schedule_occurrences
+		WHERE schedule_id = $1 ORDER BY scheduled_for DESC`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return occurrences, nil
+}
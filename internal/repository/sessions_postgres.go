@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type SessionsRepo struct {
+	db *sqlx.DB
+}
+
+func NewSessionsRepo(db *sqlx.DB) *SessionsRepo {
+	return &SessionsRepo{db: db}
+}
+
+func (r *SessionsRepo) Create(ctx context.Context, session domain.Session, token domain.RefreshToken) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO sessions (id, user_id, device, ip, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		session.ID, session.UserID, session.Device, session.IP, session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertRefreshToken(ctx, tx, token); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SessionsRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.Session, error) {
+	var session domain.Session
+
+	err := r.db.GetContext(ctx, &session,
+		`SELECT id, user_id, device, ip, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions WHERE id = $1`, id)
+	if err != nil {
+		return domain.Session{}, wrapNotFound(err)
+	}
+
+	return session, nil
+}
+
+func (r *SessionsRepo) GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]domain.Session, error) {
+	sessions := make([]domain.Session, 0)
+
+	err := r.db.SelectContext(ctx, &sessions,
+		`SELECT id, user_id, device, ip, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now()
+		ORDER BY last_seen_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionsRepo) GetRefreshToken(ctx context.Context, tokenHash string) (domain.RefreshToken, error) {
+	var token domain.RefreshToken
+
+	err := r.db.GetContext(ctx, &token,
+		`SELECT token_hash, session_id, expires_at, used_at, created_at FROM refresh_tokens WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		return domain.RefreshToken{}, wrapNotFound(err)
+	}
+
+	return token, nil
+}
+
+func (r *SessionsRepo) Rotate(ctx context.Context, usedHash string, session domain.Session, next domain.RefreshToken) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE refresh_tokens SET used_at = $2 WHERE token_hash = $1 AND used_at IS NULL`,
+		usedHash, session.LastSeenAt)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		return domain.ErrRefreshTokenReused
+	}
+
+	res, err = tx.ExecContext(ctx,
+		`UPDATE sessions SET device = $2, ip = $3, last_seen_at = $4, expires_at = $5
+		WHERE id = $1 AND revoked_at IS NULL`,
+		session.ID, session.Device, session.IP, session.LastSeenAt, session.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SessionsRepo) Revoke(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+
+	return err
+}
+
+func insertRefreshToken(ctx context.Context, tx *sqlx.Tx, token domain.RefreshToken) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO refresh_tokens (token_hash, session_id, expires_at, created_at) VALUES ($1, $2, $3, $4)`,
+		token.Hash, token.SessionID, token.ExpiresAt, token.CreatedAt)
+
+	return err
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type StatsRepo struct {
+	db *sqlx.DB
+}
+
+func NewStatsRepo(db *sqlx.DB) *StatsRepo {
+	return &StatsRepo{db: db}
+}
+
+func (r *StatsRepo) GetByUser(ctx context.Context, userID uuid.UUID) (domain.Stats, error) {
+	var stats domain.Stats
+
+	err := r.db.GetContext(ctx, &stats,
+		`SELECT user_id, neuro_mean, crypto_data, stats_data, analysis, api_info, full_api_info
+		FROM user_stats WHERE user_id = $1`, userID)
+	if err != nil {
+		return domain.Stats{}, wrapNotFound(err)
+	}
+
+	return stats, nil
+}
+
+// statsQueries select the flows of each metric of the user $1 between $4
+// and $5, as columns bucket, currency, category and amount. The bucket is
+// the local start of the date_trunc field $2 in the time zone $3.
+var statsQueries = map[domain.StatsMetric]string{
+	domain.StatsSpending: `SELECT date_trunc($2, e.created_at AT TIME ZONE $3) AS bucket, p.currency,
+			CASE WHEN p.target_type = 'fine' THEN '` + domain.StatsCategoryFines + `' ELSE p.merchant_id END AS category,
+			p.amount
+		FROM journal_entries e
+		JOIN payments p ON p.id = e.payment_id
+		WHERE p.user_id = $1 AND e.kind = 'settlement' AND e.created_at >= $4 AND e.created_at < $5`,
+	domain.StatsIncome: `SELECT date_trunc($2, r.updated_at AT TIME ZONE $3) AS bucket, r.currency,
+			CASE WHEN p.target_type = 'fine' THEN '` + domain.StatsCategoryFines + `' ELSE p.merchant_id END AS category,
+			r.amount
+		FROM refunds r
+		JOIN payments p ON p.id = r.payment_id
+		WHERE r.user_id = $1 AND r.status = 'succeeded' AND r.updated_at >= $4 AND r.updated_at < $5`,
+	domain.StatsFines: `SELECT date_trunc($2, f.issued_at AT TIME ZONE $3) AS bucket, 'RUB' AS currency,
+			f.issuer AS category, f.amount
+		FROM fines f
+		WHERE f.user_id = $1 AND f.status <> 'cancelled' AND f.issued_at >= $4 AND f.issued_at < $5`,
+}
+
+func (r *StatsRepo) Aggregate(ctx context.Context, userID uuid.UUID, query domain.StatsQuery) ([]domain.StatsRow, error) {
+	flows, ok := statsQueries[query.Metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown stats metric %q", query.Metric)
+	}
+
+	rows := make([]domain.StatsRow, 0)
+
+	err := r.db.SelectContext(ctx, &rows,
+		`SELECT bucket, currency, category, sum(amount) AS amount, count(*) AS count
+		FROM (`+flows+`) AS flows
+		GROUP BY bucket, currency, category
+		ORDER BY bucket, currency, category`,
+		userID, string(query.Bucket), query.Location.String(), query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
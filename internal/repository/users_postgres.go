@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type UsersRepo struct {
+	db *sqlx.DB
+}
+
+func NewUsersRepo(db *sqlx.DB) *UsersRepo {
+	return &UsersRepo{db: db}
+}
+
+func (r *UsersRepo) Create(ctx context.Context, user domain.User) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO users (id, name, email, phone, password_hash, role, registered_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		user.ID, user.Name, user.Email, user.Phone, user.Password, user.Role, user.RegisteredAt)
+	if isUniqueViolation(err) {
+		return domain.ErrUserAlreadyExists
+	}
+
+	return err
+}
+
+func (r *UsersRepo) GetByID(ctx context.Context, id uuid.UUID) (domain.User, error) {
+	var user domain.User
+
+	err := r.db.GetContext(ctx, &user,
+		`SELECT id, name, email, phone, role, registered_at, totp_secret, totp_enabled, totp_counter
+		FROM users WHERE id = $1`, id)
+	if err != nil {
+		return domain.User{}, wrapNotFound(err)
+	}
+
+	return user, nil
+}
+
+func (r *UsersRepo) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	var user domain.User
+
+	err := r.db.GetContext(ctx, &user,
+		`SELECT id, name, email, phone, password_hash, role, registered_at, totp_secret, totp_enabled, totp_counter
+		FROM users WHERE email = $1`, email)
+	if err != nil {
+		return domain.User{}, wrapNotFound(err)
+	}
+
+	return user, nil
+}
+
+func (r *UsersRepo) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET password_hash = $1 WHERE id = $2`, passwordHash, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *UsersRepo) SetTOTPSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET totp_secret = $1, totp_enabled = false, totp_counter = 0 WHERE id = $2`, secret, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *UsersRepo) EnableTOTP(ctx context.Context, id uuid.UUID, counter int64) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET totp_enabled = true, totp_counter = $1 WHERE id = $2`, counter, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *UsersRepo) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET totp_secret = '', totp_enabled = false, totp_counter = 0 WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+func (r *UsersRepo) UseTOTPCounter(ctx context.Context, id uuid.UUID, counter int64) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET totp_counter = $1 WHERE id = $2 AND totp_counter < $1`, counter, id)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrInvalidOTP
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"backend-vtb/internal/domain"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const webhookEventColumns = `id, provider, event_id, event_type, payment_id, payload, signature, sent_at, received_at,
+	processed_at, outcome, detail`
+
+type WebhooksRepo struct {
+	db *sqlx.DB
+}
+
+func NewWebhooksRepo(db *sqlx.DB) *WebhooksRepo {
+	return &WebhooksRepo{db: db}
+}
+
+func (r *WebhooksRepo) Create(ctx context.Context, event domain.WebhookEvent) error {
+	_, err := r.db.NamedExecContext(ctx,
+		`INSERT INTO webhook_events (`+webhookEventColumns+`)
+		VALUES (:id, :provider, :event_id, :event_type, :payment_id, :payload, :signature, :sent_at, :received_at,
+			:processed_at, :outcome, :detail)`, event)
+	if isUniqueViolation(err) {
+		return domain.ErrWebhookEventExists
+	}
+
+	return err
+}
+
+func (r *WebhooksRepo) GetByEventID(ctx context.Context, provider, eventID string) (domain.WebhookEvent, error) {
+	var event domain.WebhookEvent
+
+	err := r.db.GetContext(ctx, &event,
+		`SELECT `+webhookEventColumns+` FROM webhook_events WHERE provider = $1 AND event_id = $2`, provider, eventID)
+	if err != nil {
+		return domain.WebhookEvent{}, wrapNotFound(err)
+	}
+
+	return event, nil
+}
+
+func (r *WebhooksRepo) Complete(ctx context.Context, id uuid.UUID, outcome domain.WebhookOutcome, detail string, processedAt time.Time) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_events SET outcome = $2, detail = $3, processed_at = $4 WHERE id = $1`,
+		id, outcome, detail, processedAt)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/money"
+	"backend-vtb/pkg/pricefeed"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultAlertCooldown is used when AlertsConfig.Cooldown is not set.
+	defaultAlertCooldown = time.Hour
+	// defaultMaxAlertRules is used when AlertsConfig.MaxRules is not set.
+	defaultMaxAlertRules = 20
+)
+
+type AlertsService struct {
+	repos    *repository.Repository
+	base     Base
+	prices   pricefeed.Source
+	notifier Notifier
+	cfg      AlertsConfig
+	logger   *slog.Logger
+}
+
+func NewAlertsService(repos *repository.Repository, base Base, prices pricefeed.Source, notifier Notifier,
+	cfg AlertsConfig, logger *slog.Logger,
+) *AlertsService {
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaultAlertCooldown
+	}
+
+	if cfg.MaxRules <= 0 {
+		cfg.MaxRules = defaultMaxAlertRules
+	}
+
+	return &AlertsService{
+		repos:    repos,
+		base:     base,
+		prices:   prices,
+		notifier: notifier,
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+func (s *AlertsService) List(ctx context.Context, userID uuid.UUID) ([]domain.AlertRule, error) {
+	return s.repos.Alerts.GetByUser(ctx, userID)
+}
+
+func (s *AlertsService) Create(ctx context.Context, userID uuid.UUID, input AlertRuleInput) (domain.AlertRule, error) {
+	now := time.Now()
+	rule := domain.AlertRule{
+		ID:              uuid.New(),
+		UserID:          userID,
+		Subject:         input.Subject,
+		Asset:           strings.ToUpper(strings.TrimSpace(input.Asset)),
+		Condition:       input.Condition,
+		WindowMinutes:   input.WindowMinutes,
+		CooldownMinutes: int(s.cfg.Cooldown / time.Minute),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if input.CooldownMinutes != nil {
+		rule.CooldownMinutes = *input.CooldownMinutes
+	}
+
+	if err := rule.Validate(); err != nil {
+		return domain.AlertRule{}, err
+	}
+
+	if rule.Subject == domain.AlertPrice {
+		if _, err := s.prices.Price(ctx, rule.Asset); err != nil {
+			if errors.Is(err, pricefeed.ErrUnknownSymbol) {
+				return domain.AlertRule{}, fmt.Errorf("%w: %q", domain.ErrUnknownAsset, rule.Asset)
+			}
+
+			return domain.AlertRule{}, err
+		}
+	}
+
+	threshold, err := alertThreshold(rule.Subject, rule.Condition, input.Threshold)
+	if err != nil {
+		return domain.AlertRule{}, err
+	}
+
+	rule.Threshold = decimalString(threshold)
+
+	if err := s.repos.Alerts.Create(ctx, rule, s.cfg.MaxRules); err != nil {
+		return domain.AlertRule{}, err
+	}
+
+	return rule, nil
+}
+
+func (s *AlertsService) Delete(ctx context.Context, userID, ruleID uuid.UUID) error {
+	rule, err := s.repos.Alerts.GetByID(ctx, ruleID)
+	if err != nil {
+		return err
+	}
+
+	if rule.UserID != userID {
+		return domain.ErrNotFound
+	}
+
+	return s.repos.Alerts.Delete(ctx, ruleID)
+}
+
+func (s *AlertsService) Evaluate(ctx context.Context, now time.Time) (int, error) {
+	rules, err := s.repos.Alerts.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	values := alertValues{
+		prices:   make(map[string]*big.Rat),
+		balances: make(map[uuid.UUID]money.Money),
+	}
+
+	var fired int
+	for _, rule := range rules {
+		notification, err := s.evaluate(ctx, rule, now, values)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fired, ctx.Err()
+			}
+
+			// The rule is evaluated again by the next call.
+			s.logger.Error("failed to evaluate alert rule",
+				slog.String("rule", rule.ID.String()), slog.String("reason", err.Error()))
+
+			continue
+		}
+
+		if notification == nil {
+			continue
+		}
+
+		fired++
+
+		// The rule has fired for good at this point: a notification that
+		// fails to be delivered is not retried, so that no evaluation can
+		// deliver it twice.
+		if err := s.notifier.Notify(ctx, *notification); err != nil {
+			s.logger.Error("failed to deliver alert",
+				slog.String("rule", rule.ID.String()), slog.String("reason", err.Error()))
+		}
+	}
+
+	return fired, nil
+}
+
+// alertValues caches the watched values during one evaluation, so that
+// rules on the same asset or user see the same value.
+type alertValues struct {
+	prices   map[string]*big.Rat
+	balances map[uuid.UUID]money.Money
+}
+
+// evaluate checks the condition of the rule and stores whether it holds if
+// that changed. It returns the notification to deliver if the rule fired.
+func (s *AlertsService) evaluate(ctx context.Context, rule domain.AlertRule, now time.Time,
+	values alertValues,
+) (*domain.Notification, error) {
+	threshold, ok := new(big.Rat).SetString(rule.Threshold)
+	if !ok {
+		return nil, fmt.Errorf("invalid threshold %q", rule.Threshold)
+	}
+
+	var (
+		holds   bool
+		message string
+		err     error
+	)
+
+	switch rule.Subject {
+	case domain.AlertPrice:
+		holds, message, err = s.checkPrice(ctx, rule, threshold, now, values)
+	case domain.AlertBalance:
+		holds, message, err = s.checkBalance(ctx, rule, threshold, values)
+	default:
+		err = fmt.Errorf("unknown subject %q", rule.Subject)
+	}
+
+	if err != nil || holds == rule.Triggered {
+		return nil, err
+	}
+
+	loadedAt := rule.UpdatedAt
+	fire := holds && rule.CanFire(now)
+
+	rule.Triggered = holds
+	rule.UpdatedAt = now
+	if fire {
+		rule.FiredAt = &now
+	}
+
+	// Another evaluator stored the change first, or the user deleted the rule.
+	if err := s.repos.Alerts.Update(ctx, rule, loadedAt); errors.Is(err, domain.ErrAlertChanged) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !fire {
+		return nil, nil
+	}
+
+	s.logger.Info("alert rule fired", slog.String("rule", rule.ID.String()), slog.String("message", message))
+
+	return &domain.Notification{
+		ID:        uuid.New(),
+		UserID:    rule.UserID,
+		Kind:      domain.NotificationAlert,
+		SubjectID: &rule.ID,
+		Message:   message,
+		CreatedAt: now,
+	}, nil
+}
+
+// checkPrice reports whether the condition of the price rule holds, and
+// describes the price for the notification.
+func (s *AlertsService) checkPrice(ctx context.Context, rule domain.AlertRule, threshold *big.Rat, now time.Time,
+	values alertValues,
+) (bool, string, error) {
+	price, ok := values.prices[rule.Asset]
+	if !ok {
+		quote, err := s.prices.Price(ctx, rule.Asset)
+		if err != nil {
+			return false, "", err
+		}
+
+		price = quote.Price
+		values.prices[rule.Asset] = price
+	}
+
+	currency := s.prices.Currency()
+	current := decimalString(price) + " " + currency
+
+	switch rule.Condition {
+	case domain.AlertAbove:
+		return price.Cmp(threshold) > 0,
+			fmt.Sprintf("%s is above %s %s: %s.", rule.Asset, rule.Threshold, currency, current), nil
+	case domain.AlertBelow:
+		return price.Cmp(threshold) < 0,
+			fmt.Sprintf("%s is below %s %s: %s.", rule.Asset, rule.Threshold, currency, current), nil
+	}
+
+	// The price at the start of the window is the close of the last minute
+	// candle opened before it; without one the change is unknown.
+	start, err := s.repos.Candles.GetLastBefore(ctx, rule.Asset, domain.CandleMinute, now.Add(-rule.Window()))
+	if errors.Is(err, domain.ErrNotFound) {
+		return false, "", nil
+	}
+
+	if err != nil {
+		return false, "", err
+	}
+
+	before, ok := new(big.Rat).SetString(start.Close)
+	if !ok || before.Sign() <= 0 {
+		return false, "", fmt.Errorf("invalid close price %q", start.Close)
+	}
+
+	change := new(big.Rat).Sub(price, before)
+	change.Mul(change.Quo(change, before), big.NewRat(100, 1))
+
+	holds := change.Cmp(threshold) >= 0
+	direction := "rose"
+	if threshold.Sign() < 0 {
+		holds = change.Cmp(threshold) <= 0
+		direction = "fell"
+	}
+
+	return holds, fmt.Sprintf("%s %s %s%% over %d minutes to %s.",
+		rule.Asset, direction, new(big.Rat).Abs(change).FloatString(2), rule.WindowMinutes, current), nil
+}
+
+// checkBalance reports whether the condition of the balance rule holds, and
+// describes the amount owed for the notification.
+func (s *AlertsService) checkBalance(ctx context.Context, rule domain.AlertRule, threshold *big.Rat,
+	values alertValues,
+) (bool, string, error) {
+	amount, ok := values.balances[rule.UserID]
+	if !ok {
+		var err error
+		if amount, err = s.base.GetAmount(ctx, rule.UserID); err != nil {
+			return false, "", err
+		}
+
+		values.balances[rule.UserID] = amount
+	}
+
+	limit := money.New(threshold.Num().Int64(), amount.Currency)
+	owed := new(big.Rat).SetInt64(amount.Amount)
+
+	switch rule.Condition {
+	case domain.AlertAbove:
+		return owed.Cmp(threshold) > 0,
+			fmt.Sprintf("You owe more than %s on fines: %s.", limit, amount), nil
+	case domain.AlertBelow:
+		return owed.Cmp(threshold) < 0,
+			fmt.Sprintf("You owe less than %s on fines: %s.", limit, amount), nil
+	default:
+		return false, "", fmt.Errorf("unknown condition %q", rule.Condition)
+	}
+}
+
+// alertThreshold parses the threshold of a rule: a positive price, an
+// amount of kopecks or a nonzero percent a price cannot fall below -100.
+func alertThreshold(subject domain.AlertSubject, condition domain.AlertCondition, value string) (*big.Rat, error) {
+	// Fractions such as 1/3 would parse but cannot be stored as decimals.
+	threshold, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || strings.Contains(value, "/") {
+		return nil, fmt.Errorf("%w: invalid threshold %q", domain.ErrAlertRule, value)
+	}
+
+	switch {
+	case condition == domain.AlertChange:
+		if threshold.Sign() == 0 || threshold.Cmp(big.NewRat(-100, 1)) <= 0 {
+			return nil, fmt.Errorf("%w: a change must be a nonzero percent above -100", domain.ErrAlertRule)
+		}
+	case subject == domain.AlertBalance:
+		if !threshold.IsInt() || threshold.Sign() < 0 || !threshold.Num().IsInt64() {
+			return nil, fmt.Errorf("%w: a balance threshold must be a whole number of kopecks", domain.ErrAlertRule)
+		}
+	default:
+		if threshold.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: a price threshold must be positive", domain.ErrAlertRule)
+		}
+	}
+
+	return threshold, nil
+}
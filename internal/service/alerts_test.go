@@ -0,0 +1,254 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/internal/repository/memory"
+	"backend-vtb/pkg/money"
+	"backend-vtb/pkg/pricefeed"
+	"context"
+	"io"
+	"log/slog"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// alertPrices quotes prices set by the test.
+type alertPrices map[string]*big.Rat
+
+func (p alertPrices) Currency() string {
+	return "USD"
+}
+
+func (p alertPrices) Symbols() []string {
+	symbols := make([]string, 0, len(p))
+	for symbol := range p {
+		symbols = append(symbols, symbol)
+	}
+
+	sort.Strings(symbols)
+
+	return symbols
+}
+
+func (p alertPrices) Price(_ context.Context, symbol string) (pricefeed.Quote, error) {
+	price, ok := p[symbol]
+	if !ok {
+		return pricefeed.Quote{}, pricefeed.ErrUnknownSymbol
+	}
+
+	return pricefeed.Quote{Symbol: symbol, Currency: "USD", Price: price, At: time.Now()}, nil
+}
+
+// alertBalance reports a fixed amount owed by every user.
+type alertBalance struct {
+	Base
+	amount int64
+}
+
+func (b *alertBalance) GetAmount(context.Context, uuid.UUID) (money.Money, error) {
+	return money.New(b.amount, "RUB"), nil
+}
+
+// alertNotifier records the notifications delivered.
+type alertNotifier struct {
+	notifications []domain.Notification
+}
+
+func (n *alertNotifier) Notify(_ context.Context, notification domain.Notification) error {
+	n.notifications = append(n.notifications, notification)
+
+	return nil
+}
+
+func newAlertsTestService(prices alertPrices, balance *alertBalance) (*AlertsService, *repository.Repository, *alertNotifier) {
+	repos := memory.NewRepository()
+	notifier := &alertNotifier{}
+
+	return NewAlertsService(repos, balance, prices, notifier, AlertsConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil))),
+		repos, notifier
+}
+
+// alertStep sets the watched values, evaluates the rules at the offset from
+// the start and expects the rules to fire or not.
+type alertStep struct {
+	name   string
+	offset time.Duration
+	value  int64
+	fired  int
+}
+
+func TestAlertsEvaluateThreshold(t *testing.T) {
+	prices := alertPrices{"BTC": big.NewRat(90, 1)}
+	s, repos, notifier := newAlertsTestService(prices, &alertBalance{})
+
+	cooldown := 10
+	rule, err := s.Create(context.Background(), uuid.New(), AlertRuleInput{
+		Subject:         domain.AlertPrice,
+		Asset:           "btc",
+		Condition:       domain.AlertAbove,
+		Threshold:       "100",
+		CooldownMinutes: &cooldown,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, tc := range []alertStep{
+		{"below the threshold", 0, 90, 0},
+		{"at the threshold", time.Minute, 100, 0},
+		{"crossed", 2 * time.Minute, 110, 1},
+		{"still above", 3 * time.Minute, 120, 0},
+		{"back below", 4 * time.Minute, 90, 0},
+		{"crossed during the cooldown", 5 * time.Minute, 110, 0},
+		{"above after the cooldown", 12 * time.Minute, 110, 0},
+		{"back below after the cooldown", 13 * time.Minute, 90, 0},
+		{"crossed after the cooldown", 14 * time.Minute, 110, 1},
+		{"back below again", 15 * time.Minute, 90, 0},
+		{"crossed as the cooldown ends", 24 * time.Minute, 110, 1},
+	} {
+		prices["BTC"] = big.NewRat(tc.value, 1)
+
+		fired, err := s.Evaluate(context.Background(), start.Add(tc.offset))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if fired != tc.fired {
+			t.Errorf("%s: %d alerts fired, want %d", tc.name, fired, tc.fired)
+		}
+	}
+
+	stored, err := repos.Alerts.GetByID(context.Background(), rule.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if firedAt := start.Add(24 * time.Minute); !stored.Triggered || stored.FiredAt == nil || !stored.FiredAt.Equal(firedAt) {
+		t.Errorf("rule triggered %v, fired at %v; want triggered, fired at %v", stored.Triggered, stored.FiredAt, firedAt)
+	}
+
+	if len(notifier.notifications) != 3 {
+		t.Fatalf("%d notifications, want 3", len(notifier.notifications))
+	}
+
+	notification := notifier.notifications[0]
+	if notification.UserID != rule.UserID || notification.Kind != domain.NotificationAlert ||
+		notification.SubjectID == nil || *notification.SubjectID != rule.ID ||
+		notification.Message != "BTC is above 100 USD: 110 USD." {
+		t.Errorf("notification %+v, want an alert of the rule", notification)
+	}
+}
+
+func TestAlertsEvaluateBalance(t *testing.T) {
+	balance := &alertBalance{}
+	s, _, notifier := newAlertsTestService(alertPrices{}, balance)
+
+	cooldown := 0
+	if _, err := s.Create(context.Background(), uuid.New(), AlertRuleInput{
+		Subject:         domain.AlertBalance,
+		Condition:       domain.AlertAbove,
+		Threshold:       "50000",
+		CooldownMinutes: &cooldown,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	// Without a cooldown the rule fires each time the amount crosses.
+	for _, tc := range []alertStep{
+		{"nothing owed", 0, 0, 0},
+		{"crossed", time.Minute, 50001, 1},
+		{"owing more", 2 * time.Minute, 100000, 0},
+		{"paid", 3 * time.Minute, 0, 0},
+		{"crossed again", 3 * time.Minute, 60000, 1},
+	} {
+		balance.amount = tc.value
+
+		fired, err := s.Evaluate(context.Background(), start.Add(tc.offset))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if fired != tc.fired {
+			t.Errorf("%s: %d alerts fired, want %d", tc.name, fired, tc.fired)
+		}
+	}
+
+	if len(notifier.notifications) != 2 {
+		t.Errorf("%d notifications, want 2", len(notifier.notifications))
+	}
+}
+
+func TestAlertsEvaluateChange(t *testing.T) {
+	prices := alertPrices{"ETH": big.NewRat(100, 1)}
+	s, repos, notifier := newAlertsTestService(prices, &alertBalance{})
+
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	userID := uuid.New()
+	for _, threshold := range []string{"10", "-10"} {
+		cooldown := 0
+		if _, err := s.Create(context.Background(), userID, AlertRuleInput{
+			Subject:         domain.AlertPrice,
+			Asset:           "ETH",
+			Condition:       domain.AlertChange,
+			Threshold:       threshold,
+			WindowMinutes:   60,
+			CooldownMinutes: &cooldown,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without a candle before the window the change is unknown.
+	prices["ETH"] = big.NewRat(200, 1)
+	if fired, err := s.Evaluate(context.Background(), start); err != nil || fired != 0 {
+		t.Fatalf("no price history: %d alerts fired, error %v; want none", fired, err)
+	}
+
+	if err := repos.Candles.Merge(context.Background(), []domain.Candle{{
+		Symbol:   "ETH",
+		Interval: domain.CandleMinute,
+		Currency: "USD",
+		OpenTime: start.Add(-2 * time.Hour),
+		Open:     "100", High: "100", Low: "100", Close: "100", Volume: "0",
+		Ticks:   1,
+		FirstAt: start.Add(-2 * time.Hour),
+		LastAt:  start.Add(-2 * time.Hour),
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []alertStep{
+		{"unchanged", time.Minute, 100, 0},
+		{"rose less", 2 * time.Minute, 109, 0},
+		{"rose by the threshold", 3 * time.Minute, 110, 1},
+		{"rose more", 4 * time.Minute, 150, 0},
+		{"fell by the threshold", 5 * time.Minute, 90, 1},
+		{"fell more", 6 * time.Minute, 50, 0},
+		{"recovered", 7 * time.Minute, 100, 0},
+	} {
+		prices["ETH"] = big.NewRat(tc.value, 1)
+
+		fired, err := s.Evaluate(context.Background(), start.Add(tc.offset))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if fired != tc.fired {
+			t.Errorf("%s: %d alerts fired, want %d", tc.name, fired, tc.fired)
+		}
+	}
+
+	if len(notifier.notifications) != 2 || notifier.notifications[1].Message != "ETH fell 10.00% over 60 minutes to 90 USD." {
+		t.Errorf("notifications %+v, want a rise and a fall", notifier.notifications)
+	}
+}
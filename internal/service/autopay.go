@@ -0,0 +1,293 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/gateway"
+	"backend-vtb/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// autopayTimeLayout is how the time of a charge is shown in notifications.
+const autopayTimeLayout = "2006-01-02 15:04 MST"
+
+type AutopayService struct {
+	repos   *repository.Repository
+	rules   domain.FineRules
+	gateway gateway.PaymentGateway
+	cfg     AutopayConfig
+	logger  *slog.Logger
+}
+
+func NewAutopayService(repos *repository.Repository, rules domain.FineRules, gateway gateway.PaymentGateway,
+	cfg AutopayConfig, logger *slog.Logger,
+) *AutopayService {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+
+	return &AutopayService{
+		repos:   repos,
+		rules:   rules,
+		gateway: gateway,
+		cfg:     cfg,
+		logger:  logger,
+	}
+}
+
+func (s *AutopayService) Rule(ctx context.Context, userID uuid.UUID) (domain.AutopayRule, error) {
+	rule, err := s.repos.Autopay.GetRule(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.AutopayRule{UserID: userID}, nil
+	}
+
+	return rule, err
+}
+
+func (s *AutopayService) SetRule(ctx context.Context, userID uuid.UUID, input AutopayRuleInput) (domain.AutopayRule, error) {
+	if input.MaxPerFine < 0 || input.MonthlyLimit < 0 {
+		return domain.AutopayRule{}, fmt.Errorf("%w: limits must not be negative", domain.ErrAutopayRule)
+	}
+
+	if input.Enabled && (input.MaxPerFine == 0 || input.MonthlyLimit == 0) {
+		return domain.AutopayRule{}, fmt.Errorf("%w: limits must be positive", domain.ErrAutopayRule)
+	}
+
+	if input.MaxPerFine > input.MonthlyLimit {
+		return domain.AutopayRule{}, fmt.Errorf("%w: the limit per fine exceeds the monthly limit", domain.ErrAutopayRule)
+	}
+
+	rule, err := s.Rule(ctx, userID)
+	if err != nil {
+		return domain.AutopayRule{}, err
+	}
+
+	if input.Enabled && input.Card == nil && rule.CardToken == "" {
+		return domain.AutopayRule{}, fmt.Errorf("%w: a card is required", domain.ErrAutopayRule)
+	}
+
+	if input.Card != nil {
+		saved, err := s.gateway.SaveCard(ctx, *input.Card)
+		if err != nil {
+			return domain.AutopayRule{}, gatewayError(err)
+		}
+
+		rule.CardToken = saved.Token
+		rule.CardLast4 = saved.Last4
+	}
+
+	now := time.Now()
+	rule.Enabled = input.Enabled
+	rule.MaxPerFine = input.MaxPerFine
+	rule.MonthlyLimit = input.MonthlyLimit
+	rule.UpdatedAt = now
+
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = now
+	}
+
+	if err := s.repos.Autopay.SaveRule(ctx, rule); err != nil {
+		return domain.AutopayRule{}, err
+	}
+
+	return rule, nil
+}
+
+func (s *AutopayService) Charges(ctx context.Context, userID uuid.UUID) ([]domain.AutopayCharge, error) {
+	return s.repos.Autopay.GetCharges(ctx, userID)
+}
+
+func (s *AutopayService) Cancel(ctx context.Context, userID, chargeID uuid.UUID) (domain.AutopayCharge, error) {
+	charge, err := s.repos.Autopay.GetCharge(ctx, chargeID)
+	if err != nil {
+		return domain.AutopayCharge{}, err
+	}
+
+	// Do not reveal that a charge of another user exists.
+	if charge.UserID != userID {
+		return domain.AutopayCharge{}, domain.ErrNotFound
+	}
+
+	if charge.Status != domain.AutopayScheduled {
+		return domain.AutopayCharge{}, domain.ErrAutopayState
+	}
+
+	now := time.Now()
+
+	// The schedule is cancelled first, so that a charge reported as
+	// cancelled is never made.
+	if charge.ScheduleID != nil {
+		if err := s.cancelSchedule(ctx, *charge.ScheduleID, now); err != nil {
+			return domain.AutopayCharge{}, err
+		}
+	}
+
+	err = s.repos.Autopay.UpdateChargeStatus(ctx, charge.ID, domain.AutopayScheduled, domain.AutopayCancelled, now)
+	if err != nil {
+		return domain.AutopayCharge{}, err
+	}
+
+	charge.Status = domain.AutopayCancelled
+	charge.UpdatedAt = now
+
+	return charge, nil
+}
+
+// cancelSchedule stops the schedule paying a charge, unless its cancellation
+// window is over.
+func (s *AutopayService) cancelSchedule(ctx context.Context, scheduleID uuid.UUID, now time.Time) error {
+	schedule, err := s.repos.Schedules.GetByID(ctx, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	switch schedule.Status {
+	case domain.ScheduleCancelled:
+		// The user cancelled the schedule itself, or an earlier attempt to
+		// cancel the charge got this far.
+		return nil
+	case domain.ScheduleActive, domain.SchedulePaused:
+		// The executor may be paying the fine right now.
+		if schedule.NextRunAt == nil || !schedule.NextRunAt.After(now) {
+			return domain.ErrAutopayState
+		}
+	default:
+		return domain.ErrAutopayState
+	}
+
+	loadedAt := schedule.UpdatedAt
+	schedule.Status = domain.ScheduleCancelled
+	schedule.NextRunAt = nil
+	schedule.UpdatedAt = now
+
+	return s.repos.Schedules.Update(ctx, schedule, loadedAt)
+}
+
+func (s *AutopayService) Plan(ctx context.Context, fine domain.Fine) error {
+	rule, err := s.repos.Autopay.GetRule(ctx, fine.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil || !rule.Enabled {
+		return err
+	}
+
+	// The user has the notice period from now to cancel, or from the issue
+	// date if it is still ahead, since there is no discount before it.
+	now := time.Now()
+	chargeAt := now
+	if fine.IssuedAt.After(chargeAt) {
+		chargeAt = fine.IssuedAt
+	}
+	chargeAt = chargeAt.Add(s.cfg.Notice).In(s.cfg.Location)
+
+	fineCharge := fine.Charge(chargeAt, s.rules)
+	year, month, _ := chargeAt.Date()
+
+	charge := domain.AutopayCharge{
+		ID:        uuid.New(),
+		UserID:    fine.UserID,
+		FineID:    fine.ID,
+		Amount:    fineCharge.Total,
+		ChargeAt:  chargeAt,
+		Month:     time.Date(year, month, 1, 0, 0, 0, 0, s.cfg.Location),
+		Status:    domain.AutopayScheduled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	switch {
+	case fineCharge.State != domain.FineStateDiscounted:
+		charge.Status, charge.Reason = domain.AutopayDeclined, domain.AutopayNoDiscount
+	case charge.Amount > rule.MaxPerFine:
+		charge.Status, charge.Reason = domain.AutopayDeclined, domain.AutopayFineLimit
+	}
+
+	var schedule *domain.Schedule
+	if charge.Status == domain.AutopayScheduled {
+		schedule = autopaySchedule(fine, rule, chargeAt, now)
+		charge.ScheduleID = &schedule.ID
+	}
+
+	err = s.repos.Autopay.CreateCharge(ctx, charge, schedule)
+	if errors.Is(err, domain.ErrAutopayLimit) {
+		charge.Status, charge.Reason, charge.ScheduleID = domain.AutopayDeclined, domain.AutopayMonthlyLimit, nil
+		err = s.repos.Autopay.CreateCharge(ctx, charge, nil)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("fine autopay planned",
+		slog.String("fine", fine.ID.String()), slog.String("user", fine.UserID.String()),
+		slog.String("status", string(charge.Status)), slog.String("reason", charge.Reason),
+		slog.Time("charge_at", chargeAt))
+
+	return s.repos.Notifications.Create(ctx, autopayNotification(fine, rule, charge))
+}
+
+// autopaySchedule returns the one-off schedule paying the fine at chargeAt.
+func autopaySchedule(fine domain.Fine, rule domain.AutopayRule, chargeAt, now time.Time) *domain.Schedule {
+	fineID := fine.ID
+
+	return &domain.Schedule{
+		ID:           uuid.New(),
+		UserID:       fine.UserID,
+		ScheduleRule: domain.ScheduleRule{Kind: domain.ScheduleOnce},
+		Currency:     defaultCurrency,
+		FineID:       &fineID,
+		CardToken:    rule.CardToken,
+		CardLast4:    rule.CardLast4,
+		StartsAt:     chargeAt,
+		// Paying later than this would no longer get the discount.
+		EndsAt:    fine.DiscountUntil,
+		Status:    domain.ScheduleActive,
+		NextRunAt: &chargeAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// autopayNotification tells the user what autopay is going to do about the fine.
+func autopayNotification(fine domain.Fine, rule domain.AutopayRule, charge domain.AutopayCharge) domain.Notification {
+	notification := domain.Notification{
+		ID:        uuid.New(),
+		UserID:    fine.UserID,
+		Kind:      domain.NotificationAutopayDeclined,
+		SubjectID: &charge.FineID,
+		CreatedAt: charge.CreatedAt,
+	}
+
+	subject := "The fine from " + fine.Issuer
+	switch charge.Reason {
+	case domain.AutopayNoDiscount:
+		notification.Message = subject + " will not be paid automatically: its discount ends before the charge could be made."
+	case domain.AutopayFineLimit:
+		notification.Message = fmt.Sprintf("%s will not be paid automatically: %s exceeds your limit of %s per fine.",
+			subject, formatRubles(charge.Amount), formatRubles(rule.MaxPerFine))
+	case domain.AutopayMonthlyLimit:
+		notification.Message = fmt.Sprintf("%s will not be paid automatically: it would exceed your monthly limit of %s.",
+			subject, formatRubles(rule.MonthlyLimit))
+	default:
+		notification.Kind = domain.NotificationAutopayScheduled
+		notification.Message = fmt.Sprintf(
+			"%s will be paid automatically with the discount: %s from the card ending in %s on %s. "+
+				"Cancel the autopay before then to pay it yourself.",
+			subject, formatRubles(charge.Amount), rule.CardLast4, charge.ChargeAt.Format(autopayTimeLayout))
+	}
+
+	return notification
+}
+
+// formatRubles formats an amount in kopecks.
+func formatRubles(amount int64) string {
+	return money.New(amount, defaultCurrency).String()
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/internal/repository/memory"
+	"backend-vtb/pkg/gateway"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const autopayTestNotice = 24 * time.Hour
+
+var autopayTestRules = domain.FineRules{DiscountPercent: 50, DiscountDays: 20, PaymentDays: 70}
+
+func newAutopayTestService() (*AutopayService, *repository.Repository) {
+	repos := memory.NewRepository()
+
+	return NewAutopayService(repos, autopayTestRules, gateway.NewSimulator(gateway.SimulatorConfig{}),
+		AutopayConfig{Notice: autopayTestNotice}, slog.New(slog.NewTextHandler(io.Discard, nil))), repos
+}
+
+func autopayTestCard(number string, year int) *gateway.Card {
+	return &gateway.Card{Number: number, ExpMonth: 12, ExpYear: year, CVC: "123"}
+}
+
+func TestAutopaySetRule(t *testing.T) {
+	s, _ := newAutopayTestService()
+	userID := uuid.New()
+
+	nextYear := time.Now().Year() + 1
+	card := autopayTestCard("4242424242424242", nextYear)
+
+	for _, tc := range []struct {
+		name  string
+		input AutopayRuleInput
+		err   error
+		last4 string
+	}{
+		{"negative limit per fine", AutopayRuleInput{MaxPerFine: -1, MonthlyLimit: 100}, domain.ErrAutopayRule, ""},
+		{"negative monthly limit", AutopayRuleInput{MaxPerFine: 0, MonthlyLimit: -1}, domain.ErrAutopayRule, ""},
+		{"enabled without a limit per fine", AutopayRuleInput{Enabled: true, MonthlyLimit: 100, Card: card}, domain.ErrAutopayRule, ""},
+		{"enabled without a monthly limit", AutopayRuleInput{Enabled: true, MaxPerFine: 100, Card: card}, domain.ErrAutopayRule, ""},
+		{"limit per fine above the monthly", AutopayRuleInput{Enabled: true, MaxPerFine: 101, MonthlyLimit: 100, Card: card}, domain.ErrAutopayRule, ""},
+		{"enabled without a card", AutopayRuleInput{Enabled: true, MaxPerFine: 100, MonthlyLimit: 100}, domain.ErrAutopayRule, ""},
+		{"expired card", AutopayRuleInput{Enabled: true, MaxPerFine: 100, MonthlyLimit: 100, Card: autopayTestCard("4242424242424242", 2000)}, domain.ErrInvalidCard, ""},
+		{"disabled without limits", AutopayRuleInput{}, nil, ""},
+		{"enabled", AutopayRuleInput{Enabled: true, MaxPerFine: 100, MonthlyLimit: 100, Card: card}, nil, "4242"},
+		{"saved card kept", AutopayRuleInput{Enabled: true, MaxPerFine: 50, MonthlyLimit: 200}, nil, "4242"},
+		{"card replaced", AutopayRuleInput{Enabled: true, MaxPerFine: 50, MonthlyLimit: 200, Card: autopayTestCard("5555555555554444", nextYear)}, nil, "4444"},
+		{"disabled", AutopayRuleInput{}, nil, "4444"},
+	} {
+		rule, err := s.SetRule(context.Background(), userID, tc.input)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, tc.err)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		stored, err := s.Rule(context.Background(), userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stored.Enabled != tc.input.Enabled || stored.MaxPerFine != tc.input.MaxPerFine ||
+			stored.MonthlyLimit != tc.input.MonthlyLimit || stored.CardLast4 != tc.last4 || stored != rule {
+			t.Errorf("%s: rule %+v, want the input with card %q", tc.name, stored, tc.last4)
+		}
+	}
+}
+
+func TestAutopayPlan(t *testing.T) {
+	s, repos := newAutopayTestService()
+
+	userID := uuid.New()
+	if _, err := s.SetRule(context.Background(), userID, AutopayRuleInput{
+		Enabled:      true,
+		MaxPerFine:   60000,
+		MonthlyLimit: 100000,
+		Card:         autopayTestCard("4242424242424242", time.Now().Year()+1),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now()
+
+	fine := func(amount int64, issuedAt time.Time, discountDays int) domain.Fine {
+		discountUntil := issuedAt.AddDate(0, 0, discountDays)
+
+		return domain.Fine{
+			ID:            uuid.New(),
+			UserID:        userID,
+			Issuer:        "GIBDD",
+			Amount:        amount,
+			IssuedAt:      issuedAt,
+			DueAt:         issuedAt.AddDate(0, 0, autopayTestRules.PaymentDays),
+			DiscountUntil: &discountUntil,
+			Status:        domain.FineIssued,
+		}
+	}
+
+	issued := before.Add(-time.Hour)
+	ahead := before.AddDate(0, 0, 40)
+	// The discount ends half the notice period from now.
+	ending := before.Add(autopayTestNotice/2).AddDate(0, 0, -20)
+
+	for _, tc := range []struct {
+		name   string
+		fine   domain.Fine
+		status domain.AutopayChargeStatus
+		reason string
+		amount int64
+	}{
+		{"scheduled", fine(100000, issued, 20), domain.AutopayScheduled, "", 50000},
+		{"discount ends within the notice", fine(100000, ending, 20), domain.AutopayDeclined, domain.AutopayNoDiscount, 100000},
+		{"above the limit per fine", fine(120002, issued, 20), domain.AutopayDeclined, domain.AutopayFineLimit, 60001},
+		{"up to the monthly limit", fine(100000, issued, 20), domain.AutopayScheduled, "", 50000},
+		{"above the monthly limit", fine(2, issued, 20), domain.AutopayDeclined, domain.AutopayMonthlyLimit, 1},
+		{"in another month", fine(100000, ahead, 20), domain.AutopayScheduled, "", 50000},
+	} {
+		if err := s.Plan(context.Background(), tc.fine); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		after := time.Now()
+
+		charges, err := repos.Autopay.GetCharges(context.Background(), userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var charge *domain.AutopayCharge
+		for i := range charges {
+			if charges[i].FineID == tc.fine.ID {
+				charge = &charges[i]
+			}
+		}
+
+		if charge == nil {
+			t.Fatalf("%s: no charge planned", tc.name)
+		}
+
+		if charge.Status != tc.status || charge.Reason != tc.reason || charge.Amount != tc.amount {
+			t.Errorf("%s: charge %s %q of %d, want %s %q of %d",
+				tc.name, charge.Status, charge.Reason, charge.Amount, tc.status, tc.reason, tc.amount)
+		}
+
+		// The charge is made after the notice period, counted from the
+		// issue date if it is still ahead.
+		from, to := before, after
+		if tc.fine.IssuedAt.After(before) {
+			from, to = tc.fine.IssuedAt, tc.fine.IssuedAt
+		}
+
+		if charge.ChargeAt.Before(from.Add(autopayTestNotice)) || charge.ChargeAt.After(to.Add(autopayTestNotice)) {
+			t.Errorf("%s: charged at %v, want the notice period after %v", tc.name, charge.ChargeAt, from)
+		}
+
+		year, month, _ := charge.ChargeAt.Date()
+		if want := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC); !charge.Month.Equal(want) {
+			t.Errorf("%s: counted in %v, want %v", tc.name, charge.Month, want)
+		}
+
+		if (charge.ScheduleID != nil) != (tc.status == domain.AutopayScheduled) {
+			t.Errorf("%s: schedule %v for a %s charge", tc.name, charge.ScheduleID, charge.Status)
+		}
+	}
+}
+
+func TestAutopayPlanWithoutRule(t *testing.T) {
+	s, repos := newAutopayTestService()
+
+	disabled := uuid.New()
+	if _, err := s.SetRule(context.Background(), disabled, AutopayRuleInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, userID := range []uuid.UUID{uuid.New(), disabled} {
+		fine := domain.Fine{ID: uuid.New(), UserID: userID, Amount: 100, IssuedAt: time.Now(), DueAt: time.Now().AddDate(0, 0, 70)}
+		if err := s.Plan(context.Background(), fine); err != nil {
+			t.Fatal(err)
+		}
+
+		if charges, _ := repos.Autopay.GetCharges(context.Background(), userID); len(charges) != 0 {
+			t.Errorf("%d charges planned without autopay, want none", len(charges))
+		}
+	}
+}
@@ -1,75 +1,170 @@
 package service
 
 import (
+	"backend-vtb/internal/domain"
 	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/money"
+	"backend-vtb/pkg/uin"
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type BaseService struct {
-	repos  *repository.Repository
-	logger *slog.Logger
+	repos     *repository.Repository
+	fineRules domain.FineRules
+	crypto    Crypto
+	stats     Stats
+	logger    *slog.Logger
 }
 
-func NewBaseService(repos *repository.Repository, logger *slog.Logger) *BaseService {
+func NewBaseService(repos *repository.Repository, fineRules domain.FineRules, crypto Crypto, stats Stats,
+	logger *slog.Logger,
+) *BaseService {
 	return &BaseService{
-		repos:  repos,
-		logger: logger,
+		repos:     repos,
+		fineRules: fineRules,
+		crypto:    crypto,
+		stats:     stats,
+		logger:    logger,
 	}
 }
-func (s *BaseService) GetAchievements(id uuid.UUID) ([]string, error) {
-	return nil, nil
+
+func (s *BaseService) GetAchievements(ctx context.Context, id uuid.UUID) ([]domain.Achievement, error) {
+	return s.repos.Achievements.GetByUser(ctx, id)
 }
 
-func (s *BaseService) GetName(id uuid.UUID) (string, error) {
-	return "", nil
+func (s *BaseService) GetName(ctx context.Context, id uuid.UUID) (string, error) {
+	user, err := s.repos.Users.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	return user.Name, nil
+}
+
+func (s *BaseService) GetAmount(ctx context.Context, id uuid.UUID) (money.Money, error) {
+	fines, err := s.repos.Fines.GetByUser(ctx, id)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	disputes, err := s.repos.Disputes.GetByUser(ctx, id)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	pauses := penaltyPauses(disputes)
+	now := time.Now()
+
+	amount := money.Zero(defaultCurrency)
+	for _, fine := range fines {
+		fine.PenaltyPauses = pauses[fine.ID]
+		payable := fine.Charge(now, s.fineRules).Total
+		if amount, err = amount.Add(money.New(payable, defaultCurrency)); err != nil {
+			return money.Money{}, err
+		}
+	}
+
+	return amount, nil
 }
 
-func (s *BaseService) GetAmount(id uuid.UUID) (int, error) {
-	return 0, nil
+func (s *BaseService) GetBaseInfo(ctx context.Context, id uuid.UUID) (domain.BaseInfo, error) {
+	user, err := s.repos.Users.GetByID(ctx, id)
+	if err != nil {
+		return domain.BaseInfo{}, err
+	}
+
+	return domain.BaseInfo{
+		Name:         user.Name,
+		Email:        user.Email,
+		Phone:        user.Phone,
+		RegisteredAt: user.RegisteredAt,
+	}, nil
 }
 
-func (s *BaseService) GetBaseInfo(id uuid.UUID) (string, error) {
-	return "", nil
+func (s *BaseService) GetNeuroMean(ctx context.Context, id uuid.UUID) (float64, error) {
+	stats, err := s.getStats(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return stats.NeuroMean, nil
 }
 
-func (s *BaseService) GetNeuroMean(id uuid.UUID) (float64, error) {
-	return 0, nil
+func (s *BaseService) GetCryptoData(ctx context.Context, id uuid.UUID) (domain.CryptoPortfolio, error) {
+	return s.crypto.Portfolio(ctx, id, domain.CostBasisFIFO)
 }
 
-func (s *BaseService) GetCryptoData(id uuid.UUID) (string, error) {
-	return "", nil
+func (s *BaseService) GetAPIInfo(ctx context.Context, id uuid.UUID) (string, error) {
+	stats, err := s.getStats(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	return stats.APIInfo, nil
 }
 
-func (s *BaseService) GetAPIInfo(id uuid.UUID) (string, error) {
-	return "", nil
+func (s *BaseService) GetFullAPIInfo(ctx context.Context, id uuid.UUID) (string, error) {
+	stats, err := s.getStats(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	return stats.FullAPIInfo, nil
 }
 
-func (s *BaseService) GetFullAPIInfo(id uuid.UUID) (string, error) {
-	return "", nil
+func (s *BaseService) GetFines(ctx context.Context, id uuid.UUID) ([]domain.Fine, error) {
+	return s.repos.Fines.GetByUser(ctx, id)
 }
 
-func (s *BaseService) GetFines(id uuid.UUID) ([]int, error) {
-	return nil, nil
+func (s *BaseService) GetFineByUIN(ctx context.Context, id uuid.UUID, uin string) (domain.Fine, error) {
+	normalized, err := validateUIN(uin)
+	if err != nil {
+		return domain.Fine{}, err
+	}
+
+	return s.repos.Fines.GetByUIN(ctx, id, normalized)
 }
 
-func (s *BaseService) GetFineByID(id uuid.UUID) (int, error) {
-	return 0, nil
+func (s *BaseService) GetPayments(ctx context.Context, id uuid.UUID) ([]domain.Payment, error) {
+	return s.repos.Payments.GetByUser(ctx, id)
 }
 
-func (s *BaseService) GetPayments(id uuid.UUID) ([]int, error) {
-	return nil, nil
+func (s *BaseService) GetStatsData(ctx context.Context, id uuid.UUID) (domain.StatsReport, error) {
+	return s.stats.Report(ctx, id, StatsInput{})
 }
 
-func (s *BaseService) GetPaymentByID(id uuid.UUID) (int, error) {
-	return 0, nil
+func (s *BaseService) GetAnalyze(ctx context.Context, id uuid.UUID) (string, error) {
+	stats, err := s.getStats(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	return stats.Analyze, nil
 }
 
-func (s *BaseService) GetStatsData(id uuid.UUID) (string, error) {
-	return "", nil
+// validateUIN normalizes a UIN and checks its check digit.
+func validateUIN(value string) (string, error) {
+	normalized := uin.Normalize(value)
+	if err := uin.Validate(normalized); err != nil {
+		return "", fmt.Errorf("%w: %w", domain.ErrInvalidUIN, err)
+	}
+
+	return normalized, nil
 }
 
-func (s *BaseService) GetAnalyze(id uuid.UUID) (string, error) {
-	return "", nil
+// getStats loads the user's stats row, treating a missing row as empty stats:
+// users get one only after the analytics pipeline has processed them.
+func (s *BaseService) getStats(ctx context.Context, id uuid.UUID) (domain.Stats, error) {
+	stats, err := s.repos.Stats.GetByUser(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Stats{UserID: id}, nil
+	}
+
+	return stats, err
 }
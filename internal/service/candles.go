@@ -0,0 +1,294 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/ohlc"
+	"backend-vtb/pkg/pricefeed"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultCandles is how many candles are returned when no start is given.
+	defaultCandles = 100
+	// backfillStep is the spacing of the prices sampled to fill a gap in the
+	// ticks.
+	backfillStep = time.Minute
+	// backfillBatch is how many sampled ticks are ingested at once.
+	backfillBatch = 1000
+)
+
+type candleKey struct {
+	symbol   string
+	interval domain.CandleInterval
+	openTime int64
+}
+
+type CandlesService struct {
+	repos  *repository.Repository
+	prices pricefeed.Source
+	cfg    CandlesConfig
+	logger *slog.Logger
+}
+
+func NewCandlesService(repos *repository.Repository, prices pricefeed.Source, cfg CandlesConfig, logger *slog.Logger) *CandlesService {
+	if cfg.MaxCandles <= 0 {
+		cfg.MaxCandles = 1000
+	}
+
+	return &CandlesService{
+		repos:  repos,
+		prices: prices,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+func (s *CandlesService) Ingest(ctx context.Context, ticks []pricefeed.Tick) error {
+	var (
+		aggregated = make(map[candleKey]ohlc.Candle)
+		currencies = make(map[string]string)
+	)
+
+	for _, tick := range ticks {
+		symbol := strings.ToUpper(tick.Symbol)
+		if symbol == "" || tick.Price == nil || tick.Price.Sign() <= 0 {
+			return fmt.Errorf("invalid tick of %q at %s", tick.Symbol, tick.At.Format(time.RFC3339))
+		}
+
+		currencies[symbol] = strings.ToUpper(tick.Currency)
+
+		for _, interval := range domain.CandleIntervals {
+			candle := ohlc.New(interval.Duration(), tick.At, tick.Price, tick.Volume)
+			key := candleKey{symbol: symbol, interval: interval, openTime: candle.OpenTime.Unix()}
+
+			if stored, ok := aggregated[key]; ok {
+				candle = ohlc.Merge(stored, candle)
+			}
+
+			aggregated[key] = candle
+		}
+	}
+
+	if len(aggregated) == 0 {
+		return nil
+	}
+
+	candles := make([]domain.Candle, 0, len(aggregated))
+	for key, candle := range aggregated {
+		candles = append(candles, fromOHLC(key.symbol, key.interval, currencies[key.symbol], candle))
+	}
+
+	// A stable order keeps concurrent upserts of the same candles from
+	// deadlocking.
+	sort.Slice(candles, func(i, j int) bool {
+		a, b := candles[i], candles[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+
+		if a.Interval != b.Interval {
+			return a.Interval < b.Interval
+		}
+
+		return a.OpenTime.Before(b.OpenTime)
+	})
+
+	return s.repos.Candles.Merge(ctx, candles)
+}
+
+func (s *CandlesService) Backfill(ctx context.Context, to time.Time, maxAge time.Duration) (int, error) {
+	history, ok := s.prices.(pricefeed.History)
+	if !ok {
+		return 0, errors.New("the price source keeps no history to backfill from")
+	}
+
+	var sampled int
+	for _, symbol := range s.prices.Symbols() {
+		from := to.Add(-maxAge)
+
+		last, err := s.repos.Candles.GetLastBefore(ctx, symbol, domain.CandleMinute, to)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return sampled, err
+		}
+
+		if err == nil && last.LastAt.After(from) {
+			from = last.LastAt
+		}
+
+		ticks := make([]pricefeed.Tick, 0, backfillBatch)
+		for at := ohlc.Truncate(from, backfillStep).Add(backfillStep); at.Before(to); at = at.Add(backfillStep) {
+			quote, err := history.PriceAt(symbol, at)
+			if err != nil {
+				return sampled, fmt.Errorf("price of %s at %s: %w", symbol, at.Format(time.RFC3339), err)
+			}
+
+			ticks = append(ticks, pricefeed.Tick{
+				Symbol:   quote.Symbol,
+				Currency: quote.Currency,
+				Price:    quote.Price,
+				Volume:   new(big.Rat),
+				At:       quote.At,
+			})
+
+			if len(ticks) == backfillBatch {
+				if err := s.Ingest(ctx, ticks); err != nil {
+					return sampled, err
+				}
+
+				sampled += len(ticks)
+				ticks = ticks[:0]
+			}
+		}
+
+		if err := s.Ingest(ctx, ticks); err != nil {
+			return sampled, err
+		}
+
+		sampled += len(ticks)
+	}
+
+	s.logger.Info("candles backfilled",
+		slog.String("to", to.Format(time.RFC3339)), slog.Int("ticks", sampled))
+
+	return sampled, nil
+}
+
+func (s *CandlesService) Candles(ctx context.Context, symbol string, interval domain.CandleInterval,
+	from, to time.Time) (domain.CandleSeries, error) {
+	length := interval.Duration()
+	if length == 0 {
+		return domain.CandleSeries{}, fmt.Errorf("%w: unknown interval %q", domain.ErrCandleRange, interval)
+	}
+
+	now := time.Now()
+	if to.IsZero() {
+		to = now
+	}
+
+	if from.IsZero() {
+		from = to.Add(-defaultCandles * length)
+	}
+
+	start := ohlc.Truncate(from, length)
+	if !start.Before(to) {
+		return domain.CandleSeries{}, fmt.Errorf("%w: from must be before to", domain.ErrCandleRange)
+	}
+
+	if to.Sub(start) > time.Duration(s.cfg.MaxCandles)*length {
+		return domain.CandleSeries{}, fmt.Errorf("%w: at most %d candles at a time", domain.ErrCandleRange, s.cfg.MaxCandles)
+	}
+
+	symbol = strings.ToUpper(symbol)
+
+	stored, err := s.repos.Candles.GetRange(ctx, symbol, interval, start, to)
+	if err != nil {
+		return domain.CandleSeries{}, err
+	}
+
+	var previous *ohlc.Candle
+
+	last, err := s.repos.Candles.GetLastBefore(ctx, symbol, interval, start)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return domain.CandleSeries{}, err
+	}
+
+	series := domain.CandleSeries{
+		Symbol:   symbol,
+		Interval: interval,
+		Currency: s.prices.Currency(),
+	}
+
+	if err == nil {
+		candle, err := toOHLC(last)
+		if err != nil {
+			return domain.CandleSeries{}, err
+		}
+
+		previous = &candle
+		series.Currency = last.Currency
+	}
+
+	candles := make([]ohlc.Candle, 0, len(stored))
+	for _, candle := range stored {
+		converted, err := toOHLC(candle)
+		if err != nil {
+			return domain.CandleSeries{}, err
+		}
+
+		candles = append(candles, converted)
+		series.Currency = candle.Currency
+	}
+
+	// The intervals yet to start are not filled in.
+	series.Candles = make([]domain.Candle, 0, len(candles))
+	for _, candle := range ohlc.Fill(candles, previous, start, minTime(to, now), length) {
+		series.Candles = append(series.Candles, fromOHLC(symbol, interval, series.Currency, candle))
+	}
+
+	return series, nil
+}
+
+func toOHLC(candle domain.Candle) (ohlc.Candle, error) {
+	values := make([]*big.Rat, 0, 5)
+	for _, value := range []string{candle.Open, candle.High, candle.Low, candle.Close, candle.Volume} {
+		r, ok := new(big.Rat).SetString(value)
+		if !ok {
+			return ohlc.Candle{}, fmt.Errorf("invalid candle value %q", value)
+		}
+
+		values = append(values, r)
+	}
+
+	return ohlc.Candle{
+		OpenTime: candle.OpenTime,
+		Open:     values[0],
+		High:     values[1],
+		Low:      values[2],
+		Close:    values[3],
+		Volume:   values[4],
+		Ticks:    candle.Ticks,
+		FirstAt:  candle.FirstAt,
+		LastAt:   candle.LastAt,
+		Filled:   candle.Filled,
+	}, nil
+}
+
+func fromOHLC(symbol string, interval domain.CandleInterval, currency string, candle ohlc.Candle) domain.Candle {
+	return domain.Candle{
+		Symbol:   symbol,
+		Interval: interval,
+		Currency: currency,
+		OpenTime: candle.OpenTime,
+		Open:     decimalString(candle.Open),
+		High:     decimalString(candle.High),
+		Low:      decimalString(candle.Low),
+		Close:    decimalString(candle.Close),
+		Volume:   decimalString(candle.Volume),
+		Ticks:    candle.Ticks,
+		FirstAt:  candle.FirstAt,
+		LastAt:   candle.LastAt,
+		Filled:   candle.Filled,
+	}
+}
+
+// decimalString formats r with up to 18 decimals and no trailing zeros, the
+// precision candles are stored with.
+func decimalString(r *big.Rat) string {
+	return strings.TrimRight(strings.TrimRight(r.FloatString(18), "0"), ".")
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
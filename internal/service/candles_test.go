@@ -0,0 +1,165 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository/memory"
+	"backend-vtb/pkg/pricefeed"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func newCandlesTestService(t *testing.T, cfg CandlesConfig) *CandlesService {
+	t.Helper()
+
+	prices, err := pricefeed.NewStub(pricefeed.DefaultStubCurrency, pricefeed.DefaultStubPrices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewCandlesService(memory.NewRepository(), prices, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestCandlesIngest(t *testing.T) {
+	s := newCandlesTestService(t, CandlesConfig{})
+	base := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	tick := func(offset time.Duration, price, volume string) pricefeed.Tick {
+		p, _ := new(big.Rat).SetString(price)
+		v, _ := new(big.Rat).SetString(volume)
+
+		return pricefeed.Tick{Symbol: "btc", Currency: "usd", Price: p, Volume: v, At: base.Add(offset)}
+	}
+
+	// Out of order, split over two batches, on both sides of the 10:05
+	// boundary.
+	batches := [][]pricefeed.Tick{
+		{
+			tick(4*time.Minute+59*time.Second+999*time.Millisecond, "104", "1"),
+			tick(5*time.Minute, "105", "1"),
+			tick(10*time.Second, "100", "2"),
+		},
+		{
+			tick(2*time.Minute, "120", "1"),
+			tick(7*time.Minute, "107", "0.5"),
+			tick(time.Second, "99", "1"),
+			tick(3*time.Minute, "90", "1"),
+		},
+	}
+
+	for _, ticks := range batches {
+		if err := s.Ingest(context.Background(), ticks); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type want struct {
+		openTime               time.Time
+		open, high, low, close string
+		volume                 string
+		ticks                  int64
+		filled                 bool
+	}
+
+	for _, tc := range []struct {
+		interval domain.CandleInterval
+		from, to time.Time
+		want     []want
+	}{
+		{
+			domain.CandleFiveMinute, base, base.Add(10 * time.Minute),
+			[]want{
+				{base, "99", "120", "90", "104", "6", 5, false},
+				{base.Add(5 * time.Minute), "105", "107", "105", "107", "1.5", 2, false},
+			},
+		},
+		{
+			domain.CandleMinute, base.Add(3 * time.Minute), base.Add(6 * time.Minute),
+			[]want{
+				{base.Add(3 * time.Minute), "90", "90", "90", "90", "1", 1, false},
+				{base.Add(4 * time.Minute), "104", "104", "104", "104", "1", 1, false},
+				{base.Add(5 * time.Minute), "105", "105", "105", "105", "1", 1, false},
+			},
+		},
+		{
+			domain.CandleMinute, base.Add(5 * time.Minute), base.Add(8 * time.Minute),
+			[]want{
+				{base.Add(5 * time.Minute), "105", "105", "105", "105", "1", 1, false},
+				{base.Add(6 * time.Minute), "105", "105", "105", "105", "0", 0, true},
+				{base.Add(7 * time.Minute), "107", "107", "107", "107", "0.5", 1, false},
+			},
+		},
+		{
+			domain.CandleHour, base, base.Add(time.Hour),
+			[]want{{base, "99", "120", "90", "107", "7.5", 7, false}},
+		},
+		{
+			domain.CandleDay, base.Add(-48 * time.Hour), base.Add(time.Hour),
+			[]want{{time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), "99", "120", "90", "107", "7.5", 7, false}},
+		},
+	} {
+		series, err := s.Candles(context.Background(), "BTC", tc.interval, tc.from, tc.to)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.interval, err)
+		}
+
+		if series.Symbol != "BTC" || series.Currency != "USD" || len(series.Candles) != len(tc.want) {
+			t.Errorf("%s: %d candles of %s in %s, want %d of BTC in USD",
+				tc.interval, len(series.Candles), series.Symbol, series.Currency, len(tc.want))
+			continue
+		}
+
+		for i, w := range tc.want {
+			c := series.Candles[i]
+			if !c.OpenTime.Equal(w.openTime) || c.Open != w.open || c.High != w.high || c.Low != w.low ||
+				c.Close != w.close || c.Volume != w.volume || c.Ticks != w.ticks || c.Filled != w.filled {
+				t.Errorf("%s: candle %d at %v %s/%s/%s/%s volume %s of %d ticks, filled %v; want at %v %s/%s/%s/%s volume %s of %d, filled %v",
+					tc.interval, i, c.OpenTime, c.Open, c.High, c.Low, c.Close, c.Volume, c.Ticks, c.Filled,
+					w.openTime, w.open, w.high, w.low, w.close, w.volume, w.ticks, w.filled)
+			}
+		}
+	}
+}
+
+func TestCandlesIngestInvalid(t *testing.T) {
+	s := newCandlesTestService(t, CandlesConfig{})
+	at := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, tick := range []pricefeed.Tick{
+		{Symbol: "", Price: big.NewRat(1, 1), At: at},
+		{Symbol: "BTC", At: at},
+		{Symbol: "BTC", Price: new(big.Rat), At: at},
+		{Symbol: "BTC", Price: big.NewRat(-1, 1), At: at},
+	} {
+		if err := s.Ingest(context.Background(), []pricefeed.Tick{tick}); err == nil {
+			t.Errorf("tick %+v ingested", tick)
+		}
+	}
+}
+
+func TestCandlesRange(t *testing.T) {
+	s := newCandlesTestService(t, CandlesConfig{MaxCandles: 10})
+	base := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		interval domain.CandleInterval
+		from, to time.Time
+		err      error
+	}{
+		{"unknown interval", "2m", base, base.Add(time.Hour), domain.ErrCandleRange},
+		{"empty", domain.CandleMinute, base, base, domain.ErrCandleRange},
+		{"reversed", domain.CandleMinute, base.Add(time.Minute), base, domain.ErrCandleRange},
+		{"too many candles", domain.CandleMinute, base, base.Add(10*time.Minute + time.Nanosecond), domain.ErrCandleRange},
+		{"as many as allowed", domain.CandleMinute, base, base.Add(10 * time.Minute), nil},
+		{"within one candle", domain.CandleHour, base.Add(30 * time.Minute), base.Add(40 * time.Minute), nil},
+	} {
+		if _, err := s.Candles(context.Background(), "BTC", tc.interval, tc.from, tc.to); !errors.Is(err, tc.err) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, tc.err)
+		}
+	}
+}
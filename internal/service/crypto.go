@@ -0,0 +1,286 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/money"
+	"backend-vtb/pkg/portfolio"
+	"backend-vtb/pkg/pricefeed"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CryptoService struct {
+	repos  *repository.Repository
+	prices pricefeed.Source
+	rates  ExchangeRates
+	logger *slog.Logger
+}
+
+func NewCryptoService(repos *repository.Repository, prices pricefeed.Source, rates ExchangeRates, logger *slog.Logger) *CryptoService {
+	return &CryptoService{
+		repos:  repos,
+		prices: prices,
+		rates:  rates,
+		logger: logger,
+	}
+}
+
+func (s *CryptoService) Transactions(ctx context.Context, userID uuid.UUID) ([]domain.CryptoTransaction, error) {
+	return s.repos.Crypto.GetByUser(ctx, userID)
+}
+
+func (s *CryptoService) AddTransaction(ctx context.Context, userID uuid.UUID, input CryptoTransactionInput) (domain.CryptoTransaction, error) {
+	asset := strings.ToUpper(strings.TrimSpace(input.Asset))
+	if _, err := s.prices.Price(ctx, asset); err != nil {
+		if errors.Is(err, pricefeed.ErrUnknownSymbol) {
+			return domain.CryptoTransaction{}, fmt.Errorf("%w: %q", domain.ErrUnknownAsset, asset)
+		}
+
+		return domain.CryptoTransaction{}, err
+	}
+
+	if !input.Kind.Valid() {
+		return domain.CryptoTransaction{}, fmt.Errorf("%w: unknown kind %q", domain.ErrCryptoTransaction, input.Kind)
+	}
+
+	quantity, err := portfolio.ParseQuantity(input.Quantity)
+	if err != nil {
+		return domain.CryptoTransaction{}, fmt.Errorf("%w: %w", domain.ErrCryptoTransaction, err)
+	}
+
+	amount, err := paymentAmount(input.Amount)
+	if err != nil {
+		return domain.CryptoTransaction{}, err
+	}
+
+	if amount.IsNegative() || input.Fee < 0 {
+		return domain.CryptoTransaction{}, fmt.Errorf("%w: amounts must not be negative", domain.ErrCryptoTransaction)
+	}
+
+	now := time.Now()
+	if input.ExecutedAt.IsZero() {
+		input.ExecutedAt = now
+	}
+
+	if input.ExecutedAt.After(now) {
+		return domain.CryptoTransaction{}, fmt.Errorf("%w: executed in the future", domain.ErrCryptoTransaction)
+	}
+
+	transaction := domain.CryptoTransaction{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Asset:      asset,
+		Kind:       input.Kind,
+		Quantity:   portfolio.FormatQuantity(quantity),
+		Amount:     amount.Amount,
+		Fee:        input.Fee,
+		Currency:   amount.Currency,
+		ExecutedAt: input.ExecutedAt,
+		CreatedAt:  now,
+	}
+
+	if err := s.repos.Crypto.Create(ctx, transaction, checkCryptoHistory); err != nil {
+		return domain.CryptoTransaction{}, err
+	}
+
+	s.logger.Info("crypto transaction recorded",
+		slog.String("transaction_id", transaction.ID.String()), slog.String("asset", asset),
+		slog.String("kind", string(transaction.Kind)))
+
+	return transaction, nil
+}
+
+func (s *CryptoService) DeleteTransaction(ctx context.Context, userID, transactionID uuid.UUID) error {
+	transaction, err := s.repos.Crypto.GetByID(ctx, transactionID)
+	if err != nil {
+		return err
+	}
+
+	if transaction.UserID != userID {
+		return domain.ErrNotFound
+	}
+
+	return s.repos.Crypto.Delete(ctx, transaction, checkCryptoHistory)
+}
+
+// checkCryptoHistory verifies that the transactions in an asset are in one
+// currency and never remove more units than held at their time.
+func checkCryptoHistory(transactions []domain.CryptoTransaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	first := transactions[0]
+	for _, transaction := range transactions {
+		if transaction.Currency != first.Currency {
+			return fmt.Errorf("%w: transactions in %s are recorded in %s", domain.ErrCryptoTransaction,
+				first.Asset, first.Currency)
+		}
+	}
+
+	if _, err := cryptoPosition(portfolio.FIFO, transactions); err != nil {
+		if errors.Is(err, portfolio.ErrInsufficientQuantity) {
+			return fmt.Errorf("%w of %s", domain.ErrInsufficientHoldings, first.Asset)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// cryptoPosition computes the position from the transactions in one asset,
+// in execution order.
+func cryptoPosition(method portfolio.Method, transactions []domain.CryptoTransaction) (portfolio.Position, error) {
+	trades := make([]portfolio.Trade, 0, len(transactions))
+	for _, transaction := range transactions {
+		quantity, err := portfolio.ParseQuantity(transaction.Quantity)
+		if err != nil {
+			return portfolio.Position{}, err
+		}
+
+		trades = append(trades, portfolio.Trade{
+			Kind:     portfolio.Kind(transaction.Kind),
+			Quantity: quantity,
+			Amount:   money.New(transaction.Amount, transaction.Currency),
+			Fee:      money.New(transaction.Fee, transaction.Currency),
+		})
+	}
+
+	return portfolio.Compute(method, transactions[0].Currency, trades)
+}
+
+func (s *CryptoService) Portfolio(ctx context.Context, userID uuid.UUID, method domain.CostBasisMethod) (domain.CryptoPortfolio, error) {
+	if method == "" {
+		method = domain.CostBasisFIFO
+	}
+
+	if method != domain.CostBasisFIFO && method != domain.CostBasisAverage {
+		return domain.CryptoPortfolio{}, fmt.Errorf("%w: %q", domain.ErrCostBasisMethod, method)
+	}
+
+	transactions, err := s.repos.Crypto.GetByUser(ctx, userID)
+	if err != nil {
+		return domain.CryptoPortfolio{}, err
+	}
+
+	byAsset := make(map[string][]domain.CryptoTransaction)
+	for _, transaction := range transactions {
+		byAsset[transaction.Asset] = append(byAsset[transaction.Asset], transaction)
+	}
+
+	assets := make([]string, 0, len(byAsset))
+	for asset := range byAsset {
+		assets = append(assets, asset)
+	}
+
+	sort.Strings(assets)
+
+	now := time.Now()
+	result := domain.CryptoPortfolio{
+		Method:   method,
+		PricedAt: now,
+		Holdings: make([]domain.CryptoHolding, 0, len(assets)),
+		Totals:   make([]domain.CryptoTotal, 0),
+	}
+
+	totals := make(map[string]*domain.CryptoTotal)
+	for _, asset := range assets {
+		holding, err := s.holding(ctx, method, byAsset[asset], now)
+		if err != nil {
+			return domain.CryptoPortfolio{}, err
+		}
+
+		result.Holdings = append(result.Holdings, holding)
+
+		total, ok := totals[holding.Currency]
+		if !ok {
+			total = &domain.CryptoTotal{Currency: holding.Currency}
+			totals[holding.Currency] = total
+		}
+
+		total.CostBasis += holding.CostBasis
+		total.MarketValue += holding.MarketValue
+		total.RealizedPnL += holding.RealizedPnL
+		total.UnrealizedPnL += holding.UnrealizedPnL
+	}
+
+	for _, total := range totals {
+		result.Totals = append(result.Totals, *total)
+	}
+
+	sort.Slice(result.Totals, func(i, j int) bool {
+		return result.Totals[i].Currency < result.Totals[j].Currency
+	})
+
+	return result, nil
+}
+
+// holding values the position in one asset at its market price.
+func (s *CryptoService) holding(ctx context.Context, method domain.CostBasisMethod, transactions []domain.CryptoTransaction,
+	at time.Time) (domain.CryptoHolding, error) {
+	asset, currency := transactions[0].Asset, transactions[0].Currency
+
+	position, err := cryptoPosition(portfolio.Method(method), transactions)
+	if err != nil {
+		return domain.CryptoHolding{}, fmt.Errorf("position in %s: %w", asset, err)
+	}
+
+	price, err := s.price(ctx, asset, currency, at)
+	if err != nil {
+		return domain.CryptoHolding{}, err
+	}
+
+	value, err := portfolio.Value(position.Quantity, price, currency)
+	if err != nil {
+		return domain.CryptoHolding{}, err
+	}
+
+	unrealized, err := value.Sub(position.CostBasis)
+	if err != nil {
+		return domain.CryptoHolding{}, err
+	}
+
+	units, _ := money.MinorUnits(currency)
+
+	return domain.CryptoHolding{
+		Asset:         asset,
+		Currency:      currency,
+		Quantity:      portfolio.FormatQuantity(position.Quantity),
+		Price:         price.FloatString(units),
+		CostBasis:     position.CostBasis.Amount,
+		MarketValue:   value.Amount,
+		RealizedPnL:   position.Realized.Amount,
+		UnrealizedPnL: unrealized.Amount,
+		Fees:          position.Fees.Amount,
+	}, nil
+}
+
+// price returns the market price of one unit of the asset in the currency,
+// converted at the exchange rate of the day if the source quotes another one.
+func (s *CryptoService) price(ctx context.Context, asset, currency string, at time.Time) (*big.Rat, error) {
+	quote, err := s.prices.Price(ctx, asset)
+	if err != nil {
+		return nil, fmt.Errorf("price of %s: %w", asset, err)
+	}
+
+	if quote.Currency == currency {
+		return quote.Price, nil
+	}
+
+	rate, err := s.rates.Rate(ctx, quote.Currency, currency, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Rat).Mul(quote.Price, rate.Value()), nil
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/storage"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxAttachments is the number of evidence files a dispute can have.
+	MaxAttachments = 5
+	// MaxAttachmentSize is the size limit of an evidence file in bytes.
+	MaxAttachmentSize = 10 << 20
+)
+
+// attachmentTypes are the content types accepted as evidence, as detected
+// from the file content rather than trusted from the client.
+var attachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"application/pdf": true,
+}
+
+type DisputesService struct {
+	repos   *repository.Repository
+	storage storage.ObjectStorage
+	logger  *slog.Logger
+}
+
+func NewDisputesService(repos *repository.Repository, storage storage.ObjectStorage, logger *slog.Logger) *DisputesService {
+	return &DisputesService{
+		repos:   repos,
+		storage: storage,
+		logger:  logger,
+	}
+}
+
+func (s *DisputesService) Open(ctx context.Context, userID, fineID uuid.UUID, input DisputeOpenInput) (domain.Dispute, error) {
+	fine, err := s.getFine(ctx, Actor{UserID: userID}, fineID)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+
+	switch fine.Status {
+	case domain.FineIssued:
+	case domain.FineDisputed:
+		return domain.Dispute{}, domain.ErrDisputeExists
+	default:
+		return domain.Dispute{}, fmt.Errorf("%w: cannot dispute a %s fine", domain.ErrFineTransition, fine.Status)
+	}
+
+	now := time.Now()
+	dispute := domain.Dispute{
+		ID:        uuid.New(),
+		FineID:    fineID,
+		UserID:    userID,
+		Reason:    input.Reason,
+		Comment:   input.Comment,
+		Status:    domain.DisputeOpen,
+		CreatedAt: now,
+	}
+
+	fine.Status = domain.FineDisputed
+	fine.UpdatedAt = now
+
+	event := newFineEvent(fine, domain.FineEventDisputeOpened, userID, input.Comment)
+	event.DisputeID = &dispute.ID
+
+	if err := s.repos.Disputes.Open(ctx, dispute, event); err != nil {
+		return domain.Dispute{}, err
+	}
+
+	return dispute, nil
+}
+
+func (s *DisputesService) List(ctx context.Context, actor Actor, fineID uuid.UUID) ([]domain.Dispute, error) {
+	if _, err := s.getFine(ctx, actor, fineID); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Disputes.GetByFine(ctx, fineID)
+}
+
+func (s *DisputesService) Get(ctx context.Context, actor Actor, disputeID uuid.UUID) (domain.Dispute, error) {
+	dispute, err := s.repos.Disputes.GetByID(ctx, disputeID)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+
+	// Do not reveal that a dispute of another user exists.
+	if !actor.Reviewer && dispute.UserID != actor.UserID {
+		return domain.Dispute{}, domain.ErrNotFound
+	}
+
+	return dispute, nil
+}
+
+func (s *DisputesService) AddAttachment(ctx context.Context, userID, disputeID uuid.UUID, input AttachmentInput) (domain.DisputeAttachment, error) {
+	dispute, err := s.Get(ctx, Actor{UserID: userID}, disputeID)
+	if err != nil {
+		return domain.DisputeAttachment{}, err
+	}
+
+	if dispute.Status != domain.DisputeOpen {
+		return domain.DisputeAttachment{}, domain.ErrDisputeClosed
+	}
+
+	if input.Size > MaxAttachmentSize {
+		return domain.DisputeAttachment{}, domain.ErrAttachmentTooLarge
+	}
+
+	attachments, err := s.repos.Disputes.GetAttachments(ctx, disputeID)
+	if err != nil {
+		return domain.DisputeAttachment{}, err
+	}
+
+	if len(attachments) >= MaxAttachments {
+		return domain.DisputeAttachment{}, domain.ErrTooManyAttachments
+	}
+
+	// http.DetectContentType considers at most the first 512 bytes.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(input.Body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return domain.DisputeAttachment{}, err
+	}
+
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if !attachmentTypes[contentType] {
+		return domain.DisputeAttachment{}, fmt.Errorf("%w: %s", domain.ErrAttachmentType, contentType)
+	}
+
+	attachment := domain.DisputeAttachment{
+		ID:          uuid.New(),
+		DisputeID:   disputeID,
+		FileName:    filepath.Base(input.FileName),
+		ContentType: contentType,
+		CreatedAt:   time.Now(),
+	}
+	attachment.StorageKey = fmt.Sprintf("disputes/%s/%s", disputeID, attachment.ID)
+
+	// Read one byte past the limit to tell a file of exactly the maximum
+	// size from a larger one whose declared size was wrong.
+	body := &countingReader{r: io.LimitReader(io.MultiReader(bytes.NewReader(head), input.Body), MaxAttachmentSize+1)}
+
+	if err := s.storage.PutObject(ctx, attachment.StorageKey, body, contentType); err != nil {
+		return domain.DisputeAttachment{}, err
+	}
+
+	attachment.Size = body.n
+
+	if attachment.Size > MaxAttachmentSize {
+		s.deleteObject(attachment.StorageKey)
+		return domain.DisputeAttachment{}, domain.ErrAttachmentTooLarge
+	}
+
+	if err := s.repos.Disputes.AddAttachment(ctx, attachment); err != nil {
+		s.deleteObject(attachment.StorageKey)
+		return domain.DisputeAttachment{}, err
+	}
+
+	return attachment, nil
+}
+
+func (s *DisputesService) ListAttachments(ctx context.Context, actor Actor, disputeID uuid.UUID) ([]domain.DisputeAttachment, error) {
+	if _, err := s.Get(ctx, actor, disputeID); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Disputes.GetAttachments(ctx, disputeID)
+}
+
+func (s *DisputesService) GetAttachment(ctx context.Context, actor Actor, disputeID, attachmentID uuid.UUID) (domain.DisputeAttachment, io.ReadCloser, error) {
+	if _, err := s.Get(ctx, actor, disputeID); err != nil {
+		return domain.DisputeAttachment{}, nil, err
+	}
+
+	attachment, err := s.repos.Disputes.GetAttachment(ctx, attachmentID)
+	if err != nil {
+		return domain.DisputeAttachment{}, nil, err
+	}
+
+	if attachment.DisputeID != disputeID {
+		return domain.DisputeAttachment{}, nil, domain.ErrNotFound
+	}
+
+	body, err := s.storage.GetObject(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return domain.DisputeAttachment{}, nil, domain.ErrNotFound
+		}
+
+		return domain.DisputeAttachment{}, nil, err
+	}
+
+	return attachment, body, nil
+}
+
+func (s *DisputesService) Withdraw(ctx context.Context, userID, disputeID uuid.UUID) (domain.Dispute, error) {
+	dispute, err := s.Get(ctx, Actor{UserID: userID}, disputeID)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+
+	return s.resolve(ctx, dispute, domain.DisputeWithdrawn, userID, "")
+}
+
+func (s *DisputesService) Decide(ctx context.Context, reviewerID, disputeID uuid.UUID, input DisputeDecisionInput) (domain.Dispute, error) {
+	dispute, err := s.repos.Disputes.GetByID(ctx, disputeID)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+
+	status := domain.DisputeRejected
+	if input.Accept {
+		status = domain.DisputeAccepted
+	}
+
+	dispute.ReviewerID = &reviewerID
+
+	return s.resolve(ctx, dispute, status, reviewerID, input.Comment)
+}
+
+// resolve closes the dispute and moves the fine out of the disputed status.
+func (s *DisputesService) resolve(ctx context.Context, dispute domain.Dispute, status domain.DisputeStatus, actorID uuid.UUID, comment string) (domain.Dispute, error) {
+	if dispute.Status != domain.DisputeOpen {
+		return domain.Dispute{}, domain.ErrDisputeClosed
+	}
+
+	fine, err := s.repos.Fines.GetByID(ctx, dispute.FineID)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+
+	now := time.Now()
+	dispute.Status = status
+	dispute.Decision = comment
+	dispute.ResolvedAt = &now
+
+	eventType := domain.FineEventDisputeWithdrawn
+	fine.Status = domain.FineIssued
+
+	switch status {
+	case domain.DisputeAccepted:
+		eventType = domain.FineEventDisputeAccepted
+		fine.Status = domain.FineCancelled
+	case domain.DisputeRejected:
+		eventType = domain.FineEventDisputeRejected
+	}
+
+	fine.UpdatedAt = now
+
+	event := newFineEvent(fine, eventType, actorID, comment)
+	event.DisputeID = &dispute.ID
+
+	if err := s.repos.Disputes.Resolve(ctx, dispute, fine.Status, event); err != nil {
+		return domain.Dispute{}, err
+	}
+
+	s.logger.Info("dispute resolved",
+		slog.String("dispute", dispute.ID.String()), slog.String("status", string(status)))
+
+	return dispute, nil
+}
+
+// getFine returns the fine if the actor may see it.
+func (s *DisputesService) getFine(ctx context.Context, actor Actor, fineID uuid.UUID) (domain.Fine, error) {
+	fine, err := s.repos.Fines.GetByID(ctx, fineID)
+	if err != nil {
+		return domain.Fine{}, err
+	}
+
+	if !actor.Reviewer && fine.UserID != actor.UserID {
+		return domain.Fine{}, domain.ErrNotFound
+	}
+
+	return fine, nil
+}
+
+// deleteObject removes an object stored for an attachment that could not be
+// saved. Failures only leave an orphaned object behind, so they are logged.
+func (s *DisputesService) deleteObject(key string) {
+	if err := s.storage.DeleteObject(context.Background(), key); err != nil {
+		s.logger.Error("failed to delete orphaned object",
+			slog.String("key", key), slog.String("reason", err.Error()))
+	}
+}
+
+// penaltyPauses groups the periods the disputes were open by fine.
+func penaltyPauses(disputes []domain.Dispute) map[uuid.UUID][]domain.Period {
+	pauses := make(map[uuid.UUID][]domain.Period)
+	for _, dispute := range disputes {
+		pauses[dispute.FineID] = append(pauses[dispute.FineID], dispute.Period())
+	}
+
+	return pauses
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/internal/repository/memory"
+	"backend-vtb/pkg/storage"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// disputeTestPNG starts like a PNG image, which is all the content type
+// detection looks at.
+const disputeTestPNG = "\x89PNG\r\n\x1a\n"
+
+// newDisputesTestService keeps the evidence files in a temporary directory,
+// which it returns.
+func newDisputesTestService(t *testing.T) (*DisputesService, *repository.Repository, string) {
+	t.Helper()
+
+	root := t.TempDir()
+
+	objects, err := storage.NewFileStorage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repos := memory.NewRepository()
+
+	return NewDisputesService(repos, objects, slog.New(slog.NewTextHandler(io.Discard, nil))), repos, root
+}
+
+func disputeTestFine(t *testing.T, repos *repository.Repository, userID uuid.UUID, status domain.FineStatus) domain.Fine {
+	t.Helper()
+
+	now := time.Now()
+	fine := domain.Fine{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Issuer:    "GIBDD",
+		Amount:    50000,
+		IssuedAt:  now.Add(-time.Hour),
+		DueAt:     now.AddDate(0, 0, 70),
+		Status:    status,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := repos.Fines.Create(context.Background(), fine, newFineEvent(fine, domain.FineEventCreated, userID, "")); err != nil {
+		t.Fatal(err)
+	}
+
+	return fine
+}
+
+func TestDisputesTransitions(t *testing.T) {
+	s, repos, _ := newDisputesTestService(t)
+	ctx := context.Background()
+
+	userID, reviewerID := uuid.New(), uuid.New()
+	fine := disputeTestFine(t, repos, userID, domain.FineIssued)
+
+	open := func() (domain.Dispute, error) {
+		return s.Open(ctx, userID, fine.ID, DisputeOpenInput{Reason: domain.DisputeNotDriver})
+	}
+
+	withdraw := func(d domain.Dispute) (domain.Dispute, error) {
+		return s.Withdraw(ctx, userID, d.ID)
+	}
+
+	decide := func(accept bool) func(domain.Dispute) (domain.Dispute, error) {
+		return func(d domain.Dispute) (domain.Dispute, error) {
+			return s.Decide(ctx, reviewerID, d.ID, DisputeDecisionInput{Accept: accept, Comment: "checked"})
+		}
+	}
+
+	var dispute domain.Dispute
+
+	for _, tc := range []struct {
+		name string
+		// open opens a new dispute instead of acting on the last one.
+		open   bool
+		act    func(domain.Dispute) (domain.Dispute, error)
+		err    error
+		status domain.DisputeStatus
+		fine   domain.FineStatus
+	}{
+		{"open", true, nil, nil, domain.DisputeOpen, domain.FineDisputed},
+		{"open twice", true, nil, domain.ErrDisputeExists, domain.DisputeOpen, domain.FineDisputed},
+		{"withdraw", false, withdraw, nil, domain.DisputeWithdrawn, domain.FineIssued},
+		{"withdraw twice", false, withdraw, domain.ErrDisputeClosed, domain.DisputeWithdrawn, domain.FineIssued},
+		{"decide when withdrawn", false, decide(true), domain.ErrDisputeClosed, domain.DisputeWithdrawn, domain.FineIssued},
+		{"open again", true, nil, nil, domain.DisputeOpen, domain.FineDisputed},
+		{"reject", false, decide(false), nil, domain.DisputeRejected, domain.FineIssued},
+		{"withdraw when rejected", false, withdraw, domain.ErrDisputeClosed, domain.DisputeRejected, domain.FineIssued},
+		{"open after rejection", true, nil, nil, domain.DisputeOpen, domain.FineDisputed},
+		{"accept", false, decide(true), nil, domain.DisputeAccepted, domain.FineCancelled},
+		{"open when cancelled", true, nil, domain.ErrFineTransition, domain.DisputeAccepted, domain.FineCancelled},
+	} {
+		var (
+			next domain.Dispute
+			err  error
+		)
+
+		if tc.open {
+			next, err = open()
+		} else {
+			next, err = tc.act(dispute)
+		}
+
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s: error %v, want %v", tc.name, err, tc.err)
+		}
+
+		if err == nil {
+			dispute = next
+		}
+
+		stored, err := repos.Disputes.GetByID(ctx, dispute.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stored.Status != tc.status || (stored.Status == domain.DisputeOpen) != (stored.ResolvedAt == nil) {
+			t.Errorf("%s: dispute %s resolved at %v, want %s", tc.name, stored.Status, stored.ResolvedAt, tc.status)
+		}
+
+		if current, _ := repos.Fines.GetByID(ctx, fine.ID); current.Status != tc.fine {
+			t.Errorf("%s: fine %s, want %s", tc.name, current.Status, tc.fine)
+		}
+	}
+
+	if dispute.ReviewerID == nil || *dispute.ReviewerID != reviewerID || dispute.Decision != "checked" {
+		t.Errorf("accepted dispute by %v with %q, want by the reviewer", dispute.ReviewerID, dispute.Decision)
+	}
+
+	disputes, err := s.List(ctx, Actor{UserID: userID}, fine.ID)
+	if err != nil || len(disputes) != 3 {
+		t.Fatalf("list = %d disputes, %v; want 3", len(disputes), err)
+	}
+
+	// The periods the disputes were open pause the penalty of the fine.
+	if pauses := penaltyPauses(disputes)[fine.ID]; len(pauses) != 3 || pauses[2].To == nil {
+		t.Errorf("penalty pauses %+v, want three closed periods", pauses)
+	}
+}
+
+func TestDisputesAccess(t *testing.T) {
+	s, repos, _ := newDisputesTestService(t)
+	ctx := context.Background()
+
+	userID, otherID := uuid.New(), uuid.New()
+
+	for _, status := range []domain.FineStatus{domain.FinePaid, domain.FineCancelled} {
+		fine := disputeTestFine(t, repos, userID, status)
+		if _, err := s.Open(ctx, userID, fine.ID, DisputeOpenInput{Reason: domain.DisputeOther}); !errors.Is(err, domain.ErrFineTransition) {
+			t.Errorf("open on a %s fine: error %v, want %v", status, err, domain.ErrFineTransition)
+		}
+	}
+
+	fine := disputeTestFine(t, repos, userID, domain.FineIssued)
+
+	if _, err := s.Open(ctx, otherID, fine.ID, DisputeOpenInput{Reason: domain.DisputeOther}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("open by another user: error %v, want %v", err, domain.ErrNotFound)
+	}
+
+	dispute, err := s.Open(ctx, userID, fine.ID, DisputeOpenInput{Reason: domain.DisputeOther})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Get(ctx, Actor{UserID: otherID}, dispute.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("get by another user: error %v, want %v", err, domain.ErrNotFound)
+	}
+
+	if _, err := s.Get(ctx, Actor{UserID: otherID, Reviewer: true}, dispute.ID); err != nil {
+		t.Errorf("get by a reviewer: %v", err)
+	}
+
+	if _, err := s.Withdraw(ctx, otherID, dispute.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("withdraw by another user: error %v, want %v", err, domain.ErrNotFound)
+	}
+
+	if _, err := s.AddAttachment(ctx, otherID, dispute.ID, AttachmentInput{Body: strings.NewReader(disputeTestPNG)}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("upload by another user: error %v, want %v", err, domain.ErrNotFound)
+	}
+}
+
+func TestDisputesAttachments(t *testing.T) {
+	s, repos, root := newDisputesTestService(t)
+	ctx := context.Background()
+
+	userID := uuid.New()
+	fine := disputeTestFine(t, repos, userID, domain.FineIssued)
+
+	dispute, err := s.Open(ctx, userID, fine.ID, DisputeOpenInput{Reason: domain.DisputeAlreadyPaid})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	png := func(size int) []byte {
+		return append([]byte(disputeTestPNG), make([]byte, size-len(disputeTestPNG))...)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		file    string
+		content []byte
+		size    int64
+		err     error
+	}{
+		{"declared too large", "a.png", png(16), MaxAttachmentSize + 1, domain.ErrAttachmentTooLarge},
+		{"larger than declared", "b.png", png(MaxAttachmentSize + 1), 16, domain.ErrAttachmentTooLarge},
+		{"text", "c.png", []byte("not an image"), 12, domain.ErrAttachmentType},
+		{"empty", "d.png", nil, 0, domain.ErrAttachmentType},
+		{"executable", "e.pdf", []byte("MZ\x90\x00"), 4, domain.ErrAttachmentType},
+		{"largest allowed", "../f.png", png(MaxAttachmentSize), MaxAttachmentSize, nil},
+		{"pdf", "g.pdf", []byte("%PDF-1.7\n"), 9, nil},
+		{"small image", "h.png", png(16), 16, nil},
+		{"fourth", "i.png", png(16), 16, nil},
+		{"fifth", "j.png", png(16), 16, nil},
+		{"over the count", "k.png", png(16), 16, domain.ErrTooManyAttachments},
+	} {
+		attachment, err := s.AddAttachment(ctx, userID, dispute.ID, AttachmentInput{
+			FileName: tc.file,
+			Size:     tc.size,
+			Body:     bytes.NewReader(tc.content),
+		})
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s: error %v, want %v", tc.name, err, tc.err)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if attachment.Size != int64(len(tc.content)) || strings.Contains(attachment.FileName, "/") {
+			t.Errorf("%s: attachment %q of %d bytes, want %d bytes without a path", tc.name, attachment.FileName, attachment.Size, len(tc.content))
+		}
+
+		_, body, err := s.GetAttachment(ctx, Actor{UserID: userID}, dispute.ID, attachment.ID)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		stored, err := io.ReadAll(body)
+		body.Close()
+
+		if err != nil || !bytes.Equal(stored, tc.content) {
+			t.Errorf("%s: stored %d bytes, %v; want the upload", tc.name, len(stored), err)
+		}
+	}
+
+	attachments, err := s.ListAttachments(ctx, Actor{UserID: userID}, dispute.ID)
+	if err != nil || len(attachments) != MaxAttachments {
+		t.Fatalf("list = %d attachments, %v; want %d", len(attachments), err, MaxAttachments)
+	}
+
+	// The file that turned out too large is not kept.
+	files, err := os.ReadDir(filepath.Join(root, "disputes", dispute.ID.String()))
+	if err != nil || len(files) != MaxAttachments {
+		t.Errorf("%d files stored, %v; want %d", len(files), err, MaxAttachments)
+	}
+
+	if _, err := s.Withdraw(ctx, userID, dispute.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.AddAttachment(ctx, userID, dispute.ID, AttachmentInput{Size: 16, Body: bytes.NewReader(png(16))}); !errors.Is(err, domain.ErrDisputeClosed) {
+		t.Errorf("upload to a closed dispute: error %v, want %v", err, domain.ErrDisputeClosed)
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/exrate"
+	"backend-vtb/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+type ExchangeRatesService struct {
+	repos  *repository.Repository
+	base   string
+	cfg    ExchangeRatesConfig
+	logger *slog.Logger
+}
+
+func NewExchangeRatesService(repos *repository.Repository, cfg ExchangeRatesConfig, logger *slog.Logger) *ExchangeRatesService {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+
+	base := defaultCurrency
+	if cfg.Provider != nil {
+		base = strings.ToUpper(cfg.Provider.Base())
+	}
+
+	return &ExchangeRatesService{
+		repos:  repos,
+		base:   base,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+func (s *ExchangeRatesService) Rate(ctx context.Context, from, to string, asOf time.Time) (money.Rate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	for _, currency := range []string{from, to} {
+		if _, ok := money.MinorUnits(currency); !ok {
+			return money.Rate{}, fmt.Errorf("%w: %q", domain.ErrInvalidCurrency, currency)
+		}
+	}
+
+	if from == to {
+		return money.Identity(from), nil
+	}
+
+	fromRate, err := s.baseRate(ctx, from, asOf)
+	if err != nil {
+		return money.Rate{}, err
+	}
+
+	toRate, err := s.baseRate(ctx, to, asOf)
+	if err != nil {
+		return money.Rate{}, err
+	}
+
+	// Crossed through the base currency: from/base and base/to.
+	return fromRate.Cross(toRate.Invert())
+}
+
+func (s *ExchangeRatesService) Convert(ctx context.Context, amount money.Money, to string, asOf time.Time) (money.Money, error) {
+	rate, err := s.Rate(ctx, amount.Currency, to, asOf)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	return rate.Convert(amount)
+}
+
+// baseRate returns the price of the currency in the base currency on the day
+// of asOf.
+func (s *ExchangeRatesService) baseRate(ctx context.Context, currency string, asOf time.Time) (money.Rate, error) {
+	if currency == s.base {
+		return money.Identity(currency), nil
+	}
+
+	day := s.day(asOf)
+
+	stored, err := s.repos.ExchangeRates.GetAsOf(ctx, currency, day)
+	if errors.Is(err, domain.ErrNotFound) {
+		return money.Rate{}, fmt.Errorf("%w: %s on %s", domain.ErrNoExchangeRate, currency, day.Format(time.DateOnly))
+	}
+
+	if err != nil {
+		return money.Rate{}, err
+	}
+
+	if s.cfg.MaxAge > 0 && day.Sub(stored.Date) > s.cfg.MaxAge {
+		return money.Rate{}, fmt.Errorf("%w: %s on %s, the latest is of %s", domain.ErrNoExchangeRate,
+			currency, day.Format(time.DateOnly), stored.Date.Format(time.DateOnly))
+	}
+
+	return money.ParseRate(currency, s.base, stored.Rate)
+}
+
+func (s *ExchangeRatesService) Sync(ctx context.Context, from, to time.Time) (int, error) {
+	if s.cfg.Provider == nil {
+		return 0, errors.New("no exchange rate provider is configured")
+	}
+
+	var days int
+	for day := s.day(from); !day.After(s.day(to)); day = day.AddDate(0, 0, 1) {
+		published, err := s.cfg.Provider.Rates(ctx, day)
+		if errors.Is(err, exrate.ErrNoRates) {
+			continue
+		}
+
+		if err != nil {
+			return days, fmt.Errorf("rates of %s: %w", day.Format(time.DateOnly), err)
+		}
+
+		rates := make([]domain.ExchangeRate, 0, len(published))
+		for _, rate := range published {
+			rates = append(rates, domain.ExchangeRate{Currency: rate.Base, Date: day, Rate: rate.String()})
+		}
+
+		if err := s.repos.ExchangeRates.Save(ctx, rates...); err != nil {
+			return days, err
+		}
+
+		days++
+	}
+
+	s.logger.Info("exchange rates synced",
+		slog.String("from", s.day(from).Format(time.DateOnly)), slog.String("to", s.day(to).Format(time.DateOnly)),
+		slog.Int("days", days))
+
+	return days, nil
+}
+
+// day returns the calendar day of t in the configured location, as midnight UTC.
+func (s *ExchangeRatesService) day(t time.Time) time.Time {
+	year, month, day := t.In(s.cfg.Location).Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/paymentqr"
+	"backend-vtb/pkg/qrcode"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type FinesService struct {
+	repos   *repository.Repository
+	rules   domain.FineRules
+	payee   domain.Payee
+	autopay Autopay
+	logger  *slog.Logger
+}
+
+func NewFinesService(repos *repository.Repository, rules domain.FineRules, payee domain.Payee, autopay Autopay,
+	logger *slog.Logger,
+) *FinesService {
+	return &FinesService{
+		repos:   repos,
+		rules:   rules,
+		payee:   payee,
+		autopay: autopay,
+		logger:  logger,
+	}
+}
+
+func (s *FinesService) List(ctx context.Context, userID uuid.UUID) ([]domain.Fine, error) {
+	return s.repos.Fines.GetByUser(ctx, userID)
+}
+
+func (s *FinesService) Get(ctx context.Context, userID, fineID uuid.UUID) (domain.Fine, error) {
+	fine, err := s.repos.Fines.GetByID(ctx, fineID)
+	if err != nil {
+		return domain.Fine{}, err
+	}
+
+	// Do not reveal that a fine of another user exists.
+	if fine.UserID != userID {
+		return domain.Fine{}, domain.ErrNotFound
+	}
+
+	return fine, nil
+}
+
+func (s *FinesService) Create(ctx context.Context, userID uuid.UUID, input FineCreateInput) (domain.Fine, error) {
+	// The UIN is optional for fines entered by hand, but must be valid if given.
+	var fineUIN string
+	if strings.TrimSpace(input.UIN) != "" {
+		normalized, err := validateUIN(input.UIN)
+		if err != nil {
+			return domain.Fine{}, err
+		}
+
+		fineUIN = normalized
+	}
+
+	now := time.Now()
+	fine := domain.Fine{
+		ID:            uuid.New(),
+		UserID:        userID,
+		UIN:           fineUIN,
+		Issuer:        input.Issuer,
+		Article:       input.Article,
+		Amount:        input.Amount,
+		IssuedAt:      input.IssuedAt,
+		DueAt:         input.IssuedAt.AddDate(0, 0, s.rules.PaymentDays),
+		DiscountUntil: input.DiscountUntil,
+		Status:        domain.FineIssued,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if input.DueAt != nil {
+		if input.DueAt.Before(input.IssuedAt) {
+			return domain.Fine{}, fmt.Errorf("%w: due date is before the fine was issued", domain.ErrFineDeadline)
+		}
+
+		fine.DueAt = *input.DueAt
+	}
+
+	if fine.DiscountUntil != nil && fine.DiscountUntil.After(fine.DueAt) {
+		return domain.Fine{}, fmt.Errorf("%w: discount ends after the due date", domain.ErrFineDeadline)
+	}
+
+	if fine.DiscountUntil == nil && s.rules.DiscountPercent > 0 && s.rules.DiscountDays > 0 {
+		// The discount of a fine due early ends with it.
+		discountUntil := input.IssuedAt.AddDate(0, 0, s.rules.DiscountDays)
+		if discountUntil.After(fine.DueAt) {
+			discountUntil = fine.DueAt
+		}
+
+		fine.DiscountUntil = &discountUntil
+	}
+
+	event := newFineEvent(fine, domain.FineEventCreated, userID, "")
+	if err := s.repos.Fines.Create(ctx, fine, event); err != nil {
+		return domain.Fine{}, err
+	}
+
+	// The fine is registered all the same; the user can still pay it by hand.
+	if err := s.autopay.Plan(ctx, fine); err != nil {
+		s.logger.Error("failed to plan autopay of fine",
+			slog.String("fine", fine.ID.String()), slog.String("reason", err.Error()))
+	}
+
+	return fine, nil
+}
+
+func (s *FinesService) Update(ctx context.Context, userID, fineID uuid.UUID, input FineUpdateInput) (domain.Fine, error) {
+	fine, err := s.Get(ctx, userID, fineID)
+	if err != nil {
+		return domain.Fine{}, err
+	}
+
+	edited := input.Issuer != nil || input.Article != nil || input.Amount != nil ||
+		input.DueAt != nil || input.DiscountUntil != nil
+	if edited && fine.Status != domain.FineIssued {
+		return domain.Fine{}, fmt.Errorf("%w: fine is %s", domain.ErrFineNotEditable, fine.Status)
+	}
+
+	// The deadlines are set by the issuer; moving them later would extend the
+	// discount or postpone the penalties.
+	if input.DueAt != nil && input.DueAt.After(fine.DueAt) {
+		return domain.Fine{}, fmt.Errorf("%w: due date is %s", domain.ErrFineDeadline, fine.DueAt.Format(time.RFC3339))
+	}
+
+	if input.DiscountUntil != nil {
+		if fine.DiscountUntil == nil {
+			return domain.Fine{}, fmt.Errorf("%w: fine has no discount", domain.ErrFineDeadline)
+		}
+
+		if input.DiscountUntil.After(*fine.DiscountUntil) {
+			return domain.Fine{}, fmt.Errorf("%w: discount ends %s", domain.ErrFineDeadline,
+				fine.DiscountUntil.Format(time.RFC3339))
+		}
+	}
+
+	if input.Issuer != nil {
+		fine.Issuer = *input.Issuer
+	}
+
+	if input.Article != nil {
+		fine.Article = *input.Article
+	}
+
+	if input.Amount != nil {
+		fine.Amount = *input.Amount
+	}
+
+	if input.DueAt != nil {
+		fine.DueAt = *input.DueAt
+	}
+
+	if input.DiscountUntil != nil {
+		fine.DiscountUntil = input.DiscountUntil
+	}
+
+	if input.Status != nil && *input.Status != fine.Status {
+		if !input.Status.Valid() {
+			return domain.Fine{}, domain.ErrInvalidFineStatus
+		}
+
+		// Only a captured payment pays a fine.
+		if *input.Status == domain.FinePaid {
+			return domain.Fine{}, fmt.Errorf("%w: fines are paid by payments", domain.ErrFineTransition)
+		}
+
+		if !fine.Status.CanTransition(*input.Status) {
+			return domain.Fine{}, fmt.Errorf("%w: from %s to %s", domain.ErrFineTransition, fine.Status, *input.Status)
+		}
+
+		// A disputed fine only leaves that status through the dispute itself.
+		if fine.Status == domain.FineDisputed {
+			return domain.Fine{}, domain.ErrDisputeExists
+		}
+
+		fine.Status = *input.Status
+	}
+
+	fine.UpdatedAt = time.Now()
+
+	event := newFineEvent(fine, domain.FineEventUpdated, userID, "")
+	if err := s.repos.Fines.Update(ctx, fine, event); err != nil {
+		return domain.Fine{}, err
+	}
+
+	return fine, nil
+}
+
+func (s *FinesService) Charge(ctx context.Context, userID, fineID uuid.UUID, asOf time.Time) (domain.FineCharge, error) {
+	fine, err := s.Get(ctx, userID, fineID)
+	if err != nil {
+		return domain.FineCharge{}, err
+	}
+
+	disputes, err := s.repos.Disputes.GetByFine(ctx, fineID)
+	if err != nil {
+		return domain.FineCharge{}, err
+	}
+
+	fine.PenaltyPauses = penaltyPauses(disputes)[fineID]
+
+	return fine.Charge(asOf, s.rules), nil
+}
+
+func (s *FinesService) QR(ctx context.Context, userID, fineID uuid.UUID, format domain.QRFormat) (domain.PaymentQR, error) {
+	charge, err := s.Charge(ctx, userID, fineID, time.Now())
+	if err != nil {
+		return domain.PaymentQR{}, err
+	}
+
+	if !charge.State.Open() {
+		return domain.PaymentQR{}, fmt.Errorf("%w: fine is %s", domain.ErrFineNotPayable, charge.State)
+	}
+
+	fine, err := s.Get(ctx, userID, fineID)
+	if err != nil {
+		return domain.PaymentQR{}, err
+	}
+
+	// Banks credit state payments by the UIN, without one the payment
+	// cannot be matched to the fine.
+	if fine.UIN == "" {
+		return domain.PaymentQR{}, fmt.Errorf("%w: fine has no UIN", domain.ErrFineNotPayable)
+	}
+
+	payload, err := paymentqr.Encode(paymentqr.Payment{
+		Name:        s.payee.Name,
+		PersonalAcc: s.payee.PersonalAcc,
+		BankName:    s.payee.BankName,
+		BIC:         s.payee.BIC,
+		CorrespAcc:  s.payee.CorrespAcc,
+		Sum:         charge.Total,
+		Purpose:     finePurpose(fine),
+		PayeeINN:    s.payee.INN,
+		KPP:         s.payee.KPP,
+		CBC:         s.payee.CBC,
+		OKTMO:       s.payee.OKTMO,
+		UIN:         fine.UIN,
+	})
+	if err != nil {
+		return domain.PaymentQR{}, fmt.Errorf("fine payee is misconfigured: %w", err)
+	}
+
+	code, err := qrcode.Encode([]byte(payload), qrcode.Medium)
+	if err != nil {
+		return domain.PaymentQR{}, err
+	}
+
+	qr := domain.PaymentQR{Payload: payload, Format: format}
+
+	switch format {
+	case domain.QRFormatSVG:
+		qr.Image = code.SVG(qrCodeScale)
+	default:
+		qr.Format = domain.QRFormatPNG
+		if qr.Image, err = code.PNG(qrCodeScale); err != nil {
+			return domain.PaymentQR{}, err
+		}
+	}
+
+	return qr, nil
+}
+
+// finePurpose describes the fine in the purpose of a payment by bank transfer.
+func finePurpose(fine domain.Fine) string {
+	purpose := "Штраф " + fine.Issuer
+	if fine.Article != "" {
+		purpose += " по ст. " + fine.Article
+	}
+
+	return purpose + ", УИН " + fine.UIN
+}
+
+func (s *FinesService) History(ctx context.Context, userID, fineID uuid.UUID) ([]domain.FineEvent, error) {
+	if _, err := s.Get(ctx, userID, fineID); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Fines.GetHistory(ctx, fineID)
+}
+
+func (s *FinesService) Delete(ctx context.Context, userID, fineID uuid.UUID) error {
+	fine, err := s.Get(ctx, userID, fineID)
+	if err != nil {
+		return err
+	}
+
+	// Deleting the fine would also delete the dispute and its history.
+	if fine.Status == domain.FineDisputed {
+		return domain.ErrDisputeExists
+	}
+
+	// A paid or cancelled fine is kept for the record.
+	if fine.Status != domain.FineIssued {
+		return fmt.Errorf("%w: fine is %s", domain.ErrFineNotDeletable, fine.Status)
+	}
+
+	payments, err := s.repos.Payments.GetByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, payment := range payments {
+		if payment.FineID != nil && *payment.FineID == fineID && payment.Status.InProgress() {
+			return domain.ErrFinePaymentExists
+		}
+	}
+
+	return s.repos.Fines.Delete(ctx, fineID)
+}
+
+// newFineEvent returns a history entry for the fine in its new state.
+func newFineEvent(fine domain.Fine, eventType domain.FineEventType, actorID uuid.UUID, comment string) domain.FineEvent {
+	return domain.FineEvent{
+		ID:        uuid.New(),
+		FineID:    fine.ID,
+		Type:      eventType,
+		Status:    fine.Status,
+		ActorID:   actorID,
+		Comment:   comment,
+		CreatedAt: fine.UpdatedAt,
+	}
+}
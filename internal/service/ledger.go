@@ -0,0 +1,94 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/money"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LedgerService struct {
+	repos    *repository.Repository
+	rates    ExchangeRates
+	location *time.Location
+	logger   *slog.Logger
+}
+
+func NewLedgerService(repos *repository.Repository, rates ExchangeRates, location *time.Location, logger *slog.Logger) *LedgerService {
+	if location == nil {
+		location = time.UTC
+	}
+
+	return &LedgerService{
+		repos:    repos,
+		rates:    rates,
+		location: location,
+		logger:   logger,
+	}
+}
+
+func (s *LedgerService) Balances(ctx context.Context, userID uuid.UUID) ([]domain.AccountBalance, error) {
+	return s.repos.Ledger.GetBalances(ctx, userID, time.Now())
+}
+
+func (s *LedgerService) Entries(ctx context.Context, userID uuid.UUID) ([]domain.JournalEntry, error) {
+	return s.repos.Ledger.GetEntries(ctx, domain.UserAccount(userID))
+}
+
+func (s *LedgerService) Valuation(ctx context.Context, userID uuid.UUID, currency string, date time.Time) (domain.Valuation, error) {
+	currency = strings.ToUpper(currency)
+	if _, ok := money.MinorUnits(currency); !ok {
+		return domain.Valuation{}, fmt.Errorf("%w: %q", domain.ErrInvalidCurrency, currency)
+	}
+
+	if date.IsZero() {
+		date = time.Now().In(s.location)
+	}
+
+	year, month, day := date.Date()
+	endOfDay := time.Date(year, month, day+1, 0, 0, 0, 0, s.location).Add(-time.Nanosecond)
+
+	balances, err := s.repos.Ledger.GetBalances(ctx, userID, endOfDay)
+	if err != nil {
+		return domain.Valuation{}, err
+	}
+
+	total := money.Zero(currency)
+	valuation := domain.Valuation{
+		Currency: currency,
+		Date:     time.Date(year, month, day, 0, 0, 0, 0, time.UTC),
+		Balances: make([]domain.ValuedBalance, 0, len(balances)),
+	}
+
+	for _, balance := range balances {
+		rate, err := s.rates.Rate(ctx, balance.Currency, currency, endOfDay)
+		if err != nil {
+			return domain.Valuation{}, err
+		}
+
+		value, err := rate.Convert(money.New(balance.Balance, balance.Currency))
+		if err != nil {
+			return domain.Valuation{}, err
+		}
+
+		if total, err = total.Add(value); err != nil {
+			return domain.Valuation{}, err
+		}
+
+		valuation.Balances = append(valuation.Balances, domain.ValuedBalance{
+			AccountBalance: balance,
+			Rate:           rate.String(),
+			Value:          value.Amount,
+		})
+	}
+
+	valuation.Total = total.Amount
+
+	return valuation, nil
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/pkg/otp"
+	"backend-vtb/pkg/qrcode"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// recoveryCodeLength is the number of base32 characters in a recovery
+	// code, i.e. 50 bits of entropy.
+	recoveryCodeLength = 10
+	// qrCodeScale is the size of a QR code module in pixels.
+	qrCodeScale = 6
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func (s *UsersService) EnrollTOTP(ctx context.Context, userID uuid.UUID) (domain.TOTPEnrollment, error) {
+	user, err := s.repos.Users.GetByID(ctx, userID)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	if user.TOTPEnabled {
+		return domain.TOTPEnrollment{}, domain.ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := otp.GenerateSecret()
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	uri := s.mfa.TOTP.KeyURI(s.mfa.Issuer, user.Email, secret)
+
+	code, err := qrcode.Encode([]byte(uri), qrcode.Medium)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	png, err := code.PNG(qrCodeScale)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	if err := s.repos.Users.SetTOTPSecret(ctx, userID, secret); err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+
+	return domain.TOTPEnrollment{Secret: secret, URI: uri, QRCode: png}, nil
+}
+
+func (s *UsersService) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.repos.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, domain.ErrTOTPAlreadyEnabled
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, domain.ErrTOTPNotEnrolled
+	}
+
+	counter, ok, err := s.mfa.TOTP.Validate(user.TOTPSecret, code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, domain.ErrInvalidOTP
+	}
+
+	codes, records, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.RecoveryCodes.Replace(ctx, userID, records); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Users.EnableTOTP(ctx, userID, counter); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *UsersService) DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := s.repos.Users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return domain.ErrTOTPNotEnrolled
+	}
+
+	if err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.repos.Users.DisableTOTP(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.repos.RecoveryCodes.Replace(ctx, userID, nil)
+}
+
+func (s *UsersService) VerifyMFA(ctx context.Context, mfaToken, code string, client ClientInfo) (domain.Tokens, error) {
+	subject, err := s.tokenManager.ParseMFAToken(mfaToken)
+	if err != nil {
+		return domain.Tokens{}, domain.ErrInvalidMFAToken
+	}
+
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return domain.Tokens{}, domain.ErrInvalidMFAToken
+	}
+
+	user, err := s.repos.Users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Tokens{}, domain.ErrInvalidMFAToken
+		}
+
+		return domain.Tokens{}, err
+	}
+
+	// Two-factor authentication may have been disabled after the challenge was issued.
+	if !user.TOTPEnabled {
+		return domain.Tokens{}, domain.ErrInvalidMFAToken
+	}
+
+	if err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return domain.Tokens{}, err
+	}
+
+	return s.createSession(ctx, user, client)
+}
+
+// verifySecondFactor accepts either a TOTP code or one of the user's unused
+// recovery codes. Both can only be used once.
+func (s *UsersService) verifySecondFactor(ctx context.Context, user domain.User, code string) error {
+	code = strings.TrimSpace(code)
+	if !isTOTPCode(code, s.mfa.TOTP.Digits) {
+		return s.useRecoveryCode(ctx, user.ID, code)
+	}
+
+	counter, ok, err := s.mfa.TOTP.Validate(user.TOTPSecret, code, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return domain.ErrInvalidOTP
+	}
+
+	return s.repos.Users.UseTOTPCounter(ctx, user.ID, counter)
+}
+
+func (s *UsersService) useRecoveryCode(ctx context.Context, userID uuid.UUID, code string) error {
+	code = normalizeRecoveryCode(code)
+	if len(code) != recoveryCodeLength {
+		return domain.ErrInvalidOTP
+	}
+
+	codes, err := s.repos.RecoveryCodes.GetUnused(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range codes {
+		ok, err := s.hasher.Verify(code, record.Hash)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := s.repos.RecoveryCodes.Use(ctx, record.ID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return domain.ErrInvalidOTP
+			}
+
+			return err
+		}
+
+		s.logger.Info("recovery code used",
+			slog.String("user", userID.String()), slog.Int("remaining", len(codes)-1))
+
+		return nil
+	}
+
+	return domain.ErrInvalidOTP
+}
+
+// newRecoveryCodes generates recovery codes formatted as "xxxxx-xxxxx" for
+// the user, along with the hashed records to be stored.
+func (s *UsersService) newRecoveryCodes() ([]string, []domain.RecoveryCode, error) {
+	now := time.Now()
+	codes := make([]string, 0, s.mfa.RecoveryCodes)
+	records := make([]domain.RecoveryCode, 0, s.mfa.RecoveryCodes)
+
+	for i := 0; i < s.mfa.RecoveryCodes; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:recoveryCodeLength]
+
+		codeHash, err := s.hasher.Hash(code)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		records = append(records, domain.RecoveryCode{ID: uuid.New(), Hash: codeHash, CreatedAt: now})
+	}
+
+	return codes, records, nil
+}
+
+func isTOTPCode(code string, digits int) bool {
+	if len(code) != digits {
+		return false
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+type NotificationsService struct {
+	repos  *repository.Repository
+	logger *slog.Logger
+}
+
+func NewNotificationsService(repos *repository.Repository, logger *slog.Logger) *NotificationsService {
+	return &NotificationsService{
+		repos:  repos,
+		logger: logger,
+	}
+}
+
+func (s *NotificationsService) List(ctx context.Context, userID uuid.UUID) ([]domain.Notification, error) {
+	return s.repos.Notifications.GetByUser(ctx, userID)
+}
+
+// Notify keeps the notification for the user to see in the app.
+func (s *NotificationsService) Notify(ctx context.Context, notification domain.Notification) error {
+	return s.repos.Notifications.Create(ctx, notification)
+}
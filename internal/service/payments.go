@@ -0,0 +1,705 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/gateway"
+	"backend-vtb/pkg/money"
+	"backend-vtb/pkg/paymentqr"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultCurrency is used when a payment does not specify one. Fines are
+// always paid in it.
+const defaultCurrency = "RUB"
+
+// Failure reasons of payments that were not declined by the issuer.
+const (
+	failureVoided         = "voided"
+	failureFineNotPayable = "fine_not_payable"
+)
+
+type PaymentsService struct {
+	repos   *repository.Repository
+	fines   Fines
+	gateway gateway.PaymentGateway
+	logger  *slog.Logger
+}
+
+func NewPaymentsService(repos *repository.Repository, fines Fines, gateway gateway.PaymentGateway, logger *slog.Logger) *PaymentsService {
+	return &PaymentsService{
+		repos:   repos,
+		fines:   fines,
+		gateway: gateway,
+		logger:  logger,
+	}
+}
+
+func (s *PaymentsService) List(ctx context.Context, userID uuid.UUID) ([]domain.Payment, error) {
+	return s.repos.Payments.GetByUser(ctx, userID)
+}
+
+func (s *PaymentsService) Get(ctx context.Context, userID, paymentID uuid.UUID) (domain.Payment, error) {
+	payment, err := s.repos.Payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	// Do not reveal that a payment of another user exists.
+	if payment.UserID != userID {
+		return domain.Payment{}, domain.ErrNotFound
+	}
+
+	return payment, nil
+}
+
+func (s *PaymentsService) Create(ctx context.Context, userID uuid.UUID, input PaymentCreateInput) (domain.Payment, bool, error) {
+	requestHash := paymentRequestHash(input)
+
+	if input.IdempotencyKey != "" {
+		payment, replayed, err := s.replay(ctx, userID, input.IdempotencyKey, requestHash)
+		if err != nil || replayed {
+			return payment, replayed, err
+		}
+	}
+
+	payment, err := s.newPayment(ctx, userID, input)
+	if err != nil {
+		return domain.Payment{}, false, err
+	}
+
+	payment.IdempotencyKey = input.IdempotencyKey
+	payment.RequestHash = requestHash
+
+	if err := s.repos.Payments.Create(ctx, payment); err != nil {
+		// A concurrent request with the same key got there first.
+		if errors.Is(err, domain.ErrPaymentAlreadyExists) {
+			return s.replay(ctx, userID, input.IdempotencyKey, requestHash)
+		}
+
+		return domain.Payment{}, false, err
+	}
+
+	s.logger.Info("payment created",
+		slog.String("payment", payment.ID.String()), slog.String("user", userID.String()),
+		slog.Int64("amount", payment.Amount), slog.String("currency", payment.Currency))
+
+	return payment, false, nil
+}
+
+// replay returns the payment created earlier with the idempotency key, if
+// there is one. The key may only be reused for an identical request.
+func (s *PaymentsService) replay(ctx context.Context, userID uuid.UUID, key, requestHash string) (domain.Payment, bool, error) {
+	payment, err := s.repos.Payments.GetByIdempotencyKey(ctx, userID, key)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Payment{}, false, nil
+		}
+
+		return domain.Payment{}, false, err
+	}
+
+	if payment.RequestHash != requestHash {
+		return domain.Payment{}, false, domain.ErrIdempotencyKeyReused
+	}
+
+	return payment, true, nil
+}
+
+// newPayment validates the input and builds a pending payment. The amount of
+// a fine payment is whatever is due now, so the client may omit it.
+func (s *PaymentsService) newPayment(ctx context.Context, userID uuid.UUID, input PaymentCreateInput) (domain.Payment, error) {
+	if (input.FineID == nil) == (input.MerchantID == "") {
+		return domain.Payment{}, domain.ErrInvalidPaymentTarget
+	}
+
+	amount, err := paymentAmount(input.Amount)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	now := time.Now()
+	payment := domain.Payment{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Amount:     amount.Amount,
+		Currency:   amount.Currency,
+		Purpose:    input.Purpose,
+		TargetType: domain.PaymentTargetMerchant,
+		MerchantID: input.MerchantID,
+		Status:     domain.PaymentPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if input.FineID == nil {
+		if !amount.IsPositive() {
+			return domain.Payment{}, fmt.Errorf("%w: must be positive", domain.ErrPaymentAmount)
+		}
+
+		return payment, nil
+	}
+
+	charge, err := s.fines.Charge(ctx, userID, *input.FineID, now)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if !charge.State.Open() {
+		return domain.Payment{}, fmt.Errorf("%w: fine is %s", domain.ErrFineNotPayable, charge.State)
+	}
+
+	if payment.Currency != defaultCurrency {
+		return domain.Payment{}, fmt.Errorf("%w: fines are paid in %s", domain.ErrPaymentAmount, defaultCurrency)
+	}
+
+	if !amount.IsZero() && amount.Amount != charge.Total {
+		return domain.Payment{}, fmt.Errorf("%w: %d is due", domain.ErrPaymentAmount, charge.Total)
+	}
+
+	payment.Amount = charge.Total
+	payment.TargetType = domain.PaymentTargetFine
+	payment.FineID = input.FineID
+
+	return payment, nil
+}
+
+// paymentAmount defaults the currency of the amount to rubles and checks
+// that it is a known one.
+func paymentAmount(amount money.Money) (money.Money, error) {
+	if amount.Currency == "" {
+		amount.Currency = defaultCurrency
+	}
+
+	amount = money.New(amount.Amount, amount.Currency)
+	if err := amount.Validate(); err != nil {
+		return money.Money{}, fmt.Errorf("%w: %q", domain.ErrInvalidCurrency, amount.Currency)
+	}
+
+	return amount, nil
+}
+
+// paymentRequestHash fingerprints the request as sent by the client, so that
+// a retry matches even if the amount due has changed in between.
+func paymentRequestHash(input PaymentCreateInput) string {
+	var fineID string
+	if input.FineID != nil {
+		fineID = input.FineID.String()
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		fmt.Sprint(input.Amount.Amount), strings.ToUpper(input.Amount.Currency), input.Purpose, fineID, input.MerchantID,
+	}, "\x00")))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *PaymentsService) DraftFromQR(ctx context.Context, userID uuid.UUID, payload string) (domain.PaymentDraft, error) {
+	details, err := paymentqr.Decode([]byte(payload))
+	if err != nil {
+		return domain.PaymentDraft{}, fmt.Errorf("%w: %w", domain.ErrInvalidQRPayload, err)
+	}
+
+	draft := domain.PaymentDraft{
+		Amount:   details.Sum,
+		Currency: defaultCurrency,
+		Purpose:  details.Purpose,
+		UIN:      details.UIN,
+		Payee: domain.Payee{
+			Name:        details.Name,
+			PersonalAcc: details.PersonalAcc,
+			BankName:    details.BankName,
+			BIC:         details.BIC,
+			CorrespAcc:  details.CorrespAcc,
+			INN:         details.PayeeINN,
+			KPP:         details.KPP,
+			OKTMO:       details.OKTMO,
+			CBC:         details.CBC,
+		},
+	}
+
+	if details.UIN != "" {
+		fine, err := s.repos.Fines.GetByUIN(ctx, userID, details.UIN)
+		switch {
+		case err == nil:
+			// The printed sum may be outdated once the discount ends or
+			// penalties accrue, so the fine is charged at today's amount.
+			charge, err := s.fines.Charge(ctx, userID, fine.ID, time.Now())
+			if err != nil {
+				return domain.PaymentDraft{}, err
+			}
+
+			if !charge.State.Open() {
+				return domain.PaymentDraft{}, fmt.Errorf("%w: fine is %s", domain.ErrFineNotPayable, charge.State)
+			}
+
+			draft.Amount = charge.Total
+			draft.FineID = &fine.ID
+
+			return draft, nil
+		case !errors.Is(err, domain.ErrNotFound):
+			return domain.PaymentDraft{}, err
+		}
+	}
+
+	draft.MerchantID = details.PayeeINN
+	if draft.MerchantID == "" {
+		draft.MerchantID = details.PersonalAcc
+	}
+
+	return draft, nil
+}
+
+func (s *PaymentsService) Authorize(ctx context.Context, userID, paymentID uuid.UUID, card gateway.Card) (domain.Payment, error) {
+	payment, err := s.Get(ctx, userID, paymentID)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if payment.Status != domain.PaymentPending || payment.ChallengeURL != "" {
+		return domain.Payment{}, domain.ErrPaymentTransition
+	}
+
+	tx, err := s.gateway.Authorize(ctx, gateway.AuthorizeRequest{
+		PaymentID: payment.ID.String(),
+		Amount:    payment.Amount,
+		Currency:  payment.Currency,
+		Card:      card,
+	})
+	if err != nil {
+		return domain.Payment{}, gatewayError(err)
+	}
+
+	s.logger.Info("payment authorization",
+		slog.String("payment", payment.ID.String()), slog.String("card", card.String()),
+		slog.String("reference", tx.Reference), slog.String("status", string(tx.Status)))
+
+	return s.applyAuthorization(ctx, payment, tx)
+}
+
+func (s *PaymentsService) Authenticate(ctx context.Context, userID, paymentID uuid.UUID, response string) (domain.Payment, error) {
+	payment, err := s.Get(ctx, userID, paymentID)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if payment.Status != domain.PaymentPending || payment.ChallengeURL == "" {
+		return domain.Payment{}, domain.ErrPaymentTransition
+	}
+
+	tx, err := s.gateway.Authenticate(ctx, payment.GatewayRef, response)
+	if err != nil {
+		return domain.Payment{}, gatewayError(err)
+	}
+
+	return s.applyAuthorization(ctx, payment, tx)
+}
+
+// applyAuthorization records the outcome of an authorization or of its 3-D
+// Secure challenge on the pending payment.
+func (s *PaymentsService) applyAuthorization(ctx context.Context, payment domain.Payment, tx gateway.Transaction) (domain.Payment, error) {
+	transition := domain.PaymentTransition{
+		PaymentID:  payment.ID,
+		From:       payment.Status,
+		GatewayRef: tx.Reference,
+	}
+
+	switch tx.Status {
+	case gateway.StatusAuthorized:
+		transition.To = domain.PaymentAuthorized
+	case gateway.StatusActionRequired:
+		transition.To = domain.PaymentPending
+		transition.ChallengeURL = tx.ChallengeURL
+	case gateway.StatusDeclined:
+		transition.To = domain.PaymentFailed
+		transition.FailureReason = tx.DeclineCode
+	default:
+		return domain.Payment{}, fmt.Errorf("unexpected gateway status %q after authorization", tx.Status)
+	}
+
+	return s.transition(ctx, payment, transition)
+}
+
+// Capture re-checks that a fine is still payable at the amount of the payment
+// before charging the card, since it may have been paid, cancelled or
+// disputed, or its discount may have ended, since the payment was created.
+// The authorization is released if it is not.
+func (s *PaymentsService) Capture(ctx context.Context, userID, paymentID uuid.UUID) (domain.Payment, error) {
+	payment, err := s.Get(ctx, userID, paymentID)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if payment.Status != domain.PaymentAuthorized {
+		return domain.Payment{}, domain.ErrPaymentTransition
+	}
+
+	now := time.Now()
+	transition := domain.PaymentTransition{
+		PaymentID:  payment.ID,
+		From:       payment.Status,
+		To:         domain.PaymentCaptured,
+		GatewayRef: payment.GatewayRef,
+		Entries:    payment.CaptureEntries(now),
+	}
+
+	transition.FineEvent, err = s.finePaidEvent(ctx, payment, now)
+	if err != nil {
+		if errors.Is(err, domain.ErrFineNotPayable) {
+			if _, voidErr := s.void(ctx, payment, failureFineNotPayable); voidErr != nil {
+				return domain.Payment{}, voidErr
+			}
+		}
+
+		return domain.Payment{}, err
+	}
+
+	if _, err := s.gateway.Capture(ctx, payment.GatewayRef); err != nil {
+		return domain.Payment{}, gatewayError(err)
+	}
+
+	payment, err = s.transition(ctx, payment, transition)
+	if err != nil {
+		// The card has been charged; the payment has to be reconciled with
+		// the acquirer by hand.
+		s.logger.Error("captured payment not recorded",
+			slog.String("payment", paymentID.String()), slog.String("reference", transition.GatewayRef),
+			slog.String("reason", err.Error()))
+
+		return domain.Payment{}, err
+	}
+
+	s.logger.Info("payment captured",
+		slog.String("payment", payment.ID.String()), slog.Int64("amount", payment.Amount),
+		slog.String("currency", payment.Currency))
+
+	return payment, nil
+}
+
+// finePaidEvent returns the event marking the fine of a captured payment as
+// paid, or nil for a merchant payment. It returns domain.ErrFineNotPayable if
+// the fine no longer has to be paid, or not at the amount of the payment.
+func (s *PaymentsService) finePaidEvent(ctx context.Context, payment domain.Payment, at time.Time) (*domain.FineEvent, error) {
+	if payment.FineID == nil {
+		return nil, nil
+	}
+
+	charge, err := s.fines.Charge(ctx, payment.UserID, *payment.FineID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if !charge.State.Open() {
+		return nil, fmt.Errorf("%w: fine is %s", domain.ErrFineNotPayable, charge.State)
+	}
+
+	// The discount may have ended or a penalty accrued since the payment was
+	// created at the amount due then.
+	if charge.Total != payment.Amount {
+		return nil, fmt.Errorf("%w: %d is due instead of %d", domain.ErrFineNotPayable, charge.Total, payment.Amount)
+	}
+
+	fine, err := s.repos.Fines.GetByID(ctx, *payment.FineID)
+	if err != nil {
+		return nil, err
+	}
+
+	fine.Status = domain.FinePaid
+	fine.UpdatedAt = at
+	event := newFineEvent(fine, domain.FineEventPaid, payment.UserID, "payment "+payment.ID.String())
+
+	return &event, nil
+}
+
+func (s *PaymentsService) Void(ctx context.Context, userID, paymentID uuid.UUID) (domain.Payment, error) {
+	payment, err := s.Get(ctx, userID, paymentID)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	return s.void(ctx, payment, failureVoided)
+}
+
+// void releases the authorization of the payment, if it got one, and fails
+// the payment with the given reason.
+func (s *PaymentsService) void(ctx context.Context, payment domain.Payment, reason string) (domain.Payment, error) {
+	if payment.Status != domain.PaymentPending && payment.Status != domain.PaymentAuthorized {
+		return domain.Payment{}, domain.ErrPaymentTransition
+	}
+
+	if payment.GatewayRef != "" {
+		if _, err := s.gateway.Void(ctx, payment.GatewayRef); err != nil {
+			return domain.Payment{}, gatewayError(err)
+		}
+	}
+
+	return s.transition(ctx, payment, domain.PaymentTransition{
+		PaymentID:     payment.ID,
+		From:          payment.Status,
+		To:            domain.PaymentFailed,
+		FailureReason: reason,
+		GatewayRef:    payment.GatewayRef,
+	})
+}
+
+func (s *PaymentsService) Refunds(ctx context.Context, userID, paymentID uuid.UUID) ([]domain.Refund, error) {
+	if _, err := s.Get(ctx, userID, paymentID); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Refunds.GetByPayment(ctx, paymentID)
+}
+
+func (s *PaymentsService) Refund(ctx context.Context, actor Actor, paymentID uuid.UUID, input RefundCreateInput) (domain.Refund, bool, error) {
+	if !actor.Refunder {
+		return domain.Refund{}, false, domain.ErrRefundNotAllowed
+	}
+
+	payment, err := s.repos.Payments.GetByID(ctx, paymentID)
+	if err != nil {
+		return domain.Refund{}, false, err
+	}
+
+	requestHash := refundRequestHash(input)
+
+	if input.IdempotencyKey != "" {
+		refund, replayed, err := s.replayRefund(ctx, paymentID, input.IdempotencyKey, requestHash)
+		if err != nil || replayed {
+			return refund, replayed, err
+		}
+	}
+
+	if payment.Status != domain.PaymentCaptured {
+		return domain.Refund{}, false, domain.ErrPaymentTransition
+	}
+
+	refundable := money.New(payment.Refundable(), payment.Currency)
+
+	amount := input.Amount
+	if amount.Currency == "" {
+		amount.Currency = payment.Currency
+	}
+
+	amount = money.New(amount.Amount, amount.Currency)
+	if amount.IsZero() {
+		amount = refundable
+	}
+
+	if cmp, err := amount.Cmp(refundable); err != nil || !amount.IsPositive() || cmp > 0 {
+		return domain.Refund{}, false, fmt.Errorf("%w: %s is refundable", domain.ErrRefundAmount, refundable)
+	}
+
+	now := time.Now()
+	refund := domain.Refund{
+		ID:             uuid.New(),
+		PaymentID:      payment.ID,
+		UserID:         payment.UserID,
+		Amount:         amount.Amount,
+		Currency:       amount.Currency,
+		Reason:         input.Reason,
+		Status:         domain.RefundPending,
+		IdempotencyKey: input.IdempotencyKey,
+		RequestHash:    requestHash,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if err := s.repos.Refunds.Create(ctx, refund); err != nil {
+		// A concurrent request with the same key got there first.
+		if errors.Is(err, domain.ErrRefundAlreadyExists) {
+			return s.replayRefund(ctx, paymentID, input.IdempotencyKey, requestHash)
+		}
+
+		return domain.Refund{}, false, err
+	}
+
+	_, gatewayErr := s.gateway.Refund(ctx, payment.GatewayRef, amount.Amount)
+
+	refund, err = s.completeRefund(ctx, payment, refund, gatewayErr)
+	if err != nil {
+		return domain.Refund{}, false, err
+	}
+
+	if gatewayErr != nil {
+		return refund, false, gatewayError(gatewayErr)
+	}
+
+	s.logger.Info("payment refunded",
+		slog.String("payment", payment.ID.String()), slog.String("refund", refund.ID.String()),
+		slog.Int64("amount", refund.Amount), slog.String("currency", refund.Currency))
+
+	return refund, false, nil
+}
+
+// completeRefund records the outcome of a pending refund at the acquirer:
+// failed if gatewayErr is set, succeeded otherwise.
+func (s *PaymentsService) completeRefund(ctx context.Context, payment domain.Payment, refund domain.Refund, gatewayErr error) (domain.Refund, error) {
+	now := time.Now()
+	completion := domain.RefundCompletion{
+		RefundID: refund.ID,
+		Status:   domain.RefundSucceeded,
+		At:       now,
+	}
+
+	if gatewayErr != nil {
+		completion.Status = domain.RefundFailed
+		completion.FailureReason = refundFailureReason(gatewayErr)
+	} else {
+		entry := payment.RefundEntry(refund.Amount, now)
+		completion.Entry = &entry
+
+		event, err := s.fineRefundedEvent(ctx, payment, refund, now)
+		if err != nil {
+			return domain.Refund{}, err
+		}
+		completion.FineEvent = event
+	}
+
+	if err := s.repos.Refunds.Complete(ctx, completion); err != nil {
+		if gatewayErr == nil {
+			// The money has been returned; the refund has to be reconciled
+			// with the acquirer by hand.
+			s.logger.Error("succeeded refund not recorded",
+				slog.String("payment", payment.ID.String()), slog.String("refund", refund.ID.String()),
+				slog.String("reason", err.Error()))
+		}
+
+		return domain.Refund{}, err
+	}
+
+	return s.repos.Refunds.GetByID(ctx, refund.ID)
+}
+
+// fineRefundedEvent notes a refund in the history of the paid fine, or
+// returns nil for a merchant payment. Only the refund of the whole payment
+// makes the fine payable again, which the repository decides atomically; a
+// partial refund leaves it paid.
+func (s *PaymentsService) fineRefundedEvent(ctx context.Context, payment domain.Payment, refund domain.Refund, at time.Time) (*domain.FineEvent, error) {
+	if payment.FineID == nil {
+		return nil, nil
+	}
+
+	fine, err := s.repos.Fines.GetByID(ctx, *payment.FineID)
+	if err != nil {
+		return nil, err
+	}
+
+	fine.UpdatedAt = at
+	event := newFineEvent(fine, domain.FineEventPaymentRefunded, payment.UserID,
+		fmt.Sprintf("refund %s of %d", refund.ID, refund.Amount))
+
+	return &event, nil
+}
+
+// reconcileRefunds records a succeeded refund for whatever part of total,
+// the amount the acquirer reports as refunded, is not covered by the
+// payment's refunds yet. It reports whether a refund was recorded.
+func (s *PaymentsService) reconcileRefunds(ctx context.Context, payment domain.Payment, total int64) (bool, error) {
+	now := time.Now()
+	refund := domain.Refund{
+		ID:        uuid.New(),
+		PaymentID: payment.ID,
+		UserID:    payment.UserID,
+		Currency:  payment.Currency,
+		Reason:    "refunded at the acquirer",
+		Status:    domain.RefundPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// The missing part is computed by the repository under the lock of the
+	// payment, so that concurrent deliveries do not both record it.
+	refund, err := s.repos.Refunds.CreateUpTo(ctx, refund, total)
+	if err != nil || refund.Amount == 0 {
+		return false, err
+	}
+
+	if _, err := s.completeRefund(ctx, payment, refund, nil); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// replayRefund returns the refund created earlier with the idempotency key,
+// if there is one. The key may only be reused for an identical request.
+func (s *PaymentsService) replayRefund(ctx context.Context, paymentID uuid.UUID, key, requestHash string) (domain.Refund, bool, error) {
+	refund, err := s.repos.Refunds.GetByIdempotencyKey(ctx, paymentID, key)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Refund{}, false, nil
+		}
+
+		return domain.Refund{}, false, err
+	}
+
+	if refund.RequestHash != requestHash {
+		return domain.Refund{}, false, domain.ErrIdempotencyKeyReused
+	}
+
+	return refund, true, nil
+}
+
+// transition applies the transition to the payment and returns the payment
+// as it is stored afterwards.
+func (s *PaymentsService) transition(ctx context.Context, payment domain.Payment, transition domain.PaymentTransition) (domain.Payment, error) {
+	if transition.To != transition.From && !transition.From.CanTransition(transition.To) {
+		return domain.Payment{}, domain.ErrPaymentTransition
+	}
+
+	if err := s.repos.Payments.Transition(ctx, transition); err != nil {
+		return domain.Payment{}, err
+	}
+
+	return s.repos.Payments.GetByID(ctx, payment.ID)
+}
+
+// gatewayError translates the errors of the payment gateway into domain errors.
+func gatewayError(err error) error {
+	switch {
+	case errors.Is(err, gateway.ErrUnavailable):
+		return domain.ErrGatewayUnavailable
+	case errors.Is(err, gateway.ErrInvalidState), errors.Is(err, gateway.ErrUnknownTransaction):
+		return fmt.Errorf("%w: %w", domain.ErrPaymentTransition, err)
+	case errors.Is(err, gateway.ErrInvalidAmount):
+		return fmt.Errorf("%w: %w", domain.ErrRefundAmount, err)
+	case errors.Is(err, gateway.ErrInvalidCard):
+		return domain.ErrInvalidCard
+	default:
+		return err
+	}
+}
+
+// refundFailureReason describes why the acquirer did not refund.
+func refundFailureReason(err error) string {
+	switch {
+	case errors.Is(err, gateway.ErrUnavailable):
+		return "gateway_unavailable"
+	case errors.Is(err, gateway.ErrInvalidAmount):
+		return "invalid_amount"
+	case errors.Is(err, gateway.ErrInvalidState):
+		return "invalid_state"
+	case errors.Is(err, gateway.ErrUnknownTransaction):
+		return "unknown_transaction"
+	default:
+		return "gateway_error"
+	}
+}
+
+// refundRequestHash fingerprints a refund request, see paymentRequestHash.
+func refundRequestHash(input RefundCreateInput) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		fmt.Sprint(input.Amount.Amount), strings.ToUpper(input.Amount.Currency), input.Reason,
+	}, "\x00")))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"backend-vtb/pkg/money"
+	"testing"
+)
+
+func TestRefundRequestHash(t *testing.T) {
+	base := RefundCreateInput{Amount: money.New(100, "RUB"), Reason: "duplicate"}
+
+	for _, tc := range []struct {
+		name  string
+		input RefundCreateInput
+		same  bool
+	}{
+		{"identical", base, true},
+		{"key aside", RefundCreateInput{IdempotencyKey: "key", Amount: base.Amount, Reason: base.Reason}, true},
+		{"currency case", RefundCreateInput{Amount: money.Money{Amount: 100, Currency: "rub"}, Reason: base.Reason}, true},
+		{"other currency", RefundCreateInput{Amount: money.New(100, "USD"), Reason: base.Reason}, false},
+		{"no currency", RefundCreateInput{Amount: money.Money{Amount: 100}, Reason: base.Reason}, false},
+		{"other amount", RefundCreateInput{Amount: money.New(101, "RUB"), Reason: base.Reason}, false},
+		{"other reason", RefundCreateInput{Amount: base.Amount, Reason: "fraud"}, false},
+	} {
+		if same := refundRequestHash(tc.input) == refundRequestHash(base); same != tc.same {
+			t.Errorf("%s: same hash %v, want %v", tc.name, same, tc.same)
+		}
+	}
+}
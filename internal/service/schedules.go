@@ -0,0 +1,392 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/gateway"
+	"backend-vtb/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// failureAuthenticationRequired fails a scheduled payment the issuer wants
+// the cardholder to confirm, which nobody is there to do.
+const failureAuthenticationRequired = "authentication_required"
+
+// failureInvalidCard fails a scheduled payment whose saved card the acquirer
+// no longer accepts.
+const failureInvalidCard = "invalid_card"
+
+// defaultScheduleBatchSize is used when SchedulesConfig.BatchSize is not set.
+const defaultScheduleBatchSize = 100
+
+type SchedulesService struct {
+	repos    *repository.Repository
+	fines    Fines
+	payments Payments
+	gateway  gateway.PaymentGateway
+	cfg      SchedulesConfig
+	logger   *slog.Logger
+}
+
+func NewSchedulesService(repos *repository.Repository, fines Fines, payments Payments, gateway gateway.PaymentGateway,
+	cfg SchedulesConfig, logger *slog.Logger,
+) *SchedulesService {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultScheduleBatchSize
+	}
+
+	return &SchedulesService{
+		repos:    repos,
+		fines:    fines,
+		payments: payments,
+		gateway:  gateway,
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+func (s *SchedulesService) Create(ctx context.Context, userID uuid.UUID, input ScheduleCreateInput) (domain.Schedule, error) {
+	if err := input.Rule.Validate(); err != nil {
+		return domain.Schedule{}, err
+	}
+
+	if (input.FineID == nil) == (input.MerchantID == "") {
+		return domain.Schedule{}, domain.ErrInvalidPaymentTarget
+	}
+
+	if input.FineID != nil {
+		if input.Rule.Kind != domain.ScheduleOnce {
+			return domain.Schedule{}, fmt.Errorf("%w: a fine can only be paid once", domain.ErrScheduleRule)
+		}
+
+		// The amount due is only known at the time of the payment.
+		if !input.Amount.IsZero() {
+			return domain.Schedule{}, fmt.Errorf("%w: a fine is paid at the amount due", domain.ErrPaymentAmount)
+		}
+
+		if _, err := s.fines.Get(ctx, userID, *input.FineID); err != nil {
+			return domain.Schedule{}, err
+		}
+	} else if !input.Amount.IsPositive() {
+		return domain.Schedule{}, fmt.Errorf("%w: must be positive", domain.ErrPaymentAmount)
+	}
+
+	amount, err := paymentAmount(input.Amount)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+
+	now := time.Now()
+	schedule := domain.Schedule{
+		ID:           uuid.New(),
+		UserID:       userID,
+		ScheduleRule: input.Rule,
+		Amount:       amount.Amount,
+		Currency:     amount.Currency,
+		Purpose:      input.Purpose,
+		FineID:       input.FineID,
+		MerchantID:   input.MerchantID,
+		StartsAt:     input.StartsAt,
+		EndsAt:       input.EndsAt,
+		Status:       domain.ScheduleActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if schedule.StartsAt.IsZero() {
+		schedule.StartsAt = now
+	}
+
+	// A schedule starting now runs right away.
+	s.advance(&schedule, now.Add(-time.Nanosecond))
+	if schedule.Status == domain.ScheduleCompleted {
+		return domain.Schedule{}, fmt.Errorf("%w: the schedule has no runs in the future", domain.ErrScheduleRule)
+	}
+
+	saved, err := s.gateway.SaveCard(ctx, input.Card)
+	if err != nil {
+		return domain.Schedule{}, gatewayError(err)
+	}
+
+	schedule.CardToken = saved.Token
+	schedule.CardLast4 = saved.Last4
+
+	if err := s.repos.Schedules.Create(ctx, schedule); err != nil {
+		return domain.Schedule{}, err
+	}
+
+	s.logger.Info("payment schedule created",
+		slog.String("schedule", schedule.ID.String()), slog.String("user", userID.String()),
+		slog.String("kind", string(schedule.Kind)), slog.Time("next_run_at", *schedule.NextRunAt))
+
+	return schedule, nil
+}
+
+func (s *SchedulesService) List(ctx context.Context, userID uuid.UUID) ([]domain.Schedule, error) {
+	return s.repos.Schedules.GetByUser(ctx, userID)
+}
+
+func (s *SchedulesService) Get(ctx context.Context, userID, scheduleID uuid.UUID) (domain.Schedule, error) {
+	schedule, err := s.repos.Schedules.GetByID(ctx, scheduleID)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+
+	// Do not reveal that a schedule of another user exists.
+	if schedule.UserID != userID {
+		return domain.Schedule{}, domain.ErrNotFound
+	}
+
+	return schedule, nil
+}
+
+func (s *SchedulesService) Occurrences(ctx context.Context, userID, scheduleID uuid.UUID) ([]domain.ScheduleOccurrence, error) {
+	if _, err := s.Get(ctx, userID, scheduleID); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Schedules.GetOccurrences(ctx, scheduleID)
+}
+
+func (s *SchedulesService) Pause(ctx context.Context, userID, scheduleID uuid.UUID) (domain.Schedule, error) {
+	schedule, err := s.Get(ctx, userID, scheduleID)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+
+	if schedule.Status != domain.ScheduleActive {
+		return domain.Schedule{}, domain.ErrScheduleState
+	}
+
+	loadedAt := schedule.UpdatedAt
+	schedule.Status = domain.SchedulePaused
+	schedule.UpdatedAt = time.Now()
+
+	if err := s.repos.Schedules.Update(ctx, schedule, loadedAt); err != nil {
+		return domain.Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+func (s *SchedulesService) Resume(ctx context.Context, userID, scheduleID uuid.UUID) (domain.Schedule, error) {
+	schedule, err := s.Get(ctx, userID, scheduleID)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+
+	if schedule.Status != domain.SchedulePaused {
+		return domain.Schedule{}, domain.ErrScheduleState
+	}
+
+	now := time.Now()
+	loadedAt := schedule.UpdatedAt
+	schedule.Status = domain.ScheduleActive
+	schedule.UpdatedAt = now
+
+	var skipped []domain.ScheduleOccurrence
+	for schedule.NextRunAt != nil && !schedule.NextRunAt.After(now) {
+		at := *schedule.NextRunAt
+		skipped = append(skipped, newOccurrence(schedule, at, domain.OccurrenceSkipped, domain.OccurrencePaused, now))
+		s.advance(&schedule, at)
+	}
+
+	if err := s.repos.Schedules.Update(ctx, schedule, loadedAt, skipped...); err != nil {
+		return domain.Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+func (s *SchedulesService) Cancel(ctx context.Context, userID, scheduleID uuid.UUID) (domain.Schedule, error) {
+	schedule, err := s.Get(ctx, userID, scheduleID)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+
+	if schedule.Status != domain.ScheduleActive && schedule.Status != domain.SchedulePaused {
+		return domain.Schedule{}, domain.ErrScheduleState
+	}
+
+	loadedAt := schedule.UpdatedAt
+	schedule.Status = domain.ScheduleCancelled
+	schedule.NextRunAt = nil
+	schedule.UpdatedAt = time.Now()
+
+	if err := s.repos.Schedules.Update(ctx, schedule, loadedAt); err != nil {
+		return domain.Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+// RunDue processes one run per due schedule. A schedule that fell behind by
+// several runs catches up over the following calls, skipping the runs that
+// are too late to make.
+func (s *SchedulesService) RunDue(ctx context.Context, now time.Time) (int, error) {
+	schedules, err := s.repos.Schedules.GetDue(ctx, now, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var processed int
+	for _, schedule := range schedules {
+		if err := s.run(ctx, schedule, now); err != nil {
+			if ctx.Err() != nil {
+				return processed, ctx.Err()
+			}
+
+			// The run stays due and is retried by the next call.
+			s.logger.Error("failed to run payment schedule",
+				slog.String("schedule", schedule.ID.String()), slog.String("reason", err.Error()))
+
+			continue
+		}
+
+		processed++
+	}
+
+	return processed, nil
+}
+
+// run makes the payment of the next run of the schedule and records the
+// outcome.
+func (s *SchedulesService) run(ctx context.Context, schedule domain.Schedule, now time.Time) error {
+	at := *schedule.NextRunAt
+
+	// A run is also missed once the schedule has ended, e.g. when a fine is
+	// only to be paid while its discount lasts.
+	var occurrence domain.ScheduleOccurrence
+	if (s.cfg.MissedAfter > 0 && now.Sub(at) > s.cfg.MissedAfter) || (schedule.EndsAt != nil && now.After(*schedule.EndsAt)) {
+		occurrence = newOccurrence(schedule, at, domain.OccurrenceSkipped, domain.OccurrenceMissed, now)
+	} else {
+		var err error
+		if occurrence, err = s.pay(ctx, schedule, at, now); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("payment schedule run",
+		slog.String("schedule", schedule.ID.String()), slog.Time("scheduled_for", at),
+		slog.String("status", string(occurrence.Status)), slog.String("reason", occurrence.Reason))
+
+	loadedAt := schedule.UpdatedAt
+	s.advance(&schedule, at)
+	schedule.UpdatedAt = now
+
+	err := s.repos.Schedules.Update(ctx, schedule, loadedAt, occurrence)
+	if !errors.Is(err, domain.ErrScheduleChanged) {
+		return err
+	}
+
+	// The user paused or cancelled the schedule while the payment was made.
+	// The run happened all the same, so it is recorded on top of their change.
+	current, err := s.repos.Schedules.GetByID(ctx, schedule.ID)
+	if err != nil {
+		return err
+	}
+
+	loadedAt = current.UpdatedAt
+	if current.NextRunAt != nil && current.NextRunAt.Equal(at) {
+		s.advance(&current, at)
+	}
+	current.UpdatedAt = now
+
+	return s.repos.Schedules.Update(ctx, current, loadedAt, occurrence)
+}
+
+// pay takes the payment of the run through creation, authorization with the
+// saved card and capture. A run interrupted by an error resumes with the same
+// payment when retried, since the payment is created with a key per run.
+func (s *SchedulesService) pay(ctx context.Context, schedule domain.Schedule, at, now time.Time) (domain.ScheduleOccurrence, error) {
+	payment, _, err := s.payments.Create(ctx, schedule.UserID, PaymentCreateInput{
+		IdempotencyKey: fmt.Sprintf("schedule:%s:%d", schedule.ID, at.Unix()),
+		Amount:         money.New(schedule.Amount, schedule.Currency),
+		Purpose:        schedule.Purpose,
+		FineID:         schedule.FineID,
+		MerchantID:     schedule.MerchantID,
+	})
+
+	switch {
+	case errors.Is(err, domain.ErrFineNotPayable), errors.Is(err, domain.ErrFinePaymentExists),
+		errors.Is(err, domain.ErrNotFound):
+		return newOccurrence(schedule, at, domain.OccurrenceSkipped, domain.OccurrenceFineNotPayable, now), nil
+	case err != nil:
+		return domain.ScheduleOccurrence{}, err
+	}
+
+	card := gateway.Card{Token: schedule.CardToken}
+
+	for {
+		switch {
+		case payment.Status == domain.PaymentPending && payment.ChallengeURL != "":
+			payment, err = s.payments.Void(ctx, schedule.UserID, payment.ID)
+			if err == nil {
+				return paidOccurrence(schedule, at, payment, failureAuthenticationRequired, now), nil
+			}
+		case payment.Status == domain.PaymentPending:
+			var authorized domain.Payment
+			authorized, err = s.payments.Authorize(ctx, schedule.UserID, payment.ID, card)
+			if errors.Is(err, domain.ErrInvalidCard) {
+				if payment, err = s.payments.Void(ctx, schedule.UserID, payment.ID); err == nil {
+					return paidOccurrence(schedule, at, payment, failureInvalidCard, now), nil
+				}
+			} else if err == nil {
+				payment = authorized
+			}
+		case payment.Status == domain.PaymentAuthorized:
+			payment, err = s.payments.Capture(ctx, schedule.UserID, payment.ID)
+		default:
+			return paidOccurrence(schedule, at, payment, payment.FailureReason, now), nil
+		}
+
+		if err != nil {
+			return domain.ScheduleOccurrence{}, err
+		}
+	}
+}
+
+// advance moves the schedule past the given run, evaluating the rule in the
+// configured time zone.
+func (s *SchedulesService) advance(schedule *domain.Schedule, after time.Time) {
+	schedule.StartsAt = schedule.StartsAt.In(s.cfg.Location)
+	schedule.Advance(after)
+}
+
+func newOccurrence(schedule domain.Schedule, at time.Time, status domain.OccurrenceStatus, reason string,
+	now time.Time,
+) domain.ScheduleOccurrence {
+	return domain.ScheduleOccurrence{
+		ID:           uuid.New(),
+		ScheduleID:   schedule.ID,
+		ScheduledFor: at,
+		Status:       status,
+		Reason:       reason,
+		CreatedAt:    now,
+	}
+}
+
+// paidOccurrence records a run that got as far as a payment, successful or not.
+func paidOccurrence(schedule domain.Schedule, at time.Time, payment domain.Payment, reason string,
+	now time.Time,
+) domain.ScheduleOccurrence {
+	status := domain.OccurrenceFailed
+	if payment.Status == domain.PaymentCaptured || payment.Status == domain.PaymentRefunded {
+		status, reason = domain.OccurrenceSucceeded, ""
+	}
+
+	occurrence := newOccurrence(schedule, at, status, reason, now)
+	occurrence.PaymentID = &payment.ID
+
+	return occurrence
+}
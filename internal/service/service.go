@@ -1,35 +1,571 @@
 package service
 
 import (
+	"backend-vtb/internal/domain"
 	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/auth"
+	"backend-vtb/pkg/exrate"
+	"backend-vtb/pkg/gateway"
+	"backend-vtb/pkg/hash"
+	"backend-vtb/pkg/money"
+	"backend-vtb/pkg/otp"
+	"backend-vtb/pkg/pricefeed"
+	"backend-vtb/pkg/storage"
+	"context"
+	"io"
 	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Base interface {
-	GetName(id uuid.UUID) (string, error)
-	GetAmount(id uuid.UUID) (int, error)
-	GetAchievements(id uuid.UUID) ([]string, error)
-	GetBaseInfo(id uuid.UUID) (string, error)
-	GetNeuroMean(id uuid.UUID) (float64, error)
-	GetCryptoData(id uuid.UUID) (string, error)
-	GetAPIInfo(id uuid.UUID) (string, error)
-	GetFullAPIInfo(id uuid.UUID) (string, error)
-	GetFines(id uuid.UUID) ([]int, error)
-	GetFineByID(id uuid.UUID) (int, error)
-	GetPayments(id uuid.UUID) ([]int, error)
-	GetPaymentByID(id uuid.UUID) (int, error)
-	GetStatsData(id uuid.UUID) (string, error)
-	GetAnalyze(id uuid.UUID) (string, error)
+	GetName(ctx context.Context, id uuid.UUID) (string, error)
+	// GetAmount returns the total currently payable across the user's open
+	// fines. Fines whose payment was refunded in full are open again.
+	GetAmount(ctx context.Context, id uuid.UUID) (money.Money, error)
+	GetAchievements(ctx context.Context, id uuid.UUID) ([]domain.Achievement, error)
+	GetBaseInfo(ctx context.Context, id uuid.UUID) (domain.BaseInfo, error)
+	GetNeuroMean(ctx context.Context, id uuid.UUID) (float64, error)
+	// GetCryptoData returns the crypto portfolio of the user, by FIFO.
+	GetCryptoData(ctx context.Context, id uuid.UUID) (domain.CryptoPortfolio, error)
+	GetAPIInfo(ctx context.Context, id uuid.UUID) (string, error)
+	GetFullAPIInfo(ctx context.Context, id uuid.UUID) (string, error)
+	GetFines(ctx context.Context, id uuid.UUID) ([]domain.Fine, error)
+	GetFineByUIN(ctx context.Context, id uuid.UUID, uin string) (domain.Fine, error)
+	GetPayments(ctx context.Context, id uuid.UUID) ([]domain.Payment, error)
+	// GetStatsData returns the daily statistics of the user over the last 30
+	// days, see Stats.
+	GetStatsData(ctx context.Context, id uuid.UUID) (domain.StatsReport, error)
+	GetAnalyze(ctx context.Context, id uuid.UUID) (string, error)
+}
+
+type FineCreateInput struct {
+	UIN           string
+	Issuer        string
+	Article       string
+	Amount        int64
+	IssuedAt      time.Time
+	DueAt         *time.Time
+	DiscountUntil *time.Time
+}
+
+// FineUpdateInput holds the fields to change; nil fields are left as is.
+type FineUpdateInput struct {
+	Issuer        *string
+	Article       *string
+	Amount        *int64
+	DueAt         *time.Time
+	DiscountUntil *time.Time
+	Status        *domain.FineStatus
+}
+
+// Fines manages the fines of a user. A fine that belongs to another user is
+// reported as domain.ErrNotFound.
+type Fines interface {
+	List(ctx context.Context, userID uuid.UUID) ([]domain.Fine, error)
+	Get(ctx context.Context, userID, fineID uuid.UUID) (domain.Fine, error)
+	Create(ctx context.Context, userID uuid.UUID, input FineCreateInput) (domain.Fine, error)
+	// Update edits an issued fine or cancels it. The due date and the end of
+	// the discount can only be moved earlier, and fines are only paid by
+	// captured payments.
+	Update(ctx context.Context, userID, fineID uuid.UUID, input FineUpdateInput) (domain.Fine, error)
+	// Charge returns the state of the fine and the amount payable at asOf.
+	Charge(ctx context.Context, userID, fineID uuid.UUID, asOf time.Time) (domain.FineCharge, error)
+	// QR renders the payment details of an unpaid fine, with the amount
+	// payable now, as a GOST R 56042 QR code that banking apps can scan.
+	QR(ctx context.Context, userID, fineID uuid.UUID, format domain.QRFormat) (domain.PaymentQR, error)
+	// History returns the status history of the fine, oldest first.
+	History(ctx context.Context, userID, fineID uuid.UUID) ([]domain.FineEvent, error)
+	// Delete removes an issued fine that no payment is in progress for.
+	Delete(ctx context.Context, userID, fineID uuid.UUID) error
+}
+
+// Actor is the user performing an action on a dispute or a payment.
+// Reviewers can see and decide on the disputes of all users; refunders can
+// refund the payments of all users, and are the only ones who can.
+type Actor struct {
+	UserID   uuid.UUID
+	Reviewer bool
+	Refunder bool
+}
+
+type DisputeOpenInput struct {
+	Reason  domain.DisputeReason
+	Comment string
+}
+
+type DisputeDecisionInput struct {
+	Accept  bool
+	Comment string
+}
+
+type AttachmentInput struct {
+	FileName string
+	Size     int64
+	Body     io.Reader
+}
+
+// Disputes manages the objections of users to their fines. Opening a dispute
+// suspends the fine until the user withdraws it or a reviewer decides on it.
+type Disputes interface {
+	Open(ctx context.Context, userID, fineID uuid.UUID, input DisputeOpenInput) (domain.Dispute, error)
+	List(ctx context.Context, actor Actor, fineID uuid.UUID) ([]domain.Dispute, error)
+	Get(ctx context.Context, actor Actor, disputeID uuid.UUID) (domain.Dispute, error)
+	// AddAttachment stores an evidence file for an open dispute. Only JPEG,
+	// PNG and PDF files are accepted.
+	AddAttachment(ctx context.Context, userID, disputeID uuid.UUID, input AttachmentInput) (domain.DisputeAttachment, error)
+	ListAttachments(ctx context.Context, actor Actor, disputeID uuid.UUID) ([]domain.DisputeAttachment, error)
+	// GetAttachment returns the attachment and its content, which the caller must close.
+	GetAttachment(ctx context.Context, actor Actor, disputeID, attachmentID uuid.UUID) (domain.DisputeAttachment, io.ReadCloser, error)
+	Withdraw(ctx context.Context, userID, disputeID uuid.UUID) (domain.Dispute, error)
+	// Decide closes the dispute: an accepted dispute cancels the fine, a
+	// rejected one makes it payable again.
+	Decide(ctx context.Context, reviewerID, disputeID uuid.UUID, input DisputeDecisionInput) (domain.Dispute, error)
+}
+
+type PaymentCreateInput struct {
+	// IdempotencyKey identifies the request across retries; optional.
+	IdempotencyKey string
+	// Amount may be zero for a fine, which is paid at the amount due; an
+	// empty currency means rubles.
+	Amount     money.Money
+	Purpose    string
+	FineID     *uuid.UUID
+	MerchantID string
+}
+
+type RefundCreateInput struct {
+	// IdempotencyKey identifies the request across retries; optional.
+	IdempotencyKey string
+	// Amount to refund, in the currency of the payment if none is given;
+	// zero refunds whatever has not been refunded yet.
+	Amount money.Money
+	Reason string
+}
+
+// Payments manages the payments of a user. A payment that belongs to another
+// user is reported as domain.ErrNotFound.
+type Payments interface {
+	List(ctx context.Context, userID uuid.UUID) ([]domain.Payment, error)
+	Get(ctx context.Context, userID, paymentID uuid.UUID) (domain.Payment, error)
+	// Create starts a payment of either a fine or a merchant. A request
+	// repeated with the same idempotency key returns the payment created by
+	// the first one, with replayed set, instead of paying again.
+	Create(ctx context.Context, userID uuid.UUID, input PaymentCreateInput) (payment domain.Payment, replayed bool, err error)
+	// Authorize reserves the amount of a pending payment on the card. A
+	// declined card fails the payment; if the issuer asks for 3-D Secure the
+	// payment stays pending with a challenge URL until Authenticate is called.
+	Authorize(ctx context.Context, userID, paymentID uuid.UUID, card gateway.Card) (domain.Payment, error)
+	// Authenticate completes the 3-D Secure challenge of a pending payment.
+	Authenticate(ctx context.Context, userID, paymentID uuid.UUID, response string) (domain.Payment, error)
+	// Capture charges an authorized payment and settles it to its target.
+	Capture(ctx context.Context, userID, paymentID uuid.UUID) (domain.Payment, error)
+	// Void releases the authorization of a payment that has not been captured.
+	Void(ctx context.Context, userID, paymentID uuid.UUID) (domain.Payment, error)
+	// Refund returns all or part of a captured payment to the card. Refunds
+	// never exceed the captured amount in total. A refund the acquirer
+	// rejects is kept as failed. Only a refunder may refund, see Actor.
+	Refund(ctx context.Context, actor Actor, paymentID uuid.UUID, input RefundCreateInput) (refund domain.Refund, replayed bool, err error)
+	// Refunds returns the refunds of the payment, oldest first.
+	Refunds(ctx context.Context, userID, paymentID uuid.UUID) ([]domain.Refund, error)
+	// DraftFromQR pre-fills a payment from the payload of a scanned GOST R
+	// 56042 QR code. Nothing is stored; the draft is meant for Create.
+	DraftFromQR(ctx context.Context, userID uuid.UUID, payload string) (domain.PaymentDraft, error)
+}
+
+type ScheduleCreateInput struct {
+	Rule domain.ScheduleRule
+	// StartsAt is the first possible run; its time of day is kept by all runs.
+	StartsAt   time.Time
+	EndsAt     *time.Time
+	Amount     money.Money
+	Purpose    string
+	FineID     *uuid.UUID
+	MerchantID string
+	// Card is saved at the acquirer and charged at every run.
+	Card gateway.Card
+}
+
+// Schedules manages the scheduled and recurring payments of a user. A
+// schedule that belongs to another user is reported as domain.ErrNotFound.
+type Schedules interface {
+	// Create sets up a schedule paying a merchant by a calendar rule, or a
+	// fine once at a later date.
+	Create(ctx context.Context, userID uuid.UUID, input ScheduleCreateInput) (domain.Schedule, error)
+	List(ctx context.Context, userID uuid.UUID) ([]domain.Schedule, error)
+	Get(ctx context.Context, userID, scheduleID uuid.UUID) (domain.Schedule, error)
+	// Occurrences returns the past runs of the schedule, newest first.
+	Occurrences(ctx context.Context, userID, scheduleID uuid.UUID) ([]domain.ScheduleOccurrence, error)
+	// Pause stops the schedule from making payments until it is resumed.
+	Pause(ctx context.Context, userID, scheduleID uuid.UUID) (domain.Schedule, error)
+	// Resume reactivates a paused schedule. Runs that fell into the pause are
+	// recorded as skipped rather than made up for.
+	Resume(ctx context.Context, userID, scheduleID uuid.UUID) (domain.Schedule, error)
+	Cancel(ctx context.Context, userID, scheduleID uuid.UUID) (domain.Schedule, error)
+	// RunDue makes the payments of the runs due at now, creating each through
+	// Payments with an idempotency key per run so that a run is never paid
+	// twice. It returns the number of runs processed.
+	RunDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type AutopayRuleInput struct {
+	Enabled      bool
+	MaxPerFine   int64
+	MonthlyLimit int64
+	// Card replaces the saved card; it is required to enable autopay for the
+	// first time.
+	Card *gateway.Card
+}
+
+// Autopay pays the new fines of users who opt in while their discount lasts.
+type Autopay interface {
+	// Rule returns the autopay rule of the user, disabled if never set.
+	Rule(ctx context.Context, userID uuid.UUID) (domain.AutopayRule, error)
+	SetRule(ctx context.Context, userID uuid.UUID, input AutopayRuleInput) (domain.AutopayRule, error)
+	// Charges returns the decisions of autopay on the user's fines, newest first.
+	Charges(ctx context.Context, userID uuid.UUID) ([]domain.AutopayCharge, error)
+	// Cancel calls off a scheduled charge while its cancellation window lasts.
+	Cancel(ctx context.Context, userID, chargeID uuid.UUID) (domain.AutopayCharge, error)
+	// Plan decides on a newly registered fine. If the user opted in, the fine
+	// is scheduled to be paid at the discount once the notice period is over,
+	// unless that would exceed a limit of the rule. Either way the user is
+	// notified.
+	Plan(ctx context.Context, fine domain.Fine) error
+}
+
+// Notifications shows users the messages the service left for them.
+type Notifications interface {
+	// List returns the notifications of the user, newest first.
+	List(ctx context.Context, userID uuid.UUID) ([]domain.Notification, error)
+}
+
+// Notifier delivers notifications to users.
+type Notifier interface {
+	Notify(ctx context.Context, notification domain.Notification) error
+}
+
+// Ledger shows users how their money moved.
+type Ledger interface {
+	// Balances returns the balances of the user's accounts, one per currency.
+	Balances(ctx context.Context, userID uuid.UUID) ([]domain.AccountBalance, error)
+	// Entries returns the journal entries touching the user's account, newest first.
+	Entries(ctx context.Context, userID uuid.UUID) ([]domain.JournalEntry, error)
+	// Valuation returns the balances of the user's accounts at the end of the
+	// calendar day of date, converted into the currency at the rates of that
+	// day. A zero date means today.
+	Valuation(ctx context.Context, userID uuid.UUID, currency string, date time.Time) (domain.Valuation, error)
+}
+
+type CryptoTransactionInput struct {
+	Asset    string
+	Kind     domain.CryptoTransactionKind
+	Quantity string
+	// Amount is what was paid or received, fees excluded; an empty currency
+	// means rubles.
+	Amount money.Money
+	// Fee is in minor units of the currency of Amount.
+	Fee int64
+	// ExecutedAt defaults to now.
+	ExecutedAt time.Time
+}
+
+// Crypto tracks the crypto holdings of users from the transactions they
+// record. A transaction that belongs to another user is reported as
+// domain.ErrNotFound.
+type Crypto interface {
+	// Transactions returns the transactions of the user in execution order.
+	Transactions(ctx context.Context, userID uuid.UUID) ([]domain.CryptoTransaction, error)
+	// AddTransaction records a transaction in an asset quoted by the price
+	// source. Transactions in one asset must be in one currency, and a sale
+	// or outgoing transfer must not remove more than was held at its time.
+	AddTransaction(ctx context.Context, userID uuid.UUID, input CryptoTransactionInput) (domain.CryptoTransaction, error)
+	// DeleteTransaction removes a transaction, unless a later one would then
+	// remove more than was held.
+	DeleteTransaction(ctx context.Context, userID, transactionID uuid.UUID) error
+	// Portfolio computes the holdings of the user with the cost basis method,
+	// FIFO by default, and values them at current market prices.
+	Portfolio(ctx context.Context, userID uuid.UUID, method domain.CostBasisMethod) (domain.CryptoPortfolio, error)
+}
+
+type AlertRuleInput struct {
+	Subject   domain.AlertSubject
+	Asset     string
+	Condition domain.AlertCondition
+	// Threshold is a decimal price, an amount in kopecks or a percent, see
+	// domain.AlertRule.
+	Threshold     string
+	WindowMinutes int
+	// CooldownMinutes defaults to AlertsConfig.Cooldown.
+	CooldownMinutes *int
+}
+
+// Alerts notifies users when crypto prices or the amounts they owe on fines
+// meet the conditions of their alert rules. A rule that belongs to another
+// user is reported as domain.ErrNotFound.
+type Alerts interface {
+	// List returns the rules of the user, newest first.
+	List(ctx context.Context, userID uuid.UUID) ([]domain.AlertRule, error)
+	Create(ctx context.Context, userID uuid.UUID, input AlertRuleInput) (domain.AlertRule, error)
+	Delete(ctx context.Context, userID, ruleID uuid.UUID) error
+	// Evaluate checks the conditions of all rules at now and notifies the
+	// users of the rules that fired, which it returns the number of. A rule
+	// fires once when its condition starts to hold, unless it is cooling
+	// down; concurrent evaluations do not fire a rule twice.
+	Evaluate(ctx context.Context, now time.Time) (int, error)
+}
+
+// StatsInput selects the period of a statistics report. Zero values select
+// the defaults.
+type StatsInput struct {
+	// Bucket defaults to days.
+	Bucket domain.StatsBucket
+	// Timezone is the IANA name of the time zone of the buckets, by default
+	// StatsConfig.Location.
+	Timezone string
+	// From and To are the first and the last day of the period. To defaults
+	// to today, and From to 30 days, 12 weeks or 12 months before it.
+	From time.Time
+	To   time.Time
+}
+
+// Stats reports how much users spend, get back and are fined over time.
+type Stats interface {
+	// Report returns a series per metric and currency over the buckets
+	// covering the days from input.From through input.To, with category
+	// breakdowns and the totals of as many buckets right before.
+	Report(ctx context.Context, userID uuid.UUID, input StatsInput) (domain.StatsReport, error)
+}
+
+// Candles aggregates crypto price ticks into OHLCV candles of each of
+// domain.CandleIntervals.
+type Candles interface {
+	// Ingest merges the ticks into the stored candles. Ticks may arrive in
+	// any order and in any number of batches.
+	Ingest(ctx context.Context, ticks []pricefeed.Tick) error
+	// Backfill samples the history of the price source once a minute over
+	// the gap between the last ingested tick of each symbol and to, going
+	// back at most maxAge, and returns the number of ticks sampled.
+	Backfill(ctx context.Context, to time.Time, maxAge time.Duration) (int, error)
+	// Candles returns the candles of the symbol in the interval with a start
+	// in [from, to). Intervals without ticks carry over the previous close.
+	// A zero to means now, and a zero from the last 100 intervals before to.
+	Candles(ctx context.Context, symbol string, interval domain.CandleInterval, from, to time.Time) (domain.CandleSeries, error)
+}
+
+// ExchangeRates converts money between currencies at the official daily rates.
+type ExchangeRates interface {
+	// Rate returns the price of one unit of from in to on the day of asOf.
+	// Days without rates of their own, such as weekends, use the latest
+	// earlier rates unless they are too old.
+	Rate(ctx context.Context, from, to string, asOf time.Time) (money.Rate, error)
+	// Convert exchanges the amount into the currency at the rate of the day
+	// of asOf, rounding half to even.
+	Convert(ctx context.Context, amount money.Money, to string, asOf time.Time) (money.Money, error)
+	// Sync stores the rates the provider published for the days from from to
+	// to inclusive, and returns the number of days that had rates.
+	Sync(ctx context.Context, from, to time.Time) (int, error)
+}
+
+// WebhookDelivery is a webhook request as received.
+type WebhookDelivery struct {
+	Provider  string
+	Signature string
+	Timestamp string
+	Payload   []byte
+}
+
+// Webhooks processes notifications sent by payment providers.
+type Webhooks interface {
+	// HandlePayment verifies a payment status webhook and applies it to the
+	// payment. Redelivered events are not applied again; the event as first
+	// processed is returned instead.
+	HandlePayment(ctx context.Context, delivery WebhookDelivery) (domain.WebhookEvent, error)
+}
+
+// ClientInfo describes the device a request came from.
+type ClientInfo struct {
+	Device string
+	IP     string
+}
+
+type UserSignUpInput struct {
+	Name     string
+	Email    string
+	Phone    string
+	Password string
+	Client   ClientInfo
+}
+
+type UserSignInInput struct {
+	Email    string
+	Password string
+	Client   ClientInfo
+}
+
+type Users interface {
+	SignUp(ctx context.Context, input UserSignUpInput) (domain.Tokens, error)
+	SignIn(ctx context.Context, input UserSignInInput) (domain.SignInResult, error)
+	// VerifyMFA exchanges the challenge returned by SignIn and a TOTP or
+	// recovery code for a pair of tokens.
+	VerifyMFA(ctx context.Context, mfaToken, code string, client ClientInfo) (domain.Tokens, error)
+	RefreshTokens(ctx context.Context, refreshToken string, client ClientInfo) (domain.Tokens, error)
+	SignOut(ctx context.Context, refreshToken string) error
+	GetSessions(ctx context.Context, userID uuid.UUID) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	EnrollTOTP(ctx context.Context, userID uuid.UUID) (domain.TOTPEnrollment, error)
+	// ConfirmTOTP enables two-factor authentication once the user proves the
+	// authenticator works, and returns the recovery codes in plain text.
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error
+}
+
+// MFAConfig configures two-factor authentication.
+type MFAConfig struct {
+	// Issuer is the service name shown in authenticator apps.
+	Issuer        string
+	TOTP          otp.TOTP
+	ChallengeTTL  time.Duration
+	RecoveryCodes int
+}
+
+// WebhooksConfig configures the verification of webhooks.
+type WebhooksConfig struct {
+	// Secrets holds the signing secret of each provider; webhooks of other
+	// providers are rejected.
+	Secrets map[string][]byte
+	// Tolerance is the maximum age of a delivery.
+	Tolerance time.Duration
+}
+
+// SchedulesConfig configures the execution of payment schedules.
+type SchedulesConfig struct {
+	// Location is the time zone calendar rules are evaluated in.
+	Location *time.Location
+	// MissedAfter is how late a run may still be made, e.g. after downtime;
+	// later runs are skipped.
+	MissedAfter time.Duration
+	// BatchSize limits the runs made per call of RunDue.
+	BatchSize int
+}
+
+// AutopayConfig configures the autopay of fines.
+type AutopayConfig struct {
+	// Notice is the time between the notification and the charge, in which
+	// the user can cancel it.
+	Notice time.Duration
+	// Location is the time zone calendar months of the monthly limit are
+	// counted in.
+	Location *time.Location
+}
+
+// ExchangeRatesConfig configures currency conversion.
+type ExchangeRatesConfig struct {
+	// Provider publishes the rates Sync stores; conversions only use stored
+	// rates. Without a provider, rates are quoted in rubles.
+	Provider exrate.Provider
+	// Location is the time zone the days of rates are counted in.
+	Location *time.Location
+	// MaxAge is how much older than the requested day the latest rate may
+	// be, e.g. over long holidays.
+	MaxAge time.Duration
+}
+
+// CandlesConfig configures the candles of crypto prices.
+type CandlesConfig struct {
+	// MaxCandles limits how many candles are returned at once.
+	MaxCandles int
+}
+
+// StatsConfig configures statistics.
+type StatsConfig struct {
+	// Location is the time zone of reports that name none.
+	Location *time.Location
+}
+
+// AlertsConfig configures alert rules.
+type AlertsConfig struct {
+	// Cooldown is the cooldown of rules created without one.
+	Cooldown time.Duration
+	// MaxRules limits the number of rules of a user.
+	MaxRules int
 }
 
 type Service struct {
-	Base Base
+	Base          Base
+	Users         Users
+	Fines         Fines
+	Disputes      Disputes
+	Payments      Payments
+	Ledger        Ledger
+	Webhooks      Webhooks
+	Schedules     Schedules
+	Autopay       Autopay
+	Notifications Notifications
+	ExchangeRates ExchangeRates
+	Crypto        Crypto
+	Candles       Candles
+	Alerts        Alerts
+	Stats         Stats
 }
 
-func NewService(repos *repository.Repository, logger *slog.Logger) *Service {
+type Deps struct {
+	Repos           *repository.Repository
+	Hasher          hash.PasswordHasher
+	TokenManager    auth.TokenManager
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	MFA             MFAConfig
+	FineRules       domain.FineRules
+	FinePayee       domain.Payee
+	Storage         storage.ObjectStorage
+	Gateway         gateway.PaymentGateway
+	PriceSource     pricefeed.Source
+	Webhooks        WebhooksConfig
+	Schedules       SchedulesConfig
+	Autopay         AutopayConfig
+	ExchangeRates   ExchangeRatesConfig
+	Candles         CandlesConfig
+	Alerts          AlertsConfig
+	Stats           StatsConfig
+	// Notifier delivers alerts; they are only kept as in-app notifications
+	// by default.
+	Notifier Notifier
+	Logger   *slog.Logger
+}
+
+func NewService(deps Deps) *Service {
+	autopay := NewAutopayService(deps.Repos, deps.FineRules, deps.Gateway, deps.Autopay, deps.Logger)
+	fines := NewFinesService(deps.Repos, deps.FineRules, deps.FinePayee, autopay, deps.Logger)
+	payments := NewPaymentsService(deps.Repos, fines, deps.Gateway, deps.Logger)
+	rates := NewExchangeRatesService(deps.Repos, deps.ExchangeRates, deps.Logger)
+	crypto := NewCryptoService(deps.Repos, deps.PriceSource, rates, deps.Logger)
+	stats := NewStatsService(deps.Repos, deps.Stats, deps.Logger)
+	base := NewBaseService(deps.Repos, deps.FineRules, crypto, stats, deps.Logger)
+	notifications := NewNotificationsService(deps.Repos, deps.Logger)
+
+	notifier := deps.Notifier
+	if notifier == nil {
+		notifier = notifications
+	}
+
 	return &Service{
-		Base: NewBaseService(repos, logger),
+		Base: base,
+		Users: NewUsersService(deps.Repos, deps.Hasher, deps.TokenManager,
+			deps.AccessTokenTTL, deps.RefreshTokenTTL, deps.MFA, deps.Logger),
+		Fines:    fines,
+		Disputes: NewDisputesService(deps.Repos, deps.Storage, deps.Logger),
+		Payments: payments,
+		Ledger:   NewLedgerService(deps.Repos, rates, deps.ExchangeRates.Location, deps.Logger),
+		Webhooks: NewWebhooksService(deps.Repos, payments, deps.Webhooks, deps.Logger),
+		Schedules: NewSchedulesService(deps.Repos, fines, payments, deps.Gateway,
+			deps.Schedules, deps.Logger),
+		Autopay:       autopay,
+		Notifications: notifications,
+		ExchangeRates: rates,
+		Crypto:        crypto,
+		Candles:       NewCandlesService(deps.Repos, deps.PriceSource, deps.Candles, deps.Logger),
+		Alerts:        NewAlertsService(deps.Repos, base, deps.PriceSource, notifier, deps.Alerts, deps.Logger),
+		Stats:         stats,
 	}
 }
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxStatsBuckets limits the number of buckets of a report, a year of days.
+const maxStatsBuckets = 366
+
+// defaultStatsBuckets is how many buckets a report without a start covers.
+var defaultStatsBuckets = map[domain.StatsBucket]int{
+	domain.StatsDay:   30,
+	domain.StatsWeek:  12,
+	domain.StatsMonth: 12,
+}
+
+type seriesKey struct {
+	metric   domain.StatsMetric
+	currency string
+}
+
+type StatsService struct {
+	repos  *repository.Repository
+	cfg    StatsConfig
+	logger *slog.Logger
+}
+
+func NewStatsService(repos *repository.Repository, cfg StatsConfig, logger *slog.Logger) *StatsService {
+	if cfg.Location == nil {
+		cfg.Location = time.UTC
+	}
+
+	return &StatsService{
+		repos:  repos,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+func (s *StatsService) Report(ctx context.Context, userID uuid.UUID, input StatsInput) (domain.StatsReport, error) {
+	bucket := input.Bucket
+	if bucket == "" {
+		bucket = domain.StatsDay
+	}
+
+	if !bucket.Valid() {
+		return domain.StatsReport{}, fmt.Errorf("%w: unknown bucket %q", domain.ErrStatsQuery, bucket)
+	}
+
+	location := s.cfg.Location
+	if input.Timezone != "" {
+		var err error
+
+		// "Local" names the zone of the server, which means nothing to the
+		// user or to Postgres.
+		location, err = time.LoadLocation(input.Timezone)
+		if err != nil || location == time.Local {
+			return domain.StatsReport{}, fmt.Errorf("%w: unknown timezone %q", domain.ErrStatsQuery, input.Timezone)
+		}
+	}
+
+	// From and To are calendar days, whatever the zone they were parsed in.
+	to := statsDay(input.To, location)
+	if input.To.IsZero() {
+		to = statsDay(time.Now().In(location), location)
+	}
+
+	last := bucket.Start(to)
+	start := bucket.Add(last, 1-defaultStatsBuckets[bucket])
+
+	if !input.From.IsZero() {
+		from := statsDay(input.From, location)
+		if from.After(to) {
+			return domain.StatsReport{}, fmt.Errorf("%w: from must not be after to", domain.ErrStatsQuery)
+		}
+
+		start = bucket.Start(from)
+	}
+
+	// Buckets are counted from the start, as months differ in length.
+	var starts []time.Time
+	for n := 0; !bucket.Add(start, n).After(last); n++ {
+		if n == maxStatsBuckets {
+			return domain.StatsReport{}, fmt.Errorf("%w: at most %d buckets at a time", domain.ErrStatsQuery, maxStatsBuckets)
+		}
+
+		starts = append(starts, bucket.Add(start, n))
+	}
+
+	report := domain.StatsReport{
+		Bucket:       bucket,
+		Timezone:     location.String(),
+		From:         start,
+		To:           bucket.Add(last, 1),
+		PreviousFrom: bucket.Add(start, -len(starts)),
+	}
+
+	points := make(map[int64]int, len(starts))
+	for i, start := range starts {
+		points[start.Unix()] = i
+	}
+
+	var (
+		series     = make(map[seriesKey]*domain.StatsSeries)
+		categories = make(map[seriesKey]map[string]*domain.StatsCategory)
+	)
+
+	seriesOf := func(key seriesKey) *domain.StatsSeries {
+		if stats, ok := series[key]; ok {
+			return stats
+		}
+
+		stats := &domain.StatsSeries{
+			Metric:     key.metric,
+			Currency:   key.currency,
+			Points:     make([]domain.StatsPoint, len(starts)),
+			Categories: make([]domain.StatsCategory, 0),
+		}
+		for i, start := range starts {
+			stats.Points[i].Start = start
+		}
+
+		series[key] = stats
+		categories[key] = make(map[string]*domain.StatsCategory)
+
+		return stats
+	}
+
+	for _, metric := range domain.StatsMetrics {
+		seriesOf(seriesKey{metric: metric, currency: defaultCurrency})
+
+		rows, err := s.repos.Stats.Aggregate(ctx, userID, domain.StatsQuery{
+			Metric:   metric,
+			Bucket:   bucket,
+			Location: location,
+			From:     report.PreviousFrom,
+			To:       report.To,
+		})
+		if err != nil {
+			return domain.StatsReport{}, err
+		}
+
+		for _, row := range rows {
+			key := seriesKey{metric: metric, currency: row.Currency}
+			stats := seriesOf(key)
+
+			i, ok := points[statsDay(row.Start, location).Unix()]
+			if !ok {
+				stats.PreviousTotal += row.Amount
+				stats.PreviousCount += row.Count
+
+				continue
+			}
+
+			stats.Points[i].Amount += row.Amount
+			stats.Points[i].Count += row.Count
+			stats.Total += row.Amount
+			stats.Count += row.Count
+
+			category, ok := categories[key][row.Category]
+			if !ok {
+				category = &domain.StatsCategory{Category: row.Category}
+				categories[key][row.Category] = category
+			}
+
+			category.Amount += row.Amount
+			category.Count += row.Count
+		}
+	}
+
+	report.Series = make([]domain.StatsSeries, 0, len(series))
+	for key, stats := range series {
+		for _, category := range categories[key] {
+			stats.Categories = append(stats.Categories, *category)
+		}
+
+		sort.Slice(stats.Categories, func(i, j int) bool {
+			a, b := stats.Categories[i], stats.Categories[j]
+			if a.Amount != b.Amount {
+				return a.Amount > b.Amount
+			}
+
+			return a.Category < b.Category
+		})
+
+		if stats.PreviousTotal != 0 {
+			change := float64(stats.Total-stats.PreviousTotal) / float64(stats.PreviousTotal) * 100
+			change = math.Round(change*100) / 100
+			stats.Change = &change
+		}
+
+		report.Series = append(report.Series, *stats)
+	}
+
+	// Series follow the order of the metrics, rubles first.
+	order := make(map[domain.StatsMetric]int, len(domain.StatsMetrics))
+	for i, metric := range domain.StatsMetrics {
+		order[metric] = i
+	}
+
+	sort.Slice(report.Series, func(i, j int) bool {
+		a, b := report.Series[i], report.Series[j]
+		if a.Metric != b.Metric {
+			return order[a.Metric] < order[b.Metric]
+		}
+
+		if (a.Currency == defaultCurrency) != (b.Currency == defaultCurrency) {
+			return a.Currency == defaultCurrency
+		}
+
+		return a.Currency < b.Currency
+	})
+
+	return report, nil
+}
+
+// statsDay returns midnight in location on the calendar day of t.
+func statsDay(t time.Time, location *time.Location) time.Time {
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, location)
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// statsRows serves fixed rows per metric and records the queries.
+type statsRows struct {
+	rows    map[domain.StatsMetric][]domain.StatsRow
+	queries []domain.StatsQuery
+}
+
+func (r *statsRows) GetByUser(context.Context, uuid.UUID) (domain.Stats, error) {
+	return domain.Stats{}, domain.ErrNotFound
+}
+
+func (r *statsRows) Aggregate(_ context.Context, _ uuid.UUID, query domain.StatsQuery) ([]domain.StatsRow, error) {
+	r.queries = append(r.queries, query)
+
+	return r.rows[query.Metric], nil
+}
+
+func newStatsTestService(rows map[domain.StatsMetric][]domain.StatsRow) (*StatsService, *statsRows) {
+	repo := &statsRows{rows: rows}
+
+	return NewStatsService(&repository.Repository{Stats: repo}, StatsConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil))), repo
+}
+
+func statsDate(year int, month time.Month, day int, location *time.Location) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, location)
+}
+
+func TestStatsReportPeriod(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		input    StatsInput
+		from     time.Time
+		to       time.Time
+		previous time.Time
+		points   int
+	}{
+		{
+			name:     "30 days by default",
+			input:    StatsInput{To: statsDate(2024, time.March, 10, time.UTC)},
+			from:     statsDate(2024, time.February, 10, time.UTC),
+			to:       statsDate(2024, time.March, 11, time.UTC),
+			previous: statsDate(2024, time.January, 11, time.UTC),
+			points:   30,
+		},
+		{
+			name:     "a single day",
+			input:    StatsInput{From: statsDate(2024, time.March, 10, time.UTC), To: statsDate(2024, time.March, 10, time.UTC)},
+			from:     statsDate(2024, time.March, 10, time.UTC),
+			to:       statsDate(2024, time.March, 11, time.UTC),
+			previous: statsDate(2024, time.March, 9, time.UTC),
+			points:   1,
+		},
+		{
+			name:     "weeks start on monday",
+			input:    StatsInput{Bucket: domain.StatsWeek, To: statsDate(2024, time.March, 10, time.UTC)},
+			from:     statsDate(2023, time.December, 18, time.UTC),
+			to:       statsDate(2024, time.March, 11, time.UTC),
+			previous: statsDate(2023, time.September, 25, time.UTC),
+			points:   12,
+		},
+		{
+			name:     "a monday starts its own week",
+			input:    StatsInput{Bucket: domain.StatsWeek, From: statsDate(2024, time.March, 4, time.UTC), To: statsDate(2024, time.March, 11, time.UTC)},
+			from:     statsDate(2024, time.March, 4, time.UTC),
+			to:       statsDate(2024, time.March, 18, time.UTC),
+			previous: statsDate(2024, time.February, 19, time.UTC),
+			points:   2,
+		},
+		{
+			name:     "12 months by default",
+			input:    StatsInput{Bucket: domain.StatsMonth, To: statsDate(2024, time.March, 31, time.UTC)},
+			from:     statsDate(2023, time.April, 1, time.UTC),
+			to:       statsDate(2024, time.April, 1, time.UTC),
+			previous: statsDate(2022, time.April, 1, time.UTC),
+			points:   12,
+		},
+		{
+			name:     "months from the end of a month",
+			input:    StatsInput{Bucket: domain.StatsMonth, From: statsDate(2024, time.January, 31, time.UTC), To: statsDate(2024, time.March, 1, time.UTC)},
+			from:     statsDate(2024, time.January, 1, time.UTC),
+			to:       statsDate(2024, time.April, 1, time.UTC),
+			previous: statsDate(2023, time.October, 1, time.UTC),
+			points:   3,
+		},
+		{
+			name:     "calendar days in the time zone",
+			input:    StatsInput{Timezone: "Europe/Moscow", From: statsDate(2024, time.March, 1, time.UTC), To: statsDate(2024, time.March, 2, time.UTC)},
+			from:     statsDate(2024, time.March, 1, moscow),
+			to:       statsDate(2024, time.March, 3, moscow),
+			previous: statsDate(2024, time.February, 28, moscow),
+			points:   2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, repo := newStatsTestService(nil)
+
+			report, err := s.Report(context.Background(), uuid.New(), tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !report.From.Equal(tc.from) || !report.To.Equal(tc.to) || !report.PreviousFrom.Equal(tc.previous) {
+				t.Errorf("period = %v..%v after %v, want %v..%v after %v",
+					report.From, report.To, report.PreviousFrom, tc.from, tc.to, tc.previous)
+			}
+
+			for _, query := range repo.queries {
+				if !query.From.Equal(tc.previous) || !query.To.Equal(tc.to) {
+					t.Errorf("%s queried %v..%v, want %v..%v", query.Metric, query.From, query.To, tc.previous, tc.to)
+				}
+			}
+
+			if len(report.Series) != len(domain.StatsMetrics) {
+				t.Fatalf("%d series, want one per metric", len(report.Series))
+			}
+
+			for _, series := range report.Series {
+				if len(series.Points) != tc.points {
+					t.Fatalf("%s: %d points, want %d", series.Metric, len(series.Points), tc.points)
+				}
+
+				if !series.Points[0].Start.Equal(tc.from) {
+					t.Errorf("%s: first point at %v, want %v", series.Metric, series.Points[0].Start, tc.from)
+				}
+
+				for i := 1; i < len(series.Points); i++ {
+					if want := report.Bucket.Add(series.Points[0].Start, i); !series.Points[i].Start.Equal(want) {
+						t.Errorf("%s: point %d at %v, want %v", series.Metric, i, series.Points[i].Start, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestStatsReportInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input StatsInput
+	}{
+		{"unknown bucket", StatsInput{Bucket: "year"}},
+		{"unknown timezone", StatsInput{Timezone: "Mars/Olympus"}},
+		{"server timezone", StatsInput{Timezone: "Local"}},
+		{"from after to", StatsInput{From: statsDate(2024, time.March, 2, time.UTC), To: statsDate(2024, time.March, 1, time.UTC)}},
+		{"too many buckets", StatsInput{From: statsDate(2023, time.January, 1, time.UTC), To: statsDate(2024, time.January, 2, time.UTC)}},
+	} {
+		s, _ := newStatsTestService(nil)
+
+		if _, err := s.Report(context.Background(), uuid.New(), tc.input); !errors.Is(err, domain.ErrStatsQuery) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, domain.ErrStatsQuery)
+		}
+	}
+}
+
+func TestStatsReportSeries(t *testing.T) {
+	row := func(day int, currency, category string, amount, count int64) domain.StatsRow {
+		return domain.StatsRow{
+			Start:    statsDate(2024, time.March, day, time.UTC),
+			Currency: currency,
+			Category: category,
+			Amount:   amount,
+			Count:    count,
+		}
+	}
+
+	s, _ := newStatsTestService(map[domain.StatsMetric][]domain.StatsRow{
+		domain.StatsSpending: {
+			{Start: statsDate(2024, time.February, 28, time.UTC), Currency: "RUB", Category: domain.StatsCategoryFines, Amount: 500, Count: 1},
+			row(1, "RUB", domain.StatsCategoryFines, 1000, 1),
+			row(2, "USD", "shop", 700, 1),
+			row(3, "RUB", "shop", 3000, 2),
+		},
+		domain.StatsFines: {
+			{Start: statsDate(2024, time.February, 29, time.UTC), Currency: "RUB", Category: "GIBDD", Amount: 200, Count: 1},
+		},
+	})
+
+	report, err := s.Report(context.Background(), uuid.New(), StatsInput{
+		From: statsDate(2024, time.March, 1, time.UTC),
+		To:   statsDate(2024, time.March, 3, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	change := func(v float64) *float64 { return &v }
+
+	want := []struct {
+		metric     domain.StatsMetric
+		currency   string
+		points     []int64
+		total      int64
+		count      int64
+		previous   int64
+		change     *float64
+		categories []string
+	}{
+		{domain.StatsSpending, "RUB", []int64{1000, 0, 3000}, 4000, 3, 500, change(700), []string{"shop", domain.StatsCategoryFines}},
+		{domain.StatsSpending, "USD", []int64{0, 700, 0}, 700, 1, 0, nil, []string{"shop"}},
+		{domain.StatsIncome, "RUB", []int64{0, 0, 0}, 0, 0, 0, nil, nil},
+		{domain.StatsFines, "RUB", []int64{0, 0, 0}, 0, 0, 200, change(-100), nil},
+	}
+
+	if len(report.Series) != len(want) {
+		t.Fatalf("%d series, want %d", len(report.Series), len(want))
+	}
+
+	for i, w := range want {
+		got := report.Series[i]
+		if got.Metric != w.metric || got.Currency != w.currency {
+			t.Errorf("series %d = %s %s, want %s %s", i, got.Metric, got.Currency, w.metric, w.currency)
+			continue
+		}
+
+		for j, amount := range w.points {
+			if got.Points[j].Amount != amount {
+				t.Errorf("%s %s: point %d = %d, want %d", w.metric, w.currency, j, got.Points[j].Amount, amount)
+			}
+		}
+
+		if got.Total != w.total || got.Count != w.count || got.PreviousTotal != w.previous {
+			t.Errorf("%s %s: total %d of %d after %d, want %d of %d after %d",
+				w.metric, w.currency, got.Total, got.Count, got.PreviousTotal, w.total, w.count, w.previous)
+		}
+
+		if (got.Change == nil) != (w.change == nil) || got.Change != nil && *got.Change != *w.change {
+			t.Errorf("%s %s: change %v, want %v", w.metric, w.currency, got.Change, w.change)
+		}
+
+		if got.Categories == nil || len(got.Categories) != len(w.categories) {
+			t.Errorf("%s %s: categories %+v, want %v", w.metric, w.currency, got.Categories, w.categories)
+			continue
+		}
+
+		for j, category := range w.categories {
+			if got.Categories[j].Category != category {
+				t.Errorf("%s %s: category %d = %q, want %q", w.metric, w.currency, j, got.Categories[j].Category, category)
+			}
+		}
+	}
+}
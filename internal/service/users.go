@@ -0,0 +1,295 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/auth"
+	"backend-vtb/pkg/hash"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dummyPassword is hashed once to have a hash to verify passwords against
+// when no user has the email signed in with.
+const dummyPassword = "dummy password of no user"
+
+type UsersService struct {
+	repos           *repository.Repository
+	hasher          hash.PasswordHasher
+	dummyHash       string
+	tokenManager    auth.TokenManager
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	mfa             MFAConfig
+	logger          *slog.Logger
+}
+
+func NewUsersService(repos *repository.Repository, hasher hash.PasswordHasher, tokenManager auth.TokenManager,
+	accessTokenTTL, refreshTokenTTL time.Duration, mfa MFAConfig, logger *slog.Logger) *UsersService {
+	// Without the dummy hash unknown emails are only answered faster.
+	dummyHash, err := hasher.Hash(dummyPassword)
+	if err != nil {
+		logger.Error("failed to hash the dummy password", slog.String("reason", err.Error()))
+	}
+
+	return &UsersService{
+		repos:           repos,
+		hasher:          hasher,
+		dummyHash:       dummyHash,
+		tokenManager:    tokenManager,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+		mfa:             mfa,
+		logger:          logger,
+	}
+}
+
+func (s *UsersService) SignUp(ctx context.Context, input UserSignUpInput) (domain.Tokens, error) {
+	passwordHash, err := s.hasher.Hash(input.Password)
+	if err != nil {
+		return domain.Tokens{}, err
+	}
+
+	user := domain.User{
+		ID:           uuid.New(),
+		Name:         input.Name,
+		Email:        normalizeEmail(input.Email),
+		Phone:        input.Phone,
+		Password:     passwordHash,
+		Role:         domain.RoleCustomer,
+		RegisteredAt: time.Now(),
+	}
+
+	if err := s.repos.Users.Create(ctx, user); err != nil {
+		return domain.Tokens{}, err
+	}
+
+	return s.createSession(ctx, user, input.Client)
+}
+
+func (s *UsersService) SignIn(ctx context.Context, input UserSignInInput) (domain.SignInResult, error) {
+	user, err := s.repos.Users.GetByEmail(ctx, normalizeEmail(input.Email))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			// Verify the password all the same, so that the time taken does
+			// not tell whether the email is registered.
+			_, _ = s.hasher.Verify(input.Password, s.dummyHash)
+
+			return domain.SignInResult{}, domain.ErrInvalidCredentials
+		}
+
+		return domain.SignInResult{}, err
+	}
+
+	ok, err := s.hasher.Verify(input.Password, user.Password)
+	if err != nil {
+		return domain.SignInResult{}, err
+	}
+
+	if !ok {
+		return domain.SignInResult{}, domain.ErrInvalidCredentials
+	}
+
+	if s.hasher.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user.ID, input.Password)
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := s.tokenManager.NewMFAToken(user.ID.String(), s.mfa.ChallengeTTL)
+		if err != nil {
+			return domain.SignInResult{}, err
+		}
+
+		return domain.SignInResult{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	tokens, err := s.createSession(ctx, user, input.Client)
+	if err != nil {
+		return domain.SignInResult{}, err
+	}
+
+	return domain.SignInResult{Tokens: tokens}, nil
+}
+
+func (s *UsersService) RefreshTokens(ctx context.Context, refreshToken string, client ClientInfo) (domain.Tokens, error) {
+	tokenHash := auth.HashRefreshToken(refreshToken)
+
+	token, err := s.repos.Sessions.GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Tokens{}, domain.ErrInvalidRefreshToken
+		}
+
+		return domain.Tokens{}, err
+	}
+
+	session, err := s.repos.Sessions.GetByID(ctx, token.SessionID)
+	if err != nil {
+		return domain.Tokens{}, err
+	}
+
+	now := time.Now()
+	if !session.Active(now) {
+		return domain.Tokens{}, domain.ErrInvalidRefreshToken
+	}
+
+	if token.UsedAt != nil {
+		return domain.Tokens{}, s.revokeReusedSession(ctx, session)
+	}
+
+	if now.After(token.ExpiresAt) {
+		return domain.Tokens{}, domain.ErrInvalidRefreshToken
+	}
+
+	// Reload the user so that role changes take effect on the next refresh.
+	user, err := s.repos.Users.GetByID(ctx, session.UserID)
+	if err != nil {
+		return domain.Tokens{}, err
+	}
+
+	accessToken, next, nextToken, err := s.issueTokens(user, session, now)
+	if err != nil {
+		return domain.Tokens{}, err
+	}
+
+	session.Device = client.Device
+	session.IP = client.IP
+	session.LastSeenAt = now
+	session.ExpiresAt = next.ExpiresAt
+
+	if err := s.repos.Sessions.Rotate(ctx, tokenHash, session, next); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRefreshTokenReused):
+			// Another request has rotated the same token concurrently.
+			return domain.Tokens{}, s.revokeReusedSession(ctx, session)
+		case errors.Is(err, domain.ErrNotFound):
+			return domain.Tokens{}, domain.ErrInvalidRefreshToken
+		default:
+			return domain.Tokens{}, err
+		}
+	}
+
+	return domain.Tokens{AccessToken: accessToken, RefreshToken: nextToken}, nil
+}
+
+func (s *UsersService) SignOut(ctx context.Context, refreshToken string) error {
+	token, err := s.repos.Sessions.GetRefreshToken(ctx, auth.HashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	return s.repos.Sessions.Revoke(ctx, token.SessionID)
+}
+
+func (s *UsersService) GetSessions(ctx context.Context, userID uuid.UUID) ([]domain.Session, error) {
+	return s.repos.Sessions.GetActiveByUser(ctx, userID)
+}
+
+func (s *UsersService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	session, err := s.repos.Sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != userID {
+		return domain.ErrNotFound
+	}
+
+	return s.repos.Sessions.Revoke(ctx, sessionID)
+}
+
+// revokeReusedSession revokes the whole token family after a refresh token has
+// been presented twice: either the legitimate client or an attacker holds a
+// stolen copy, and there is no way to tell which one.
+func (s *UsersService) revokeReusedSession(ctx context.Context, session domain.Session) error {
+	s.logger.Warn("refresh token reuse detected, revoking session",
+		slog.String("user", session.UserID.String()), slog.String("session", session.ID.String()))
+
+	if err := s.repos.Sessions.Revoke(ctx, session.ID); err != nil {
+		return err
+	}
+
+	return domain.ErrRefreshTokenReused
+}
+
+// rehashPassword replaces the stored hash with one produced by the current
+// algorithm. Failures are only logged: the user has already been authenticated
+// and the upgrade will be retried at the next sign-in.
+func (s *UsersService) rehashPassword(ctx context.Context, userID uuid.UUID, password string) {
+	passwordHash, err := s.hasher.Hash(password)
+	if err == nil {
+		err = s.repos.Users.UpdatePassword(ctx, userID, passwordHash)
+	}
+
+	if err != nil {
+		s.logger.Error("failed to upgrade password hash",
+			slog.String("user", userID.String()), slog.String("reason", err.Error()))
+	}
+}
+
+// createSession starts a new session (token family) for the user.
+func (s *UsersService) createSession(ctx context.Context, user domain.User, client ClientInfo) (domain.Tokens, error) {
+	now := time.Now()
+	session := domain.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		Device:     client.Device,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	accessToken, token, refreshToken, err := s.issueTokens(user, session, now)
+	if err != nil {
+		return domain.Tokens{}, err
+	}
+
+	session.ExpiresAt = token.ExpiresAt
+
+	if err := s.repos.Sessions.Create(ctx, session, token); err != nil {
+		return domain.Tokens{}, err
+	}
+
+	return domain.Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// issueTokens creates an access token carrying the user's role and scopes and
+// a refresh token for the session. It returns the refresh token both in plain
+// text for the client and as the hashed record to be stored.
+func (s *UsersService) issueTokens(user domain.User, session domain.Session, now time.Time) (string, domain.RefreshToken, string, error) {
+	accessToken, err := s.tokenManager.NewJWT(auth.Claims{
+		Subject: user.ID.String(),
+		Roles:   []string{string(user.Role)},
+		Scopes:  user.Role.Scopes(),
+	}, s.accessTokenTTL)
+	if err != nil {
+		return "", domain.RefreshToken{}, "", err
+	}
+
+	refreshToken, err := s.tokenManager.NewRefreshToken()
+	if err != nil {
+		return "", domain.RefreshToken{}, "", err
+	}
+
+	token := domain.RefreshToken{
+		Hash:      auth.HashRefreshToken(refreshToken),
+		SessionID: session.ID,
+		ExpiresAt: now.Add(s.refreshTokenTTL),
+		CreatedAt: now,
+	}
+
+	return accessToken, token, refreshToken, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
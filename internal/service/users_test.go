@@ -0,0 +1,70 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository/memory"
+	"backend-vtb/pkg/auth"
+	"backend-vtb/pkg/hash"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// verifyRecorder records the hashes passwords are verified against.
+type verifyRecorder struct {
+	hash.PasswordHasher
+	verified []string
+}
+
+func (h *verifyRecorder) Verify(password, encoded string) (bool, error) {
+	h.verified = append(h.verified, encoded)
+
+	return h.PasswordHasher.Verify(password, encoded)
+}
+
+func TestUsersSignInVerifiesUnknownEmails(t *testing.T) {
+	tokens, err := auth.NewManager("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hasher := &verifyRecorder{PasswordHasher: hash.NewBcryptHasher(4)}
+	s := NewUsersService(memory.NewRepository(), hasher, tokens, time.Minute, time.Hour, MFAConfig{},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx := context.Background()
+
+	if _, err := s.SignUp(ctx, UserSignUpInput{Name: "Alice", Email: "alice@example.com", Password: "password1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		email    string
+		password string
+		err      error
+	}{
+		{"unknown email", "bob@example.com", "password1", domain.ErrInvalidCredentials},
+		{"wrong password", "alice@example.com", "password2", domain.ErrInvalidCredentials},
+		{"correct", "Alice@Example.com", "password1", nil},
+	} {
+		hasher.verified = nil
+
+		if _, err := s.SignIn(ctx, UserSignInInput{Email: tc.email, Password: tc.password}); !errors.Is(err, tc.err) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, tc.err)
+		}
+
+		// Every sign-in verifies a password against a real hash, so that
+		// unknown emails take as long as wrong passwords.
+		if len(hasher.verified) != 1 || hasher.verified[0] == "" || hasher.NeedsRehash(hasher.verified[0]) {
+			t.Errorf("%s: verified against %q, want one current hash", tc.name, hasher.verified)
+		}
+	}
+
+	if ok, err := hasher.PasswordHasher.Verify(dummyPassword, s.dummyHash); err != nil || !ok {
+		t.Errorf("dummy hash verifies the dummy password = %v, %v; want true", ok, err)
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"backend-vtb/internal/domain"
+	"backend-vtb/internal/repository"
+	"backend-vtb/pkg/gateway"
+	"backend-vtb/pkg/webhook"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebhooksService struct {
+	repos    *repository.Repository
+	payments *PaymentsService
+	cfg      WebhooksConfig
+	logger   *slog.Logger
+}
+
+func NewWebhooksService(repos *repository.Repository, payments *PaymentsService, cfg WebhooksConfig, logger *slog.Logger) *WebhooksService {
+	return &WebhooksService{
+		repos:    repos,
+		payments: payments,
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+func (s *WebhooksService) HandlePayment(ctx context.Context, delivery WebhookDelivery) (domain.WebhookEvent, error) {
+	secret, ok := s.cfg.Secrets[delivery.Provider]
+	if !ok || len(secret) == 0 {
+		return domain.WebhookEvent{}, domain.ErrWebhookProvider
+	}
+
+	now := time.Now()
+
+	sentAt, err := webhook.Verify(secret, delivery.Signature, delivery.Timestamp, delivery.Payload, now, s.cfg.Tolerance)
+	if err != nil {
+		s.logger.Warn("webhook rejected",
+			slog.String("provider", delivery.Provider), slog.String("reason", err.Error()))
+
+		return domain.WebhookEvent{}, fmt.Errorf("%w: %w", domain.ErrWebhookSignature, err)
+	}
+
+	var payload gateway.Event
+	if err := json.Unmarshal(delivery.Payload, &payload); err != nil || payload.ID == "" {
+		return domain.WebhookEvent{}, domain.ErrWebhookPayload
+	}
+
+	paymentID, err := uuid.Parse(payload.Transaction.PaymentID)
+	if err != nil {
+		return domain.WebhookEvent{}, fmt.Errorf("%w: invalid payment id", domain.ErrWebhookPayload)
+	}
+
+	event := domain.WebhookEvent{
+		ID:         uuid.New(),
+		Provider:   delivery.Provider,
+		EventID:    payload.ID,
+		EventType:  payload.Type,
+		Payload:    delivery.Payload,
+		Signature:  delivery.Signature,
+		SentAt:     sentAt,
+		ReceivedAt: now,
+	}
+
+	// Do not reference a payment that does not exist; the event is kept all
+	// the same, as there may be a bug on either side to investigate.
+	if _, err := s.repos.Payments.GetByID(ctx, paymentID); err == nil {
+		event.PaymentID = &paymentID
+	}
+
+	if err := s.repos.Webhooks.Create(ctx, event); err != nil {
+		if !errors.Is(err, domain.ErrWebhookEventExists) {
+			return domain.WebhookEvent{}, err
+		}
+
+		// A redelivery. Only retry it if processing the first one failed.
+		event, err = s.repos.Webhooks.GetByEventID(ctx, delivery.Provider, payload.ID)
+		if err != nil || event.ProcessedAt != nil {
+			return event, err
+		}
+	}
+
+	event.Outcome, event.Detail, err = s.apply(ctx, paymentID, payload.Transaction, now)
+	if err != nil {
+		// Leave the event unprocessed; the provider retries failed deliveries.
+		s.logger.Error("failed to apply webhook",
+			slog.String("provider", event.Provider), slog.String("event", event.EventID),
+			slog.String("reason", err.Error()))
+
+		return domain.WebhookEvent{}, err
+	}
+
+	event.ProcessedAt = &now
+	if err := s.repos.Webhooks.Complete(ctx, event.ID, event.Outcome, event.Detail, now); err != nil {
+		return domain.WebhookEvent{}, err
+	}
+
+	s.logger.Info("webhook processed",
+		slog.String("provider", event.Provider), slog.String("event", event.EventID),
+		slog.String("payment", paymentID.String()), slog.String("outcome", string(event.Outcome)),
+		slog.String("detail", event.Detail))
+
+	return event, nil
+}
+
+// apply moves the payment to the status the transaction has at the acquirer.
+//
+// Events may arrive out of order. If the payment has to pass through
+// intermediate statuses, e.g. a capture reported before the authorization,
+// each step is applied in turn so that the ledger receives every entry. An
+// event reporting a status the payment has already passed is stale and
+// ignored. Refunded amounts only ever grow, so they are reconciled whatever
+// the order.
+func (s *WebhooksService) apply(ctx context.Context, paymentID uuid.UUID, tx gateway.Transaction, at time.Time) (domain.WebhookOutcome, string, error) {
+	payment, err := s.repos.Payments.GetByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.WebhookIgnored, "unknown payment", nil
+		}
+
+		return "", "", err
+	}
+
+	if payment.GatewayRef != "" && payment.GatewayRef != tx.Reference {
+		return domain.WebhookIgnored, "transaction reference does not match the payment", nil
+	}
+
+	target, reason, ok := paymentStatusOf(tx)
+	if !ok {
+		return domain.WebhookIgnored, fmt.Sprintf("unknown transaction status %q", tx.Status), nil
+	}
+
+	if payment.Status == domain.PaymentRefunded {
+		return domain.WebhookIgnored, "payment is already refunded", nil
+	}
+
+	// Refunds are recorded as refunds of the captured payment rather than as a
+	// change of its status, so that partial refunds made at the acquirer are
+	// accounted for as well.
+	refunded := tx.Refunded
+	if target == domain.PaymentRefunded {
+		target = domain.PaymentCaptured
+		if refunded == 0 {
+			refunded = payment.Amount
+		}
+	}
+
+	applied := false
+
+	switch {
+	case target == payment.Status && target == domain.PaymentPending && tx.ChallengeURL != payment.ChallengeURL:
+		payment, err = s.payments.transition(ctx, payment, domain.PaymentTransition{
+			PaymentID:    payment.ID,
+			From:         payment.Status,
+			To:           payment.Status,
+			GatewayRef:   tx.Reference,
+			ChallengeURL: tx.ChallengeURL,
+		})
+		if err != nil {
+			return "", "", err
+		}
+
+		applied = true
+	case target != payment.Status:
+		path := payment.Status.PathTo(target)
+		if path == nil {
+			return domain.WebhookIgnored, fmt.Sprintf("stale event: payment is %s", payment.Status), nil
+		}
+
+		for _, to := range path {
+			transition := domain.PaymentTransition{
+				PaymentID:  payment.ID,
+				From:       payment.Status,
+				To:         to,
+				GatewayRef: tx.Reference,
+			}
+
+			switch to {
+			case domain.PaymentFailed:
+				transition.FailureReason = reason
+			case domain.PaymentCaptured:
+				transition.Entries = payment.CaptureEntries(at)
+
+				transition.FineEvent, err = s.payments.finePaidEvent(ctx, payment, at)
+				if errors.Is(err, domain.ErrFineNotPayable) {
+					// The money has been taken already; record it and leave
+					// the fine to be refunded or reconciled by hand.
+					s.logger.Warn("payment captured for a fine that is not payable",
+						slog.String("payment", payment.ID.String()), slog.String("reason", err.Error()))
+				} else if err != nil {
+					return "", "", err
+				}
+			}
+
+			payment, err = s.payments.transition(ctx, payment, transition)
+			if err != nil {
+				return "", "", err
+			}
+		}
+
+		applied = true
+	}
+
+	if target == domain.PaymentCaptured && refunded > 0 {
+		recorded, err := s.payments.reconcileRefunds(ctx, payment, refunded)
+		if err != nil {
+			return "", "", err
+		}
+
+		if recorded {
+			applied = true
+			if payment, err = s.repos.Payments.GetByID(ctx, payment.ID); err != nil {
+				return "", "", err
+			}
+		}
+	}
+
+	if !applied {
+		return domain.WebhookIgnored, fmt.Sprintf("payment is already %s", payment.Status), nil
+	}
+
+	return domain.WebhookApplied, fmt.Sprintf("payment is %s", payment.Status), nil
+}
+
+// paymentStatusOf maps the status of a gateway transaction to the payment
+// status it implies, together with the failure reason of a failed payment.
+func paymentStatusOf(tx gateway.Transaction) (domain.PaymentStatus, string, bool) {
+	switch tx.Status {
+	case gateway.StatusActionRequired:
+		return domain.PaymentPending, "", true
+	case gateway.StatusAuthorized:
+		return domain.PaymentAuthorized, "", true
+	case gateway.StatusCaptured:
+		return domain.PaymentCaptured, "", true
+	case gateway.StatusRefunded:
+		return domain.PaymentRefunded, "", true
+	case gateway.StatusDeclined:
+		return domain.PaymentFailed, tx.DeclineCode, true
+	case gateway.StatusVoided:
+		return domain.PaymentFailed, failureVoided, true
+	default:
+		return "", "", false
+	}
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_stats;
+DROP TABLE IF EXISTS achievements;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS fines;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,47 @@
+CREATE TABLE users (
+    id            uuid PRIMARY KEY,
+    name          text        NOT NULL,
+    email         text        NOT NULL UNIQUE,
+    phone         text        NOT NULL DEFAULT '',
+    registered_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE fines (
+    id        uuid PRIMARY KEY,
+    user_id   uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    amount    bigint      NOT NULL CHECK (amount >= 0),
+    paid      boolean     NOT NULL DEFAULT false,
+    issued_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX fines_user_id_idx ON fines (user_id);
+
+CREATE TABLE payments (
+    id         uuid PRIMARY KEY,
+    user_id    uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    amount     bigint      NOT NULL CHECK (amount >= 0),
+    status     text        NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX payments_user_id_idx ON payments (user_id);
+
+CREATE TABLE achievements (
+    id          uuid PRIMARY KEY,
+    user_id     uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    title       text        NOT NULL,
+    description text        NOT NULL DEFAULT '',
+    achieved_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX achievements_user_id_idx ON achievements (user_id);
+
+CREATE TABLE user_stats (
+    user_id       uuid PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    neuro_mean    double precision NOT NULL DEFAULT 0,
+    crypto_data   text             NOT NULL DEFAULT '',
+    stats_data    text             NOT NULL DEFAULT '',
+    analysis      text             NOT NULL DEFAULT '',
+    api_info      text             NOT NULL DEFAULT '',
+    full_api_info text             NOT NULL DEFAULT ''
+);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS sessions;
+
+CREATE TABLE sessions (
+    id            uuid PRIMARY KEY,
+    user_id       uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    refresh_token text        NOT NULL UNIQUE,
+    expires_at    timestamptz NOT NULL,
+    created_at    timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
@@ -0,0 +1,27 @@
+-- Refresh tokens used to be stored in plain text, one row per token. They are
+-- now stored hashed and grouped into sessions (token families), so existing
+-- tokens cannot be migrated and users have to sign in again.
+DROP TABLE sessions;
+
+CREATE TABLE sessions (
+    id           uuid PRIMARY KEY,
+    user_id      uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    device       text        NOT NULL DEFAULT '',
+    ip           text        NOT NULL DEFAULT '',
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    last_seen_at timestamptz NOT NULL DEFAULT now(),
+    expires_at   timestamptz NOT NULL,
+    revoked_at   timestamptz
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+
+CREATE TABLE refresh_tokens (
+    token_hash text PRIMARY KEY,
+    session_id uuid        NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+    expires_at timestamptz NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens (session_id);
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN role text NOT NULL DEFAULT 'customer'
+        CHECK (role IN ('customer', 'operator', 'admin'));
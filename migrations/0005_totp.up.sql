@@ -0,0 +1,14 @@
+ALTER TABLE users
+    ADD COLUMN totp_secret  text    NOT NULL DEFAULT '',
+    ADD COLUMN totp_enabled boolean NOT NULL DEFAULT false,
+    ADD COLUMN totp_counter bigint  NOT NULL DEFAULT 0;
+
+CREATE TABLE recovery_codes (
+    id         uuid PRIMARY KEY,
+    user_id    uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  text        NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes (user_id);
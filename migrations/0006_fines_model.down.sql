@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS fines_user_id_uin_idx;
+
+ALTER TABLE fines ADD COLUMN paid boolean NOT NULL DEFAULT false;
+
+UPDATE fines SET paid = true WHERE status = 'paid';
+
+ALTER TABLE fines
+    DROP COLUMN updated_at,
+    DROP COLUMN created_at,
+    DROP COLUMN status,
+    DROP COLUMN discount_until,
+    DROP COLUMN due_at,
+    DROP COLUMN article,
+    DROP COLUMN issuer,
+    DROP COLUMN uin;
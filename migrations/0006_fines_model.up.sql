@@ -0,0 +1,21 @@
+-- Fines used to be a bare amount with a paid flag. Existing rows get an empty
+-- UIN and the statutory 70 days to pay (10 days to take effect plus 60 days).
+ALTER TABLE fines
+    ADD COLUMN uin            text        NOT NULL DEFAULT '',
+    ADD COLUMN issuer         text        NOT NULL DEFAULT '',
+    ADD COLUMN article        text        NOT NULL DEFAULT '',
+    ADD COLUMN due_at         timestamptz,
+    ADD COLUMN discount_until timestamptz,
+    ADD COLUMN status         text        NOT NULL DEFAULT 'issued'
+        CHECK (status IN ('issued', 'paid', 'cancelled')),
+    ADD COLUMN created_at     timestamptz NOT NULL DEFAULT now(),
+    ADD COLUMN updated_at     timestamptz NOT NULL DEFAULT now();
+
+UPDATE fines SET due_at = issued_at + interval '70 days';
+UPDATE fines SET status = 'paid' WHERE paid;
+
+ALTER TABLE fines
+    ALTER COLUMN due_at SET NOT NULL,
+    DROP COLUMN paid;
+
+CREATE UNIQUE INDEX fines_user_id_uin_idx ON fines (user_id, uin) WHERE uin <> '';
@@ -0,0 +1,5 @@
+UPDATE fines SET status = 'issued' WHERE status = 'disputed';
+
+ALTER TABLE fines
+    DROP CONSTRAINT fines_status_check,
+    ADD CONSTRAINT fines_status_check CHECK (status IN ('issued', 'paid', 'cancelled'));
@@ -0,0 +1,3 @@
+ALTER TABLE fines
+    DROP CONSTRAINT fines_status_check,
+    ADD CONSTRAINT fines_status_check CHECK (status IN ('issued', 'paid', 'cancelled', 'disputed'));
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS fine_events;
+DROP FUNCTION IF EXISTS fine_events_append_only();
+DROP TABLE IF EXISTS dispute_attachments;
+DROP TABLE IF EXISTS disputes;
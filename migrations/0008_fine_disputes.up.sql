@@ -0,0 +1,64 @@
+CREATE TABLE disputes (
+    id          uuid PRIMARY KEY,
+    fine_id     uuid        NOT NULL REFERENCES fines (id) ON DELETE CASCADE,
+    user_id     uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason      text        NOT NULL
+        CHECK (reason IN ('not_driver', 'vehicle_sold', 'already_paid', 'incorrect_amount', 'other')),
+    comment     text        NOT NULL DEFAULT '',
+    status      text        NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'accepted', 'rejected', 'withdrawn')),
+    reviewer_id uuid REFERENCES users (id) ON DELETE SET NULL,
+    decision    text        NOT NULL DEFAULT '',
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    resolved_at timestamptz
+);
+
+CREATE INDEX disputes_fine_id_idx ON disputes (fine_id);
+CREATE INDEX disputes_user_id_idx ON disputes (user_id);
+CREATE UNIQUE INDEX disputes_open_fine_id_idx ON disputes (fine_id) WHERE status = 'open';
+
+CREATE TABLE dispute_attachments (
+    id           uuid PRIMARY KEY,
+    dispute_id   uuid        NOT NULL REFERENCES disputes (id) ON DELETE CASCADE,
+    file_name    text        NOT NULL,
+    content_type text        NOT NULL,
+    size         bigint      NOT NULL CHECK (size >= 0),
+    storage_key  text        NOT NULL,
+    created_at   timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX dispute_attachments_dispute_id_idx ON dispute_attachments (dispute_id);
+
+CREATE TABLE fine_events (
+    id         uuid PRIMARY KEY,
+    fine_id    uuid        NOT NULL REFERENCES fines (id) ON DELETE CASCADE,
+    type       text        NOT NULL,
+    status     text        NOT NULL,
+    dispute_id uuid,
+    actor_id   uuid        NOT NULL,
+    comment    text        NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX fine_events_fine_id_idx ON fine_events (fine_id, created_at);
+
+-- The history is append-only. Rows may only disappear together with their
+-- fine, through the cascading delete, which runs as a nested trigger.
+CREATE FUNCTION fine_events_append_only() RETURNS trigger AS
+$$
+BEGIN
+    IF TG_OP = 'UPDATE' OR pg_trigger_depth() <= 1 THEN
+        RAISE EXCEPTION 'fine_events is append-only';
+    END IF;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER fine_events_append_only
+    BEFORE UPDATE OR DELETE ON fine_events
+    FOR EACH ROW EXECUTE FUNCTION fine_events_append_only();
+
+-- Start the history of existing fines.
+INSERT INTO fine_events (id, fine_id, type, status, actor_id, created_at)
+SELECT gen_random_uuid(), id, 'created', status, user_id, created_at FROM fines;
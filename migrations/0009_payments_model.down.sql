@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS payments_fine_id_in_progress_idx;
+DROP INDEX IF EXISTS payments_user_id_idempotency_key_idx;
+
+ALTER TABLE payments
+    DROP CONSTRAINT payments_status_check,
+    ALTER COLUMN status DROP DEFAULT;
+
+ALTER TABLE payments
+    DROP COLUMN updated_at,
+    DROP COLUMN request_hash,
+    DROP COLUMN idempotency_key,
+    DROP COLUMN failure_reason,
+    DROP COLUMN merchant_id,
+    DROP COLUMN fine_id,
+    DROP COLUMN target_type,
+    DROP COLUMN purpose,
+    DROP COLUMN currency;
@@ -0,0 +1,34 @@
+-- Payments used to be a bare amount with a free-form status. Existing rows
+-- are treated as RUB merchant payments; statuses outside the new set are
+-- mapped to captured when they meant success and to failed otherwise.
+ALTER TABLE payments
+    ADD COLUMN currency        text        NOT NULL DEFAULT 'RUB',
+    ADD COLUMN purpose         text        NOT NULL DEFAULT '',
+    ADD COLUMN target_type     text        NOT NULL DEFAULT 'merchant'
+        CHECK (target_type IN ('fine', 'merchant')),
+    ADD COLUMN fine_id         uuid REFERENCES fines (id) ON DELETE SET NULL,
+    ADD COLUMN merchant_id     text        NOT NULL DEFAULT '',
+    ADD COLUMN failure_reason  text        NOT NULL DEFAULT '',
+    ADD COLUMN idempotency_key text        NOT NULL DEFAULT '',
+    ADD COLUMN request_hash    text        NOT NULL DEFAULT '',
+    ADD COLUMN updated_at      timestamptz NOT NULL DEFAULT now();
+
+UPDATE payments SET status = CASE
+    WHEN status IN ('pending', 'authorized', 'captured', 'failed', 'refunded') THEN status
+    WHEN lower(status) IN ('success', 'succeeded', 'paid', 'completed', 'done') THEN 'captured'
+    ELSE 'failed'
+END;
+
+UPDATE payments SET updated_at = created_at;
+
+ALTER TABLE payments
+    ALTER COLUMN status SET DEFAULT 'pending',
+    ADD CONSTRAINT payments_status_check
+        CHECK (status IN ('pending', 'authorized', 'captured', 'failed', 'refunded'));
+
+CREATE UNIQUE INDEX payments_user_id_idempotency_key_idx ON payments (user_id, idempotency_key)
+    WHERE idempotency_key <> '';
+
+-- A fine can only have one payment that has taken or may still take money.
+CREATE UNIQUE INDEX payments_fine_id_in_progress_idx ON payments (fine_id)
+    WHERE status IN ('pending', 'authorized', 'captured');
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS postings;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS ledger_accounts;
+
+DROP FUNCTION IF EXISTS postings_balanced();
+DROP FUNCTION IF EXISTS ledger_immutable();
@@ -0,0 +1,76 @@
+CREATE TABLE ledger_accounts (
+    code       text        NOT NULL,
+    currency   text        NOT NULL,
+    type       text        NOT NULL CHECK (type IN ('asset', 'liability')),
+    user_id    uuid,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (code, currency)
+);
+
+CREATE INDEX ledger_accounts_user_id_idx ON ledger_accounts (user_id) WHERE user_id IS NOT NULL;
+
+-- Entries keep the payment id without a foreign key: the ledger must outlive
+-- the rows it describes.
+CREATE TABLE journal_entries (
+    id          uuid PRIMARY KEY,
+    kind        text        NOT NULL CHECK (kind IN ('payment', 'settlement', 'refund')),
+    payment_id  uuid,
+    description text        NOT NULL DEFAULT '',
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX journal_entries_payment_id_idx ON journal_entries (payment_id);
+
+CREATE TABLE postings (
+    id           uuid PRIMARY KEY,
+    entry_id     uuid   NOT NULL REFERENCES journal_entries (id),
+    account_code text   NOT NULL,
+    currency     text   NOT NULL,
+    amount       bigint NOT NULL CHECK (amount <> 0),
+    FOREIGN KEY (account_code, currency) REFERENCES ledger_accounts (code, currency)
+);
+
+CREATE INDEX postings_entry_id_idx ON postings (entry_id);
+CREATE INDEX postings_account_idx ON postings (account_code, currency);
+
+-- Committed entries are never changed; corrections are new entries.
+CREATE FUNCTION ledger_immutable() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION '% is immutable', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER journal_entries_immutable
+    BEFORE UPDATE OR DELETE ON journal_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
+
+CREATE TRIGGER journal_entries_no_truncate
+    BEFORE TRUNCATE ON journal_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION ledger_immutable();
+
+CREATE TRIGGER postings_immutable
+    BEFORE UPDATE OR DELETE ON postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
+
+CREATE TRIGGER postings_no_truncate
+    BEFORE TRUNCATE ON postings
+    FOR EACH STATEMENT EXECUTE FUNCTION ledger_immutable();
+
+-- The postings of an entry must balance in every currency. The check runs at
+-- commit, once all postings of the entry have been inserted.
+CREATE FUNCTION postings_balanced() RETURNS trigger AS
+$$
+BEGIN
+    IF EXISTS (SELECT 1 FROM postings WHERE entry_id = NEW.entry_id GROUP BY currency HAVING sum(amount) <> 0) THEN
+        RAISE EXCEPTION 'journal entry % does not balance', NEW.entry_id;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER postings_balanced
+    AFTER INSERT ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION postings_balanced();
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS payments_gateway_ref_idx;
+
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS challenge_url,
+    DROP COLUMN IF EXISTS gateway_ref;
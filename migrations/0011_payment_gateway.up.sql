@@ -0,0 +1,7 @@
+-- The acquirer's reference of the card transaction, and the 3-D Secure page
+-- the user has to visit while the authorization awaits a challenge.
+ALTER TABLE payments
+    ADD COLUMN gateway_ref   text NOT NULL DEFAULT '',
+    ADD COLUMN challenge_url text NOT NULL DEFAULT '';
+
+CREATE INDEX payments_gateway_ref_idx ON payments (gateway_ref) WHERE gateway_ref <> '';
@@ -0,0 +1,20 @@
+-- Verified webhook deliveries with their raw payloads, kept for audit. The
+-- unique index makes a redelivered event a no-op.
+CREATE TABLE webhook_events (
+    id           uuid PRIMARY KEY,
+    provider     text        NOT NULL,
+    event_id     text        NOT NULL,
+    event_type   text        NOT NULL DEFAULT '',
+    payment_id   uuid REFERENCES payments (id) ON DELETE SET NULL,
+    payload      bytea       NOT NULL,
+    signature    text        NOT NULL,
+    sent_at      timestamptz NOT NULL,
+    received_at  timestamptz NOT NULL DEFAULT now(),
+    processed_at timestamptz,
+    outcome      text        NOT NULL DEFAULT ''
+        CHECK (outcome IN ('', 'applied', 'ignored')),
+    detail       text        NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX webhook_events_provider_event_id_idx ON webhook_events (provider, event_id);
+CREATE INDEX webhook_events_payment_id_idx ON webhook_events (payment_id, received_at);
@@ -0,0 +1,25 @@
+ALTER TABLE payments
+    ADD COLUMN refunded bigint NOT NULL DEFAULT 0,
+    ADD CONSTRAINT payments_refunded_check CHECK (refunded >= 0 AND refunded <= amount);
+
+UPDATE payments SET refunded = amount WHERE status = 'refunded';
+
+CREATE TABLE refunds (
+    id              uuid PRIMARY KEY,
+    payment_id      uuid        NOT NULL REFERENCES payments (id) ON DELETE CASCADE,
+    user_id         uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    amount          bigint      NOT NULL CHECK (amount > 0),
+    currency        text        NOT NULL,
+    reason          text        NOT NULL DEFAULT '',
+    status          text        NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'succeeded', 'failed')),
+    failure_reason  text        NOT NULL DEFAULT '',
+    idempotency_key text        NOT NULL DEFAULT '',
+    request_hash    text        NOT NULL DEFAULT '',
+    created_at      timestamptz NOT NULL DEFAULT now(),
+    updated_at      timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX refunds_payment_id_idx ON refunds (payment_id, created_at);
+CREATE UNIQUE INDEX refunds_payment_id_idempotency_key_idx ON refunds (payment_id, idempotency_key)
+    WHERE idempotency_key <> '';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS payment_schedule_occurrences;
+DROP TABLE IF EXISTS payment_schedules;
@@ -0,0 +1,42 @@
+-- Scheduled and recurring payments. The card is kept at the acquirer; only
+-- its token is stored here.
+CREATE TABLE payment_schedules (
+    id             uuid PRIMARY KEY,
+    user_id        uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind           text        NOT NULL
+        CHECK (kind IN ('once', 'monthly', 'last_business_day', 'weekly')),
+    day            integer     NOT NULL DEFAULT 0,
+    interval_weeks integer     NOT NULL DEFAULT 0,
+    amount         bigint      NOT NULL CHECK (amount >= 0),
+    currency       text        NOT NULL,
+    purpose        text        NOT NULL DEFAULT '',
+    fine_id        uuid REFERENCES fines (id) ON DELETE CASCADE,
+    merchant_id    text        NOT NULL DEFAULT '',
+    card_token     text        NOT NULL,
+    card_last4     text        NOT NULL DEFAULT '',
+    starts_at      timestamptz NOT NULL,
+    ends_at        timestamptz,
+    status         text        NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'paused', 'completed', 'cancelled')),
+    next_run_at    timestamptz,
+    created_at     timestamptz NOT NULL DEFAULT now(),
+    updated_at     timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX payment_schedules_user_id_idx ON payment_schedules (user_id, created_at);
+CREATE INDEX payment_schedules_due_idx ON payment_schedules (next_run_at) WHERE status = 'active';
+
+-- One row per occurrence; the unique index keeps an occurrence from being
+-- recorded twice when executors race.
+CREATE TABLE payment_schedule_occurrences (
+    id            uuid PRIMARY KEY,
+    schedule_id   uuid        NOT NULL REFERENCES payment_schedules (id) ON DELETE CASCADE,
+    scheduled_for timestamptz NOT NULL,
+    status        text        NOT NULL CHECK (status IN ('succeeded', 'failed', 'skipped')),
+    payment_id    uuid REFERENCES payments (id) ON DELETE SET NULL,
+    reason        text        NOT NULL DEFAULT '',
+    created_at    timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX payment_schedule_occurrences_schedule_id_idx
+    ON payment_schedule_occurrences (schedule_id, scheduled_for);
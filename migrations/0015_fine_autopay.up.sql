@@ -0,0 +1,42 @@
+-- Autopay of new fines at the discount. Each user has at most one rule; the
+-- card is kept at the acquirer and only its token is stored here.
+CREATE TABLE autopay_rules (
+    user_id       uuid PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    enabled       boolean     NOT NULL DEFAULT false,
+    max_per_fine  bigint      NOT NULL CHECK (max_per_fine >= 0),
+    monthly_limit bigint      NOT NULL CHECK (monthly_limit >= 0),
+    card_token    text        NOT NULL DEFAULT '',
+    card_last4    text        NOT NULL DEFAULT '',
+    created_at    timestamptz NOT NULL DEFAULT now(),
+    updated_at    timestamptz NOT NULL DEFAULT now()
+);
+
+-- One decision per fine. Scheduled charges are paid by a one-off payment
+-- schedule; their amounts count towards the monthly limit of their month.
+CREATE TABLE autopay_charges (
+    id          uuid PRIMARY KEY,
+    user_id     uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    fine_id     uuid        NOT NULL REFERENCES fines (id) ON DELETE CASCADE,
+    schedule_id uuid REFERENCES payment_schedules (id) ON DELETE SET NULL,
+    amount      bigint      NOT NULL CHECK (amount >= 0),
+    charge_at   timestamptz NOT NULL,
+    month       timestamptz NOT NULL,
+    status      text        NOT NULL CHECK (status IN ('scheduled', 'declined', 'cancelled')),
+    reason      text        NOT NULL DEFAULT '',
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    updated_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX autopay_charges_fine_id_idx ON autopay_charges (fine_id);
+CREATE INDEX autopay_charges_user_id_idx ON autopay_charges (user_id, month) WHERE status = 'scheduled';
+
+CREATE TABLE notifications (
+    id         uuid PRIMARY KEY,
+    user_id    uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind       text        NOT NULL,
+    subject_id uuid,
+    message    text        NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX notifications_user_id_idx ON notifications (user_id, created_at);
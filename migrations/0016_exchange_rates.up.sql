@@ -0,0 +1,10 @@
+-- Official daily exchange rates, each the price of one unit of the currency
+-- in rubles. Days without published rates have no rows; the latest earlier
+-- rate applies to them.
+CREATE TABLE exchange_rates (
+    currency   text    NOT NULL,
+    date       date    NOT NULL,
+    rate       numeric NOT NULL CHECK (rate > 0),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (currency, date)
+);
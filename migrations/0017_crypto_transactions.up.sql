@@ -0,0 +1,16 @@
+-- Crypto transactions recorded by users. Holdings, cost basis and profit or
+-- loss are computed from them on request, so nothing derived is stored.
+CREATE TABLE crypto_transactions (
+    id          uuid PRIMARY KEY,
+    user_id     uuid            NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    asset       text            NOT NULL,
+    kind        text            NOT NULL CHECK (kind IN ('buy', 'sell', 'transfer_in', 'transfer_out')),
+    quantity    numeric(38, 18) NOT NULL CHECK (quantity > 0),
+    amount      bigint          NOT NULL CHECK (amount >= 0),
+    fee         bigint          NOT NULL DEFAULT 0 CHECK (fee >= 0),
+    currency    text            NOT NULL,
+    executed_at timestamptz     NOT NULL,
+    created_at  timestamptz     NOT NULL DEFAULT now()
+);
+
+CREATE INDEX crypto_transactions_user_id_idx ON crypto_transactions (user_id, asset, executed_at);
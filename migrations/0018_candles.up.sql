@@ -0,0 +1,18 @@
+-- OHLCV candles of crypto prices, one row per asset, interval and start.
+-- first_at and last_at are the times of the ticks the open and close come
+-- from, so that ticks ingested out of order still merge correctly.
+CREATE TABLE candles (
+    symbol    text        NOT NULL,
+    period    text        NOT NULL CHECK (period IN ('1m', '5m', '1h', '1d')),
+    open_time timestamptz NOT NULL,
+    currency  text        NOT NULL,
+    open      numeric     NOT NULL,
+    high      numeric     NOT NULL,
+    low       numeric     NOT NULL,
+    close     numeric     NOT NULL,
+    volume    numeric     NOT NULL DEFAULT 0,
+    ticks     bigint      NOT NULL,
+    first_at  timestamptz NOT NULL,
+    last_at   timestamptz NOT NULL,
+    PRIMARY KEY (symbol, period, open_time)
+);
@@ -0,0 +1,20 @@
+-- Alert rules on crypto prices and the amounts users owe. Triggered keeps
+-- whether the condition held at the last evaluation, so that a rule fires
+-- once when its condition starts to hold; updated_at guards against
+-- evaluators racing to fire the same rule.
+CREATE TABLE alert_rules (
+    id               uuid PRIMARY KEY,
+    user_id          uuid        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    subject          text        NOT NULL CHECK (subject IN ('price', 'balance')),
+    asset            text        NOT NULL DEFAULT '',
+    condition        text        NOT NULL CHECK (condition IN ('above', 'below', 'change')),
+    threshold        numeric     NOT NULL,
+    window_minutes   integer     NOT NULL DEFAULT 0 CHECK (window_minutes >= 0),
+    cooldown_minutes integer     NOT NULL DEFAULT 0 CHECK (cooldown_minutes >= 0),
+    triggered        boolean     NOT NULL DEFAULT false,
+    fired_at         timestamptz,
+    created_at       timestamptz NOT NULL DEFAULT now(),
+    updated_at       timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX alert_rules_user_id_idx ON alert_rules (user_id, created_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS refunds_user_id_idx;
+DROP INDEX IF EXISTS fines_user_id_issued_at_idx;
@@ -0,0 +1,3 @@
+-- Statistics sum up the fines and refunds of a user over periods of time.
+CREATE INDEX fines_user_id_issued_at_idx ON fines (user_id, issued_at);
+CREATE INDEX refunds_user_id_idx ON refunds (user_id, updated_at) WHERE status = 'succeeded';
@@ -0,0 +1,11 @@
+// Package migrations embeds the versioned SQL schema migrations.
+//
+// Every migration consists of a pair of files named
+// <version>_<name>.up.sql and <version>_<name>.down.sql, where version is a
+// zero-padded integer that defines the order in which migrations are applied.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
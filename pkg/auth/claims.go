@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Claims are the access token claims the application relies on.
+type Claims struct {
+	Subject   string
+	Roles     []string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// HasScope reports whether the claims grant the required scope.
+//
+// A granted scope matches the required one if they are equal, if the granted
+// scope is "*", or if it is a wildcard such as "fines:*" and the required
+// scope starts with its prefix ("fines:read"). Wildcards are only expanded on
+// the granted side: requiring "admin:*" is satisfied by "admin:*" or "*", not
+// by "admin:users".
+func (c *Claims) HasScope(required string) bool {
+	for _, granted := range c.Scopes {
+		if granted == required || granted == "*" {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(granted, "*"); ok && strings.HasSuffix(prefix, ":") &&
+			strings.HasPrefix(required, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MissingScopes returns the required scopes the claims do not grant.
+func (c *Claims) MissingScopes(required ...string) []string {
+	var missing []string
+	for _, scope := range required {
+		if !c.HasScope(scope) {
+			missing = append(missing, scope)
+		}
+	}
+
+	return missing
+}
+
+// tokenTypeMFA marks challenge tokens issued between the password check and
+// the second factor. Access tokens have no token type.
+const tokenTypeMFA = "mfa"
+
+const (
+	// typeAccess is the JOSE typ header of access tokens, see RFC 9068.
+	typeAccess = "at+jwt"
+	// typeLegacy is the typ header of access tokens signed before they had
+	// their own type.
+	typeLegacy = "JWT"
+	// typeMFA is the typ header of challenge tokens.
+	typeMFA = "mfa+jwt"
+	// audienceMFA is the audience of challenge tokens. Access tokens have no
+	// audience, so verifiers that require one reject challenge tokens too.
+	audienceMFA = "mfa"
+)
+
+// jwtClaims is the wire representation of Claims.
+type jwtClaims struct {
+	jwt.StandardClaims
+	Roles     []string `json:"roles,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+}
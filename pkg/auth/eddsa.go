@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEdDSA implements the EdDSA (Ed25519) JWS algorithm from RFC 8037,
+// which jwt-go v3 does not provide.
+var SigningMethodEdDSA = &signingMethodEd25519{}
+
+var errEdDSAVerification = errors.New("ed25519: verification error")
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+type signingMethodEd25519 struct{}
+
+func (m *signingMethodEd25519) Alg() string {
+	return "EdDSA"
+}
+
+// Verify checks the signature of signingString with an ed25519.PublicKey.
+func (m *signingMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	public, ok := key.(ed25519.PublicKey)
+	if !ok || len(public) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(public, []byte(signingString), sig) {
+		return errEdDSAVerification
+	}
+
+	return nil
+}
+
+// Sign signs signingString with an ed25519.PrivateKey.
+func (m *signingMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	private, ok := key.(crypto.Signer)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	if _, ok := private.Public().(ed25519.PublicKey); !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	// Ed25519 signs the message itself, so crypto.Hash(0) is required.
+	sig, err := private.Sign(nil, []byte(signingString), crypto.Hash(0))
+	if err != nil {
+		return "", err
+	}
+
+	return jwt.EncodeSegment(sig), nil
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
+)
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// newJWK converts the public part of the key to a JWK. Symmetric keys are
+// never published, so ok is false for them.
+func newJWK(key *Key) (JWK, bool) {
+	jwk := JWK{
+		KeyID:     key.ID,
+		Use:       "sig",
+		Algorithm: key.Algorithm(),
+	}
+
+	switch public := key.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = encodeBase64URL(public.N.Bytes())
+		jwk.E = encodeBase64URL(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = public.Curve.Params().Name
+		jwk.X = encodeBase64URL(public.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeBase64URL(public.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = encodeBase64URL(public)
+	default:
+		return JWK{}, false
+	}
+
+	return jwk, true
+}
+
+// JWKS returns the public keys of the set that can currently verify tokens,
+// ordered by key ID so that the document is stable between requests.
+func (s *KeySet) JWKS() JWKS {
+	keys := s.verificationKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		if jwk, ok := newJWK(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	return set
+}
+
+func encodeBase64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
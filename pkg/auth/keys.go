@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// KeyStatus is the lifecycle stage of a signing key.
+type KeyStatus string
+
+const (
+	// KeyActive keys sign new tokens. A KeySet has exactly one active key.
+	KeyActive KeyStatus = "active"
+	// KeyRetiring keys no longer sign tokens but still verify them until they expire,
+	// so that tokens issued before a rotation stay valid for their whole TTL.
+	KeyRetiring KeyStatus = "retiring"
+)
+
+// Key is a JWT signing key identified by its kid.
+type Key struct {
+	ID     string
+	Status KeyStatus
+	// ExpiresAt is the moment after which the key is neither used nor published.
+	// A zero value means the key never expires.
+	ExpiresAt time.Time
+
+	method     jwt.SigningMethod
+	signingKey interface{}
+	verifyKey  interface{}
+}
+
+// Algorithm returns the JWS algorithm name of the key, e.g. RS256.
+func (k *Key) Algorithm() string {
+	return k.method.Alg()
+}
+
+// expired reports whether the key can no longer be used at the given time.
+func (k *Key) expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// NewHMACKey creates an HS256 key from a shared secret.
+//
+// Parameters:
+//   - id: The key identifier written to the kid header; may be empty for tokens issued without one.
+//   - secret: The shared secret. Must not be empty.
+//   - status: The lifecycle stage of the key.
+//   - expiresAt: The moment the key stops being accepted, or zero for never.
+//
+// Returns:
+//   - *Key: A pointer to the created key.
+//   - error: An error if the secret is empty.
+func NewHMACKey(id string, secret []byte, status KeyStatus, expiresAt time.Time) (*Key, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("empty signing key")
+	}
+
+	return &Key{
+		ID:         id,
+		Status:     status,
+		ExpiresAt:  expiresAt,
+		method:     jwt.SigningMethodHS256,
+		signingKey: secret,
+		verifyKey:  secret,
+	}, nil
+}
+
+// LoadKey reads a PEM encoded key from a file.
+//
+// The algorithm is derived from the key type: RSA keys sign with RS256,
+// P-256 keys with ES256 and Ed25519 keys with EdDSA. Active keys need a
+// private key; retiring keys may be given as a public key only.
+//
+// Parameters:
+//   - id: The key identifier written to the kid header.
+//   - path: The path to a PKCS#8, PKCS#1 or SEC 1 private key, or a PKIX public key.
+//   - status: The lifecycle stage of the key.
+//   - expiresAt: The moment the key stops being accepted, or zero for never.
+//
+// Returns:
+//   - *Key: A pointer to the loaded key.
+//   - error: An error if the file cannot be read or holds an unsupported key.
+func LoadKey(id, path string, status KeyStatus, expiresAt time.Time) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", id, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM data found in %s", id, path)
+	}
+
+	signer, public, err := parsePEMBlock(block)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", id, err)
+	}
+
+	if status == KeyActive && signer == nil {
+		return nil, fmt.Errorf("key %s: active keys need a private key", id)
+	}
+
+	method, err := methodFor(public)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", id, err)
+	}
+
+	key := &Key{
+		ID:        id,
+		Status:    status,
+		ExpiresAt: expiresAt,
+		method:    method,
+		verifyKey: public,
+	}
+
+	if signer != nil {
+		key.signingKey = signer
+	}
+
+	return key, nil
+}
+
+func parsePEMBlock(block *pem.Block) (crypto.Signer, crypto.PublicKey, error) {
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported private key type %T", parsed)
+		}
+
+		return signer, signer.Public(), nil
+	case "RSA PRIVATE KEY":
+		signer, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return signer, signer.Public(), nil
+	case "EC PRIVATE KEY":
+		signer, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return signer, signer.Public(), nil
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return nil, public, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func methodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 ECDSA keys are supported")
+		}
+
+		return jwt.SigningMethodES256, nil
+	case ed25519.PublicKey:
+		return SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+}
+
+// KeySet holds the active signing key and the retiring keys still accepted for verification.
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+	now    func() time.Time
+}
+
+// NewKeySet creates a KeySet from the given keys.
+//
+// Parameters:
+//   - keys: The keys of the set. Exactly one must be active and all IDs must be unique.
+//
+// Returns:
+//   - *KeySet: A pointer to the created key set.
+//   - error: An error if the set has no or several active keys, or duplicate IDs.
+func NewKeySet(keys ...*Key) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*Key, len(keys)), now: time.Now}
+
+	for _, key := range keys {
+		if _, ok := set.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+
+		switch key.Status {
+		case KeyActive:
+			if set.active != nil {
+				return nil, fmt.Errorf("keys %q and %q are both active", set.active.ID, key.ID)
+			}
+
+			set.active = key
+		case KeyRetiring:
+		default:
+			return nil, fmt.Errorf("key %q has unknown status %q", key.ID, key.Status)
+		}
+
+		set.keys[key.ID] = key
+	}
+
+	if set.active == nil {
+		return nil, errors.New("no active signing key")
+	}
+
+	if set.active.expired(set.now()) {
+		return nil, fmt.Errorf("active key %q has expired", set.active.ID)
+	}
+
+	return set, nil
+}
+
+// Active returns the key used to sign new tokens.
+func (s *KeySet) Active() *Key {
+	return s.active
+}
+
+// Lookup returns the key with the given ID if it can still verify tokens.
+func (s *KeySet) Lookup(id string) (*Key, bool) {
+	key, ok := s.keys[id]
+	if !ok || key.expired(s.now()) {
+		return nil, false
+	}
+
+	return key, true
+}
+
+// verificationKeys returns all keys that can currently verify tokens.
+func (s *KeySet) verificationKeys() []*Key {
+	now := s.now()
+
+	keys := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		if !key.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestManagerKeyRotation(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "old.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keySet := func(keys ...*Key) *Manager {
+		t.Helper()
+
+		set, err := NewKeySet(keys...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return NewManagerWithKeys(set)
+	}
+
+	loadKey := func(status KeyStatus, expiresAt time.Time) *Key {
+		t.Helper()
+
+		key, err := LoadKey("old", path, status, expiresAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return key
+	}
+
+	hmacKey := func(id, secret string, status KeyStatus) *Key {
+		t.Helper()
+
+		key, err := NewHMACKey(id, []byte(secret), status, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return key
+	}
+
+	before := keySet(loadKey(KeyActive, time.Time{}))
+
+	old, err := before.NewJWT(Claims{Subject: "user"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := keySet(hmacKey("new", "secret", KeyActive), loadKey(KeyRetiring, time.Now().Add(time.Hour)))
+
+	current, err := rotated.NewJWT(Claims{Subject: "user"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// forge signs a token with the secret of the new key under any kid.
+	forge := func(kid string, method jwt.SigningMethod) string {
+		token := jwt.NewWithClaims(method, jwtClaims{
+			StandardClaims: jwt.StandardClaims{Subject: "user", ExpiresAt: time.Now().Add(time.Minute).Unix()},
+		})
+		token.Header["typ"] = typeAccess
+		token.Header["kid"] = kid
+
+		signed, err := token.SignedString([]byte("secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return signed
+	}
+
+	for _, tc := range []struct {
+		name    string
+		manager *Manager
+		token   string
+		valid   bool
+	}{
+		{"issued with the active key", rotated, current, true},
+		{"issued with the rotated out key", rotated, old, true},
+		{"rotated out key retired", keySet(hmacKey("new", "secret", KeyActive)), old, false},
+		{"rotated out key expired", keySet(hmacKey("new", "secret", KeyActive), loadKey(KeyRetiring, time.Now().Add(-time.Second))), old, false},
+		{"issued with a key unknown before", before, current, false},
+		{"unknown kid", rotated, forge("newer", jwt.SigningMethodHS256), false},
+		{"no kid", rotated, forge("", jwt.SigningMethodHS256), false},
+		{"kid of a key of another algorithm", rotated, forge("old", jwt.SigningMethodHS256), false},
+		{"signed with the secret of the active key", rotated, forge("new", jwt.SigningMethodHS256), true},
+	} {
+		claims, err := tc.manager.Parse(tc.token)
+		if tc.valid && (err != nil || claims.Subject != "user") {
+			t.Errorf("%s: Parse = %v, %v; want subject user", tc.name, claims, err)
+		}
+
+		if !tc.valid && err == nil {
+			t.Errorf("%s: Parse accepted the token", tc.name)
+		}
+	}
+
+	parsed, _, err := new(jwt.Parser).ParseUnverified(current, &jwt.StandardClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if kid := parsed.Header["kid"]; kid != "new" {
+		t.Errorf("kid = %v, want new", kid)
+	}
+}
+
+func TestNewKeySet(t *testing.T) {
+	key := func(id string, status KeyStatus, expiresAt time.Time) *Key {
+		k, err := NewHMACKey(id, []byte("secret-"+id), status, expiresAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return k
+	}
+
+	for _, tc := range []struct {
+		name  string
+		keys  []*Key
+		valid bool
+	}{
+		{"one active", []*Key{key("a", KeyActive, time.Time{})}, true},
+		{"active and retiring", []*Key{key("a", KeyActive, time.Time{}), key("b", KeyRetiring, time.Now().Add(time.Hour))}, true},
+		{"none", nil, false},
+		{"only retiring", []*Key{key("b", KeyRetiring, time.Time{})}, false},
+		{"two active", []*Key{key("a", KeyActive, time.Time{}), key("b", KeyActive, time.Time{})}, false},
+		{"duplicate id", []*Key{key("a", KeyActive, time.Time{}), key("a", KeyRetiring, time.Time{})}, false},
+		{"unknown status", []*Key{key("a", KeyActive, time.Time{}), key("b", "revoked", time.Time{})}, false},
+		{"active expired", []*Key{key("a", KeyActive, time.Now().Add(-time.Second))}, false},
+	} {
+		_, err := NewKeySet(tc.keys...)
+		if tc.valid && err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+
+		if !tc.valid && err == nil {
+			t.Errorf("%s: key set created", tc.name)
+		}
+	}
+}
@@ -1,26 +1,33 @@
 package auth
 
 import (
-	"errors"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"math/rand"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
 type TokenManager interface {
-	NewJWT(userId string, ttl time.Duration) (string, error)
-	Parse(accessToken string) (string, error)
+	NewJWT(claims Claims, ttl time.Duration) (string, error)
+	Parse(accessToken string) (*Claims, error)
+	NewMFAToken(subject string, ttl time.Duration) (string, error)
+	ParseMFAToken(mfaToken string) (string, error)
 	NewRefreshToken() (string, error)
+	JWKS() JWKS
 }
 
 type Manager struct {
-	signingKey string
+	keys *KeySet
 }
 
 // NewManager creates a new instance of Manager with the provided signingKey.
 //
+// Tokens are signed with HS256 and carry no kid header. Use NewManagerWithKeys
+// to sign with asymmetric keys that can be published as a JWKS.
+//
 // Parameters:
 //   - signingKey: A string used to sign tokens. Must not be empty.
 //
@@ -28,62 +35,136 @@ type Manager struct {
 //   - *Manager: A pointer to the newly created Manager instance.
 //   - error: An error if the signingKey is empty.
 func NewManager(signingKey string) (*Manager, error) {
-	if signingKey == "" {
-		return nil, errors.New("empty signing key")
+	key, err := NewHMACKey("", []byte(signingKey), KeyActive, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := NewKeySet(key)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Manager{signingKey: signingKey}, nil
+	return NewManagerWithKeys(keys), nil
+}
+
+// NewManagerWithKeys creates a new instance of Manager that signs tokens with
+// the active key of the set and verifies them with any non-expired key.
+//
+// Parameters:
+//   - keys: The key set used for signing and verification.
+//
+// Returns:
+//   - *Manager: A pointer to the newly created Manager instance.
+func NewManagerWithKeys(keys *KeySet) *Manager {
+	return &Manager{keys: keys}
 }
 
-// NewJWT creates a new JWT token containing the provided user ID and TTL.
+// NewJWT creates a new JWT token containing the provided claims and TTL.
 //
-// The subject of the token will be set to the userId, and the expiration time
-// will be set to the current time plus the provided ttl.
+// The subject, roles and scopes are taken from claims, and the expiration time
+// will be set to the current time plus the provided ttl. The token is signed
+// with the active key, whose ID is written to the kid header.
 //
 // Parameters:
-//   - userId: The user ID to be included in the token.
+//   - claims: The subject, roles and scopes to be included in the token.
 //   - ttl: The TTL for which the token will remain valid.
 //
 // Returns:
 //   - string: The signed JWT token.
 //   - error: An error if the token could not be signed.
-func (m *Manager) NewJWT(userId string, ttl time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
-		ExpiresAt: time.Now().Add(ttl).Unix(),
-		Subject:   userId,
-	})
-
-	return token.SignedString([]byte(m.signingKey))
+func (m *Manager) NewJWT(claims Claims, ttl time.Duration) (string, error) {
+	return m.sign(typeAccess, jwtClaims{
+		StandardClaims: jwt.StandardClaims{Subject: claims.Subject},
+		Roles:          claims.Roles,
+		Scopes:         claims.Scopes,
+	}, ttl)
 }
 
-// Parse verifies the provided accessToken and returns the user ID contained
-// within the subject claim (sub) if the token is valid.
+// Parse verifies the provided accessToken and returns its claims if the token is valid.
+//
+// The verification key is selected by the kid header and must use the same
+// algorithm as the token, so a token cannot downgrade e.g. RS256 to HS256.
 //
 // Parameters:
 //   - accessToken: The JWT token to be verified and parsed.
 //
 // Returns:
-//   - string: The user ID contained in the subject claim of the token.
-//   - error: An error if the token is invalid, or if the subject claim is not
-//     present or could not be parsed as a string.
-func (m *Manager) Parse(accessToken string) (string, error) {
-	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (i interface{}, err error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
+//   - *Claims: The subject, roles, scopes and expiration time of the token.
+//   - error: An error if the token is invalid, if the subject claim is not present,
+//     or if it is an MFA challenge token.
+func (m *Manager) Parse(accessToken string) (*Claims, error) {
+	claims, typ, err := m.parse(accessToken)
+	if err != nil {
+		return nil, err
+	}
 
-		return []byte(m.signingKey), nil
-	})
+	if typ != typeAccess && typ != typeLegacy {
+		return nil, fmt.Errorf("unexpected token typ %q", typ)
+	}
+
+	if claims.TokenType != "" || claims.Audience != "" {
+		return nil, fmt.Errorf("unexpected token type %q for audience %q", claims.TokenType, claims.Audience)
+	}
+
+	return &Claims{
+		Subject:   claims.Subject,
+		Roles:     claims.Roles,
+		Scopes:    claims.Scopes,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}, nil
+}
+
+// NewMFAToken creates a short-lived challenge token proving that the user
+// has passed the first authentication factor.
+//
+// The token carries no roles or scopes and is rejected by Parse, so it cannot
+// be used as an access token. It is only accepted by ParseMFAToken and must be
+// exchanged for real tokens once the second factor has been verified. As it is
+// signed with the same keys, it has its own typ header and audience, so that
+// services verifying access tokens with the JWKS reject it as well.
+//
+// Parameters:
+//   - subject: The ID of the user who passed the password check.
+//   - ttl: The TTL for which the challenge will remain valid.
+//
+// Returns:
+//   - string: The signed challenge token.
+//   - error: An error if the token could not be signed.
+func (m *Manager) NewMFAToken(subject string, ttl time.Duration) (string, error) {
+	return m.sign(typeMFA, jwtClaims{
+		StandardClaims: jwt.StandardClaims{Subject: subject, Audience: audienceMFA},
+		TokenType:      tokenTypeMFA,
+	}, ttl)
+}
+
+// ParseMFAToken verifies a challenge token created by NewMFAToken.
+//
+// Parameters:
+//   - mfaToken: The challenge token returned by NewMFAToken.
+//
+// Returns:
+//   - string: The subject of the challenge.
+//   - error: An error if the token is invalid, expired or not an MFA challenge.
+func (m *Manager) ParseMFAToken(mfaToken string) (string, error) {
+	claims, typ, err := m.parse(mfaToken)
 	if err != nil {
 		return "", err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", fmt.Errorf("error get user claims from token")
+	if typ != typeMFA || claims.TokenType != tokenTypeMFA || !claims.VerifyAudience(audienceMFA, true) {
+		return "", fmt.Errorf("unexpected token type %q", claims.TokenType)
 	}
 
-	return claims["sub"].(string), nil
+	return claims.Subject, nil
+}
+
+// JWKS returns the public keys that downstream services can use to verify
+// access tokens offline. HS256 keys are never included. Services must only
+// accept tokens with the typ header "at+jwt" and no audience; MFA challenge
+// tokens are signed with the same keys.
+func (m *Manager) JWKS() JWKS {
+	return m.keys.JWKS()
 }
 
 // NewRefreshToken generates a cryptographically secure random string, which can be used to generate a refresh token.
@@ -94,12 +175,76 @@ func (m *Manager) Parse(accessToken string) (string, error) {
 func (m *Manager) NewRefreshToken() (string, error) {
 	b := make([]byte, 32)
 
-	s := rand.NewSource(time.Now().Unix())
-	r := rand.New(s)
-
-	if _, err := r.Read(b); err != nil {
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
 
-	return fmt.Sprintf("%x", b), nil
+	return hex.EncodeToString(b), nil
+}
+
+// HashRefreshToken returns the SHA-256 digest of a refresh token in hex.
+//
+// Refresh tokens carry 256 bits of entropy, so a fast unsalted digest is
+// enough to keep them useless to anyone who reads the database.
+//
+// Parameters:
+//   - token: The refresh token returned by NewRefreshToken.
+//
+// Returns:
+//   - string: The hex-encoded digest suitable for storage and lookups.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// sign sets the expiration time of claims and signs them with the active key,
+// whose ID is written to the kid header, and typ to the typ header.
+func (m *Manager) sign(typ string, claims jwtClaims, ttl time.Duration) (string, error) {
+	key := m.keys.Active()
+
+	claims.ExpiresAt = time.Now().Add(ttl).Unix()
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["typ"] = typ
+
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+
+	return token.SignedString(key.signingKey)
+}
+
+// parse verifies the signature and expiration time of a token and returns
+// its claims and typ header.
+//
+// The verification key is selected by the kid header and must use the same
+// algorithm as the token, so a token cannot downgrade e.g. RS256 to HS256.
+func (m *Manager) parse(token string) (jwtClaims, string, error) {
+	var claims jwtClaims
+
+	parsed, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (i interface{}, err error) {
+		kid, _ := token.Header["kid"].(string)
+
+		key, ok := m.keys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+
+		if token.Method.Alg() != key.Algorithm() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return key.verifyKey, nil
+	})
+	if err != nil {
+		return jwtClaims{}, "", err
+	}
+
+	if claims.Subject == "" {
+		return jwtClaims{}, "", fmt.Errorf("error get user claims from token")
+	}
+
+	typ, _ := parsed.Header["typ"].(string)
+
+	return claims, typ, nil
 }
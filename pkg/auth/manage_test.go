@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestManagerSeparatesTokenTypes(t *testing.T) {
+	m, err := NewManager("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	access, err := m.NewJWT(Claims{Subject: "user", Scopes: []string{"fines:read"}}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err := m.NewMFAToken("user", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims, err := m.Parse(access); err != nil || claims.Subject != "user" {
+		t.Errorf("Parse(access) = %v, %v; want subject user", claims, err)
+	}
+
+	if _, err := m.Parse(challenge); err == nil {
+		t.Error("Parse accepted an MFA challenge token")
+	}
+
+	if subject, err := m.ParseMFAToken(challenge); err != nil || subject != "user" {
+		t.Errorf("ParseMFAToken(challenge) = %q, %v; want user", subject, err)
+	}
+
+	if _, err := m.ParseMFAToken(access); err == nil {
+		t.Error("ParseMFAToken accepted an access token")
+	}
+
+	// A verifier that only knows the keys tells the tokens apart by their
+	// headers and audience.
+	for _, tc := range []struct {
+		token    string
+		typ      string
+		audience string
+	}{
+		{access, typeAccess, ""},
+		{challenge, typeMFA, audienceMFA},
+	} {
+		var claims jwt.StandardClaims
+
+		parsed, _, err := new(jwt.Parser).ParseUnverified(tc.token, &claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if typ := parsed.Header["typ"]; typ != tc.typ {
+			t.Errorf("typ = %v, want %s", typ, tc.typ)
+		}
+
+		if claims.Audience != tc.audience {
+			t.Errorf("aud = %q, want %q", claims.Audience, tc.audience)
+		}
+	}
+}
+
+func TestManagerParseRejectsLegacyChallenge(t *testing.T) {
+	m, err := NewManager("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Challenge tokens signed before they had their own typ header.
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims{
+		StandardClaims: jwt.StandardClaims{Subject: "user", ExpiresAt: time.Now().Add(time.Minute).Unix()},
+		TokenType:      tokenTypeMFA,
+	})
+
+	signed, err := token.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Parse(signed); err == nil {
+		t.Error("Parse accepted a legacy MFA challenge token")
+	}
+}
@@ -0,0 +1,27 @@
+// Package exrate provides official daily exchange rates.
+//
+// Official rates, such as those of the Bank of Russia, are published once a
+// day against a single base currency; other pairs are crossed through it.
+// Provider abstracts where they come from, so that FileProvider can be
+// replaced by a client of the publisher without changes to the callers.
+package exrate
+
+import (
+	"backend-vtb/pkg/money"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNoRates is returned for a day on which no rates were published, e.g. a
+// weekend or a holiday.
+var ErrNoRates = errors.New("no exchange rates published for the day")
+
+// Provider is a source of official daily exchange rates.
+type Provider interface {
+	// Base returns the currency all rates are quoted in.
+	Base() string
+	// Rates returns the rates published for the calendar day of date, each the
+	// price of one unit of a currency in the base currency.
+	Rates(ctx context.Context, date time.Time) ([]money.Rate, error)
+}
@@ -0,0 +1,121 @@
+package exrate
+
+import (
+	"backend-vtb/pkg/money"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayout is the layout of the dates in rate files.
+const dateLayout = "2006-01-02"
+
+// FileProvider is a Provider backed by a CSV file holding the history of
+// daily rates, e.g. exported from the publisher. It is meant for development
+// and for backfilling historical rates.
+//
+// The file has a header row and the columns date (YYYY-MM-DD), currency,
+// nominal and rate, where rate is the price of nominal units of the currency
+// in the base currency, as the Bank of Russia quotes e.g. 100 JPY.
+type FileProvider struct {
+	base  string
+	rates map[string][]money.Rate
+}
+
+// NewFileProvider reads the rates of the file into memory.
+//
+// Parameters:
+//   - path: The CSV file to read.
+//   - base: The currency the rates in the file are quoted in.
+//
+// Returns:
+//   - *FileProvider: A pointer to the newly created FileProvider instance.
+//   - error: An error if the file cannot be read or has an invalid row.
+func NewFileProvider(path, base string) (*FileProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadFile(f, base)
+}
+
+// ReadFile reads rates in the format of FileProvider from r.
+func ReadFile(r io.Reader, base string) (*FileProvider, error) {
+	p := &FileProvider{base: strings.ToUpper(base), rates: make(map[string][]money.Rate)}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return p, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if line == 1 {
+			continue
+		}
+
+		date, rate, err := p.parseRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		key := date.Format(dateLayout)
+		p.rates[key] = append(p.rates[key], rate)
+	}
+}
+
+func (p *FileProvider) parseRecord(record []string) (time.Time, money.Rate, error) {
+	date, err := time.Parse(dateLayout, record[0])
+	if err != nil {
+		return time.Time{}, money.Rate{}, err
+	}
+
+	nominal, err := strconv.ParseInt(record[2], 10, 64)
+	if err != nil || nominal <= 0 {
+		return time.Time{}, money.Rate{}, fmt.Errorf("invalid nominal %q", record[2])
+	}
+
+	value, ok := new(big.Rat).SetString(record[3])
+	if !ok {
+		return time.Time{}, money.Rate{}, fmt.Errorf("%w: %q", money.ErrInvalidRate, record[3])
+	}
+
+	rate, err := money.NewRate(record[1], p.base, value.Quo(value, big.NewRat(nominal, 1)))
+	if err != nil {
+		return time.Time{}, money.Rate{}, err
+	}
+
+	return date, rate, nil
+}
+
+func (p *FileProvider) Base() string {
+	return p.base
+}
+
+// Rates returns the rates of the file for the calendar day of date, in the
+// location of date.
+func (p *FileProvider) Rates(_ context.Context, date time.Time) ([]money.Rate, error) {
+	rates, ok := p.rates[date.Format(dateLayout)]
+	if !ok {
+		return nil, ErrNoRates
+	}
+
+	return append([]money.Rate(nil), rates...), nil
+}
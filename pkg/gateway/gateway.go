@@ -0,0 +1,145 @@
+// Package gateway defines the interface to card acquirers.
+//
+// A payment is first authorized, which reserves the amount on the card and
+// may require the cardholder to pass a 3-D Secure challenge, and then either
+// captured or voided. Captured payments can be refunded, fully or in parts.
+// Adapters to real acquirers implement PaymentGateway; Simulator does so
+// locally for development and tests.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrUnknownTransaction is returned for a reference the gateway does not know.
+	ErrUnknownTransaction = errors.New("unknown transaction")
+	// ErrInvalidState is returned when the operation is not allowed in the
+	// current status of the transaction, e.g. capturing a voided payment.
+	ErrInvalidState = errors.New("operation not allowed in the current transaction state")
+	// ErrInvalidAmount is returned when capturing or refunding more than is available.
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrInvalidCard is returned when a card cannot be saved or a saved card
+	// token is unknown.
+	ErrInvalidCard = errors.New("invalid card")
+	// ErrUnavailable is returned when the acquirer cannot process the request
+	// right now. The request may be retried.
+	ErrUnavailable = errors.New("payment gateway unavailable")
+)
+
+// Status is the status of a transaction at the acquirer.
+type Status string
+
+const (
+	StatusAuthorized Status = "authorized"
+	// StatusActionRequired means the cardholder has to pass a 3-D Secure
+	// challenge before the payment is authorized.
+	StatusActionRequired Status = "action_required"
+	StatusCaptured       Status = "captured"
+	StatusVoided         Status = "voided"
+	StatusRefunded       Status = "refunded"
+	StatusDeclined       Status = "declined"
+)
+
+// Card holds the card details of an authorization.
+type Card struct {
+	Number   string
+	ExpMonth int
+	ExpYear  int
+	CVC      string
+	Holder   string
+	// Token refers to a card saved with SaveCard. When set, the other fields
+	// are ignored.
+	Token string
+}
+
+// String implements fmt.Stringer for log output without exposing card data.
+func (c Card) String() string {
+	if c.Token != "" {
+		return "saved card"
+	}
+
+	if len(c.Number) < 4 {
+		return "card"
+	}
+
+	return fmt.Sprintf("card *%s", c.Number[len(c.Number)-4:])
+}
+
+// SavedCard is a card kept by the acquirer for payments the cardholder does
+// not take part in, such as scheduled payments.
+type SavedCard struct {
+	// Token is passed as Card.Token to authorize with the card.
+	Token    string `json:"-"`
+	Last4    string `json:"last4"`
+	ExpMonth int    `json:"expMonth"`
+	ExpYear  int    `json:"expYear"`
+}
+
+// AuthorizeRequest asks to reserve an amount on a card.
+type AuthorizeRequest struct {
+	// PaymentID identifies the payment on our side. Authorizing the same
+	// payment again returns the existing transaction.
+	PaymentID string
+	// Amount is in minor units of Currency.
+	Amount   int64
+	Currency string
+	Card     Card
+	// ReturnURL is where the cardholder is sent after a 3-D Secure challenge.
+	ReturnURL string
+}
+
+// Transaction is the state of a payment at the acquirer.
+type Transaction struct {
+	// Reference is the acquirer's identifier of the transaction.
+	Reference string `json:"reference"`
+	PaymentID string `json:"paymentId"`
+	Status    Status `json:"status"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Captured  int64  `json:"captured"`
+	Refunded  int64  `json:"refunded"`
+	// DeclineCode tells why the payment was declined, e.g. insufficient_funds.
+	DeclineCode string `json:"declineCode,omitempty"`
+	// ChallengeURL is the 3-D Secure page to send the cardholder to when the
+	// status is StatusActionRequired.
+	ChallengeURL string `json:"challengeUrl,omitempty"`
+}
+
+// Event notifies about a change of a transaction. Acquirers deliver events
+// as webhooks signed as described in package webhook; deliveries may be
+// repeated and may arrive out of order.
+type Event struct {
+	// ID is unique per event and stays the same when a delivery is retried.
+	ID          string      `json:"id"`
+	Type        string      `json:"type"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	Transaction Transaction `json:"transaction"`
+}
+
+// PaymentGateway is a card acquirer.
+//
+// Declines are reported as a transaction in StatusDeclined rather than as an
+// error; errors mean the request itself could not be processed.
+type PaymentGateway interface {
+	// SaveCard stores the card and returns a token to authorize with it
+	// later. Authorizations with a saved card are merchant initiated and are
+	// not challenged with 3-D Secure.
+	SaveCard(ctx context.Context, card Card) (SavedCard, error)
+	// Authorize reserves the amount on the card.
+	Authorize(ctx context.Context, req AuthorizeRequest) (Transaction, error)
+	// Authenticate completes the 3-D Secure challenge of a transaction with
+	// the cardholder's response.
+	Authenticate(ctx context.Context, reference, response string) (Transaction, error)
+	// Capture charges the authorized amount.
+	Capture(ctx context.Context, reference string) (Transaction, error)
+	// Void releases an authorization that will not be captured.
+	Void(ctx context.Context, reference string) (Transaction, error)
+	// Refund returns amount of a captured transaction to the card.
+	Refund(ctx context.Context, reference string, amount int64) (Transaction, error)
+	// Status returns the current state of the transaction.
+	Status(ctx context.Context, reference string) (Transaction, error)
+}
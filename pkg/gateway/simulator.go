@@ -0,0 +1,353 @@
+package gateway
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Test cards understood by the Simulator. Any other number passing the Luhn
+// check is approved, subject to SimulatorConfig.DeclineRate.
+const (
+	CardDeclined          = "4000000000000002"
+	CardInsufficientFunds = "4000000000009995"
+	CardExpired           = "4000000000000069"
+	CardChallenge         = "4000000000003220"
+	CardUnavailable       = "4000000000000119"
+)
+
+// Decline codes reported by the Simulator.
+const (
+	DeclineCardDeclined       = "card_declined"
+	DeclineInsufficientFunds  = "insufficient_funds"
+	DeclineExpiredCard        = "expired_card"
+	DeclineInvalidNumber      = "invalid_number"
+	DeclineDoNotHonor         = "do_not_honor"
+	DeclineAuthenticationFail = "authentication_failed"
+)
+
+// SimulatorConfig tunes the behaviour of the Simulator.
+type SimulatorConfig struct {
+	// Latency is added to every call to mimic the round trip to an acquirer.
+	Latency time.Duration
+	// Jitter adds a random delay of up to Jitter on top of Latency.
+	Jitter time.Duration
+	// DeclineRate is the share of otherwise approved authorizations, from 0
+	// to 1, that are declined with do_not_honor.
+	DeclineRate float64
+	// ChallengeCode is the response that passes a 3-D Secure challenge.
+	ChallengeCode string
+}
+
+// Simulator is a PaymentGateway that keeps transactions in memory. It lets
+// the whole payment path be exercised without an acquirer.
+type Simulator struct {
+	cfg SimulatorConfig
+
+	mu        sync.Mutex
+	txs       map[string]*Transaction
+	byPayment map[string]string
+	cards     map[string]Card
+}
+
+// NewSimulator creates a Simulator.
+//
+// Parameters:
+//   - cfg: The latency, decline and 3-D Secure settings of the simulator.
+//
+// Returns:
+//   - *Simulator: A pointer to the newly created Simulator instance.
+func NewSimulator(cfg SimulatorConfig) *Simulator {
+	if cfg.ChallengeCode == "" {
+		cfg.ChallengeCode = "1234"
+	}
+
+	return &Simulator{
+		cfg:       cfg,
+		txs:       make(map[string]*Transaction),
+		byPayment: make(map[string]string),
+		cards:     make(map[string]Card),
+	}
+}
+
+// SaveCard accepts any card with a valid number that has not expired; the
+// test cards keep their behaviour when authorized with the token.
+func (s *Simulator) SaveCard(ctx context.Context, card Card) (SavedCard, error) {
+	if err := s.wait(ctx); err != nil {
+		return SavedCard{}, err
+	}
+
+	if !luhn(card.Number) || expired(card.ExpMonth, card.ExpYear, time.Now()) {
+		return SavedCard{}, ErrInvalidCard
+	}
+
+	saved := SavedCard{
+		Token:    "sim_card_" + uuid.NewString(),
+		Last4:    card.Number[len(card.Number)-4:],
+		ExpMonth: card.ExpMonth,
+		ExpYear:  card.ExpYear,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cards[saved.Token] = card
+
+	return saved, nil
+}
+
+func (s *Simulator) Authorize(ctx context.Context, req AuthorizeRequest) (Transaction, error) {
+	if err := s.wait(ctx); err != nil {
+		return Transaction{}, err
+	}
+
+	if req.Amount <= 0 {
+		return Transaction{}, ErrInvalidAmount
+	}
+
+	saved := req.Card.Token != ""
+	if saved {
+		s.mu.Lock()
+		card, ok := s.cards[req.Card.Token]
+		s.mu.Unlock()
+
+		if !ok {
+			return Transaction{}, ErrInvalidCard
+		}
+
+		req.Card = card
+	}
+
+	if req.Card.Number == CardUnavailable {
+		return Transaction{}, ErrUnavailable
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ref, ok := s.byPayment[req.PaymentID]; ok && req.PaymentID != "" {
+		return *s.txs[ref], nil
+	}
+
+	tx := &Transaction{
+		Reference: "sim_" + uuid.NewString(),
+		PaymentID: req.PaymentID,
+		Status:    StatusAuthorized,
+		Amount:    req.Amount,
+		Currency:  req.Currency,
+	}
+
+	switch code := s.decline(req.Card); {
+	case code != "":
+		tx.Status = StatusDeclined
+		tx.DeclineCode = code
+	case req.Card.Number == CardChallenge && !saved:
+		tx.Status = StatusActionRequired
+		tx.ChallengeURL = "simulator://3ds/" + tx.Reference
+	}
+
+	s.txs[tx.Reference] = tx
+	if req.PaymentID != "" {
+		s.byPayment[req.PaymentID] = tx.Reference
+	}
+
+	return *tx, nil
+}
+
+// Authenticate passes the challenge if response equals the configured
+// ChallengeCode and declines the transaction otherwise.
+func (s *Simulator) Authenticate(ctx context.Context, reference, response string) (Transaction, error) {
+	return s.update(ctx, reference, func(tx *Transaction) error {
+		if tx.Status != StatusActionRequired {
+			return ErrInvalidState
+		}
+
+		tx.ChallengeURL = ""
+		if response != s.cfg.ChallengeCode {
+			tx.Status = StatusDeclined
+			tx.DeclineCode = DeclineAuthenticationFail
+			return nil
+		}
+
+		tx.Status = StatusAuthorized
+		return nil
+	})
+}
+
+func (s *Simulator) Capture(ctx context.Context, reference string) (Transaction, error) {
+	return s.update(ctx, reference, func(tx *Transaction) error {
+		if tx.Status == StatusCaptured {
+			return nil
+		}
+
+		if tx.Status != StatusAuthorized {
+			return ErrInvalidState
+		}
+
+		tx.Status = StatusCaptured
+		tx.Captured = tx.Amount
+		return nil
+	})
+}
+
+func (s *Simulator) Void(ctx context.Context, reference string) (Transaction, error) {
+	return s.update(ctx, reference, func(tx *Transaction) error {
+		switch tx.Status {
+		case StatusVoided:
+			return nil
+		case StatusAuthorized, StatusActionRequired:
+			tx.Status = StatusVoided
+			tx.ChallengeURL = ""
+			return nil
+		default:
+			return ErrInvalidState
+		}
+	})
+}
+
+// Refund supports partial refunds. The transaction moves to StatusRefunded
+// once the whole captured amount has been returned.
+func (s *Simulator) Refund(ctx context.Context, reference string, amount int64) (Transaction, error) {
+	return s.update(ctx, reference, func(tx *Transaction) error {
+		if tx.Status != StatusCaptured {
+			return ErrInvalidState
+		}
+
+		if amount <= 0 || tx.Refunded+amount > tx.Captured {
+			return ErrInvalidAmount
+		}
+
+		tx.Refunded += amount
+		if tx.Refunded == tx.Captured {
+			tx.Status = StatusRefunded
+		}
+
+		return nil
+	})
+}
+
+func (s *Simulator) Status(ctx context.Context, reference string) (Transaction, error) {
+	return s.update(ctx, reference, func(*Transaction) error { return nil })
+}
+
+// update waits for the simulated latency and applies fn to the transaction.
+// The transaction is left untouched if fn fails.
+func (s *Simulator) update(ctx context.Context, reference string, fn func(tx *Transaction) error) (Transaction, error) {
+	if err := s.wait(ctx); err != nil {
+		return Transaction{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, ok := s.txs[reference]
+	if !ok {
+		return Transaction{}, ErrUnknownTransaction
+	}
+
+	next := *tx
+	if err := fn(&next); err != nil {
+		return Transaction{}, err
+	}
+
+	*tx = next
+
+	return next, nil
+}
+
+// decline returns the decline code for the card, or an empty string if the
+// authorization is approved.
+func (s *Simulator) decline(card Card) string {
+	switch card.Number {
+	case CardDeclined:
+		return DeclineCardDeclined
+	case CardInsufficientFunds:
+		return DeclineInsufficientFunds
+	case CardExpired:
+		return DeclineExpiredCard
+	}
+
+	if !luhn(card.Number) {
+		return DeclineInvalidNumber
+	}
+
+	if expired(card.ExpMonth, card.ExpYear, time.Now()) {
+		return DeclineExpiredCard
+	}
+
+	if s.cfg.DeclineRate > 0 && rand.Float64() < s.cfg.DeclineRate {
+		return DeclineDoNotHonor
+	}
+
+	return ""
+}
+
+// wait sleeps for the configured latency, returning early with the context
+// error if ctx is done first.
+func (s *Simulator) wait(ctx context.Context) error {
+	d := s.cfg.Latency
+	if s.cfg.Jitter > 0 {
+		d += rand.N(s.cfg.Jitter)
+	}
+
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// luhn reports whether number is a 12 to 19 digit string with a valid Luhn
+// check digit.
+func luhn(number string) bool {
+	if len(number) < 12 || len(number) > 19 {
+		return false
+	}
+
+	sum := 0
+	for i := range len(number) {
+		d, err := strconv.Atoi(number[len(number)-1-i : len(number)-i])
+		if err != nil {
+			return false
+		}
+
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+	}
+
+	return sum%10 == 0
+}
+
+// expired reports whether a card valid through month/year has expired at now.
+// Cards are valid until the end of their expiry month.
+func expired(month, year int, now time.Time) bool {
+	if month < 1 || month > 12 {
+		return true
+	}
+
+	if year < 100 {
+		year += 2000
+	}
+
+	end := time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC)
+
+	return !now.Before(end)
+}
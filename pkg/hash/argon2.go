@@ -0,0 +1,144 @@
+package hash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2Params are the tunable parameters of argon2id.
+type Argon2Params struct {
+	// Memory is the amount of memory used, in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follows the second recommended option of RFC 9106.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Argon2idHasher hashes passwords with argon2id and a random per-password salt.
+//
+// Hashes are encoded in the PHC string format, which keeps the algorithm and
+// its parameters next to the salt and the key:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+type Argon2idHasher struct {
+	params Argon2Params
+}
+
+// NewArgon2idHasher creates a new Argon2idHasher with the provided parameters.
+//
+// Parameters:
+//   - params: The argon2id cost parameters used for new hashes.
+//
+// Returns:
+//   - *Argon2idHasher: A pointer to the newly created Argon2idHasher instance.
+func NewArgon2idHasher(params Argon2Params) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+// Hash derives an argon2id key from the password and a fresh random salt.
+//
+// Parameters:
+//   - password: The string to be hashed.
+//
+// Returns:
+//   - string: The encoded hash including algorithm, parameters and salt.
+//   - error: An error if the random salt could not be generated.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return encodeArgon2id(h.params, salt, key), nil
+}
+
+// Verify derives a key from the password using the parameters stored in
+// encoded and compares it with the stored key in constant time.
+//
+// Returns:
+//   - bool: True if the password matches the hash.
+//   - error: ErrMalformedHash if encoded cannot be parsed.
+func (h *Argon2idHasher) Verify(password, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash reports whether encoded was produced with parameters other than the configured ones.
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params != h.params
+}
+
+// Recognizes reports whether encoded is an argon2id PHC string.
+func (h *Argon2idHasher) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+func encodeArgon2id(params Argon2Params, salt, key []byte) string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2Params{}, nil, nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, ErrMalformedHash
+	}
+
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, ErrMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, ErrMalformedHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2Params{}, nil, nil, ErrMalformedHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
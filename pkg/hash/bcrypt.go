@@ -0,0 +1,74 @@
+package hash
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher hashes passwords with bcrypt.
+//
+// bcrypt hashes are self-describing: the modular crypt format
+// $2a$<cost>$<salt><key> already carries the algorithm version and cost.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher creates a new BcryptHasher with the provided cost.
+//
+// Parameters:
+//   - cost: The bcrypt cost factor; values outside the supported range fall back to bcrypt.DefaultCost.
+//
+// Returns:
+//   - *BcryptHasher: A pointer to the newly created BcryptHasher instance.
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+
+	return &BcryptHasher{cost: cost}
+}
+
+// Hash returns the bcrypt hash of the password.
+//
+// Note: bcrypt only uses the first 72 bytes of the password.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Verify reports whether the password matches the bcrypt hash.
+//
+// Returns:
+//   - bool: True if the password matches the hash.
+//   - error: ErrMalformedHash if encoded is not a valid bcrypt hash.
+func (h *BcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, ErrMalformedHash
+	}
+}
+
+// NeedsRehash reports whether encoded was produced with a cost other than the configured one.
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+
+	return err != nil || cost != h.cost
+}
+
+// Recognizes reports whether encoded is a bcrypt hash.
+func (h *BcryptHasher) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
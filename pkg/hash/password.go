@@ -2,15 +2,43 @@ package hash
 
 import (
 	"crypto/sha1"
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnknownHash is returned when an encoded hash was produced by an unsupported algorithm.
+	ErrUnknownHash = errors.New("unknown password hash format")
+	// ErrMalformedHash is returned when an encoded hash cannot be parsed.
+	ErrMalformedHash = errors.New("malformed password hash")
 )
 
 // PasswordHasher provides hashing logic to securely store passwords.
 type PasswordHasher interface {
+	// Hash returns the encoded hash of the password.
 	Hash(password string) (string, error)
+	// Verify reports whether the password matches the encoded hash.
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash reports whether the encoded hash should be replaced with a
+	// fresh Hash of the same password, e.g. because it was produced by an
+	// outdated algorithm or with weaker parameters.
+	NeedsRehash(encoded string) bool
+}
+
+// Algorithm is a PasswordHasher that can recognize hashes it has produced.
+type Algorithm interface {
+	PasswordHasher
+	// Recognizes reports whether encoded looks like a hash of this algorithm.
+	Recognizes(encoded string) bool
 }
 
 // SHA1Hasher uses SHA1 to hash passwords with provided salt.
+//
+// Deprecated: the salt is prepended to the digest instead of being mixed into
+// its input, so the hash is effectively unsalted. SHA1Hasher is kept only to
+// verify legacy hashes; use Argon2idHasher or BcryptHasher for new ones.
 type SHA1Hasher struct {
 	salt string
 }
@@ -45,3 +73,85 @@ func (h *SHA1Hasher) Hash(password string) (string, error) {
 
 	return fmt.Sprintf("%x", hash.Sum([]byte(h.salt))), nil
 }
+
+// Verify hashes the password and compares the result with encoded in constant time.
+//
+// Parameters:
+//   - password: The plain text password to check.
+//   - encoded: A hash previously returned by Hash.
+//
+// Returns:
+//   - bool: True if the password matches the hash.
+//   - error: An error if there was a problem while hashing the password.
+func (h *SHA1Hasher) Verify(password, encoded string) (bool, error) {
+	hash, err := h.Hash(password)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(encoded)) == 1, nil
+}
+
+// NeedsRehash always returns false: SHA1Hasher has no tunable parameters.
+func (h *SHA1Hasher) NeedsRehash(string) bool {
+	return false
+}
+
+// Recognizes reports whether encoded is a hex string of the length produced by Hash.
+func (h *SHA1Hasher) Recognizes(encoded string) bool {
+	if len(encoded) != 2*(len(h.salt)+sha1.Size) {
+		return false
+	}
+
+	return strings.Trim(encoded, "0123456789abcdef") == ""
+}
+
+// UpgradingHasher hashes new passwords with the current algorithm while still
+// verifying hashes produced by legacy ones. Any hash that was not produced by
+// the current algorithm with its current parameters needs a rehash.
+type UpgradingHasher struct {
+	current Algorithm
+	legacy  []Algorithm
+}
+
+// NewUpgradingHasher creates a new UpgradingHasher.
+//
+// Parameters:
+//   - current: The algorithm used for new hashes.
+//   - legacy: Algorithms whose hashes are still accepted by Verify.
+//
+// Returns:
+//   - *UpgradingHasher: A pointer to the newly created UpgradingHasher instance.
+func NewUpgradingHasher(current Algorithm, legacy ...Algorithm) *UpgradingHasher {
+	return &UpgradingHasher{current: current, legacy: legacy}
+}
+
+// Hash hashes the password with the current algorithm.
+func (h *UpgradingHasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+// Verify checks the password with whichever algorithm recognizes the encoded hash.
+//
+// Returns:
+//   - bool: True if the password matches the hash.
+//   - error: An error if no configured algorithm recognizes the hash or it is malformed.
+func (h *UpgradingHasher) Verify(password, encoded string) (bool, error) {
+	if h.current.Recognizes(encoded) {
+		return h.current.Verify(password, encoded)
+	}
+
+	for _, algorithm := range h.legacy {
+		if algorithm.Recognizes(encoded) {
+			return algorithm.Verify(password, encoded)
+		}
+	}
+
+	return false, ErrUnknownHash
+}
+
+// NeedsRehash reports whether encoded was produced by a legacy algorithm or by
+// the current one with outdated parameters.
+func (h *UpgradingHasher) NeedsRehash(encoded string) bool {
+	return !h.current.Recognizes(encoded) || h.current.NeedsRehash(encoded)
+}
@@ -0,0 +1,200 @@
+package hash
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params keep the tests fast; they are far too weak for passwords.
+var testArgon2Params = Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestAlgorithmsRoundTrip(t *testing.T) {
+	for name, algorithm := range map[string]Algorithm{
+		"argon2id": NewArgon2idHasher(testArgon2Params),
+		"bcrypt":   NewBcryptHasher(bcrypt.MinCost),
+		"sha1":     NewSHA1Hasher("salt"),
+	} {
+		encoded, err := algorithm.Hash("correct horse")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if !algorithm.Recognizes(encoded) || algorithm.NeedsRehash(encoded) {
+			t.Errorf("%s: hash %q not recognized as current", name, encoded)
+		}
+
+		for password, want := range map[string]bool{"correct horse": true, "correct horsE": false, "": false} {
+			if ok, err := algorithm.Verify(password, encoded); err != nil || ok != want {
+				t.Errorf("%s: verify %q = %v, %v; want %v", name, password, ok, err, want)
+			}
+		}
+	}
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	h := NewArgon2idHasher(testArgon2Params)
+
+	first, err := h.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := h.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second {
+		t.Error("two hashes of the same password are equal, want different salts")
+	}
+
+	if want := "$argon2id$v=19$m=64,t=1,p=1$"; !strings.HasPrefix(first, want) {
+		t.Errorf("hash %q, want prefix %q", first, want)
+	}
+
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+
+	// Hashes keep their own parameters, so they still verify after a change.
+	upgraded := NewArgon2idHasher(stronger)
+	if ok, err := upgraded.Verify("password", first); err != nil || !ok {
+		t.Errorf("verify with other parameters = %v, %v; want true", ok, err)
+	}
+
+	if !upgraded.NeedsRehash(first) {
+		t.Error("hash with fewer iterations needs no rehash")
+	}
+
+	for _, encoded := range []string{
+		"",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$",
+	} {
+		if _, err := h.Verify("password", encoded); !errors.Is(err, ErrMalformedHash) {
+			t.Errorf("verify %q: error %v, want %v", encoded, err, ErrMalformedHash)
+		}
+
+		if !h.NeedsRehash(encoded) {
+			t.Errorf("malformed hash %q needs no rehash", encoded)
+		}
+	}
+}
+
+func TestBcryptHasher(t *testing.T) {
+	h := NewBcryptHasher(bcrypt.MinCost)
+
+	encoded, err := h.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !NewBcryptHasher(bcrypt.MinCost + 1).NeedsRehash(encoded) {
+		t.Error("hash of a lower cost needs no rehash")
+	}
+
+	// Out of range costs fall back to the default.
+	if NewBcryptHasher(bcrypt.MaxCost+1).cost != bcrypt.DefaultCost {
+		t.Error("cost above the maximum kept")
+	}
+
+	if _, err := h.Verify("password", "$2a$04$short"); !errors.Is(err, ErrMalformedHash) {
+		t.Errorf("verify malformed: error %v, want %v", err, ErrMalformedHash)
+	}
+
+	if !h.NeedsRehash("$2a$04$short") {
+		t.Error("malformed hash needs no rehash")
+	}
+}
+
+func TestSHA1Hasher(t *testing.T) {
+	h := NewSHA1Hasher("s")
+
+	// The hex of the salt followed by the hex of the unsalted digest.
+	const legacy = "73" + "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8"
+
+	if encoded, err := h.Hash("password"); err != nil || encoded != legacy {
+		t.Errorf("hash = %q, %v; want %q", encoded, err, legacy)
+	}
+
+	for encoded, want := range map[string]bool{
+		legacy:                  true,
+		strings.ToUpper(legacy): false,
+		legacy[:len(legacy)-2]:  false,
+		"$2a$04$" + legacy[7:]:  false,
+	} {
+		if h.Recognizes(encoded) != want {
+			t.Errorf("recognizes %q = %v, want %v", encoded, !want, want)
+		}
+	}
+}
+
+func TestUpgradingHasher(t *testing.T) {
+	argon := NewArgon2idHasher(testArgon2Params)
+	bcryptHasher := NewBcryptHasher(bcrypt.MinCost)
+	sha := NewSHA1Hasher("s")
+
+	h := NewUpgradingHasher(argon, bcryptHasher, sha)
+
+	weaker := testArgon2Params
+	weaker.Memory = 32
+
+	hashes := make(map[string]string)
+	for name, algorithm := range map[string]PasswordHasher{
+		"current": h,
+		"weaker":  NewArgon2idHasher(weaker),
+		"bcrypt":  bcryptHasher,
+		"sha1":    sha,
+	} {
+		encoded, err := algorithm.Hash("password")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		hashes[name] = encoded
+	}
+
+	for name, rehash := range map[string]bool{"current": false, "weaker": true, "bcrypt": true, "sha1": true} {
+		encoded := hashes[name]
+
+		if ok, err := h.Verify("password", encoded); err != nil || !ok {
+			t.Errorf("%s: verify = %v, %v; want true", name, ok, err)
+		}
+
+		if ok, err := h.Verify("wrong", encoded); err != nil || ok {
+			t.Errorf("%s: verify wrong password = %v, %v; want false", name, ok, err)
+		}
+
+		if h.NeedsRehash(encoded) != rehash {
+			t.Errorf("%s: needs rehash %v, want %v", name, !rehash, rehash)
+		}
+	}
+
+	// Rehashing a legacy hash on sign-in moves it to the current algorithm.
+	rehashed, err := h.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !argon.Recognizes(rehashed) || h.NeedsRehash(rehashed) {
+		t.Errorf("rehashed %q is not a current argon2id hash", rehashed)
+	}
+
+	if _, err := h.Verify("password", "plain"); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("verify unknown: error %v, want %v", err, ErrUnknownHash)
+	}
+
+	if !h.NeedsRehash("plain") {
+		t.Error("unknown hash needs no rehash")
+	}
+
+	// Without the legacy algorithms their hashes are unknown.
+	if _, err := NewUpgradingHasher(argon).Verify("password", hashes["sha1"]); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("verify legacy without it: error %v, want %v", err, ErrUnknownHash)
+	}
+}
@@ -0,0 +1,370 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// lockKey identifies the Postgres advisory lock held while migrations run,
+// so that replicas starting at the same time apply them one after another.
+const lockKey int64 = 0x76746261636b // "vtback"
+
+const migrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    integer PRIMARY KEY,
+	name       text        NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([\w-]+)\.(up|down)\.sql$`)
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes whether a known migration has been applied.
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations loaded from a file system to a Postgres database
+// and records them in the schema_migrations table.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+// New loads migrations from fsys and returns a Migrator for the given database.
+//
+// Parameters:
+//   - db: The database the migrations are applied to.
+//   - fsys: A file system containing <version>_<name>.(up|down).sql files.
+//   - logger: A logger used to report applied migrations.
+//
+// Returns:
+//   - *Migrator: A pointer to the initialized Migrator.
+//   - error: An error if the migrations could not be loaded.
+func New(db *sqlx.DB, fsys fs.FS, logger *slog.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations, logger: logger}, nil
+}
+
+// Load reads migrations from the root of fsys and returns them ordered by version.
+//
+// Every version must have an up file; the down file is optional, but a
+// migration without one cannot be rolled back.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest returns the highest known migration version, or 0 if there are none.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			return nil
+		}
+
+		versions := sortedVersions(applied)
+
+		return m.rollback(ctx, conn, applied, versions[len(versions)-1]-1)
+	})
+}
+
+// To migrates the schema up or down so that exactly the migrations with a
+// version less than or equal to target are applied.
+func (m *Migrator) To(ctx context.Context, target int) error {
+	if target < 0 {
+		return fmt.Errorf("invalid target version %d", target)
+	}
+
+	if target != 0 && m.find(target) == nil {
+		return fmt.Errorf("unknown migration version %d", target)
+	}
+
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if err := m.apply(ctx, conn, applied, target); err != nil {
+			return err
+		}
+
+		return m.rollback(ctx, conn, applied, target)
+	})
+}
+
+// Status reports every known migration together with its applied state.
+//
+// It holds the migrations lock like Up and Down do, so that it neither races
+// another replica creating the migrations table nor reports a migration that
+// is being applied as pending.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var applied map[int]time.Time
+
+	if err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		var err error
+		applied, err = appliedVersions(ctx, conn)
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for i, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, m.migrations[i])
+		}
+	}
+
+	return pending, nil
+}
+
+// apply runs every pending up migration with a version less than or equal to target.
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, applied map[int]time.Time, target int) error {
+	for _, migration := range m.migrations {
+		if migration.Version > target {
+			break
+		}
+
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err := m.exec(ctx, conn, migration.Version, migration.Name, migration.Up,
+			`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name); err != nil {
+			return err
+		}
+
+		m.logger.Info("migration applied", slog.Int("version", migration.Version), slog.String("name", migration.Name))
+	}
+
+	return nil
+}
+
+// rollback runs the down migrations of every applied version greater than target,
+// newest first.
+func (m *Migrator) rollback(ctx context.Context, conn *sqlx.Conn, applied map[int]time.Time, target int) error {
+	versions := sortedVersions(applied)
+	for i := len(versions) - 1; i >= 0 && versions[i] > target; i-- {
+		migration := m.find(versions[i])
+		if migration == nil {
+			return fmt.Errorf("applied migration %d is unknown to this binary", versions[i])
+		}
+
+		if migration.Down == "" {
+			return fmt.Errorf("migration %d_%s cannot be rolled back", migration.Version, migration.Name)
+		}
+
+		if err := m.exec(ctx, conn, migration.Version, migration.Name, migration.Down,
+			`DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+			return err
+		}
+
+		m.logger.Info("migration rolled back", slog.Int("version", migration.Version), slog.String("name", migration.Name))
+	}
+
+	return nil
+}
+
+// exec runs a migration script and its bookkeeping statement in one transaction.
+func (m *Migrator) exec(ctx context.Context, conn *sqlx.Conn, version int, name, script, bookkeeping string, args ...any) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", version, name, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return fmt.Errorf("failed to record migration %d_%s: %w", version, name, err)
+	}
+
+	return tx.Commit()
+}
+
+// withLock runs fn on a dedicated connection that holds the migrations advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migrations lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	if _, err := conn.ExecContext(ctx, migrationsTable); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+
+	return nil
+}
+
+func appliedVersions(ctx context.Context, conn *sqlx.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryxContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func sortedVersions(applied map[int]time.Time) []int {
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+
+	sort.Ints(versions)
+
+	return versions
+}
@@ -0,0 +1,221 @@
+package migrate
+
+import (
+	"backend-vtb/migrations"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loaded) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	// Versions have no gaps, so that To can reach every schema, and every
+	// migration can be rolled back.
+	for i, migration := range loaded {
+		if migration.Version != i+1 {
+			t.Errorf("migration %d_%s is at position %d", migration.Version, migration.Name, i+1)
+		}
+
+		if migration.Down == "" {
+			t.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := func(body string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(body)}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		fsys     fstest.MapFS
+		versions []int
+		valid    bool
+	}{
+		{
+			name: "ordered by version",
+			fsys: fstest.MapFS{
+				"0010_ten.up.sql":   file("SELECT 10"),
+				"0002_two.up.sql":   file("SELECT 2"),
+				"0002_two.down.sql": file("SELECT -2"),
+				"0001_one.up.sql":   file("SELECT 1"),
+			},
+			versions: []int{1, 2, 10},
+			valid:    true,
+		},
+		{
+			name: "other files ignored",
+			fsys: fstest.MapFS{
+				"0001_one.up.sql":    file("SELECT 1"),
+				"README.md":          file("docs"),
+				"0002_two.sql":       file("SELECT 2"),
+				"nested/0003.up.sql": file("SELECT 3"),
+			},
+			versions: []int{1},
+			valid:    true,
+		},
+		{
+			name:  "no up file",
+			fsys:  fstest.MapFS{"0001_one.down.sql": file("SELECT -1")},
+			valid: false,
+		},
+		{
+			name:  "conflicting names",
+			fsys:  fstest.MapFS{"0001_one.up.sql": file("SELECT 1"), "0001_uno.down.sql": file("SELECT -1")},
+			valid: false,
+		},
+		{
+			name:  "version zero",
+			fsys:  fstest.MapFS{"0000_zero.up.sql": file("SELECT 0")},
+			valid: false,
+		},
+	} {
+		loaded, err := Load(tc.fsys)
+		if !tc.valid {
+			if err == nil {
+				t.Errorf("%s: loaded %d migrations", tc.name, len(loaded))
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+
+		versions := make([]int, 0, len(loaded))
+		for _, migration := range loaded {
+			versions = append(versions, migration.Version)
+		}
+
+		if len(versions) != len(tc.versions) {
+			t.Errorf("%s: versions %v, want %v", tc.name, versions, tc.versions)
+			continue
+		}
+
+		for i := range versions {
+			if versions[i] != tc.versions[i] {
+				t.Errorf("%s: versions %v, want %v", tc.name, versions, tc.versions)
+				break
+			}
+		}
+	}
+}
+
+// TestMigratorPostgres applies the embedded migrations up and down. It needs
+// an empty database, given as a lib/pq connection string in
+// MIGRATE_TEST_DSN, and is skipped without one.
+func TestMigratorPostgres(t *testing.T) {
+	dsn := os.Getenv("MIGRATE_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MIGRATE_TEST_DSN is not set")
+	}
+
+	ctx := context.Background()
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := New(db, migrations.FS, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := m.To(context.Background(), 0); err != nil {
+			t.Errorf("roll back all: %v", err)
+		}
+	})
+
+	applied := func(name string, want int) {
+		t.Helper()
+
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		for _, status := range statuses {
+			if status.Applied != (status.Version <= want) || status.Applied == status.AppliedAt.IsZero() {
+				t.Errorf("%s: migration %d applied %v at %v, want migrations up to %d applied",
+					name, status.Version, status.Applied, status.AppliedAt, want)
+			}
+		}
+
+		if len(pending) != m.Latest()-want || len(pending) > 0 && pending[0].Version != want+1 {
+			t.Errorf("%s: %d pending migrations, want %d from %d", name, len(pending), m.Latest()-want, want+1)
+		}
+	}
+
+	// Status creates the migrations table of an empty database.
+	applied("empty", 0)
+
+	// Replicas starting together apply the migrations one after another.
+	errs := make(chan error, 3)
+	for range 3 {
+		go func() {
+			errs <- m.Up(ctx)
+		}()
+	}
+
+	for range 3 {
+		if err := <-errs; err != nil {
+			t.Fatalf("up: %v", err)
+		}
+	}
+
+	applied("up", m.Latest())
+
+	if err := m.Down(ctx); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+
+	applied("down", m.Latest()-1)
+
+	if err := m.To(ctx, 1); err != nil {
+		t.Fatalf("to 1: %v", err)
+	}
+
+	applied("to 1", 1)
+
+	// Every down migration undid its up migration, so all apply again.
+	if err := m.To(ctx, 0); err != nil {
+		t.Fatalf("to 0: %v", err)
+	}
+
+	applied("to 0", 0)
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+
+	applied("up again", m.Latest())
+
+	if err := m.To(ctx, m.Latest()+1); err == nil {
+		t.Error("migrated to an unknown version")
+	}
+}
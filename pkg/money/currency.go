@@ -0,0 +1,30 @@
+package money
+
+import "strings"
+
+// minorUnits holds the number of digits after the decimal point of ISO 4217
+// currencies.
+var minorUnits = map[string]int{
+	"AED": 2, "AMD": 2, "AUD": 2, "AZN": 2, "BHD": 3, "BRL": 2, "BYN": 2, "CAD": 2,
+	"CHF": 2, "CLP": 0, "CNY": 2, "CZK": 2, "DKK": 2, "EGP": 2, "EUR": 2, "GBP": 2,
+	"GEL": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2, "ISK": 0, "JOD": 3,
+	"JPY": 0, "KGS": 2, "KRW": 0, "KWD": 3, "KZT": 2, "MDL": 2, "MXN": 2, "NOK": 2,
+	"NZD": 2, "OMR": 3, "PLN": 2, "QAR": 2, "RON": 2, "RSD": 2, "RUB": 2, "SAR": 2,
+	"SEK": 2, "SGD": 2, "THB": 2, "TJS": 2, "TMT": 2, "TND": 3, "TRY": 2, "UAH": 2,
+	"USD": 2, "UZS": 2, "VND": 0, "ZAR": 2,
+}
+
+// MinorUnits returns the number of decimal places of the currency, e.g. 2
+// for RUB and 0 for JPY.
+//
+// Parameters:
+//   - currency: The ISO 4217 code of the currency, in any case.
+//
+// Returns:
+//   - int: The number of minor unit digits.
+//   - bool: false if the currency is not known.
+func MinorUnits(currency string) (int, bool) {
+	units, ok := minorUnits[strings.ToUpper(currency)]
+
+	return units, ok
+}
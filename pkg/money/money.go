@@ -0,0 +1,198 @@
+// Package money represents amounts of money as whole minor units of an ISO
+// 4217 currency and converts them between currencies.
+//
+// Arithmetic never silently overflows or mixes currencies: operations that
+// would return an error instead. Whenever a result falls between two minor
+// units it is rounded half to even (banker's rounding), so that rounding
+// errors do not accumulate in one direction over many operations.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+	ErrOverflow         = errors.New("amount out of range")
+)
+
+// Money is an amount in minor units of a currency, e.g. kopecks for RUB.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// New returns the amount of minor units of the currency. The currency code
+// is upper-cased but not validated; see Validate.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Zero returns no money in the currency.
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// Validate checks that the currency is a known ISO 4217 currency.
+func (m Money) Validate() error {
+	if _, ok := MinorUnits(m.Currency); !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownCurrency, m.Currency)
+	}
+
+	return nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + o. Both must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrOverflow
+	}
+
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o. Both must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	neg, err := o.Neg()
+	if err != nil {
+		return Money{}, err
+	}
+
+	return m.Add(neg)
+}
+
+// Neg returns -m.
+func (m Money) Neg() (Money, error) {
+	if m.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+
+	return Money{Amount: -m.Amount, Currency: m.Currency}, nil
+}
+
+// Mul returns m multiplied by n.
+func (m Money) Mul(n int64) (Money, error) {
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(n))
+	if !product.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+
+	return Money{Amount: product.Int64(), Currency: m.Currency}, nil
+}
+
+// MulRat returns m multiplied by r, rounded half to even.
+func (m Money) MulRat(r *big.Rat) (Money, error) {
+	amount, err := roundHalfEven(new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), r))
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+// Cmp compares m and o, returning -1, 0 or +1. Both must be in the same currency.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// String formats the amount in major units, e.g. "1234.50 RUB". Amounts of
+// unknown currencies are shown in minor units.
+func (m Money) String() string {
+	units, ok := MinorUnits(m.Currency)
+	if !ok || units == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	sign := ""
+	abs := new(big.Int).SetInt64(m.Amount)
+	if abs.Sign() < 0 {
+		sign = "-"
+		abs.Neg(abs)
+	}
+
+	major, minor := new(big.Int).QuoRem(abs, pow10(units), new(big.Int))
+
+	return fmt.Sprintf("%s%s.%0*d %s", sign, major.String(), units, minor.Int64(), m.Currency)
+}
+
+// Sum adds up the amounts, which must all be in the currency.
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+
+	return total, nil
+}
+
+func (m Money) sameCurrency(o Money) error {
+	if m.Currency != o.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+
+	return nil
+}
+
+// roundHalfEven rounds r to the nearest integer, and halves to the even one.
+func roundHalfEven(r *big.Rat) (int64, error) {
+	num, den := r.Num(), r.Denom()
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	twiceRem := new(big.Int).Abs(rem)
+	twiceRem.Lsh(twiceRem, 1)
+
+	if c := twiceRem.Cmp(den); c > 0 || (c == 0 && quo.Bit(0) == 1) {
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	if !quo.IsInt64() {
+		return 0, ErrOverflow
+	}
+
+	return quo.Int64(), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
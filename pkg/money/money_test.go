@@ -0,0 +1,243 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestMulRatRoundsHalfToEven(t *testing.T) {
+	for _, tc := range []struct {
+		amount int64
+		rat    *big.Rat
+		want   int64
+	}{
+		// Exact halves go to the even neighbour, in both directions.
+		{5, big.NewRat(1, 2), 2},   // 2.5
+		{7, big.NewRat(1, 2), 4},   // 3.5
+		{1, big.NewRat(1, 2), 0},   // 0.5
+		{3, big.NewRat(1, 2), 2},   // 1.5
+		{-5, big.NewRat(1, 2), -2}, // -2.5
+		{-7, big.NewRat(1, 2), -4}, // -3.5
+		{-1, big.NewRat(1, 2), 0},  // -0.5
+		{5, big.NewRat(-1, 2), -2}, // -2.5
+		// Anything off the half goes to the nearest.
+		{251, big.NewRat(1, 100), 3},   // 2.51
+		{249, big.NewRat(1, 100), 2},   // 2.49
+		{-251, big.NewRat(1, 100), -3}, // -2.51
+		{-249, big.NewRat(1, 100), -2}, // -2.49
+		{10, big.NewRat(1, 3), 3},      // 3.33...
+		{20, big.NewRat(1, 3), 7},      // 6.66...
+		{-20, big.NewRat(1, 3), -7},    // -6.66...
+		{4, big.NewRat(1, 1), 4},
+		{0, big.NewRat(1, 2), 0},
+	} {
+		got, err := New(tc.amount, "RUB").MulRat(tc.rat)
+		if err != nil {
+			t.Fatalf("%d * %s: %v", tc.amount, tc.rat, err)
+		}
+
+		if got.Amount != tc.want || got.Currency != "RUB" {
+			t.Errorf("%d * %s = %v, want %d RUB", tc.amount, tc.rat, got, tc.want)
+		}
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	max := New(math.MaxInt64, "RUB")
+	min := New(math.MinInt64, "RUB")
+	one := New(1, "RUB")
+
+	halfPastMax, _ := new(big.Rat).SetString("18446744073709551615/2")
+
+	for _, tc := range []struct {
+		name string
+		op   func() (Money, error)
+	}{
+		{"add", func() (Money, error) { return max.Add(one) }},
+		{"add negative", func() (Money, error) { return min.Add(New(-1, "RUB")) }},
+		{"sub", func() (Money, error) { return min.Sub(one) }},
+		{"sub min", func() (Money, error) { return Zero("RUB").Sub(min) }},
+		{"neg", func() (Money, error) { return min.Neg() }},
+		{"mul", func() (Money, error) { return max.Mul(2) }},
+		{"mul min by -1", func() (Money, error) { return min.Mul(-1) }},
+		{"mul rat", func() (Money, error) { return max.MulRat(big.NewRat(3, 2)) }},
+		// MaxInt64 + 0.5 rounds to the even MaxInt64 + 1.
+		{"mul rat rounding up", func() (Money, error) { return one.MulRat(halfPastMax) }},
+		{"sum", func() (Money, error) { return Sum("RUB", max, one) }},
+	} {
+		if _, err := tc.op(); !errors.Is(err, ErrOverflow) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, ErrOverflow)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		op   func() (Money, error)
+		want int64
+	}{
+		{"add to max", func() (Money, error) { return New(math.MaxInt64-1, "RUB").Add(one) }, math.MaxInt64},
+		{"sub to min", func() (Money, error) { return New(math.MinInt64+1, "RUB").Sub(one) }, math.MinInt64},
+		{"add opposite signs", func() (Money, error) { return max.Add(min) }, -1},
+		{"mul min by 1", func() (Money, error) { return min.Mul(1) }, math.MinInt64},
+		// 4611686018427387903.5 rounds to the even neighbour.
+		{"mul rat back in range", func() (Money, error) { return max.MulRat(big.NewRat(1, 2)) }, math.MaxInt64/2 + 1},
+	} {
+		got, err := tc.op()
+		if err != nil || got.Amount != tc.want {
+			t.Errorf("%s = %v, %v; want %d", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestCurrencyMismatch(t *testing.T) {
+	rub, usd := New(100, "RUB"), New(100, "usd")
+
+	if _, err := rub.Add(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add: err = %v, want %v", err, ErrCurrencyMismatch)
+	}
+
+	if _, err := rub.Sub(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub: err = %v, want %v", err, ErrCurrencyMismatch)
+	}
+
+	if _, err := rub.Cmp(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp: err = %v, want %v", err, ErrCurrencyMismatch)
+	}
+
+	if _, err := Sum("RUB", rub, usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sum: err = %v, want %v", err, ErrCurrencyMismatch)
+	}
+
+	rate, err := ParseRate("USD", "RUB", "92.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rate.Convert(rub); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Convert: err = %v, want %v", err, ErrCurrencyMismatch)
+	}
+
+	if _, err := rate.Cross(rate); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cross: err = %v, want %v", err, ErrCurrencyMismatch)
+	}
+
+	// Codes are compared upper-cased.
+	if got, err := New(1, "rub").Add(rub); err != nil || got.Amount != 101 {
+		t.Errorf("Add of rub and RUB = %v, %v; want 101 RUB", got, err)
+	}
+}
+
+func TestCmp(t *testing.T) {
+	for _, tc := range []struct {
+		a, b int64
+		want int
+	}{
+		{1, 2, -1},
+		{2, 2, 0},
+		{-1, -2, 1},
+	} {
+		if got, err := New(tc.a, "RUB").Cmp(New(tc.b, "RUB")); err != nil || got != tc.want {
+			t.Errorf("Cmp(%d, %d) = %d, %v; want %d", tc.a, tc.b, got, err, tc.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, tc := range []struct {
+		money Money
+		want  string
+	}{
+		{New(123450, "RUB"), "1234.50 RUB"},
+		{New(5, "RUB"), "0.05 RUB"},
+		{New(-5, "RUB"), "-0.05 RUB"},
+		{New(math.MinInt64, "RUB"), "-92233720368547758.08 RUB"},
+		{New(1500, "JPY"), "1500 JPY"},
+		{New(1500, "XXX"), "1500 XXX"},
+	} {
+		if got := tc.money.String(); got != tc.want {
+			t.Errorf("String = %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := New(1, "rub").Validate(); err != nil {
+		t.Errorf("Validate(rub) = %v", err)
+	}
+
+	if err := New(1, "XXX").Validate(); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("Validate(XXX) = %v, want %v", err, ErrUnknownCurrency)
+	}
+}
+
+func TestRateConvert(t *testing.T) {
+	for _, tc := range []struct {
+		base, quote, rate string
+		amount            int64
+		want              int64
+	}{
+		// 1.00 USD at 92.5 is 92.50 RUB.
+		{"USD", "RUB", "92.5", 100, 9250},
+		// 0.01 USD at 92.505 is 0.92505 RUB, rounded to 0.93.
+		{"USD", "RUB", "92.505", 1, 93},
+		// 1 JPY at 0.625 is 0.625 RUB, a half kopeck rounded to the even 62.
+		{"JPY", "RUB", "0.625", 1, 62},
+		// 1 JPY at 0.635 is 63.5 kopecks, rounded to 64.
+		{"JPY", "RUB", "0.635", 1, 64},
+		// 1.50 RUB at 1/100 is 0.015 USD, a half cent rounded to 2.
+		{"RUB", "USD", "0.01", 150, 2},
+		// 2.50 RUB at 0.01 is 0.025 USD, rounded to the even 2.
+		{"RUB", "USD", "0.01", 250, 2},
+		{"RUB", "USD", "0.01", -250, -2},
+		// 12.34 USD to yen, which has no minor units.
+		{"USD", "JPY", "150", 1234, 1851},
+	} {
+		rate, err := ParseRate(tc.base, tc.quote, tc.rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := rate.Convert(New(tc.amount, tc.base))
+		if err != nil || got.Amount != tc.want || got.Currency != tc.quote {
+			t.Errorf("%d %s at %s = %v, %v; want %d %s", tc.amount, tc.base, tc.rate, got, err, tc.want, tc.quote)
+		}
+	}
+}
+
+func TestRateInvertAndCross(t *testing.T) {
+	usdRub, _ := ParseRate("USD", "RUB", "90")
+	rubCny, _ := ParseRate("RUB", "CNY", "0.08")
+
+	usdCny, err := usdRub.Cross(rubCny)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if usdCny.Base != "USD" || usdCny.Quote != "CNY" || usdCny.String() != "7.2" {
+		t.Errorf("Cross = %s/%s %s, want USD/CNY 7.2", usdCny.Base, usdCny.Quote, usdCny)
+	}
+
+	rubUsd := usdRub.Invert()
+	if rubUsd.Base != "RUB" || rubUsd.Quote != "USD" || rubUsd.String() != "0.0111111111" {
+		t.Errorf("Invert = %s/%s %s, want RUB/USD 0.0111111111", rubUsd.Base, rubUsd.Quote, rubUsd)
+	}
+
+	// The inverse is kept exact: 90.00 RUB converts back to 1.00 USD.
+	if got, err := rubUsd.Convert(New(9000, "RUB")); err != nil || got.Amount != 100 {
+		t.Errorf("Convert = %v, %v; want 1.00 USD", got, err)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for _, value := range []string{"", "0", "-1", "abc", "1/0"} {
+		if _, err := ParseRate("USD", "RUB", value); !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("ParseRate(%q) = %v, want %v", value, err, ErrInvalidRate)
+		}
+	}
+
+	if _, err := ParseRate("USD", "XXX", "1"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("ParseRate of XXX = %v, want %v", err, ErrUnknownCurrency)
+	}
+}
@@ -0,0 +1,115 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidRate is returned for rates that are not positive decimal numbers.
+var ErrInvalidRate = errors.New("invalid exchange rate")
+
+// rateDigits is the number of decimal places a rate is formatted with.
+const rateDigits = 10
+
+// Rate is an exchange rate: the price of one major unit of the base currency
+// in the quote currency, e.g. 92.5 for USD/RUB. It is kept exact, so
+// cross and inverse rates do not lose precision until an amount is rounded.
+type Rate struct {
+	Base  string
+	Quote string
+	value *big.Rat
+}
+
+// ParseRate parses a rate given as a decimal number, e.g. "92.5012".
+//
+// Parameters:
+//   - base: The currency the rate is the price of.
+//   - quote: The currency the price is in.
+//   - value: The price of one unit of base, a positive decimal number.
+//
+// Returns:
+//   - Rate: The exchange rate.
+//   - error: ErrUnknownCurrency or ErrInvalidRate if the input is not valid.
+func ParseRate(base, quote, value string) (Rate, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || r.Sign() <= 0 {
+		return Rate{}, fmt.Errorf("%w: %q", ErrInvalidRate, value)
+	}
+
+	return NewRate(base, quote, r)
+}
+
+// NewRate returns the rate with the given exact value, which must be positive.
+func NewRate(base, quote string, value *big.Rat) (Rate, error) {
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+
+	for _, currency := range []string{base, quote} {
+		if _, ok := MinorUnits(currency); !ok {
+			return Rate{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+		}
+	}
+
+	if value == nil || value.Sign() <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+
+	return Rate{Base: base, Quote: quote, value: new(big.Rat).Set(value)}, nil
+}
+
+// Identity returns the rate of a currency in itself.
+func Identity(currency string) Rate {
+	currency = strings.ToUpper(currency)
+
+	return Rate{Base: currency, Quote: currency, value: big.NewRat(1, 1)}
+}
+
+// Value returns the exact value of the rate.
+func (r Rate) Value() *big.Rat {
+	return new(big.Rat).Set(r.value)
+}
+
+// String formats the rate as a decimal number, rounded to 10 decimal places
+// for rates that are not exact decimals.
+func (r Rate) String() string {
+	return strings.TrimRight(strings.TrimRight(r.value.FloatString(rateDigits), "0"), ".")
+}
+
+// Invert returns the rate of the quote currency in the base currency.
+func (r Rate) Invert() Rate {
+	return Rate{Base: r.Quote, Quote: r.Base, value: new(big.Rat).Inv(r.value)}
+}
+
+// Cross chains r with a rate whose base is the quote of r, e.g. USD/RUB
+// with RUB/CNY into USD/CNY.
+func (r Rate) Cross(next Rate) (Rate, error) {
+	if r.Quote != next.Base {
+		return Rate{}, fmt.Errorf("%w: %s/%s cannot be chained with %s/%s",
+			ErrCurrencyMismatch, r.Base, r.Quote, next.Base, next.Quote)
+	}
+
+	return Rate{Base: r.Base, Quote: next.Quote, value: new(big.Rat).Mul(r.value, next.value)}, nil
+}
+
+// Convert exchanges an amount in the base currency into the quote currency,
+// rounding half to even to a minor unit of the quote currency.
+func (r Rate) Convert(m Money) (Money, error) {
+	if m.Currency != r.Base {
+		return Money{}, fmt.Errorf("%w: %s amount at a %s/%s rate", ErrCurrencyMismatch, m.Currency, r.Base, r.Quote)
+	}
+
+	baseUnits, _ := MinorUnits(r.Base)
+	quoteUnits, _ := MinorUnits(r.Quote)
+
+	// Minor units of the base to major units, at the rate, to minor units
+	// of the quote.
+	factor := new(big.Rat).Mul(r.value, new(big.Rat).SetFrac(pow10(quoteUnits), pow10(baseUnits)))
+
+	converted, err := Money{Amount: m.Amount, Currency: r.Quote}.MulRat(factor)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return converted, nil
+}
@@ -0,0 +1,142 @@
+// Package ohlc aggregates price ticks into OHLCV candles: the open, high,
+// low and close prices and the volume traded in each interval of time.
+//
+// Candles of the same interval merge regardless of the order their ticks
+// arrived in, so that ticks may be ingested in batches, replayed or
+// backfilled out of order.
+package ohlc
+
+import (
+	"math/big"
+	"time"
+)
+
+// Candle is the summary of the ticks in an interval of time.
+type Candle struct {
+	OpenTime time.Time
+	Open     *big.Rat
+	High     *big.Rat
+	Low      *big.Rat
+	Close    *big.Rat
+	Volume   *big.Rat
+	Ticks    int64
+	// FirstAt and LastAt are the times of the ticks Open and Close come from.
+	FirstAt time.Time
+	LastAt  time.Time
+	// Filled marks a candle of an interval without ticks, see Fill.
+	Filled bool
+}
+
+// Truncate returns the start of the interval of the given length containing
+// t. Intervals are aligned to the Unix epoch, so days start at midnight UTC.
+func Truncate(t time.Time, interval time.Duration) time.Time {
+	return t.UTC().Truncate(interval)
+}
+
+// New returns the candle of a single tick.
+func New(interval time.Duration, at time.Time, price, volume *big.Rat) Candle {
+	if volume == nil {
+		volume = new(big.Rat)
+	}
+
+	return Candle{
+		OpenTime: Truncate(at, interval),
+		Open:     new(big.Rat).Set(price),
+		High:     new(big.Rat).Set(price),
+		Low:      new(big.Rat).Set(price),
+		Close:    new(big.Rat).Set(price),
+		Volume:   new(big.Rat).Set(volume),
+		Ticks:    1,
+		FirstAt:  at,
+		LastAt:   at,
+	}
+}
+
+// Merge returns the candle of the ticks of both candles, which must be of
+// the same interval. Ticks at the same moment keep the open of a and the
+// close of b.
+func Merge(a, b Candle) Candle {
+	merged := Candle{
+		OpenTime: a.OpenTime,
+		Open:     a.Open,
+		High:     a.High,
+		Low:      a.Low,
+		Close:    b.Close,
+		Volume:   new(big.Rat).Add(a.Volume, b.Volume),
+		Ticks:    a.Ticks + b.Ticks,
+		FirstAt:  a.FirstAt,
+		LastAt:   b.LastAt,
+	}
+
+	if b.FirstAt.Before(a.FirstAt) {
+		merged.Open, merged.FirstAt = b.Open, b.FirstAt
+	}
+
+	if a.LastAt.After(b.LastAt) {
+		merged.Close, merged.LastAt = a.Close, a.LastAt
+	}
+
+	if b.High.Cmp(a.High) > 0 {
+		merged.High = b.High
+	}
+
+	if b.Low.Cmp(a.Low) < 0 {
+		merged.Low = b.Low
+	}
+
+	return merged
+}
+
+// Fill returns a candle for every interval with a start in [from, to),
+// taking those given and carrying the previous close over the intervals
+// without ticks as flat candles of no volume. Intervals before the first
+// known price are left out.
+//
+// Parameters:
+//   - candles: The candles with ticks, oldest first.
+//   - previous: The last candle before from, if any, for the close to carry.
+//   - from: The start of the first interval.
+//   - to: The end of the range.
+//   - interval: The length of the intervals.
+//
+// Returns:
+//   - []Candle: The candles of the range, oldest first.
+func Fill(candles []Candle, previous *Candle, from, to time.Time, interval time.Duration) []Candle {
+	var (
+		filled  = make([]Candle, 0, len(candles))
+		last    = previous
+		pending = candles
+	)
+
+	for start := Truncate(from, interval); start.Before(to); start = start.Add(interval) {
+		for len(pending) > 0 && pending[0].OpenTime.Before(start) {
+			pending = pending[1:]
+		}
+
+		if len(pending) > 0 && pending[0].OpenTime.Equal(start) {
+			filled = append(filled, pending[0])
+			last = &pending[0]
+			pending = pending[1:]
+
+			continue
+		}
+
+		if last == nil {
+			continue
+		}
+
+		filled = append(filled, Candle{
+			OpenTime: start,
+			Open:     last.Close,
+			High:     last.Close,
+			Low:      last.Close,
+			Close:    last.Close,
+			Volume:   new(big.Rat),
+			FirstAt:  start,
+			LastAt:   start,
+			Filled:   true,
+		})
+	}
+
+	return filled
+}
@@ -0,0 +1,201 @@
+package ohlc
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func rat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("invalid rational " + s)
+	}
+
+	return r
+}
+
+func TestTruncate(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	for _, tc := range []struct {
+		at       time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{time.Date(2024, 3, 1, 10, 4, 59, 999999999, time.UTC), 5 * time.Minute, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 1, 10, 5, 0, 0, time.UTC), 5 * time.Minute, time.Date(2024, 3, 1, 10, 5, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 1, 10, 5, 0, 1, time.UTC), time.Minute, time.Date(2024, 3, 1, 10, 5, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 1, 10, 59, 59, 0, time.UTC), time.Hour, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 1, 23, 59, 59, 0, time.UTC), 24 * time.Hour, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		// Days start at midnight UTC, not in the zone of the tick.
+		{time.Date(2024, 3, 2, 1, 0, 0, 0, moscow), 24 * time.Hour, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 2, 3, 0, 0, 0, moscow), 24 * time.Hour, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+	} {
+		if got := Truncate(tc.at, tc.interval); !got.Equal(tc.want) || got.Location() != time.UTC {
+			t.Errorf("Truncate(%v, %v) = %v, want %v", tc.at, tc.interval, got, tc.want)
+		}
+	}
+}
+
+func TestMergeOutOfOrder(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	type tick struct {
+		offset time.Duration
+		price  string
+		volume string
+	}
+
+	ticks := []tick{
+		{10 * time.Second, "100", "1"},
+		{20 * time.Second, "120", "2"},
+		{30 * time.Second, "90", "0.5"},
+		{40 * time.Second, "110", "1.5"},
+	}
+
+	// Every order the ticks may arrive in.
+	orders := [][]int{
+		{0, 1, 2, 3},
+		{3, 2, 1, 0},
+		{2, 0, 3, 1},
+		{1, 3, 0, 2},
+	}
+
+	for _, order := range orders {
+		var candle Candle
+		for i, n := range order {
+			next := New(time.Minute, base.Add(ticks[n].offset), rat(ticks[n].price), rat(ticks[n].volume))
+			if i == 0 {
+				candle = next
+				continue
+			}
+
+			candle = Merge(candle, next)
+		}
+
+		if candle.Open.Cmp(rat("100")) != 0 || candle.Close.Cmp(rat("110")) != 0 ||
+			candle.High.Cmp(rat("120")) != 0 || candle.Low.Cmp(rat("90")) != 0 ||
+			candle.Volume.Cmp(rat("5")) != 0 || candle.Ticks != 4 {
+			t.Errorf("order %v: candle %s/%s/%s/%s volume %s of %d ticks, want 100/120/90/110 volume 5 of 4",
+				order, candle.Open, candle.High, candle.Low, candle.Close, candle.Volume, candle.Ticks)
+		}
+
+		if !candle.OpenTime.Equal(base) || !candle.FirstAt.Equal(base.Add(10*time.Second)) || !candle.LastAt.Equal(base.Add(40*time.Second)) {
+			t.Errorf("order %v: candle at %v from %v to %v", order, candle.OpenTime, candle.FirstAt, candle.LastAt)
+		}
+	}
+}
+
+func TestMergeSameMoment(t *testing.T) {
+	at := time.Date(2024, 3, 1, 10, 0, 30, 0, time.UTC)
+
+	a := New(time.Minute, at, rat("100"), nil)
+	b := New(time.Minute, at, rat("101"), nil)
+
+	// The open of the first candle and the close of the second win.
+	candle := Merge(a, b)
+	if candle.Open.Cmp(rat("100")) != 0 || candle.Close.Cmp(rat("101")) != 0 || candle.Volume.Sign() != 0 {
+		t.Errorf("candle %s..%s with volume %s, want 100..101 without volume", candle.Open, candle.Close, candle.Volume)
+	}
+}
+
+func TestMergeDoesNotAlias(t *testing.T) {
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	price := rat("100")
+
+	a := New(time.Minute, at, price, nil)
+	price.SetInt64(1)
+
+	if a.Open.Cmp(rat("100")) != 0 {
+		t.Errorf("open changed with the price of the tick to %s", a.Open)
+	}
+
+	merged := Merge(a, New(time.Minute, at.Add(time.Second), rat("50"), rat("1")))
+	merged.Volume.SetInt64(7)
+
+	if a.Volume.Sign() != 0 {
+		t.Errorf("volume of the merged candle changed with the merge to %s", a.Volume)
+	}
+}
+
+func TestFill(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	minute := func(n int, price string) Candle {
+		return New(time.Minute, base.Add(time.Duration(n)*time.Minute+time.Second), rat(price), rat("1"))
+	}
+
+	previous := minute(-3, "99")
+
+	for _, tc := range []struct {
+		name     string
+		candles  []Candle
+		previous *Candle
+		from     time.Time
+		to       time.Time
+		want     []string
+		filled   []bool
+	}{
+		{
+			name:    "no gaps",
+			candles: []Candle{minute(0, "100"), minute(1, "101")},
+			from:    base, to: base.Add(2 * time.Minute),
+			want: []string{"100", "101"}, filled: []bool{false, false},
+		},
+		{
+			name:    "gap carries the close",
+			candles: []Candle{minute(0, "100"), minute(3, "103")},
+			from:    base, to: base.Add(5 * time.Minute),
+			want: []string{"100", "100", "100", "103", "103"}, filled: []bool{false, true, true, false, true},
+		},
+		{
+			name:    "nothing before the first price",
+			candles: []Candle{minute(2, "102")},
+			from:    base, to: base.Add(4 * time.Minute),
+			want: []string{"102", "102"}, filled: []bool{false, true},
+		},
+		{
+			name:    "previous candle carried",
+			candles: []Candle{minute(2, "102")}, previous: &previous,
+			from: base, to: base.Add(3 * time.Minute),
+			want: []string{"99", "99", "102"}, filled: []bool{true, true, false},
+		},
+		{
+			name:    "unaligned start",
+			candles: []Candle{minute(0, "100"), minute(1, "101")},
+			from:    base.Add(30 * time.Second), to: base.Add(2 * time.Minute),
+			want: []string{"100", "101"}, filled: []bool{false, false},
+		},
+		{
+			name:    "candles before the range skipped",
+			candles: []Candle{minute(-2, "98"), minute(1, "101")},
+			from:    base, to: base.Add(2 * time.Minute),
+			want: []string{"101"}, filled: []bool{false},
+		},
+		{
+			name: "empty range",
+			from: base, to: base,
+		},
+	} {
+		filled := Fill(tc.candles, tc.previous, tc.from, tc.to, time.Minute)
+		if len(filled) != len(tc.want) {
+			t.Errorf("%s: %d candles, want %d", tc.name, len(filled), len(tc.want))
+			continue
+		}
+
+		for i, candle := range filled {
+			if candle.Close.Cmp(rat(tc.want[i])) != 0 || candle.Filled != tc.filled[i] {
+				t.Errorf("%s: candle %d closes at %s, filled %v; want %s, %v",
+					tc.name, i, candle.Close, candle.Filled, tc.want[i], tc.filled[i])
+			}
+
+			if i > 0 && !candle.OpenTime.Equal(filled[i-1].OpenTime.Add(time.Minute)) {
+				t.Errorf("%s: candle %d opens at %v after %v", tc.name, i, candle.OpenTime, filled[i-1].OpenTime)
+			}
+
+			if candle.Filled && (candle.Volume.Sign() != 0 || candle.Ticks != 0 || candle.Open.Cmp(candle.Close) != 0) {
+				t.Errorf("%s: filled candle %d is not flat", tc.name, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// secretSize is the length of generated secrets in bytes, as recommended by RFC 4226.
+const secretSize = 20
+
+var (
+	// ErrInvalidSecret is returned when a secret is not valid base32.
+	ErrInvalidSecret = errors.New("invalid TOTP secret")
+
+	encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// TOTP generates and validates RFC 6238 time-based one-time passwords with HMAC-SHA1.
+type TOTP struct {
+	// Period is the length of a time step.
+	Period time.Duration
+	// Digits is the number of digits in a code, usually 6.
+	Digits int
+	// Skew is the number of time steps before and after the current one that
+	// are still accepted to tolerate clock drift between client and server.
+	Skew uint
+}
+
+// NewTOTP creates a TOTP with the parameters understood by common authenticator
+// apps: 30 second steps and 6 digit codes.
+//
+// Parameters:
+//   - skew: The number of adjacent time steps accepted on each side.
+//
+// Returns:
+//   - TOTP: The configured generator.
+func NewTOTP(skew uint) TOTP {
+	return TOTP{Period: 30 * time.Second, Digits: 6, Skew: skew}
+}
+
+// GenerateSecret returns a new random secret encoded in unpadded base32.
+//
+// Returns:
+//   - string: The base32 encoded secret.
+//   - error: An error if the random number generator fails.
+func GenerateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// Counter returns the time step number for the given moment.
+func (t TOTP) Counter(at time.Time) int64 {
+	return at.Unix() / int64(t.Period/time.Second)
+}
+
+// Code returns the one-time password for the given moment.
+//
+// Parameters:
+//   - secret: The base32 encoded shared secret.
+//   - at: The moment the code is generated for.
+//
+// Returns:
+//   - string: The zero-padded code.
+//   - error: ErrInvalidSecret if the secret cannot be decoded.
+func (t TOTP) Code(secret string, at time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return t.hotp(key, t.Counter(at)), nil
+}
+
+// Validate checks a code against every time step within the skew window.
+//
+// The matching time step is returned so that callers can remember the last
+// accepted one and reject codes from it or earlier steps, since a code must
+// not be usable twice.
+//
+// Parameters:
+//   - secret: The base32 encoded shared secret.
+//   - code: The code entered by the user.
+//   - at: The moment of validation.
+//
+// Returns:
+//   - int64: The time step the code belongs to, if valid.
+//   - bool: True if the code is valid for any step in the window.
+//   - error: ErrInvalidSecret if the secret cannot be decoded.
+func (t TOTP) Validate(secret, code string, at time.Time) (int64, bool, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) != t.Digits {
+		return 0, false, nil
+	}
+
+	current := t.Counter(at)
+	for offset := -int64(t.Skew); offset <= int64(t.Skew); offset++ {
+		counter := current + offset
+		if subtle.ConstantTimeCompare([]byte(t.hotp(key, counter)), []byte(code)) == 1 {
+			return counter, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// KeyURI returns the otpauth:// URI understood by authenticator apps.
+//
+// Parameters:
+//   - issuer: The service name shown in the app.
+//   - account: The account name, usually the user's email.
+//   - secret: The base32 encoded shared secret.
+//
+// Returns:
+//   - string: The key URI, suitable for encoding in a QR code.
+func (t TOTP) KeyURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(t.Digits))
+	query.Set("period", fmt.Sprint(int64(t.Period/time.Second)))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
+// hotp implements the HOTP algorithm from RFC 4226 with dynamic truncation.
+func (t TOTP) hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < t.Digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", t.Digits, value%mod)
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+
+	return key, nil
+}
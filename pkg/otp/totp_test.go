@@ -0,0 +1,197 @@
+package otp
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of the test vectors of RFC 4226 and RFC 6238.
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238(t *testing.T) {
+	// Appendix B of RFC 6238, SHA1 with 8 digits and 30 second steps.
+	totp := TOTP{Period: 30 * time.Second, Digits: 8}
+
+	for _, tc := range []struct {
+		unix    int64
+		counter int64
+		want    string
+	}{
+		{59, 0x1, "94287082"},
+		{1111111109, 0x23523EC, "07081804"},
+		{1111111111, 0x23523ED, "14050471"},
+		{1234567890, 0x273EF07, "89005924"},
+		{2000000000, 0x3F940AA, "69279037"},
+		{20000000000, 0x27BC86AA, "65353130"},
+	} {
+		at := time.Unix(tc.unix, 0).UTC()
+
+		if counter := totp.Counter(at); counter != tc.counter {
+			t.Errorf("Counter(%d) = %#x, want %#x", tc.unix, counter, tc.counter)
+		}
+
+		code, err := totp.Code(rfcSecret, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != tc.want {
+			t.Errorf("Code(%d) = %s, want %s", tc.unix, code, tc.want)
+		}
+
+		if counter, ok, err := totp.Validate(rfcSecret, tc.want, at); err != nil || !ok || counter != tc.counter {
+			t.Errorf("Validate(%d) = %#x, %t, %v; want %#x", tc.unix, counter, ok, err, tc.counter)
+		}
+	}
+}
+
+func TestHOTPRFC4226(t *testing.T) {
+	// Appendix D of RFC 4226, 6 digits.
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	key, err := decodeSecret(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totp := NewTOTP(0)
+	for counter, code := range want {
+		if got := totp.hotp(key, int64(counter)); got != code {
+			t.Errorf("hotp(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	// Step 100 runs from 3000 to 3029.
+	start := time.Unix(3000, 0)
+
+	code := func(step int64) string {
+		c, err := NewTOTP(0).Code(rfcSecret, time.Unix(step*30, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return c
+	}
+
+	for _, tc := range []struct {
+		name string
+		skew uint
+		at   time.Time
+		step int64
+		want bool
+	}{
+		{"current step", 0, start, 100, true},
+		{"last second of the step", 0, start.Add(29 * time.Second), 100, true},
+		{"first second of the next step", 0, start.Add(30 * time.Second), 100, false},
+		{"last second of the previous step", 0, start.Add(-time.Second), 100, false},
+		{"previous step within skew", 1, start, 99, true},
+		{"next step within skew", 1, start, 101, true},
+		{"two steps back beyond skew", 1, start, 98, false},
+		{"two steps ahead beyond skew", 1, start, 102, false},
+		{"previous step without skew", 0, start, 99, false},
+		{"next step without skew", 0, start, 101, false},
+		{"edge of a wider skew", 2, start.Add(29 * time.Second), 98, true},
+		{"past a wider skew", 2, start.Add(30 * time.Second), 98, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			counter, ok, err := NewTOTP(tc.skew).Validate(rfcSecret, code(tc.step), tc.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ok != tc.want {
+				t.Fatalf("Validate = %t, want %t", ok, tc.want)
+			}
+
+			// The step is returned so that the code cannot be used again.
+			if ok && counter != tc.step {
+				t.Errorf("counter = %d, want %d", counter, tc.step)
+			}
+		})
+	}
+}
+
+func TestValidateMalformed(t *testing.T) {
+	totp := NewTOTP(1)
+	at := time.Unix(59, 0)
+
+	code, err := totp.Code(rfcSecret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		code string
+		want bool
+	}{
+		{"surrounding spaces", " " + code + " ", true},
+		{"too short", code[:5], false},
+		{"too long", code + "0", false},
+		{"empty", "", false},
+	} {
+		if _, ok, err := totp.Validate(rfcSecret, tc.code, at); err != nil || ok != tc.want {
+			t.Errorf("%s: Validate = %t, %v; want %t", tc.name, ok, err, tc.want)
+		}
+	}
+
+	for _, secret := range []string{"", "not base32!", "1234"} {
+		if _, _, err := totp.Validate(secret, code, at); !errors.Is(err, ErrInvalidSecret) {
+			t.Errorf("Validate with secret %q: err = %v, want %v", secret, err, ErrInvalidSecret)
+		}
+
+		if _, err := totp.Code(secret, at); !errors.Is(err, ErrInvalidSecret) {
+			t.Errorf("Code with secret %q: err = %v, want %v", secret, err, ErrInvalidSecret)
+		}
+	}
+
+	// Secrets are often shown lower-cased or padded.
+	if got, err := totp.Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq====", at); err != nil || got != code {
+		t.Errorf("Code with a lower-case padded secret = %s, %v; want %s", got, err, code)
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := decodeSecret(secret)
+	if err != nil || len(key) != secretSize {
+		t.Errorf("secret %q decodes to %d bytes, %v; want %d", secret, len(key), err, secretSize)
+	}
+
+	other, err := GenerateSecret()
+	if err != nil || other == secret {
+		t.Errorf("GenerateSecret returned %q twice", secret)
+	}
+}
+
+func TestKeyURI(t *testing.T) {
+	u, err := url.Parse(NewTOTP(1).KeyURI("VTB", "user@example.com", "JBSWY3DPEHPK3PXP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/VTB:user@example.com" {
+		t.Errorf("URI = %s", u)
+	}
+
+	query := u.Query()
+	for key, want := range map[string]string{
+		"secret":    "JBSWY3DPEHPK3PXP",
+		"issuer":    "VTB",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package paymentqr encodes and decodes the payment details carried by QR
+// codes on Russian invoices, utility bills and fines, as defined by
+// GOST R 56042-2014.
+//
+// A payload starts with the format identifier "ST0001", a digit naming the
+// character set (1 for windows-1251, 2 for UTF-8, 3 for KOI8-R) and the
+// character separating the fields, usually "|", followed by Key=Value pairs:
+//
+//	ST00012|Name=УФК по г. Москве|PersonalAcc=03100643000000017300|...
+//
+// The five fields Name, PersonalAcc, BankName, BIC and CorrespAcc are
+// mandatory and must come first, in this order.
+package paymentqr
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	formatID         = "ST0001"
+	defaultSeparator = '|'
+)
+
+// Charset is the character set of a payload, encoded by the digit that
+// follows the format identifier.
+type Charset byte
+
+const (
+	Windows1251 Charset = '1'
+	UTF8        Charset = '2'
+	KOI8R       Charset = '3'
+)
+
+// Field names defined by the standard. Fields not listed here are kept in
+// Payment.Extra.
+const (
+	FieldName         = "Name"
+	FieldPersonalAcc  = "PersonalAcc"
+	FieldBankName     = "BankName"
+	FieldBIC          = "BIC"
+	FieldCorrespAcc   = "CorrespAcc"
+	FieldSum          = "Sum"
+	FieldPurpose      = "Purpose"
+	FieldPayeeINN     = "PayeeINN"
+	FieldPayerINN     = "PayerINN"
+	FieldKPP          = "KPP"
+	FieldCBC          = "CBC"
+	FieldOKTMO        = "OKTMO"
+	FieldUIN          = "UIN"
+	FieldLastName     = "LastName"
+	FieldFirstName    = "FirstName"
+	FieldMiddleName   = "MiddleName"
+	FieldPayerAddress = "PayerAddress"
+)
+
+var (
+	// ErrFormat is returned when a payload is not in the ST00012 format.
+	ErrFormat = errors.New("paymentqr: not a GOST R 56042 payload")
+	// ErrInvalidField is returned, wrapped with the field name, when a field
+	// is missing or its value is not allowed.
+	ErrInvalidField = errors.New("paymentqr: invalid field")
+)
+
+// Payment holds the payment details of a payload. Sum is in kopecks; zero
+// means the payer enters the amount.
+type Payment struct {
+	Name        string
+	PersonalAcc string
+	BankName    string
+	BIC         string
+	CorrespAcc  string
+
+	Sum          int64
+	Purpose      string
+	PayeeINN     string
+	PayerINN     string
+	KPP          string
+	CBC          string
+	OKTMO        string
+	UIN          string
+	LastName     string
+	FirstName    string
+	MiddleName   string
+	PayerAddress string
+
+	// Extra holds the other fields of the payload by name.
+	Extra map[string]string
+}
+
+// fields lists the fields of the payment in the order they are encoded.
+func (p Payment) fields() [][2]string {
+	fields := [][2]string{
+		{FieldName, p.Name},
+		{FieldPersonalAcc, p.PersonalAcc},
+		{FieldBankName, p.BankName},
+		{FieldBIC, p.BIC},
+		{FieldCorrespAcc, p.CorrespAcc},
+	}
+
+	if p.Sum != 0 {
+		fields = append(fields, [2]string{FieldSum, strconv.FormatInt(p.Sum, 10)})
+	}
+
+	for _, field := range [][2]string{
+		{FieldPurpose, p.Purpose},
+		{FieldPayeeINN, p.PayeeINN},
+		{FieldPayerINN, p.PayerINN},
+		{FieldKPP, p.KPP},
+		{FieldCBC, p.CBC},
+		{FieldOKTMO, p.OKTMO},
+		{FieldUIN, p.UIN},
+		{FieldLastName, p.LastName},
+		{FieldFirstName, p.FirstName},
+		{FieldMiddleName, p.MiddleName},
+		{FieldPayerAddress, p.PayerAddress},
+	} {
+		if field[1] != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	names := make([]string, 0, len(p.Extra))
+	for name := range p.Extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fields = append(fields, [2]string{name, p.Extra[name]})
+	}
+
+	return fields
+}
+
+// set assigns a decoded field to the payment.
+func (p *Payment) set(name, value string) error {
+	switch name {
+	case FieldName:
+		p.Name = value
+	case FieldPersonalAcc:
+		p.PersonalAcc = value
+	case FieldBankName:
+		p.BankName = value
+	case FieldBIC:
+		p.BIC = value
+	case FieldCorrespAcc:
+		p.CorrespAcc = value
+	case FieldSum:
+		sum, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w %s: not a number", ErrInvalidField, name)
+		}
+		p.Sum = sum
+	case FieldPurpose:
+		p.Purpose = value
+	case FieldPayeeINN:
+		p.PayeeINN = value
+	case FieldPayerINN:
+		p.PayerINN = value
+	case FieldKPP:
+		p.KPP = value
+	case FieldCBC:
+		p.CBC = value
+	case FieldOKTMO:
+		p.OKTMO = value
+	case FieldUIN:
+		p.UIN = value
+	case FieldLastName:
+		p.LastName = value
+	case FieldFirstName:
+		p.FirstName = value
+	case FieldMiddleName:
+		p.MiddleName = value
+	case FieldPayerAddress:
+		p.PayerAddress = value
+	default:
+		if p.Extra == nil {
+			p.Extra = make(map[string]string)
+		}
+		p.Extra[name] = value
+	}
+
+	return nil
+}
+
+// Encode validates the payment and encodes it as a UTF-8 payload with "|"
+// separating the fields.
+//
+// Parameters:
+//   - p: The payment details.
+//
+// Returns:
+//   - string: The payload to put into a QR code.
+//   - error: ErrInvalidField if the payment does not validate.
+func Encode(p Payment) (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(formatID)
+	b.WriteByte(byte(UTF8))
+
+	for _, field := range p.fields() {
+		if strings.ContainsRune(field[1], defaultSeparator) {
+			return "", fmt.Errorf("%w %s: contains the separator %q", ErrInvalidField, field[0], defaultSeparator)
+		}
+
+		b.WriteRune(defaultSeparator)
+		b.WriteString(field[0])
+		b.WriteByte('=')
+		b.WriteString(field[1])
+	}
+
+	return b.String(), nil
+}
+
+// Decode parses and validates a payload in any of the three character sets.
+//
+// Parameters:
+//   - payload: The raw content of the QR code.
+//
+// Returns:
+//   - Payment: The decoded payment details.
+//   - error: ErrFormat if the payload is not in the format, or
+//     ErrInvalidField if a field does not validate.
+func Decode(payload []byte) (Payment, error) {
+	if len(payload) < len(formatID)+2 || string(payload[:len(formatID)]) != formatID {
+		return Payment{}, ErrFormat
+	}
+
+	text, err := decodeCharset(Charset(payload[len(formatID)]), payload[len(formatID)+1:])
+	if err != nil {
+		return Payment{}, err
+	}
+
+	text = strings.TrimRight(text, "\r\n")
+
+	separator, size := utf8.DecodeRuneInString(text)
+	if separator == '=' || separator == utf8.RuneError {
+		return Payment{}, ErrFormat
+	}
+
+	var p Payment
+	seen := make(map[string]bool)
+
+	for i, field := range strings.Split(text[size:], string(separator)) {
+		if field == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(field, "=")
+		if !ok || name == "" {
+			return Payment{}, fmt.Errorf("%w: malformed field %q", ErrFormat, field)
+		}
+
+		if i < len(requiredFields) && name != requiredFields[i] {
+			return Payment{}, fmt.Errorf("%w %s: mandatory fields must come first", ErrInvalidField, requiredFields[i])
+		}
+
+		if seen[name] {
+			return Payment{}, fmt.Errorf("%w %s: repeated", ErrInvalidField, name)
+		}
+		seen[name] = true
+
+		if err := p.set(name, strings.TrimSpace(value)); err != nil {
+			return Payment{}, err
+		}
+	}
+
+	if err := p.Validate(); err != nil {
+		return Payment{}, err
+	}
+
+	return p, nil
+}
+
+func decodeCharset(charset Charset, data []byte) (string, error) {
+	switch charset {
+	case UTF8:
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("%w: invalid UTF-8", ErrFormat)
+		}
+
+		return string(data), nil
+	case Windows1251:
+		return charmap.Windows1251.NewDecoder().String(string(data))
+	case KOI8R:
+		return charmap.KOI8R.NewDecoder().String(string(data))
+	default:
+		return "", fmt.Errorf("%w: unknown character set %q", ErrFormat, charset)
+	}
+}
@@ -0,0 +1,196 @@
+package paymentqr
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func testPayment() Payment {
+	return Payment{
+		Name:        "УФК по г. Москве (ГУ МВД России по г. Москве)",
+		PersonalAcc: "03100643000000017300",
+		BankName:    "ГУ Банка России по ЦФО",
+		BIC:         "004525988",
+		CorrespAcc:  "40102810545370000003",
+		Sum:         150000,
+		Purpose:     "Штраф по постановлению",
+		PayeeINN:    "7707089101",
+		KPP:         "770731005",
+		CBC:         "18811601121010001140",
+		OKTMO:       "45000000",
+		UIN:         "18810177000000000002",
+		LastName:    "Иванов",
+		FirstName:   "Иван",
+		Extra:       map[string]string{"DocNo": "1881017700", "Category": "7"},
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		payment Payment
+	}{
+		{"full", testPayment()},
+		{"mandatory only", Payment{
+			Name:        "ООО Ромашка",
+			PersonalAcc: "40702810900000000001",
+			BankName:    "ПАО Банк",
+			BIC:         "044525225",
+			CorrespAcc:  "0",
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := Encode(tc.payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := Decode([]byte(payload))
+			if err != nil {
+				t.Fatalf("Decode(%q): %v", payload, err)
+			}
+
+			if !reflect.DeepEqual(decoded, tc.payment) {
+				t.Errorf("Decode(Encode(p)) = %+v, want %+v", decoded, tc.payment)
+			}
+		})
+	}
+}
+
+func TestEncodeLayout(t *testing.T) {
+	payload, err := Encode(testPayment())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "ST00012|Name=УФК по г. Москве (ГУ МВД России по г. Москве)|PersonalAcc=03100643000000017300" +
+		"|BankName=ГУ Банка России по ЦФО|BIC=004525988|CorrespAcc=40102810545370000003|Sum=150000" +
+		"|Purpose=Штраф по постановлению|PayeeINN=7707089101|KPP=770731005|CBC=18811601121010001140" +
+		"|OKTMO=45000000|UIN=18810177000000000002|LastName=Иванов|FirstName=Иван|Category=7|DocNo=1881017700"
+	if payload != want {
+		t.Errorf("Encode =\n%s\nwant\n%s", payload, want)
+	}
+}
+
+func TestDecodeCharsets(t *testing.T) {
+	text := "|Name=ООО Ромашка|PersonalAcc=40702810900000000001|BankName=ПАО Банк|BIC=044525225|CorrespAcc=30101810400000000225|Sum=100"
+
+	for _, tc := range []struct {
+		name    string
+		charset Charset
+		encode  func(string) (string, error)
+	}{
+		{"utf-8", UTF8, func(s string) (string, error) { return s, nil }},
+		{"windows-1251", Windows1251, charmap.Windows1251.NewEncoder().String},
+		{"koi8-r", KOI8R, charmap.KOI8R.NewEncoder().String},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := tc.encode(text)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := Decode([]byte(formatID + string(tc.charset) + encoded + "\r\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if p.Name != "ООО Ромашка" || p.BankName != "ПАО Банк" || p.Sum != 100 {
+				t.Errorf("Decode = %+v", p)
+			}
+		})
+	}
+}
+
+func TestDecodeOtherSeparator(t *testing.T) {
+	p, err := Decode([]byte("ST00012#Name=A|B#PersonalAcc=40702810900000000001#BankName=Bank#BIC=044525225#CorrespAcc=0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Name != "A|B" {
+		t.Errorf("Name = %q, want A|B", p.Name)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	const valid = "|Name=A|PersonalAcc=40702810900000000001|BankName=Bank|BIC=044525225|CorrespAcc=0"
+
+	for _, tc := range []struct {
+		name    string
+		payload string
+		want    error
+	}{
+		{"empty", "", ErrFormat},
+		{"wrong format", "ST00022" + valid, ErrFormat},
+		{"unknown charset", "ST00019" + valid, ErrFormat},
+		{"invalid UTF-8", "ST00012|Name=\xff", ErrFormat},
+		{"no separator", "ST00012=Name", ErrFormat},
+		{"malformed field", "ST00012" + valid + "|Sum", ErrFormat},
+		{"mandatory out of order", "ST00012|PersonalAcc=40702810900000000001|Name=A|BankName=Bank|BIC=044525225|CorrespAcc=0", ErrInvalidField},
+		{"mandatory missing", "ST00012|Name=A|PersonalAcc=40702810900000000001|BankName=Bank|BIC=044525225", ErrInvalidField},
+		{"repeated field", "ST00012" + valid + "|Sum=1|Sum=2", ErrInvalidField},
+		{"sum not a number", "ST00012" + valid + "|Sum=1.5", ErrInvalidField},
+		{"invalid UIN", "ST00012" + valid + "|UIN=18810177000000000003", ErrInvalidField},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Decode([]byte(tc.payload)); !errors.Is(err, tc.want) {
+				t.Errorf("Decode(%q) = %v, want %v", tc.payload, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		field  string
+		modify func(*Payment)
+	}{
+		{"no name", FieldName, func(p *Payment) { p.Name = "" }},
+		{"long name", FieldName, func(p *Payment) { p.Name = strings.Repeat("я", 161) }},
+		{"no account", FieldPersonalAcc, func(p *Payment) { p.PersonalAcc = "" }},
+		{"short account", FieldPersonalAcc, func(p *Payment) { p.PersonalAcc = "0310064300000001730" }},
+		{"no bank", FieldBankName, func(p *Payment) { p.BankName = "" }},
+		{"no BIC", FieldBIC, func(p *Payment) { p.BIC = "" }},
+		{"BIC with letters", FieldBIC, func(p *Payment) { p.BIC = "00452598A" }},
+		{"no correspondent account", FieldCorrespAcc, func(p *Payment) { p.CorrespAcc = "" }},
+		{"correspondent account 00", FieldCorrespAcc, func(p *Payment) { p.CorrespAcc = "00" }},
+		{"payee INN of 11 digits", FieldPayeeINN, func(p *Payment) { p.PayeeINN = "77070891010" }},
+		{"OKTMO of 9 digits", FieldOKTMO, func(p *Payment) { p.OKTMO = "450000000" }},
+		{"negative sum", FieldSum, func(p *Payment) { p.Sum = -1 }},
+		{"sum of 19 digits", FieldSum, func(p *Payment) { p.Sum = 1000000000000000000 }},
+		{"extra with =", "a=b", func(p *Payment) { p.Extra["a=b"] = "c" }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := testPayment()
+			tc.modify(&p)
+
+			err := p.Validate()
+			if !errors.Is(err, ErrInvalidField) || !strings.Contains(err.Error(), tc.field) {
+				t.Errorf("Validate = %v, want %v for %s", err, ErrInvalidField, tc.field)
+			}
+
+			if _, err := Encode(p); !errors.Is(err, ErrInvalidField) {
+				t.Errorf("Encode = %v, want %v", err, ErrInvalidField)
+			}
+		})
+	}
+
+	if err := testPayment().Validate(); err != nil {
+		t.Errorf("Validate of a valid payment = %v", err)
+	}
+}
+
+func TestEncodeRejectsSeparator(t *testing.T) {
+	p := testPayment()
+	p.Purpose = "fine|penalty"
+
+	if _, err := Encode(p); !errors.Is(err, ErrInvalidField) {
+		t.Errorf("Encode = %v, want %v", err, ErrInvalidField)
+	}
+}
@@ -0,0 +1,100 @@
+package paymentqr
+
+import (
+	"backend-vtb/pkg/uin"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// requiredFields are the mandatory fields in the order the standard puts them.
+var requiredFields = []string{FieldName, FieldPersonalAcc, FieldBankName, FieldBIC, FieldCorrespAcc}
+
+// maxSumDigits is the longest sum the standard allows, in kopecks.
+const maxSumDigits = 18
+
+// Validate checks that the mandatory fields are present and that every field
+// fits the length and the characters the standard allows.
+//
+// Returns:
+//   - error: ErrInvalidField wrapped with the name of the first invalid
+//     field, nil otherwise.
+func (p Payment) Validate() error {
+	checks := []struct {
+		name     string
+		value    string
+		required bool
+		check    func(string) bool
+	}{
+		{FieldName, p.Name, true, maxLength(160)},
+		{FieldPersonalAcc, p.PersonalAcc, true, digits(20)},
+		{FieldBankName, p.BankName, true, maxLength(45)},
+		{FieldBIC, p.BIC, true, digits(9)},
+		// Payees served by the Bank of Russia itself have no correspondent
+		// account, which the standard encodes as "0".
+		{FieldCorrespAcc, p.CorrespAcc, true, func(s string) bool { return s == "0" || digits(20)(s) }},
+		{FieldPurpose, p.Purpose, false, maxLength(210)},
+		{FieldPayeeINN, p.PayeeINN, false, digits(10, 12)},
+		{FieldPayerINN, p.PayerINN, false, digits(10, 12)},
+		{FieldKPP, p.KPP, false, digits(9)},
+		{FieldCBC, p.CBC, false, digits(20)},
+		{FieldOKTMO, p.OKTMO, false, digits(8, 11)},
+		{FieldUIN, p.UIN, false, func(s string) bool { return uin.Validate(s) == nil }},
+		{FieldLastName, p.LastName, false, maxLength(60)},
+		{FieldFirstName, p.FirstName, false, maxLength(60)},
+		{FieldMiddleName, p.MiddleName, false, maxLength(60)},
+		{FieldPayerAddress, p.PayerAddress, false, maxLength(210)},
+	}
+
+	for _, c := range checks {
+		if c.value == "" {
+			if c.required {
+				return fmt.Errorf("%w %s: missing", ErrInvalidField, c.name)
+			}
+
+			continue
+		}
+
+		if !c.check(c.value) {
+			return fmt.Errorf("%w %s: %q is not allowed", ErrInvalidField, c.name, c.value)
+		}
+	}
+
+	if p.Sum < 0 || len(fmt.Sprint(p.Sum)) > maxSumDigits {
+		return fmt.Errorf("%w %s: must be a non-negative number of at most %d digits", ErrInvalidField, FieldSum, maxSumDigits)
+	}
+
+	for name, value := range p.Extra {
+		if name == "" || strings.ContainsRune(name, '=') || !utf8.ValidString(name) || !utf8.ValidString(value) {
+			return fmt.Errorf("%w %s: malformed", ErrInvalidField, name)
+		}
+	}
+
+	return nil
+}
+
+// maxLength accepts strings of at most n characters.
+func maxLength(n int) func(string) bool {
+	return func(s string) bool {
+		return utf8.RuneCountInString(s) <= n
+	}
+}
+
+// digits accepts strings of digits of any of the given lengths.
+func digits(lengths ...int) func(string) bool {
+	return func(s string) bool {
+		for i := 0; i < len(s); i++ {
+			if s[i] < '0' || s[i] > '9' {
+				return false
+			}
+		}
+
+		for _, n := range lengths {
+			if len(s) == n {
+				return true
+			}
+		}
+
+		return false
+	}
+}
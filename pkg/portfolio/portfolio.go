@@ -0,0 +1,333 @@
+// Package portfolio computes positions in an asset from its transactions:
+// the quantity held, its cost basis and the profit or loss realized by
+// sales.
+//
+// The cost of a sale is found either by FIFO, where the oldest lots are sold
+// first, or by the average cost of everything held. Quantities are exact
+// decimals; amounts of money are rounded half to even where a lot is split.
+package portfolio
+
+import (
+	"backend-vtb/pkg/money"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrInvalidQuantity      = errors.New("invalid quantity")
+	ErrInsufficientQuantity = errors.New("quantity exceeds the holdings")
+	ErrUnknownMethod        = errors.New("unknown cost basis method")
+	ErrUnknownKind          = errors.New("unknown transaction kind")
+)
+
+// MaxQuantityDigits is the number of decimal places quantities are kept with.
+const MaxQuantityDigits = 18
+
+// Method is a cost basis method.
+type Method string
+
+const (
+	// FIFO sells the lots in the order they were acquired.
+	FIFO Method = "fifo"
+	// AverageCost sells at the average cost of all units held.
+	AverageCost Method = "average"
+)
+
+// Kind is the kind of a transaction.
+type Kind string
+
+const (
+	Buy  Kind = "buy"
+	Sell Kind = "sell"
+	// TransferIn adds units acquired elsewhere, e.g. from another wallet.
+	TransferIn Kind = "transfer_in"
+	// TransferOut removes units without selling them.
+	TransferOut Kind = "transfer_out"
+)
+
+// Trade is a transaction in an asset.
+type Trade struct {
+	Kind     Kind
+	Quantity *big.Rat
+	// Amount is what was paid for a purchase or received for a sale. For an
+	// incoming transfer it is the cost basis the units bring along; it is
+	// ignored for outgoing transfers.
+	Amount money.Money
+	// Fee is added to the cost of purchases and deducted from the proceeds
+	// of sales. Fees of transfers are realized as a loss.
+	Fee money.Money
+}
+
+// Position is the result of the trades in an asset.
+type Position struct {
+	Quantity *big.Rat
+	// CostBasis is the cost of the units still held.
+	CostBasis money.Money
+	// Realized is the sum of the proceeds of sales less their cost, and of
+	// the fees of transfers.
+	Realized money.Money
+	// Fees is the sum of all fees paid.
+	Fees money.Money
+}
+
+// Compute replays the trades, in the order they were executed, with the
+// given cost basis method.
+//
+// Parameters:
+//   - method: How the cost of units sold is found.
+//   - currency: The currency of all amounts and fees.
+//   - trades: The trades in the order of execution.
+//
+// Returns:
+//   - Position: The position after the last trade.
+//   - error: ErrInsufficientQuantity if a trade removes more units than
+//     held at its time, or another error if a trade is not valid.
+func Compute(method Method, currency string, trades []Trade) (Position, error) {
+	if method != FIFO && method != AverageCost {
+		return Position{}, fmt.Errorf("%w: %q", ErrUnknownMethod, method)
+	}
+
+	b := &book{
+		method: method,
+		position: Position{
+			Quantity:  new(big.Rat),
+			CostBasis: money.Zero(currency),
+			Realized:  money.Zero(currency),
+			Fees:      money.Zero(currency),
+		},
+	}
+
+	for i, trade := range trades {
+		if err := b.apply(trade); err != nil {
+			return Position{}, fmt.Errorf("trade %d: %w", i+1, err)
+		}
+	}
+
+	return b.position, nil
+}
+
+// lot is a quantity acquired at once, with what it cost.
+type lot struct {
+	quantity *big.Rat
+	cost     money.Money
+}
+
+type book struct {
+	method   Method
+	lots     []lot
+	position Position
+}
+
+func (b *book) apply(trade Trade) error {
+	if trade.Quantity == nil || trade.Quantity.Sign() <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	fee := trade.Fee
+	if fee.Currency == "" {
+		fee = money.Zero(b.position.Fees.Currency)
+	}
+
+	fees, err := b.position.Fees.Add(fee)
+	if err != nil {
+		return err
+	}
+
+	b.position.Fees = fees
+
+	switch trade.Kind {
+	case Buy, TransferIn:
+		cost, err := trade.Amount.Add(fee)
+		if err != nil {
+			return err
+		}
+
+		return b.add(trade.Quantity, cost)
+	case Sell:
+		cost, err := b.remove(trade.Quantity)
+		if err != nil {
+			return err
+		}
+
+		proceeds, err := trade.Amount.Sub(fee)
+		if err != nil {
+			return err
+		}
+
+		gain, err := proceeds.Sub(cost)
+		if err != nil {
+			return err
+		}
+
+		return b.realize(gain)
+	case TransferOut:
+		if _, err := b.remove(trade.Quantity); err != nil {
+			return err
+		}
+
+		loss, err := fee.Neg()
+		if err != nil {
+			return err
+		}
+
+		return b.realize(loss)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownKind, trade.Kind)
+	}
+}
+
+func (b *book) add(quantity *big.Rat, cost money.Money) error {
+	basis, err := b.position.CostBasis.Add(cost)
+	if err != nil {
+		return err
+	}
+
+	b.position.CostBasis = basis
+	b.position.Quantity = new(big.Rat).Add(b.position.Quantity, quantity)
+
+	if b.method == FIFO {
+		b.lots = append(b.lots, lot{quantity: new(big.Rat).Set(quantity), cost: cost})
+	}
+
+	return nil
+}
+
+// remove takes the quantity out of the holdings and returns its cost.
+func (b *book) remove(quantity *big.Rat) (money.Money, error) {
+	switch quantity.Cmp(b.position.Quantity) {
+	case 1:
+		return money.Money{}, fmt.Errorf("%w: %s held", ErrInsufficientQuantity, FormatQuantity(b.position.Quantity))
+	case 0:
+		// Selling out takes the whole cost basis, leaving no rounding behind.
+		cost := b.position.CostBasis
+		b.lots = nil
+		b.position.Quantity = new(big.Rat)
+		b.position.CostBasis = money.Zero(cost.Currency)
+
+		return cost, nil
+	}
+
+	var (
+		cost money.Money
+		err  error
+	)
+
+	if b.method == FIFO {
+		cost, err = b.removeLots(quantity)
+	} else {
+		cost, err = b.position.CostBasis.MulRat(new(big.Rat).Quo(quantity, b.position.Quantity))
+	}
+
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	basis, err := b.position.CostBasis.Sub(cost)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	b.position.CostBasis = basis
+	b.position.Quantity = new(big.Rat).Sub(b.position.Quantity, quantity)
+
+	return cost, nil
+}
+
+// removeLots takes the quantity from the oldest lots, splitting the last lot
+// touched if needed.
+func (b *book) removeLots(quantity *big.Rat) (money.Money, error) {
+	cost := money.Zero(b.position.CostBasis.Currency)
+	remaining := new(big.Rat).Set(quantity)
+
+	for remaining.Sign() > 0 {
+		first := &b.lots[0]
+
+		if first.quantity.Cmp(remaining) <= 0 {
+			var err error
+			if cost, err = cost.Add(first.cost); err != nil {
+				return money.Money{}, err
+			}
+
+			remaining.Sub(remaining, first.quantity)
+			b.lots = b.lots[1:]
+
+			continue
+		}
+
+		part, err := first.cost.MulRat(new(big.Rat).Quo(remaining, first.quantity))
+		if err != nil {
+			return money.Money{}, err
+		}
+
+		if cost, err = cost.Add(part); err != nil {
+			return money.Money{}, err
+		}
+
+		if first.cost, err = first.cost.Sub(part); err != nil {
+			return money.Money{}, err
+		}
+
+		first.quantity = new(big.Rat).Sub(first.quantity, remaining)
+		remaining.SetInt64(0)
+	}
+
+	return cost, nil
+}
+
+func (b *book) realize(amount money.Money) error {
+	realized, err := b.position.Realized.Add(amount)
+	if err != nil {
+		return err
+	}
+
+	b.position.Realized = realized
+
+	return nil
+}
+
+// ParseQuantity parses a positive decimal quantity with at most
+// MaxQuantityDigits decimal places, e.g. "0.015".
+func ParseQuantity(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+
+	if _, fraction, ok := strings.Cut(value, "."); ok && len(fraction) > MaxQuantityDigits {
+		return nil, fmt.Errorf("%w: more than %d decimal places", ErrInvalidQuantity, MaxQuantityDigits)
+	}
+
+	if strings.ContainsAny(value, "eE/") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidQuantity, value)
+	}
+
+	quantity, ok := new(big.Rat).SetString(value)
+	if !ok || quantity.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidQuantity, value)
+	}
+
+	return quantity, nil
+}
+
+// FormatQuantity formats a quantity as a decimal number without trailing
+// zeros.
+func FormatQuantity(quantity *big.Rat) string {
+	formatted := quantity.FloatString(MaxQuantityDigits)
+	if strings.Contains(formatted, ".") {
+		formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+	}
+
+	return formatted
+}
+
+// Value returns what the quantity is worth at the price of one unit, rounded
+// half to even to a minor unit of the currency.
+func Value(quantity, price *big.Rat, currency string) (money.Money, error) {
+	units, ok := money.MinorUnits(currency)
+	if !ok {
+		return money.Money{}, fmt.Errorf("%w: %q", money.ErrUnknownCurrency, currency)
+	}
+
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(units)), nil))
+
+	return money.New(1, currency).MulRat(new(big.Rat).Mul(new(big.Rat).Mul(quantity, price), scale))
+}
@@ -0,0 +1,316 @@
+package portfolio
+
+import (
+	"backend-vtb/pkg/money"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func qty(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("invalid quantity " + s)
+	}
+
+	return r
+}
+
+func rub(amount int64) money.Money {
+	return money.New(amount, "RUB")
+}
+
+type wantPosition struct {
+	quantity  string
+	costBasis int64
+	realized  int64
+	fees      int64
+}
+
+func checkPosition(t *testing.T, got Position, want wantPosition) {
+	t.Helper()
+
+	if got.Quantity.Cmp(qty(want.quantity)) != 0 {
+		t.Errorf("quantity = %s, want %s", FormatQuantity(got.Quantity), want.quantity)
+	}
+
+	for _, m := range []struct {
+		name string
+		got  money.Money
+		want int64
+	}{
+		{"cost basis", got.CostBasis, want.costBasis},
+		{"realized", got.Realized, want.realized},
+		{"fees", got.Fees, want.fees},
+	} {
+		if m.got.Amount != m.want || m.got.Currency != "RUB" {
+			t.Errorf("%s = %v, want %d RUB", m.name, m.got, m.want)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		trades []Trade
+		fifo   wantPosition
+		avg    wantPosition
+	}{
+		{
+			name: "fees on buy and sell",
+			// The first lot costs 101.00 with its fee, the second 150.00 a
+			// unit; the sale brings 498.00 after its fee.
+			trades: []Trade{
+				{Kind: Buy, Quantity: qty("1"), Amount: rub(10000), Fee: rub(100)},
+				{Kind: Buy, Quantity: qty("2"), Amount: rub(30000)},
+				{Kind: Sell, Quantity: qty("2"), Amount: rub(50000), Fee: rub(200)},
+			},
+			// 101.00 of the first lot and 150.00 of the second.
+			fifo: wantPosition{quantity: "1", costBasis: 15000, realized: 49800 - 25100, fees: 300},
+			// Two thirds of 401.00 is 267.3333.
+			avg: wantPosition{quantity: "1", costBasis: 13367, realized: 49800 - 26733, fees: 300},
+		},
+		{
+			name: "partial sells across lots",
+			trades: []Trade{
+				{Kind: Buy, Quantity: qty("1"), Amount: rub(1000)},
+				{Kind: Buy, Quantity: qty("1"), Amount: rub(2000)},
+				{Kind: Buy, Quantity: qty("1"), Amount: rub(3000)},
+				{Kind: Sell, Quantity: qty("1.5"), Amount: rub(6000)},
+				{Kind: Sell, Quantity: qty("1"), Amount: rub(1000)},
+			},
+			// The first sale costs 10.00 + 10.00, the second the 10.00 left
+			// of the second lot and 15.00 of the third.
+			fifo: wantPosition{quantity: "0.5", costBasis: 1500, realized: 4000 - 1500},
+			avg:  wantPosition{quantity: "0.5", costBasis: 1000, realized: 3000 - 1000},
+		},
+		{
+			name: "split lot rounds half to even",
+			trades: []Trade{
+				{Kind: Buy, Quantity: qty("4"), Amount: rub(2)},
+				{Kind: Sell, Quantity: qty("1"), Amount: rub(0)},
+			},
+			// A quarter of 0.02 is half a kopeck, rounded to 0.
+			fifo: wantPosition{quantity: "3", costBasis: 2, realized: 0},
+			avg:  wantPosition{quantity: "3", costBasis: 2, realized: 0},
+		},
+		{
+			name: "selling out leaves no rounding behind",
+			trades: []Trade{
+				{Kind: Buy, Quantity: qty("3"), Amount: rub(1000)},
+				{Kind: Sell, Quantity: qty("1"), Amount: rub(500)},
+				{Kind: Sell, Quantity: qty("2"), Amount: rub(500)},
+			},
+			fifo: wantPosition{quantity: "0", costBasis: 0, realized: 0},
+			avg:  wantPosition{quantity: "0", costBasis: 0, realized: 0},
+		},
+		{
+			name: "transfers",
+			trades: []Trade{
+				{Kind: TransferIn, Quantity: qty("0.4"), Amount: rub(4000), Fee: rub(10)},
+				{Kind: Buy, Quantity: qty("0.6"), Amount: rub(9000)},
+				{Kind: TransferOut, Quantity: qty("0.5"), Amount: rub(99999), Fee: rub(20)},
+			},
+			// FIFO moves the whole first lot and a sixth of the second.
+			fifo: wantPosition{quantity: "0.5", costBasis: 7500, realized: -20, fees: 30},
+			avg:  wantPosition{quantity: "0.5", costBasis: 6505, realized: -20, fees: 30},
+		},
+		{
+			name: "loss",
+			trades: []Trade{
+				{Kind: Buy, Quantity: qty("0.001"), Amount: rub(500000), Fee: rub(1000)},
+				{Kind: Sell, Quantity: qty("0.001"), Amount: rub(400000), Fee: rub(1000)},
+			},
+			fifo: wantPosition{quantity: "0", costBasis: 0, realized: -102000, fees: 2000},
+			avg:  wantPosition{quantity: "0", costBasis: 0, realized: -102000, fees: 2000},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, method := range []struct {
+				method Method
+				want   wantPosition
+			}{
+				{FIFO, tc.fifo},
+				{AverageCost, tc.avg},
+			} {
+				t.Run(string(method.method), func(t *testing.T) {
+					position, err := Compute(method.method, "RUB", tc.trades)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					checkPosition(t, position, method.want)
+				})
+			}
+		})
+	}
+}
+
+func TestComputeDoesNotModifyTrades(t *testing.T) {
+	trades := []Trade{
+		{Kind: Buy, Quantity: qty("2"), Amount: rub(2000)},
+		{Kind: Sell, Quantity: qty("1"), Amount: rub(2000)},
+	}
+
+	if _, err := Compute(FIFO, "RUB", trades); err != nil {
+		t.Fatal(err)
+	}
+
+	if trades[0].Quantity.Cmp(qty("2")) != 0 || trades[1].Quantity.Cmp(qty("1")) != 0 {
+		t.Errorf("quantities changed to %s and %s", trades[0].Quantity, trades[1].Quantity)
+	}
+}
+
+func TestComputeInsufficientQuantity(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		trades []Trade
+	}{
+		{"sell without holdings", []Trade{
+			{Kind: Sell, Quantity: qty("1"), Amount: rub(100)},
+		}},
+		{"sell more than held", []Trade{
+			{Kind: Buy, Quantity: qty("1"), Amount: rub(100)},
+			{Kind: Sell, Quantity: qty("1.000000000000000001"), Amount: rub(100)},
+		}},
+		{"sell more than left", []Trade{
+			{Kind: Buy, Quantity: qty("1"), Amount: rub(100)},
+			{Kind: Sell, Quantity: qty("0.5"), Amount: rub(100)},
+			{Kind: Sell, Quantity: qty("0.6"), Amount: rub(100)},
+		}},
+		{"transfer more than held", []Trade{
+			{Kind: Buy, Quantity: qty("1"), Amount: rub(100)},
+			{Kind: TransferOut, Quantity: qty("2")},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, method := range []Method{FIFO, AverageCost} {
+				_, err := Compute(method, "RUB", tc.trades)
+				if !errors.Is(err, ErrInsufficientQuantity) {
+					t.Errorf("%s: err = %v, want %v", method, err, ErrInsufficientQuantity)
+				}
+			}
+		})
+	}
+
+	// The error names the trade and what was held at its time.
+	_, err := Compute(FIFO, "RUB", []Trade{
+		{Kind: Buy, Quantity: qty("1"), Amount: rub(100)},
+		{Kind: Sell, Quantity: qty("0.25"), Amount: rub(100)},
+		{Kind: Sell, Quantity: qty("1"), Amount: rub(100)},
+	})
+	if err == nil || !strings.HasPrefix(err.Error(), "trade 3:") || !strings.Contains(err.Error(), "0.75 held") {
+		t.Errorf("err = %v, want trade 3 with 0.75 held", err)
+	}
+}
+
+func TestComputeInvalid(t *testing.T) {
+	buy := Trade{Kind: Buy, Quantity: qty("1"), Amount: rub(100)}
+
+	for _, tc := range []struct {
+		name   string
+		method Method
+		trades []Trade
+		want   error
+	}{
+		{"unknown method", "lifo", nil, ErrUnknownMethod},
+		{"unknown kind", FIFO, []Trade{{Kind: "gift", Quantity: qty("1")}}, ErrUnknownKind},
+		{"no quantity", FIFO, []Trade{{Kind: Buy, Amount: rub(100)}}, ErrInvalidQuantity},
+		{"zero quantity", FIFO, []Trade{{Kind: Buy, Quantity: new(big.Rat), Amount: rub(100)}}, ErrInvalidQuantity},
+		{"negative quantity", FIFO, []Trade{{Kind: Buy, Quantity: qty("-1"), Amount: rub(100)}}, ErrInvalidQuantity},
+		{"amount in another currency", FIFO, []Trade{{Kind: Buy, Quantity: qty("1"), Amount: money.New(100, "USD")}}, money.ErrCurrencyMismatch},
+		{"fee in another currency", AverageCost, []Trade{buy, {Kind: Sell, Quantity: qty("1"), Amount: rub(100), Fee: money.New(1, "USD")}}, money.ErrCurrencyMismatch},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Compute(tc.method, "RUB", tc.trades); !errors.Is(err, tc.want) {
+				t.Errorf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnrealized(t *testing.T) {
+	trades := []Trade{
+		{Kind: Buy, Quantity: qty("1"), Amount: rub(10000), Fee: rub(100)},
+		{Kind: Buy, Quantity: qty("2"), Amount: rub(30000)},
+		{Kind: Sell, Quantity: qty("2"), Amount: rub(50000), Fee: rub(200)},
+	}
+
+	for _, tc := range []struct {
+		method Method
+		want   int64
+	}{
+		// The unit left is worth 200.00 and cost 150.00 by FIFO, 133.67 on
+		// average.
+		{FIFO, 5000},
+		{AverageCost, 6633},
+	} {
+		position, err := Compute(tc.method, "RUB", trades)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		value, err := Value(position.Quantity, qty("200"), "RUB")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		unrealized, err := value.Sub(position.CostBasis)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if unrealized.Amount != tc.want {
+			t.Errorf("%s: unrealized = %v, want %d", tc.method, unrealized, tc.want)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	for _, tc := range []struct {
+		quantity string
+		price    string
+		currency string
+		want     int64
+	}{
+		{"0.5", "65000.5", "USD", 3250025},
+		// 0.00125 at 100.00 is 0.125, rounded to the even 12 cents.
+		{"0.00125", "100", "USD", 12},
+		{"0.00135", "100", "USD", 14},
+		{"3", "0.5", "JPY", 2},
+		{"5", "0.5", "JPY", 2},
+	} {
+		got, err := Value(qty(tc.quantity), qty(tc.price), tc.currency)
+		if err != nil || got.Amount != tc.want || got.Currency != tc.currency {
+			t.Errorf("Value(%s, %s) = %v, %v; want %d %s", tc.quantity, tc.price, got, err, tc.want, tc.currency)
+		}
+	}
+
+	if _, err := Value(qty("1"), qty("1"), "XXX"); !errors.Is(err, money.ErrUnknownCurrency) {
+		t.Errorf("Value in XXX: err = %v, want %v", err, money.ErrUnknownCurrency)
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  string
+	}{
+		{"1", "1"},
+		{" 0.015 ", "0.015"},
+		{"0.000000000000000001", "0.000000000000000001"},
+		{"12.500", "12.5"},
+	} {
+		got, err := ParseQuantity(tc.value)
+		if err != nil || FormatQuantity(got) != tc.want {
+			t.Errorf("ParseQuantity(%q) = %v, %v; want %s", tc.value, got, err, tc.want)
+		}
+	}
+
+	for _, value := range []string{"", "0", "-1", "abc", "1e3", "1/3", "0.0000000000000000001"} {
+		if _, err := ParseQuantity(value); !errors.Is(err, ErrInvalidQuantity) {
+			t.Errorf("ParseQuantity(%q) = %v, want %v", value, err, ErrInvalidQuantity)
+		}
+	}
+}
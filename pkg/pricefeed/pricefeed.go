@@ -0,0 +1,34 @@
+// Package pricefeed provides market prices of crypto assets.
+//
+// Source abstracts where the prices come from, so that the Stub used in
+// development can be replaced by a client of an exchange without changes to
+// the callers.
+package pricefeed
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+)
+
+// ErrUnknownSymbol is returned for assets the source does not quote.
+var ErrUnknownSymbol = errors.New("unknown symbol")
+
+// Quote is the price of one unit of an asset at a moment.
+type Quote struct {
+	Symbol   string
+	Currency string
+	Price    *big.Rat
+	At       time.Time
+}
+
+// Source is a source of current market prices.
+type Source interface {
+	// Currency returns the currency all prices are quoted in.
+	Currency() string
+	// Symbols returns the assets the source quotes, in alphabetical order.
+	Symbols() []string
+	// Price returns the latest price of the asset.
+	Price(ctx context.Context, symbol string) (Quote, error)
+}
@@ -0,0 +1,116 @@
+package pricefeed
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultStubCurrency is the currency of DefaultStubPrices.
+const DefaultStubCurrency = "USD"
+
+// DefaultStubPrices are the reference prices of the assets the Stub quotes
+// unless told otherwise. They are made up.
+var DefaultStubPrices = map[string]string{
+	"BTC":  "67000",
+	"ETH":  "3200",
+	"SOL":  "150",
+	"TON":  "5.5",
+	"USDT": "1",
+}
+
+// Stub oscillations: a daily swing and a smaller hourly one, as shares of
+// the reference price.
+const (
+	stubDailySwing  = 0.05
+	stubHourlySwing = 0.01
+)
+
+// Stub is a Source of made-up prices for development. Each asset oscillates
+// around its reference price as a function of time only, so that every
+// instance quotes the same price at the same moment and replays are
+// reproducible.
+type Stub struct {
+	currency string
+	prices   map[string]float64
+	now      func() time.Time
+}
+
+// NewStub creates a Stub.
+//
+// Parameters:
+//   - currency: The currency the reference prices are in.
+//   - prices: The reference price of each asset as a decimal number.
+//
+// Returns:
+//   - *Stub: A pointer to the newly created Stub instance.
+//   - error: An error if a price is not a positive decimal number.
+func NewStub(currency string, prices map[string]string) (*Stub, error) {
+	s := &Stub{
+		currency: strings.ToUpper(currency),
+		prices:   make(map[string]float64, len(prices)),
+		now:      time.Now,
+	}
+
+	for symbol, value := range prices {
+		price, ok := new(big.Rat).SetString(value)
+		if !ok || price.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid price %q of %s", value, symbol)
+		}
+
+		s.prices[strings.ToUpper(symbol)], _ = price.Float64()
+	}
+
+	return s, nil
+}
+
+func (s *Stub) Currency() string {
+	return s.currency
+}
+
+func (s *Stub) Symbols() []string {
+	symbols := make([]string, 0, len(s.prices))
+	for symbol := range s.prices {
+		symbols = append(symbols, symbol)
+	}
+
+	sort.Strings(symbols)
+
+	return symbols
+}
+
+func (s *Stub) Price(_ context.Context, symbol string) (Quote, error) {
+	return s.PriceAt(symbol, s.now())
+}
+
+// PriceAt returns the price of the asset at the given moment, rounded to a
+// hundredth of the currency.
+func (s *Stub) PriceAt(symbol string, at time.Time) (Quote, error) {
+	symbol = strings.ToUpper(symbol)
+
+	reference, ok := s.prices[symbol]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: %s", ErrUnknownSymbol, symbol)
+	}
+
+	// Each asset gets its own phase, so they do not move in lockstep.
+	h := fnv.New32a()
+	h.Write([]byte(symbol))
+	phase := float64(h.Sum32()) / math.MaxUint32 * 2 * math.Pi
+
+	seconds := float64(at.Unix())
+	day := (24 * time.Hour).Seconds()
+	hour := time.Hour.Seconds()
+
+	factor := 1 + stubDailySwing*math.Sin(2*math.Pi*seconds/day+phase) +
+		stubHourlySwing*math.Sin(2*math.Pi*seconds/hour+2*phase)
+
+	price, _ := new(big.Rat).SetString(fmt.Sprintf("%.2f", reference*factor))
+
+	return Quote{Symbol: symbol, Currency: s.currency, Price: price, At: at}, nil
+}
@@ -0,0 +1,169 @@
+package pricefeed
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Tick is a price observed at a moment, with the volume traded at it if known.
+type Tick struct {
+	Symbol   string
+	Currency string
+	Price    *big.Rat
+	// Volume is the quantity traded, zero if the source does not report it.
+	Volume *big.Rat
+	At     time.Time
+}
+
+// TickSource is a stream of price ticks.
+type TickSource interface {
+	// Next blocks until ticks are available and returns a batch of them. A
+	// finite source returns io.EOF once exhausted.
+	Next(ctx context.Context) ([]Tick, error)
+}
+
+// History is implemented by sources that can tell past prices, which lets
+// gaps in the ticks, e.g. after downtime, be backfilled.
+type History interface {
+	PriceAt(symbol string, at time.Time) (Quote, error)
+}
+
+// Poller is a TickSource that asks a Source for the prices of all of its
+// symbols every interval, returning them as one batch. The ticks carry no
+// volume.
+type Poller struct {
+	source   Source
+	interval time.Duration
+	next     time.Time
+}
+
+// NewPoller creates a Poller that polls the source right away and then
+// every interval.
+func NewPoller(source Source, interval time.Duration) *Poller {
+	return &Poller{source: source, interval: interval}
+}
+
+func (p *Poller) Next(ctx context.Context) ([]Tick, error) {
+	if wait := time.Until(p.next); wait > 0 {
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	p.next = time.Now().Add(p.interval)
+
+	symbols := p.source.Symbols()
+	ticks := make([]Tick, 0, len(symbols))
+
+	for _, symbol := range symbols {
+		quote, err := p.source.Price(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		ticks = append(ticks, Tick{
+			Symbol:   quote.Symbol,
+			Currency: quote.Currency,
+			Price:    quote.Price,
+			Volume:   new(big.Rat),
+			At:       quote.At,
+		})
+	}
+
+	return ticks, nil
+}
+
+// Replay is a TickSource reading recorded ticks from a CSV file with a
+// header row and the columns time (RFC 3339), symbol, price and volume.
+// Lines starting with # are comments.
+type Replay struct {
+	reader    *csv.Reader
+	currency  string
+	batchSize int
+	line      int
+}
+
+// NewReplay reads ticks quoted in the currency from r, up to batchSize at a
+// time.
+func NewReplay(r io.Reader, currency string, batchSize int) *Replay {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	return &Replay{reader: reader, currency: strings.ToUpper(currency), batchSize: max(batchSize, 1)}
+}
+
+func (r *Replay) Next(ctx context.Context) ([]Tick, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ticks := make([]Tick, 0, r.batchSize)
+	for len(ticks) < r.batchSize {
+		record, err := r.reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		r.line++
+		if r.line == 1 {
+			continue
+		}
+
+		tick, err := r.parseRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+
+		ticks = append(ticks, tick)
+	}
+
+	if len(ticks) == 0 {
+		return nil, io.EOF
+	}
+
+	return ticks, nil
+}
+
+func (r *Replay) parseRecord(record []string) (Tick, error) {
+	at, err := time.Parse(time.RFC3339, record[0])
+	if err != nil {
+		return Tick{}, err
+	}
+
+	price, ok := new(big.Rat).SetString(record[2])
+	if !ok || price.Sign() <= 0 {
+		return Tick{}, fmt.Errorf("invalid price %q", record[2])
+	}
+
+	volume := new(big.Rat)
+	if record[3] != "" {
+		if _, ok := volume.SetString(record[3]); !ok || volume.Sign() < 0 {
+			return Tick{}, fmt.Errorf("invalid volume %q", record[3])
+		}
+	}
+
+	return Tick{
+		Symbol:   strings.ToUpper(record[1]),
+		Currency: r.currency,
+		Price:    price,
+		Volume:   volume,
+		At:       at,
+	}, nil
+}
@@ -0,0 +1,318 @@
+package qrcode
+
+// drawFunctionPatterns draws the finder, timing and alignment patterns and
+// reserves the format and version information areas.
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinderPattern(3, 3)
+	c.drawFinderPattern(c.Size-4, 3)
+	c.drawFinderPattern(3, c.Size-4)
+
+	positions := alignmentPatternPositions(c.Version)
+	last := len(positions) - 1
+
+	for i, x := range positions {
+		for j, y := range positions {
+			// Skip the three corners occupied by finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+
+			c.drawAlignmentPattern(x, y)
+		}
+	}
+
+	// Reserve the format area with a dummy mask; the real one is drawn by applyBestMask.
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+func (c *Code) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= c.Size || yy < 0 || yy >= c.Size {
+				continue
+			}
+
+			dist := max(abs(dx), abs(dy))
+			c.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the 15-bit format information, which
+// encodes the error correction level and the mask with a BCH(15,5) code.
+func (c *Code) drawFormatBits(mask int) {
+	data := formatBits[c.Level]<<3 | mask
+
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+
+	bits := (data<<10 | rem) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.Size-1-i, 8, bit(bits, i))
+	}
+
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(bits, i))
+	}
+
+	c.setFunction(8, c.Size-8, true)
+}
+
+// drawVersion draws both copies of the 18-bit version information, present
+// from version 7 on and protected by a BCH(18,6) code.
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+
+	rem := c.Version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+
+	bits := c.Version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		a := c.Size - 11 + i%3
+		b := i / 3
+		c.setFunction(a, b, bit(bits, i))
+		c.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords places the final codewords in the zigzag order of the
+// standard, two columns at a time from the bottom right corner.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				upward := (right+1)&2 == 0
+
+				y := vert
+				if upward {
+					y = c.Size - 1 - vert
+				}
+
+				if !c.isFunction[y][x] && i < len(data)*8 {
+					c.modules[y][x] = bit(int(data[i>>3]), 7-(i&7))
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask XORs every non-function module with the given mask pattern.
+// Applying the same mask twice restores the original modules.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var invert bool
+
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+
+			if invert && !c.isFunction[y][x] {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// applyBestMask tries all eight masks and keeps the one with the lowest penalty.
+func (c *Code) applyBestMask() {
+	best, bestPenalty := 0, -1
+
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+
+		if penalty := c.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+
+		c.applyMask(mask)
+	}
+
+	c.applyMask(best)
+	c.drawFormatBits(best)
+}
+
+// penalty scores the symbol with the four rules of the standard: long runs,
+// 2x2 blocks, finder-like patterns and an unbalanced dark module ratio.
+func (c *Code) penalty() int {
+	result := 0
+
+	for i := 0; i < c.Size; i++ {
+		result += runPenalty(c.Size, func(j int) bool { return c.modules[i][j] })
+		result += runPenalty(c.Size, func(j int) bool { return c.modules[j][i] })
+		result += finderLikePenalty(c.Size, func(j int) bool { return c.modules[i][j] })
+		result += finderLikePenalty(c.Size, func(j int) bool { return c.modules[j][i] })
+	}
+
+	for y := 0; y < c.Size-1; y++ {
+		for x := 0; x < c.Size-1; x++ {
+			color := c.modules[y][x]
+			if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+				result += 3
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+		}
+	}
+
+	total := c.Size * c.Size
+	result += abs(dark*100/total-50) / 5 * 10
+
+	return result
+}
+
+func runPenalty(size int, at func(int) bool) int {
+	result, run := 0, 1
+
+	for j := 1; j <= size; j++ {
+		if j < size && at(j) == at(j-1) {
+			run++
+			continue
+		}
+
+		if run >= 5 {
+			result += 3 + run - 5
+		}
+
+		run = 1
+	}
+
+	return result
+}
+
+// finderLikePenalty counts the 1:1:3:1:1 dark-light pattern with four light
+// modules on either side, treating modules outside the symbol as light.
+func finderLikePenalty(size int, at func(int) bool) int {
+	pattern := [...]bool{true, false, true, true, true, false, true}
+	light := func(j int) bool { return j < 0 || j >= size || !at(j) }
+
+	result := 0
+	for start := 0; start+len(pattern) <= size; start++ {
+		matches := true
+		for k, dark := range pattern {
+			if at(start+k) != dark {
+				matches = false
+				break
+			}
+		}
+
+		if !matches {
+			continue
+		}
+
+		before, after := true, true
+		for k := 1; k <= 4; k++ {
+			before = before && light(start-k)
+			after = after && light(start+len(pattern)-1+k)
+		}
+
+		if before || after {
+			result += 40
+		}
+	}
+
+	return result
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.isFunction[y][x] = true
+}
+
+// alignmentPatternPositions returns the centre coordinates of alignment
+// patterns along each axis for the given version.
+func alignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+
+	positions := make([]int, numAlign)
+	positions[0] = 6
+
+	for i, pos := numAlign-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+
+	return positions
+}
+
+func bit(x, i int) bool {
+	return (x>>i)&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
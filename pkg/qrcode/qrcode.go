@@ -0,0 +1,215 @@
+// Package qrcode encodes binary data into QR Code symbols (ISO/IEC 18004)
+// and renders them as images.
+//
+// Only byte mode segments are produced, which any reader can decode and which
+// is what URIs and UTF-8 payloads need.
+package qrcode
+
+import (
+	"errors"
+)
+
+// Level is the error correction level of a symbol.
+type Level int
+
+const (
+	// Low recovers about 7% of the symbol.
+	Low Level = iota
+	// Medium recovers about 15% of the symbol.
+	Medium
+	// Quartile recovers about 25% of the symbol.
+	Quartile
+	// High recovers about 30% of the symbol.
+	High
+)
+
+const (
+	minVersion = 1
+	maxVersion = 40
+)
+
+// ErrDataTooLong is returned when the data does not fit into a version 40 symbol.
+var ErrDataTooLong = errors.New("qrcode: data too long")
+
+// formatBits are the two bits that identify a Level in the format information.
+var formatBits = [...]int{Low: 1, Medium: 0, Quartile: 3, High: 2}
+
+// eccCodewordsPerBlock and numErrorCorrectionBlocks are indexed by level and
+// version (index 0 is unused), following table 9 of the standard.
+var eccCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var numErrorCorrectionBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Code is an encoded QR Code symbol.
+type Code struct {
+	// Version is the symbol version, from 1 to 40.
+	Version int
+	// Size is the number of modules on each side.
+	Size  int
+	Level Level
+
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// Encode returns the smallest QR Code symbol that holds data at the given
+// error correction level.
+//
+// Parameters:
+//   - data: The bytes to encode.
+//   - level: The error correction level.
+//
+// Returns:
+//   - *Code: The encoded symbol.
+//   - error: ErrDataTooLong if the data does not fit into any version.
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < Low || level > High {
+		return nil, errors.New("qrcode: invalid error correction level")
+	}
+
+	version := minVersion
+	for ; version <= maxVersion; version++ {
+		if segmentBits(version, len(data)) <= numDataCodewords(version, level)*8 {
+			break
+		}
+	}
+
+	if version > maxVersion {
+		return nil, ErrDataTooLong
+	}
+
+	codewords := makeDataCodewords(data, version, level)
+
+	code := newCode(version, level)
+	code.drawFunctionPatterns()
+	code.drawCodewords(code.addECCAndInterleave(codewords))
+	code.applyBestMask()
+
+	return code, nil
+}
+
+// Dark reports whether the module at column x and row y is dark.
+// Coordinates outside of the symbol are light.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+
+	return c.modules[y][x]
+}
+
+func newCode(version int, level Level) *Code {
+	size := version*4 + 17
+
+	code := &Code{
+		Version:    version,
+		Size:       size,
+		Level:      level,
+		modules:    make([][]bool, size),
+		isFunction: make([][]bool, size),
+	}
+
+	for i := range code.modules {
+		code.modules[i] = make([]bool, size)
+		code.isFunction[i] = make([]bool, size)
+	}
+
+	return code
+}
+
+// charCountBits returns the width of the byte mode character count indicator.
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+
+	return 16
+}
+
+// segmentBits returns the number of bits of a single byte mode segment.
+func segmentBits(version, length int) int {
+	if length >= 1<<charCountBits(version) {
+		return 1 << 30
+	}
+
+	return 4 + charCountBits(version) + length*8
+}
+
+// numRawDataModules returns the number of modules available for data and
+// error correction codewords, i.e. everything except function patterns.
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+
+		if version >= 7 {
+			result -= 36
+		}
+	}
+
+	return result
+}
+
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 -
+		eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+// makeDataCodewords builds the byte mode segment, adds the terminator and fills
+// the remaining capacity with the alternating pad bytes.
+func makeDataCodewords(data []byte, version int, level Level) []byte {
+	capacity := numDataCodewords(version, level) * 8
+
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(data), charCountBits(version))
+
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	bits.append(0, min(4, capacity-bits.len()))
+	bits.append(0, (8-bits.len()%8)%8)
+
+	for pad := 0xEC; bits.len() < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	return bits.bytes()
+}
+
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		b.bits = append(b.bits, (value>>i)&1 != 0)
+	}
+}
+
+func (b *bitBuffer) len() int {
+	return len(b.bits)
+}
+
+func (b *bitBuffer) bytes() []byte {
+	result := make([]byte, len(b.bits)/8)
+	for i, bit := range b.bits {
+		if bit {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,291 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// The data codewords of "HELLO WORLD" in a 1-M symbol and their error
+	// correction codewords.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Errorf("remainder = %v, want %v", got, want)
+	}
+}
+
+func TestReedSolomonDivisor(t *testing.T) {
+	// x^7 + α^87x^6 + α^229x^5 + α^146x^4 + α^149x^3 + α^238x^2 + α^102x + α^21
+	want := []byte{127, 122, 154, 164, 11, 68, 117}
+
+	if got := reedSolomonDivisor(7); !bytes.Equal(got, want) {
+		t.Errorf("divisor = %v, want %v", got, want)
+	}
+}
+
+func TestGFMultiply(t *testing.T) {
+	for _, tc := range []struct {
+		x, y, want byte
+	}{
+		{0, 0x53, 0},
+		{1, 0x53, 0x53},
+		{0x02, 0x80, 0x1D},
+		{0x53, 0xCA, 0x8F},
+		{0xFF, 0xFF, 0xE2},
+	} {
+		if got := gfMultiply(tc.x, tc.y); got != tc.want {
+			t.Errorf("%#x * %#x = %#x, want %#x", tc.x, tc.y, got, tc.want)
+		}
+
+		if got := gfMultiply(tc.y, tc.x); got != tc.want {
+			t.Errorf("%#x * %#x = %#x, want %#x", tc.y, tc.x, got, tc.want)
+		}
+	}
+}
+
+func TestMakeDataCodewords(t *testing.T) {
+	// Byte mode 0100, length 00000010, "hi", terminator and pad bytes up to
+	// the 16 data codewords of a 1-M symbol.
+	want := []byte{0x40, 0x26, 0x86, 0x90, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+
+	if got := makeDataCodewords([]byte("hi"), 1, Medium); !bytes.Equal(got, want) {
+		t.Errorf("codewords = %x, want %x", got, want)
+	}
+}
+
+func TestAddECCAndInterleave(t *testing.T) {
+	// A 5-Q symbol has two blocks of 15 and two of 16 data codewords, each
+	// followed by 18 error correction codewords.
+	code := newCode(5, Quartile)
+
+	data := make([]byte, numDataCodewords(5, Quartile))
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	got := code.addECCAndInterleave(data)
+	if len(got) != numRawDataModules(5)/8 {
+		t.Fatalf("len = %d, want %d", len(got), numRawDataModules(5)/8)
+	}
+
+	// The data codewords are taken from each block in turn, the long blocks
+	// contributing the last one.
+	want := []byte{0, 15, 30, 46, 1, 16, 31, 47}
+	if !bytes.Equal(got[:len(want)], want) {
+		t.Errorf("first data codewords = %v, want %v", got[:len(want)], want)
+	}
+
+	if tail := got[len(data)-2 : len(data)]; !bytes.Equal(tail, []byte{45, 61}) {
+		t.Errorf("last data codewords = %v, want [45 61]", tail)
+	}
+
+	first := reedSolomonRemainder(data[:15], reedSolomonDivisor(18))
+	if got[len(data)] != first[0] || got[len(data)+4] != first[1] {
+		t.Errorf("error correction codewords of the first block are not interleaved")
+	}
+}
+
+func TestNumDataCodewords(t *testing.T) {
+	for _, tc := range []struct {
+		version int
+		level   Level
+		want    int
+	}{
+		{1, Low, 19},
+		{1, Medium, 16},
+		{1, Quartile, 13},
+		{1, High, 9},
+		{7, Medium, 124},
+		{10, High, 122},
+		{40, Low, 2956},
+		{40, High, 1276},
+	} {
+		if got := numDataCodewords(tc.version, tc.level); got != tc.want {
+			t.Errorf("numDataCodewords(%d, %d) = %d, want %d", tc.version, tc.level, got, tc.want)
+		}
+	}
+}
+
+func TestAlignmentPatternPositions(t *testing.T) {
+	for _, tc := range []struct {
+		version int
+		want    []int
+	}{
+		{2, []int{6, 18}},
+		{7, []int{6, 22, 38}},
+		{14, []int{6, 26, 46, 66}},
+		{32, []int{6, 34, 60, 86, 112, 138}},
+		{40, []int{6, 30, 58, 86, 114, 142, 170}},
+	} {
+		got := alignmentPatternPositions(tc.version)
+		if len(got) != len(tc.want) {
+			t.Errorf("version %d: positions %v, want %v", tc.version, got, tc.want)
+			continue
+		}
+
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("version %d: positions %v, want %v", tc.version, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	// The format information strings of the standard, by level and mask.
+	want := map[Level][8]string{
+		Low:      {"111011111000100", "111001011110011", "111110110101010", "111100010011101", "110011000101111", "110001100011000", "110110001000001", "110100101110110"},
+		Medium:   {"101010000010010", "101000100100101", "101111001111100", "101101101001011", "100010111111001", "100000011001110", "100111110010111", "100101010100000"},
+		Quartile: {"011010101011111", "011000001101000", "011111100110001", "011101000000110", "010010010110100", "010000110000011", "010111011011010", "010101111101101"},
+		High:     {"001011010001001", "001001110111110", "001110011100111", "001100111010000", "000011101100010", "000001001010101", "000110100001100", "000100000111011"},
+	}
+
+	for level, masks := range want {
+		for mask, s := range masks {
+			code := newCode(1, level)
+			code.drawFormatBits(mask)
+
+			bits, _ := strconv.ParseInt(s, 2, 32)
+
+			var first, second int64
+			for i := 0; i < 15; i++ {
+				var x, y int
+				switch {
+				case i < 6:
+					x, y = 8, i
+				case i < 8:
+					x, y = 8, i+1
+				case i == 8:
+					x, y = 7, 8
+				default:
+					x, y = 14-i, 8
+				}
+
+				if code.Dark(x, y) {
+					first |= 1 << i
+				}
+
+				if i < 8 {
+					x, y = code.Size-1-i, 8
+				} else {
+					x, y = 8, code.Size-15+i
+				}
+
+				if code.Dark(x, y) {
+					second |= 1 << i
+				}
+			}
+
+			if first != bits || second != bits {
+				t.Errorf("level %d mask %d: format %015b and %015b, want %s", level, mask, first, second, s)
+			}
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	for _, tc := range []struct {
+		version int
+		want    int
+	}{
+		{7, 0x07C94},
+		{8, 0x085BC},
+		{21, 0x15683},
+		{40, 0x28C69},
+	} {
+		code := newCode(tc.version, Low)
+		code.drawVersion()
+
+		var right, bottom int
+		for i := 0; i < 18; i++ {
+			a, b := code.Size-11+i%3, i/3
+
+			if code.Dark(a, b) {
+				right |= 1 << i
+			}
+
+			if code.Dark(b, a) {
+				bottom |= 1 << i
+			}
+		}
+
+		if right != tc.want || bottom != tc.want {
+			t.Errorf("version %d: %#05x and %#05x, want %#05x", tc.version, right, bottom, tc.want)
+		}
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	for _, tc := range []struct {
+		length  int
+		level   Level
+		version int
+	}{
+		// Byte mode capacities of versions 1 and 2 at each level.
+		{17, Low, 1},
+		{18, Low, 2},
+		{14, Medium, 1},
+		{15, Medium, 2},
+		{11, Quartile, 1},
+		{7, High, 1},
+		{8, High, 2},
+		{2953, Low, 40},
+		{1273, High, 40},
+	} {
+		code, err := Encode(bytes.Repeat([]byte("a"), tc.length), tc.level)
+		if err != nil {
+			t.Errorf("%d bytes at level %d: %v", tc.length, tc.level, err)
+			continue
+		}
+
+		if code.Version != tc.version || code.Size != tc.version*4+17 {
+			t.Errorf("%d bytes at level %d: version %d size %d, want version %d", tc.length, tc.level, code.Version, code.Size, tc.version)
+		}
+	}
+
+	if _, err := Encode(make([]byte, 2954), Low); !errors.Is(err, ErrDataTooLong) {
+		t.Errorf("2954 bytes: %v, want %v", err, ErrDataTooLong)
+	}
+
+	if _, err := Encode([]byte("a"), High+1); err == nil {
+		t.Error("Encode accepted an unknown level")
+	}
+}
+
+func TestEncodeFunctionPatterns(t *testing.T) {
+	code, err := Encode([]byte("ST00012|Name=A|PersonalAcc=40702810900000000001"), Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finder := [7]string{"#######", "#.....#", "#.###.#", "#.###.#", "#.###.#", "#.....#", "#######"}
+
+	for _, corner := range [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}} {
+		for dy, row := range finder {
+			for dx, module := range row {
+				if x, y := corner[0]+dx, corner[1]+dy; code.Dark(x, y) != (module == '#') {
+					t.Errorf("finder at %v: module (%d, %d) is wrong", corner, x, y)
+				}
+			}
+		}
+	}
+
+	for i := 8; i < code.Size-8; i++ {
+		if code.Dark(i, 6) != (i%2 == 0) || code.Dark(6, i) != (i%2 == 0) {
+			t.Errorf("timing module %d is wrong", i)
+		}
+	}
+
+	if !code.Dark(8, code.Size-8) {
+		t.Error("dark module is missing")
+	}
+
+	if code.Dark(-1, 0) || code.Dark(0, code.Size) {
+		t.Error("modules outside the symbol are dark")
+	}
+}
@@ -0,0 +1,93 @@
+package qrcode
+
+// addECCAndInterleave splits the data codewords into blocks, appends the
+// Reed-Solomon error correction codewords to each and interleaves the result.
+func (c *Code) addECCAndInterleave(data []byte) []byte {
+	numBlocks := numErrorCorrectionBlocks[c.Level][c.Version]
+	blockECCLen := eccCodewordsPerBlock[c.Level][c.Version]
+	rawCodewords := numRawDataModules(c.Version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLen)
+
+	blocks := make([][]byte, 0, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		length := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			length++
+		}
+
+		block := append([]byte(nil), data[k:k+length]...)
+		k += length
+
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			// Placeholder so that all blocks have the same length; skipped below.
+			block = append(block, 0)
+		}
+
+		blocks = append(blocks, append(block, ecc...))
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+
+	return result
+}
+
+// reedSolomonDivisor returns the coefficients of the generator polynomial of
+// the given degree, highest to lowest power, omitting the leading 1.
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+
+	// Multiply by (x - r^i) for i = 0..degree-1, where r = 0x02 generates GF(2^8).
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+
+		root = gfMultiply(root, 0x02)
+	}
+
+	return result
+}
+
+// reedSolomonRemainder returns the remainder of data divided by the generator polynomial.
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+
+	return result
+}
+
+// gfMultiply multiplies two elements of GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+
+	return byte(z)
+}
@@ -0,0 +1,92 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// quietZone is the width of the light border around the symbol, in modules.
+const quietZone = 4
+
+// Image renders the symbol as a black and white image with a quiet zone,
+// using scale pixels per module.
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+
+	side := (c.Size + 2*quietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			if c.Dark(x/scale-quietZone, y/scale-quietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	return img
+}
+
+// PNG renders the symbol as a PNG image with scale pixels per module.
+//
+// Parameters:
+//   - scale: The size of a module in pixels.
+//
+// Returns:
+//   - []byte: The encoded PNG image.
+//   - error: An error if the image could not be encoded.
+func (c *Code) PNG(scale int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.Image(scale)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SVG renders the symbol as an SVG image with a quiet zone, using scale user
+// units per module. Horizontal runs of dark modules are drawn as one rectangle
+// to keep the document small.
+//
+// Parameters:
+//   - scale: The size of a module in user units.
+//
+// Returns:
+//   - []byte: The SVG document.
+func (c *Code) SVG(scale int) []byte {
+	if scale < 1 {
+		scale = 1
+	}
+
+	side := c.Size + 2*quietZone
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		side*scale, side*scale, side, side)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, side, side)
+
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+
+			run := 1
+			for x+run < c.Size && c.Dark(x+run, y) {
+				run++
+			}
+
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", x+quietZone, y+quietZone, run, run)
+			x += run - 1
+		}
+	}
+
+	buf.WriteString(`"/></svg>`)
+
+	return buf.Bytes()
+}
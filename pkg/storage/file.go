@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileStorage is an ObjectStorage backed by a directory on the local disk.
+// It is meant for development and single-node deployments.
+type FileStorage struct {
+	root string
+}
+
+// NewFileStorage creates a FileStorage that keeps objects under root,
+// creating the directory if needed.
+//
+// Parameters:
+//   - root: The directory objects are stored in.
+//
+// Returns:
+//   - *FileStorage: A pointer to the newly created FileStorage instance.
+//   - error: An error if the directory cannot be created.
+func NewFileStorage(root string) (*FileStorage, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &FileStorage{root: root}, nil
+}
+
+// PutObject writes the object to a temporary file first and renames it into
+// place, so readers never see a partially written object. The content type is
+// not persisted; callers keep it alongside the key.
+func (s *FileStorage) PutObject(ctx context.Context, key string, body io.Reader, _ string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, contextReader{ctx: ctx, r: body}); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *FileStorage) GetObject(_ context.Context, key string) (io.ReadCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return f, err
+}
+
+func (s *FileStorage) DeleteObject(_ context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// path maps a key to a file below the root, rejecting keys that would
+// resolve outside of it.
+func (s *FileStorage) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// contextReader stops reading once the context is done, so that a cancelled
+// upload does not keep writing to disk.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
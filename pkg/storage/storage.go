@@ -0,0 +1,30 @@
+// Package storage keeps binary objects such as uploaded files.
+//
+// ObjectStorage mirrors the subset of the S3 API the application needs, so
+// that an S3-compatible adapter can replace FileStorage without changes to
+// the callers.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrNotFound is returned when an object does not exist.
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey is returned when a key is empty or escapes the storage root.
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// ObjectStorage stores objects under slash-separated keys.
+type ObjectStorage interface {
+	// PutObject stores the content of body under key, replacing any existing object.
+	PutObject(ctx context.Context, key string, body io.Reader, contentType string) error
+	// GetObject opens the object stored under key. The caller must close it.
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	// DeleteObject removes the object stored under key. Deleting a missing
+	// object is not an error.
+	DeleteObject(ctx context.Context, key string) error
+}
@@ -0,0 +1,106 @@
+// Package uin validates unique accrual identifiers (UIN, "УИН") used in
+// Russian state payments, following the check digit algorithm of the
+// Ministry of Finance order No. 107n.
+package uin
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// ShortLength is the length of a UIN issued by a state agency.
+	ShortLength = 20
+	// LongLength is the length of a UIN that includes the payer's
+	// participant code.
+	LongLength = 25
+)
+
+var (
+	// ErrInvalidLength is returned when a UIN is neither 20 nor 25 characters long.
+	ErrInvalidLength = errors.New("uin must be 20 or 25 digits long")
+	// ErrInvalidCharacter is returned when a UIN contains anything but digits.
+	ErrInvalidCharacter = errors.New("uin must contain only digits")
+	// ErrInvalidChecksum is returned when the last digit does not match the check digit.
+	ErrInvalidChecksum = errors.New("uin check digit mismatch")
+)
+
+// Normalize removes spaces and dashes that people copy along with a UIN
+// from paper documents.
+func Normalize(uin string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(uin))
+}
+
+// Validate checks the length, the characters and the check digit of a UIN.
+//
+// Parameters:
+//   - uin: The normalized UIN.
+//
+// Returns:
+//   - error: ErrInvalidLength, ErrInvalidCharacter or ErrInvalidChecksum if the
+//     UIN is malformed, nil otherwise.
+func Validate(uin string) error {
+	if len(uin) != ShortLength && len(uin) != LongLength {
+		return ErrInvalidLength
+	}
+
+	for i := 0; i < len(uin); i++ {
+		if uin[i] < '0' || uin[i] > '9' {
+			return ErrInvalidCharacter
+		}
+	}
+
+	digit, err := CheckDigit(uin[:len(uin)-1])
+	if err != nil {
+		return err
+	}
+
+	if uin[len(uin)-1] != digit {
+		return ErrInvalidChecksum
+	}
+
+	return nil
+}
+
+// CheckDigit computes the check digit for the UIN without its last digit.
+//
+// The digits are multiplied by weights 1 to 10, repeating from 1 after 10,
+// and the sum is taken modulo 11. A remainder of 10 is not a digit, so the
+// sum is recomputed with weights shifted by two (3 to 10, then 1, 2, ...).
+// If the remainder is 10 again, the check digit is 0.
+//
+// Parameters:
+//   - body: The first 19 or 24 digits of the UIN.
+//
+// Returns:
+//   - byte: The check digit as an ASCII character.
+//   - error: ErrInvalidCharacter if body contains anything but digits.
+func CheckDigit(body string) (byte, error) {
+	for i := 0; i < len(body); i++ {
+		if body[i] < '0' || body[i] > '9' {
+			return 0, ErrInvalidCharacter
+		}
+	}
+
+	rem := weightedSum(body, 0) % 11
+	if rem == 10 {
+		rem = weightedSum(body, 2) % 11
+	}
+
+	if rem == 10 {
+		rem = 0
+	}
+
+	return byte('0' + rem), nil
+}
+
+// weightedSum multiplies each digit by its weight, which runs from 1 to 10
+// starting at 1+shift.
+func weightedSum(body string, shift int) int {
+	sum := 0
+	for i := 0; i < len(body); i++ {
+		sum += int(body[i]-'0') * ((i+shift)%10 + 1)
+	}
+
+	return sum
+}
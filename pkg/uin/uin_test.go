@@ -0,0 +1,83 @@
+package uin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckDigit(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		body string
+		want byte
+	}{
+		// 1·1 + 8·2 + 8·3 + 1·4 + 0·5 + 1·6 + 7·7 + 7·8 = 156, 156 mod 11 = 2.
+		{"short, first pass", "1881017700000000000", '2'},
+		// The first pass leaves 10, the weights shifted by two leave 9.
+		{"short, second pass", "1881017700000000007", '9'},
+		// Both passes leave 10.
+		{"short, ten is zero", "1881017700000000041", '0'},
+		{"long, first pass", "188101770000000000000000", '2'},
+		{"long, second pass", "188101770000000000000002", '3'},
+		{"long, ten is zero", "188101770000000000000038", '0'},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CheckDigit(tc.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.want {
+				t.Errorf("CheckDigit(%s) = %c, want %c", tc.body, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckDigitRejectsNonDigits(t *testing.T) {
+	if _, err := CheckDigit("18810177000000000a0"); !errors.Is(err, ErrInvalidCharacter) {
+		t.Errorf("err = %v, want %v", err, ErrInvalidCharacter)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		uin  string
+		want error
+	}{
+		{"short", "18810177000000000002", nil},
+		{"short, second pass", "18810177000000000079", nil},
+		{"short, ten is zero", "18810177000000000410", nil},
+		{"long", "1881017700000000000000002", nil},
+		{"long, second pass", "1881017700000000000000023", nil},
+		{"long, ten is zero", "1881017700000000000000380", nil},
+		{"short, wrong check digit", "18810177000000000003", ErrInvalidChecksum},
+		{"long, wrong check digit", "1881017700000000000000020", ErrInvalidChecksum},
+		{"second pass digit taken from the first", "18810177000000000070", ErrInvalidChecksum},
+		{"empty", "", ErrInvalidLength},
+		{"19 digits", "1881017700000000000", ErrInvalidLength},
+		{"21 digits", "188101770000000000020", ErrInvalidLength},
+		{"24 digits", "188101770000000000000000", ErrInvalidLength},
+		{"26 digits", "18810177000000000000000020", ErrInvalidLength},
+		{"letter", "1881017700000000000A", ErrInvalidCharacter},
+		{"not normalized", "1881017700000000 002", ErrInvalidCharacter},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Validate(tc.uin); !errors.Is(err, tc.want) {
+				t.Errorf("Validate(%s) = %v, want %v", tc.uin, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	got := Normalize(" 1881-0177 0000 0000 0002 ")
+	if want := "18810177000000000002"; got != want {
+		t.Errorf("Normalize = %q, want %q", got, want)
+	}
+
+	if err := Validate(got); err != nil {
+		t.Errorf("Validate(Normalize) = %v", err)
+	}
+}
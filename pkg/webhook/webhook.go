@@ -0,0 +1,93 @@
+// Package webhook signs and verifies webhook deliveries.
+//
+// A delivery carries the Unix time it was sent at and an HMAC-SHA256 of the
+// string "<timestamp>.<payload>" keyed with a secret shared between sender
+// and receiver. The signature header has the form "v1=<hex>"; several
+// comma-separated signatures may be sent while a secret is being rotated.
+// Binding the timestamp into the signature lets receivers reject replays of
+// old deliveries.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheme prefixes every signature in the signature header.
+const scheme = "v1="
+
+var (
+	// ErrInvalidSignature is returned when no signature matches the payload.
+	ErrInvalidSignature = errors.New("signature does not match the payload")
+	// ErrInvalidTimestamp is returned when the timestamp is malformed or too
+	// far from the current time.
+	ErrInvalidTimestamp = errors.New("timestamp is invalid or outside the tolerance")
+)
+
+// Sign computes the signature header of a payload sent at timestamp.
+//
+// Parameters:
+//   - secret: The secret shared with the receiver.
+//   - timestamp: The time the delivery is sent at.
+//   - payload: The raw request body.
+//
+// Returns:
+//   - string: The signature header value, e.g. "v1=5257a869...".
+func Sign(secret []byte, timestamp time.Time, payload []byte) string {
+	return scheme + hex.EncodeToString(mac(secret, strconv.FormatInt(timestamp.Unix(), 10), payload))
+}
+
+// Verify checks the signature header of a delivery and that its timestamp
+// is within tolerance of now, in either direction.
+//
+// Parameters:
+//   - secret: The secret shared with the sender.
+//   - signature: The signature header value.
+//   - timestamp: The timestamp header value, in Unix seconds.
+//   - payload: The raw request body.
+//   - now: The current time.
+//   - tolerance: The maximum age of a delivery.
+//
+// Returns:
+//   - time.Time: The time the delivery was sent at.
+//   - error: ErrInvalidTimestamp or ErrInvalidSignature if the delivery is rejected.
+func Verify(secret []byte, signature, timestamp string, payload []byte, now time.Time, tolerance time.Duration) (time.Time, error) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidTimestamp
+	}
+
+	sentAt := time.Unix(seconds, 0)
+	if age := now.Sub(sentAt); age > tolerance || age < -tolerance {
+		return time.Time{}, ErrInvalidTimestamp
+	}
+
+	expected := mac(secret, timestamp, payload)
+	for _, candidate := range strings.Split(signature, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if !strings.HasPrefix(candidate, scheme) {
+			continue
+		}
+
+		sum, err := hex.DecodeString(strings.TrimPrefix(candidate, scheme))
+		if err == nil && hmac.Equal(sum, expected) {
+			return sentAt, nil
+		}
+	}
+
+	return time.Time{}, ErrInvalidSignature
+}
+
+func mac(secret []byte, timestamp string, payload []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(timestamp))
+	h.Write([]byte{'.'})
+	h.Write(payload)
+
+	return h.Sum(nil)
+}
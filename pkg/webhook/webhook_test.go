@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	secret  = []byte("whsec_test")
+	payload = []byte(`{"id":"evt_1"}`)
+	sentAt  = time.Unix(1700000000, 0)
+)
+
+const tolerance = 5 * time.Minute
+
+func TestSign(t *testing.T) {
+	// HMAC-SHA256 of `1700000000.{"id":"evt_1"}` keyed with whsec_test.
+	want := "v1=c89214b5b5da833daed6f0b8c5bb6bd58cea9022bd80ccc78230f3942d632925"
+
+	if got := Sign(secret, sentAt, payload); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signature := Sign(secret, sentAt, payload)
+	other := Sign([]byte("whsec_old"), sentAt, payload)
+
+	for _, tc := range []struct {
+		name      string
+		secret    []byte
+		signature string
+		timestamp string
+		payload   []byte
+		now       time.Time
+		want      error
+	}{
+		{"valid", secret, signature, "1700000000", payload, sentAt, nil},
+		{"valid at the tolerance", secret, signature, "1700000000", payload, sentAt.Add(tolerance), nil},
+		{"valid ahead by the tolerance", secret, signature, "1700000000", payload, sentAt.Add(-tolerance), nil},
+		{"expired", secret, signature, "1700000000", payload, sentAt.Add(tolerance + time.Second), ErrInvalidTimestamp},
+		{"from the future", secret, signature, "1700000000", payload, sentAt.Add(-tolerance - time.Second), ErrInvalidTimestamp},
+		{"malformed timestamp", secret, signature, "yesterday", payload, sentAt, ErrInvalidTimestamp},
+		{"empty timestamp", secret, signature, "", payload, sentAt, ErrInvalidTimestamp},
+		{"tampered payload", secret, signature, "1700000000", []byte(`{"id":"evt_2"}`), sentAt, ErrInvalidSignature},
+		{"tampered timestamp", secret, signature, "1700000001", payload, sentAt, ErrInvalidSignature},
+		{"tampered signature", secret, signature[:len(signature)-1] + "0", "1700000000", payload, sentAt, ErrInvalidSignature},
+		{"wrong secret", []byte("whsec_other"), signature, "1700000000", payload, sentAt, ErrInvalidSignature},
+		{"unknown scheme", secret, "v0=" + strings.TrimPrefix(signature, scheme), "1700000000", payload, sentAt, ErrInvalidSignature},
+		{"not hex", secret, "v1=zz", "1700000000", payload, sentAt, ErrInvalidSignature},
+		{"empty signature", secret, "", "1700000000", payload, sentAt, ErrInvalidSignature},
+		{"several signatures, first matches", secret, signature + "," + other, "1700000000", payload, sentAt, nil},
+		{"several signatures, last matches", secret, other + ", " + signature, "1700000000", payload, sentAt, nil},
+		{"several signatures, none matches", secret, other + ",v1=00," + other, "1700000000", payload, sentAt, ErrInvalidSignature},
+		{"several schemes", secret, "v0=abc," + signature, "1700000000", payload, sentAt, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Verify(tc.secret, tc.signature, tc.timestamp, tc.payload, tc.now, tolerance)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("Verify = %v, want %v", err, tc.want)
+			}
+
+			if err == nil && !got.Equal(sentAt) {
+				t.Errorf("sent at %v, want %v", got, sentAt)
+			}
+		})
+	}
+}
+
+func TestVerifyRotatedSecret(t *testing.T) {
+	// While a secret is rotated the sender signs with both.
+	signature := Sign([]byte("whsec_old"), sentAt, payload) + "," + Sign([]byte("whsec_new"), sentAt, payload)
+
+	for _, key := range []string{"whsec_old", "whsec_new"} {
+		if _, err := Verify([]byte(key), signature, "1700000000", payload, sentAt, tolerance); err != nil {
+			t.Errorf("Verify with %s = %v", key, err)
+		}
+	}
+}